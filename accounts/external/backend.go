@@ -222,9 +222,15 @@ func (api *ExternalSigner) SignTx(account accounts.Account, tx *types.Transactio
 	if tx.Type() != types.LegacyTxType && tx.ChainId() != nil {
 		args.ChainID = (*hexutil.Big)(tx.ChainId())
 	}
-	if tx.Type() == types.AccessListTxType {
+	switch tx.Type() {
+	case types.AccessListTxType:
 		accessList := tx.AccessList()
 		args.AccessList = &accessList
+	case types.DynamicFeeTxType:
+		accessList := tx.AccessList()
+		args.AccessList = &accessList
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
 	}
 	var res signTransactionResult
 	if err := api.client.Call(&res, "account_signTransaction", args); err != nil {
@@ -36,6 +36,8 @@ var (
 			discv5CrawlCommand,
 			discv5TestCommand,
 			discv5ListenCommand,
+			discv5AdvertiseCommand,
+			discv5SearchCommand,
 		},
 	}
 	discv5PingCommand = cli.Command{
@@ -77,8 +79,33 @@ var (
 			listenAddrFlag,
 		},
 	}
+	discv5AdvertiseCommand = cli.Command{
+		Name:      "advertise",
+		Usage:     "Runs a node advertising itself under a topic",
+		ArgsUsage: "<topic>",
+		Action:    discv5Advertise,
+		Flags: []cli.Flag{
+			bootnodesFlag,
+			nodekeyFlag,
+			nodedbFlag,
+			listenAddrFlag,
+		},
+	}
+	discv5SearchCommand = cli.Command{
+		Name:      "search",
+		Usage:     "Finds nodes advertising a topic",
+		ArgsUsage: "<topic>",
+		Action:    discv5Search,
+		Flags:     []cli.Flag{bootnodesFlag, searchTimeoutFlag},
+	}
 )
 
+var searchTimeoutFlag = cli.DurationFlag{
+	Name:  "timeout",
+	Usage: "Time limit for the search.",
+	Value: 1 * time.Minute,
+}
+
 func discv5Ping(ctx *cli.Context) error {
 	n := getNodeArg(ctx)
 	disc := startV5(ctx)
@@ -134,6 +161,37 @@ func discv5Listen(ctx *cli.Context) error {
 	select {}
 }
 
+func discv5Advertise(ctx *cli.Context) error {
+	topic := getTopicArg(ctx)
+	disc := startV5(ctx)
+	defer disc.Close()
+
+	disc.RegisterTopic(topic)
+	fmt.Println(disc.Self())
+	select {}
+}
+
+func discv5Search(ctx *cli.Context) error {
+	topic := getTopicArg(ctx)
+	disc := startV5(ctx)
+	defer disc.Close()
+
+	it := disc.TopicSearch(topic)
+	time.AfterFunc(ctx.Duration(searchTimeoutFlag.Name), it.Close)
+	for it.Next() {
+		fmt.Println(it.Node())
+	}
+	return nil
+}
+
+// getTopicArg returns the topic ID of the topic name given as the first argument.
+func getTopicArg(ctx *cli.Context) discover.TopicID {
+	if ctx.NArg() < 1 {
+		exit("missing topic as command-line argument")
+	}
+	return discover.NewTopicID(ctx.Args().First())
+}
+
 // startV5 starts an ephemeral discovery v5 node.
 func startV5(ctx *cli.Context) *discover.UDPv5 {
 	ln, config := makeDiscoveryConfig(ctx)
@@ -124,6 +124,12 @@ func (c *Conn) read66() (uint64, Message) {
 			return 0, errorf("could not rlp decode message: %v", err)
 		}
 		return ethMsg.RequestId, PooledTransactions(ethMsg.PooledTransactionsPacket)
+	case (NodeData{}.Code()):
+		ethMsg := new(eth.NodeDataPacket66)
+		if err := rlp.DecodeBytes(rawData, ethMsg); err != nil {
+			return 0, errorf("could not rlp decode message: %v", err)
+		}
+		return ethMsg.RequestId, NodeData(ethMsg.NodeDataPacket)
 	default:
 		msg = errorf("invalid message code: %d", code)
 	}
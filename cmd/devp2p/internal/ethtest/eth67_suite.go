@@ -0,0 +1,140 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethtest
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/internal/utesting"
+	"github.com/ethereum/go-ethereum/p2p"
+)
+
+// Is_67 checks if the node supports the eth67 protocol version,
+// and if not, exits the test suite
+func (s *Suite) Is_67(t *utesting.T) {
+	conn := s.dial67(t)
+	conn.handshake(t)
+	if conn.negotiatedProtoVersion < 67 {
+		t.Fail()
+	}
+}
+
+// TestStatus_67 attempts to connect to the given node and exchange
+// a status message with it on the eth67 protocol, and then check to
+// make sure the chain head and fork ID are correct.
+func (s *Suite) TestStatus_67(t *utesting.T) {
+	conn := s.dial67(t)
+	defer conn.Close()
+	// get protoHandshake
+	conn.handshake(t)
+	// get status
+	switch msg := conn.statusExchange67(t, s.chain).(type) {
+	case *Status:
+		status := *msg
+		if status.ProtocolVersion != uint32(67) {
+			t.Fatalf("mismatch in version: wanted 67, got %d", status.ProtocolVersion)
+		}
+		t.Logf("got status message: %s", pretty.Sdump(msg))
+	default:
+		t.Fatalf("unexpected: %s", pretty.Sdump(msg))
+	}
+}
+
+// TestGetBlockHeaders_67 tests whether the given node can respond to
+// a `GetBlockHeaders` request on eth67 and that the response is accurate.
+func (s *Suite) TestGetBlockHeaders_67(t *utesting.T) {
+	conn := s.setupConnection67(t)
+	defer conn.Close()
+	// get block headers
+	req := &eth.GetBlockHeadersPacket66{
+		RequestId: 3,
+		GetBlockHeadersPacket: &eth.GetBlockHeadersPacket{
+			Origin: eth.HashOrNumber{
+				Hash: s.chain.blocks[1].Hash(),
+			},
+			Amount:  2,
+			Skip:    1,
+			Reverse: false,
+		},
+	}
+	headers, err := s.getBlockHeaders66(conn, req, req.RequestId)
+	if err != nil {
+		t.Fatalf("could not get block headers: %v", err)
+	}
+	// check for correct headers
+	if !headersMatch(t, s.chain, headers) {
+		t.Fatal("received wrong header(s)")
+	}
+}
+
+// TestGetNodeData_67 sends a `GetNodeData` request, which is not part of
+// eth67, and checks that the node disconnects.
+func (s *Suite) TestGetNodeData_67(t *utesting.T) {
+	conn := s.setupConnection67(t)
+	defer conn.Close()
+
+	req := &eth.GetNodeDataPacket66{
+		RequestId:         4,
+		GetNodeDataPacket: []common.Hash{s.chain.Head().Root()},
+	}
+	if err := conn.write66(req, GetNodeData{}.Code()); err != nil {
+		t.Fatalf("could not write to connection: %v", err)
+	}
+	// wait for disconnect, skipping any announcements the node sends
+	start := time.Now()
+	for time.Since(start) < timeout {
+		switch _, msg := conn.readAndServe66(s.chain, timeout); msg := msg.(type) {
+		case *Disconnect, *Error:
+			return
+		case NodeData:
+			t.Fatalf("node data served on eth67: %s", pretty.Sdump(msg))
+		}
+	}
+	t.Fatalf("node did not disconnect")
+}
+
+func (c *Conn) statusExchange67(t *utesting.T, chain *Chain) Message {
+	status := &Status{
+		ProtocolVersion: uint32(67),
+		NetworkID:       chain.chainConfig.ChainID.Uint64(),
+		TD:              chain.TD(chain.Len()),
+		Head:            chain.blocks[chain.Len()-1].Hash(),
+		Genesis:         chain.blocks[0].Hash(),
+		ForkID:          chain.ForkID(),
+	}
+	return c.statusExchange(t, chain, status)
+}
+
+func (s *Suite) dial67(t *utesting.T) *Conn {
+	conn, err := s.dial()
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	conn.caps = append(conn.caps, p2p.Cap{Name: "eth", Version: 66}, p2p.Cap{Name: "eth", Version: 67})
+	conn.ourHighestProtoVersion = 67
+	return conn
+}
+
+func (s *Suite) setupConnection67(t *utesting.T) *Conn {
+	// create conn
+	conn := s.dial67(t)
+	conn.handshake(t)
+	conn.statusExchange67(t, s.chain)
+	return conn
+}
@@ -136,6 +136,15 @@ func (s *Suite) Eth66Tests() []utesting.Test {
 	}
 }
 
+func (s *Suite) Eth67Tests() []utesting.Test {
+	return []utesting.Test{
+		// only proceed with eth67 test suite if node supports eth 67 protocol
+		{Name: "TestStatus_67", Fn: s.TestStatus_67},
+		{Name: "TestGetBlockHeaders_67", Fn: s.TestGetBlockHeaders_67},
+		{Name: "TestGetNodeData_67", Fn: s.TestGetNodeData_67},
+	}
+}
+
 // TestStatus attempts to connect to the given node and exchange
 // a status message with it, and then check to make sure
 // the chain head is correct.
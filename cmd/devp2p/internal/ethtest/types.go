@@ -128,6 +128,16 @@ type PooledTransactions eth.PooledTransactionsPacket
 
 func (pt PooledTransactions) Code() int { return 26 }
 
+// GetNodeData represents a state data query, which is not part of eth/67.
+type GetNodeData eth.GetNodeDataPacket
+
+func (gnd GetNodeData) Code() int { return 29 }
+
+// NodeData is the network packet for state data, which is not part of eth/67.
+type NodeData eth.NodeDataPacket
+
+func (nd NodeData) Code() int { return 30 }
+
 // Conn represents an individual connection with a peer
 type Conn struct {
 	*rlpx.Conn
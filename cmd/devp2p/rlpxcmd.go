@@ -104,5 +104,11 @@ func rlpxEthTest(ctx *cli.Context) error {
 	if is66Failed {
 		return runTests(ctx, suite.EthTests())
 	}
-	return runTests(ctx, suite.AllEthTests())
+	// check if given node supports eth67, and if so, run eth67 protocol tests as well
+	tests := suite.AllEthTests()
+	is67Failed, _ := utesting.Run(utesting.Test{Name: "Is_67", Fn: suite.Is_67})
+	if !is67Failed {
+		tests = append(tests, suite.Eth67Tests()...)
+	}
+	return runTests(ctx, tests)
 }
@@ -0,0 +1,151 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+// era is a utility tool for inspecting Era1 history archives.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/era"
+	"github.com/ethereum/go-ethereum/internal/flags"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// Git SHA1 commit hash of the release (set via linker flags)
+var gitCommit = ""
+var gitDate = ""
+
+var app *cli.App
+
+var (
+	receiptsFlag = cli.BoolFlag{
+		Name:  "receipts",
+		Usage: "also print the receipts of the block",
+	}
+	blockCommand = cli.Command{
+		Name:      "block",
+		Usage:     "Print a block from an archive",
+		ArgsUsage: "<file> <number>",
+		Action:    block,
+		Flags:     []cli.Flag{receiptsFlag},
+	}
+	infoCommand = cli.Command{
+		Name:      "info",
+		Usage:     "Print the metadata of an archive",
+		ArgsUsage: "<file>",
+		Action:    info,
+	}
+)
+
+func init() {
+	app = flags.NewApp(gitCommit, gitDate, "an Era1 history archive inspector")
+	app.Commands = []cli.Command{
+		blockCommand,
+		infoCommand,
+	}
+	cli.CommandHelpTemplate = flags.OriginCommandHelpTemplate
+}
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// block prints a single block, and optionally its receipts, from an archive.
+func block(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return fmt.Errorf("usage: era block %s", ctx.Command.ArgsUsage)
+	}
+	number, err := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid block number: %v", err)
+	}
+	e, err := era.Open(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	defer e.Close()
+
+	block, err := e.GetBlockByNumber(number)
+	if err != nil {
+		return fmt.Errorf("error reading block %d: %v", number, err)
+	}
+	out := struct {
+		Hash         common.Hash        `json:"hash"`
+		Header       *types.Header      `json:"header"`
+		Transactions types.Transactions `json:"transactions"`
+		Uncles       []*types.Header    `json:"uncles"`
+		Receipts     []*types.Receipt   `json:"receipts,omitempty"`
+	}{
+		Hash:         block.Hash(),
+		Header:       block.Header(),
+		Transactions: block.Transactions(),
+		Uncles:       block.Uncles(),
+	}
+	if ctx.Bool(receiptsFlag.Name) {
+		receipts, err := e.GetReceiptsByNumber(number)
+		if err != nil {
+			return fmt.Errorf("error reading receipts %d: %v", number, err)
+		}
+		out.Receipts = receipts
+	}
+	return printJSON(out)
+}
+
+// info prints the range, accumulator and initial total difficulty of an archive.
+func info(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("usage: era info %s", ctx.Command.ArgsUsage)
+	}
+	e, err := era.Open(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	defer e.Close()
+
+	root, err := e.Accumulator()
+	if err != nil {
+		return fmt.Errorf("error reading accumulator: %v", err)
+	}
+	td, err := e.InitialTD()
+	if err != nil {
+		return fmt.Errorf("error reading total difficulty: %v", err)
+	}
+	return printJSON(struct {
+		Accumulator common.Hash `json:"accumulatorRoot"`
+		TotalDiff   *big.Int    `json:"totalDifficulty"`
+		Start       uint64      `json:"startBlock"`
+		Count       uint64      `json:"count"`
+	}{root, td, e.Start(), e.Count()})
+}
+
+func printJSON(v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
+}
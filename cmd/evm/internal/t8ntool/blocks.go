@@ -0,0 +1,409 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package t8ntool
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// blockInput is the environment and the transactions of a single block, when
+// applying a sequence of blocks.
+type blockInput struct {
+	Env *stEnv       `json:"env"`
+	Txs []*txWithKey `json:"txs"`
+}
+
+// applyBlocks applies a sequence of blocks on top of the prestate alloc, each
+// block starting from the post-state of the previous one. The alloc, result and
+// body of every block are written to a directory named after its position in
+// the sequence, or collected into a list if destined to stdout or stderr.
+func applyBlocks(ctx *cli.Context, baseDir string, inputData *input, blocksStr string, chainConfig *params.ChainConfig,
+	vmConfig vm.Config, getTracer func(txIndex int, txHash common.Hash) (vm.Tracer, error)) error {
+
+	blocks := inputData.Blocks
+	if blocksStr != stdinSelector {
+		inFile, err := os.Open(blocksStr)
+		if err != nil {
+			return NewError(ErrorIO, fmt.Errorf("failed reading blocks file: %v", err))
+		}
+		defer inFile.Close()
+		decoder := json.NewDecoder(inFile)
+		if err := decoder.Decode(&blocks); err != nil {
+			return NewError(ErrorJson, fmt.Errorf("Failed unmarshaling blocks-file: %v", err))
+		}
+	}
+	if len(blocks) == 0 {
+		return NewError(ErrorJson, errors.New("no blocks to apply"))
+	}
+	for i, block := range blocks {
+		if block.Env == nil {
+			return NewError(ErrorJson, fmt.Errorf("block %d: missing env", i))
+		}
+	}
+	// Assemble the blocks into a chain if requested
+	var builder *chainBuilder
+	if ctx.String(OutputBlocksFlag.Name) != "" {
+		var err error
+		if builder, err = newChainBuilder(ctx, chainConfig, inputData.Alloc, blocks[0].Env); err != nil {
+			return err
+		}
+		defer builder.close()
+	}
+	var (
+		alloc  = inputData.Alloc
+		stdOut []map[string]interface{}
+		stdErr []map[string]interface{}
+	)
+	for i, block := range blocks {
+		env := *block.Env
+		reward := ctx.Int64(RewardFlag.Name)
+		if builder != nil {
+			err := builder.prepare(&env)
+			if err == nil {
+				reward, err = builder.reward(ctx, &env)
+			}
+			if err != nil {
+				return NewError(ErrorVMConfig, fmt.Errorf("block %d: %v", i, err))
+			}
+		}
+		signer := types.MakeSigner(chainConfig, new(big.Int).SetUint64(env.Number))
+		txs, err := signUnsignedTransactions(block.Txs, signer)
+		if err != nil {
+			return NewError(ErrorJson, fmt.Errorf("Block %d: failed signing transactions: %v", i, err))
+		}
+		if chainConfig.IsLondon(new(big.Int).SetUint64(env.Number)) && env.BaseFee == nil {
+			return NewError(ErrorVMConfig, fmt.Errorf("block %d: EIP-1559 config but missing 'currentBaseFee' in env section", i))
+		}
+		prestate := &Prestate{Env: env, Pre: alloc}
+		state, result, err := prestate.Apply(vmConfig, chainConfig, txs, reward, getTracer)
+		if err != nil {
+			return err
+		}
+		body, _ := rlp.EncodeToBytes(txs)
+		collector := make(Alloc)
+		state.DumpToCollector(collector, false, false, false, nil, -1)
+
+		if builder != nil {
+			if err := builder.add(&env, includedTransactions(txs, result.Rejected), result); err != nil {
+				return NewError(ErrorEVM, fmt.Errorf("block %d: %v", i, err))
+			}
+		}
+		// Dispatch the output of the block, files going into a subdirectory
+		blockDir := path.Join(baseDir, strconv.Itoa(i))
+		for _, flag := range []cli.StringFlag{OutputAllocFlag, OutputResultFlag, OutputBodyFlag} {
+			if name := ctx.String(flag.Name); name != "" && name != "stdout" && name != "stderr" {
+				if err := os.MkdirAll(blockDir, 0755); err != nil {
+					return NewError(ErrorIO, fmt.Errorf("failed creating output directory: %v", err))
+				}
+				break
+			}
+		}
+		stdOutObject := make(map[string]interface{})
+		stdErrObject := make(map[string]interface{})
+		if err := collectOutput(ctx, blockDir, result, collector, body, stdOutObject, stdErrObject); err != nil {
+			return err
+		}
+		if len(stdOutObject) > 0 {
+			stdOut = append(stdOut, stdOutObject)
+		}
+		if len(stdErrObject) > 0 {
+			stdErr = append(stdErr, stdErrObject)
+		}
+		alloc = core.GenesisAlloc(collector)
+	}
+	if builder != nil {
+		if err := builder.save(baseDir, ctx.String(OutputGenesisFlag.Name), ctx.String(OutputBlocksFlag.Name)); err != nil {
+			return err
+		}
+	}
+	stdOutObject := make(map[string]interface{})
+	if len(stdOut) > 0 {
+		stdOutObject["blocks"] = stdOut
+	}
+	stdErrObject := make(map[string]interface{})
+	if len(stdErr) > 0 {
+		stdErrObject["blocks"] = stdErr
+	}
+	return writeStdOutput(stdOutObject, stdErrObject)
+}
+
+// includedTransactions returns the transactions which were not rejected.
+func includedTransactions(txs types.Transactions, rejected []int) types.Transactions {
+	skip := make(map[int]bool)
+	for _, i := range rejected {
+		skip[i] = true
+	}
+	var included types.Transactions
+	for i, tx := range txs {
+		if !skip[i] {
+			included = append(included, tx)
+		}
+	}
+	return included
+}
+
+// chainBuilder assembles the applied blocks into a chain on top of a genesis made
+// of the prestate alloc, which can be imported by geth. The fields of the block
+// environments that consensus derives from the parent block (difficulty and base
+// fee) are computed by the builder, so that the chain is valid.
+type chainBuilder struct {
+	config  *params.ChainConfig
+	genesis *core.Genesis
+	parent  *types.Header
+	hashes  map[math.HexOrDecimal64]common.Hash // Hashes of the blocks assembled so far
+	blocks  []*types.Block
+
+	cliqueKey *ecdsa.PrivateKey // Signer of the blocks if sealing with clique
+	ethash    *ethash.Ethash    // Proof-of-work sealer if sealing with ethash
+}
+
+// newChainBuilder creates the genesis block of the chain. The gas limit and the
+// difficulty of the genesis are those of the first block.
+func newChainBuilder(ctx *cli.Context, config *params.ChainConfig, alloc core.GenesisAlloc, first *stEnv) (*chainBuilder, error) {
+	b := &chainBuilder{
+		config: config,
+		hashes: make(map[math.HexOrDecimal64]common.Hash),
+	}
+	// The genesis configures the consensus engine, leave the shared config alone
+	genesisConfig := *config
+	genesis := &core.Genesis{
+		Config:     &genesisConfig,
+		Alloc:      alloc,
+		GasLimit:   first.GasLimit,
+		Difficulty: first.Difficulty,
+	}
+	switch {
+	case ctx.IsSet(SealCliqueFlag.Name):
+		if ctx.Bool(SealEthashFlag.Name) {
+			return nil, NewError(ErrorVMConfig, errors.New("both clique and ethash sealing requested"))
+		}
+		key, err := crypto.LoadECDSA(ctx.String(SealCliqueFlag.Name))
+		if err != nil {
+			return nil, NewError(ErrorIO, fmt.Errorf("failed loading clique key: %v", err))
+		}
+		b.cliqueKey = key
+
+		// The genesis of a clique chain lists its signers
+		genesisConfig.Clique = &params.CliqueConfig{Period: 0, Epoch: 30000}
+		genesis.Difficulty = big.NewInt(1)
+		genesis.ExtraData = make([]byte, 32+common.AddressLength+crypto.SignatureLength)
+		copy(genesis.ExtraData[32:], crypto.PubkeyToAddress(key.PublicKey).Bytes())
+
+	case ctx.Bool(SealEthashFlag.Name):
+		genesisConfig.Ethash = new(params.EthashConfig)
+		b.ethash = ethash.New(ethash.Config{
+			PowMode:        ethash.ModeNormal,
+			CacheDir:       ctx.String(SealEthashDirFlag.Name),
+			CachesInMem:    2,
+			CachesOnDisk:   3,
+			DatasetDir:     ctx.String(SealEthashDirFlag.Name),
+			DatasetsInMem:  1,
+			DatasetsOnDisk: 2,
+		}, nil, false)
+
+	default:
+		genesisConfig.Ethash = new(params.EthashConfig)
+	}
+	b.genesis = genesis
+	b.parent = genesis.ToBlock(nil).Header()
+	b.hashes[0] = b.parent.Hash()
+	return b, nil
+}
+
+// close releases the resources of the sealer.
+func (b *chainBuilder) close() {
+	if b.ethash != nil {
+		b.ethash.Close()
+	}
+}
+
+// prepare checks that the environment follows the last block of the chain, and
+// fills in the fields consensus derives from it.
+func (b *chainBuilder) prepare(env *stEnv) error {
+	if env.Number != b.parent.Number.Uint64()+1 {
+		return fmt.Errorf("block number %d does not follow parent %d", env.Number, b.parent.Number)
+	}
+	if env.Timestamp <= b.parent.Time {
+		return fmt.Errorf("timestamp %d not after parent timestamp %d", env.Timestamp, b.parent.Time)
+	}
+	if len(env.Ommers) > 0 {
+		return errors.New("ommers are not supported when assembling blocks")
+	}
+	var difficulty *big.Int
+	if b.cliqueKey != nil {
+		// Blocks are always signed in-turn by the single signer, who also receives
+		// the fees of the block.
+		difficulty = big.NewInt(2)
+		env.Coinbase = crypto.PubkeyToAddress(b.cliqueKey.PublicKey)
+	} else {
+		difficulty = ethash.CalcDifficulty(b.config, env.Timestamp, b.parent)
+	}
+	if env.Difficulty != nil && env.Difficulty.Cmp(difficulty) != 0 {
+		log.Warn("Overriding block difficulty", "number", env.Number, "have", env.Difficulty, "want", difficulty)
+	}
+	env.Difficulty = difficulty
+
+	if b.config.IsLondon(new(big.Int).SetUint64(env.Number)) {
+		baseFee := misc.CalcBaseFee(b.config, b.parent)
+		if env.BaseFee != nil && env.BaseFee.Cmp(baseFee) != 0 {
+			log.Warn("Overriding block base fee", "number", env.Number, "have", env.BaseFee, "want", baseFee)
+		}
+		env.BaseFee = baseFee
+	}
+	// Make the hashes of the assembled blocks available to BLOCKHASH
+	hashes := make(map[math.HexOrDecimal64]common.Hash)
+	for number, hash := range env.BlockHashes {
+		hashes[number] = hash
+	}
+	for number, hash := range b.hashes {
+		hashes[number] = hash
+	}
+	env.BlockHashes = hashes
+	return nil
+}
+
+// reward returns the mining reward to apply to the block. Unless overridden, it
+// is the block reward of ethash at the block's fork. Clique has no rewards.
+func (b *chainBuilder) reward(ctx *cli.Context, env *stEnv) (int64, error) {
+	if b.cliqueKey != nil {
+		if ctx.Int64(RewardFlag.Name) > 0 {
+			return 0, errors.New("clique does not support mining rewards")
+		}
+		return -1, nil
+	}
+	if ctx.IsSet(RewardFlag.Name) {
+		return ctx.Int64(RewardFlag.Name), nil
+	}
+	number := new(big.Int).SetUint64(env.Number)
+	switch {
+	case b.config.IsConstantinople(number):
+		return ethash.ConstantinopleBlockReward.Int64(), nil
+	case b.config.IsByzantium(number):
+		return ethash.ByzantiumBlockReward.Int64(), nil
+	default:
+		return ethash.FrontierBlockReward.Int64(), nil
+	}
+}
+
+// add assembles and seals the next block of the chain out of the result of its
+// execution.
+func (b *chainBuilder) add(env *stEnv, txs types.Transactions, result *ExecutionResult) error {
+	var gasUsed uint64
+	if len(result.Receipts) > 0 {
+		gasUsed = result.Receipts[len(result.Receipts)-1].CumulativeGasUsed
+	}
+	header := &types.Header{
+		ParentHash: b.parent.Hash(),
+		Coinbase:   env.Coinbase,
+		Root:       result.StateRoot,
+		Difficulty: env.Difficulty,
+		Number:     new(big.Int).SetUint64(env.Number),
+		GasLimit:   env.GasLimit,
+		GasUsed:    gasUsed,
+		Time:       env.Timestamp,
+		BaseFee:    env.BaseFee,
+	}
+	if b.cliqueKey != nil {
+		// The coinbase of clique blocks is a signer vote, which we don't cast
+		header.Coinbase = common.Address{}
+	}
+	block := types.NewBlock(header, txs, nil, result.Receipts, trie.NewStackTrie(nil))
+	if block.TxHash() != result.TxRoot || block.ReceiptHash() != result.ReceiptRoot {
+		return fmt.Errorf("block roots mismatch: tx %x != %x, receipt %x != %x", block.TxHash(), result.TxRoot, block.ReceiptHash(), result.ReceiptRoot)
+	}
+	block, err := b.seal(block)
+	if err != nil {
+		return err
+	}
+	log.Info("Assembled block", "number", block.Number(), "hash", block.Hash(), "txs", len(txs))
+
+	b.parent = block.Header()
+	b.hashes[math.HexOrDecimal64(env.Number)] = block.Hash()
+	b.blocks = append(b.blocks, block)
+	return nil
+}
+
+// seal seals the block with the configured consensus engine. Unsealed blocks can
+// be imported by geth with --fakepow.
+func (b *chainBuilder) seal(block *types.Block) (*types.Block, error) {
+	switch {
+	case b.cliqueKey != nil:
+		header := block.Header()
+		header.Extra = make([]byte, 32+crypto.SignatureLength)
+		sig, err := crypto.Sign(clique.SealHash(header).Bytes(), b.cliqueKey)
+		if err != nil {
+			return nil, err
+		}
+		copy(header.Extra[32:], sig)
+		return block.WithSeal(header), nil
+
+	case b.ethash != nil:
+		results := make(chan *types.Block, 1)
+		if err := b.ethash.Seal(nil, block, results, nil); err != nil {
+			return nil, err
+		}
+		return <-results, nil
+
+	default:
+		return block, nil
+	}
+}
+
+// save writes the genesis and the RLP encoded blocks of the chain, in the format
+// of geth export, to the output files.
+func (b *chainBuilder) save(baseDir, genesisFile, blocksFile string) error {
+	if err := saveFile(baseDir, genesisFile, b.genesis); err != nil {
+		return err
+	}
+	var blob []byte
+	for _, block := range b.blocks {
+		enc, err := rlp.EncodeToBytes(block)
+		if err != nil {
+			return NewError(ErrorEVM, fmt.Errorf("failed encoding block: %v", err))
+		}
+		blob = append(blob, enc...)
+	}
+	location := path.Join(baseDir, blocksFile)
+	if err := ioutil.WriteFile(location, blob, 0644); err != nil {
+		return NewError(ErrorIO, fmt.Errorf("failed writing output: %v", err))
+	}
+	log.Info("Wrote file", "file", location)
+	return nil
+}
@@ -83,6 +83,37 @@ var (
 		Usage: "`stdin` or file name of where to find the transactions to apply.",
 		Value: "txs.json",
 	}
+	InputBlocksFlag = cli.StringFlag{
+		Name: "input.blocks",
+		Usage: "`stdin` or file name of where to find a sequence of block environments and transactions to apply.\n" +
+			"\tIf set, the blocks are applied one after the other on top of the prestate alloc, and\n" +
+			"\tthe env and txs inputs are ignored.",
+		Value: "",
+	}
+	OutputBlocksFlag = cli.StringFlag{
+		Name:  "output.blocks",
+		Usage: "If set, the applied blocks are assembled into a chain, which is RLP encoded and written to this file (requires --input.blocks).",
+		Value: "",
+	}
+	OutputGenesisFlag = cli.StringFlag{
+		Name:  "output.genesis",
+		Usage: "File name of where to write the genesis of the chain of --output.blocks.",
+		Value: "genesis.json",
+	}
+	SealCliqueFlag = cli.StringFlag{
+		Name:  "seal.clique",
+		Usage: "File name of the hex encoded private key to seal the blocks of --output.blocks with, using clique",
+		Value: "",
+	}
+	SealEthashFlag = cli.BoolFlag{
+		Name:  "seal.ethash",
+		Usage: "Seal the blocks of --output.blocks with ethash proof-of-work",
+	}
+	SealEthashDirFlag = cli.StringFlag{
+		Name:  "seal.ethash.dir",
+		Usage: "Directory to store the ethash caches and datasets in",
+		Value: "",
+	}
 	RewardFlag = cli.Int64Flag{
 		Name:  "state.reward",
 		Usage: "Mining reward. Set to -1 to disable",
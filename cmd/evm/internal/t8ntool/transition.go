@@ -19,6 +19,7 @@ package t8ntool
 import (
 	"crypto/ecdsa"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -71,6 +72,8 @@ type input struct {
 	Alloc core.GenesisAlloc `json:"alloc,omitempty"`
 	Env   *stEnv            `json:"env,omitempty"`
 	Txs   []*txWithKey      `json:"txs,omitempty"`
+
+	Blocks []*blockInput `json:"blocks,omitempty"`
 }
 
 func Main(ctx *cli.Context) error {
@@ -137,10 +140,11 @@ func Main(ctx *cli.Context) error {
 
 		envStr    = ctx.String(InputEnvFlag.Name)
 		txStr     = ctx.String(InputTxsFlag.Name)
+		blocksStr = ctx.String(InputBlocksFlag.Name)
 		inputData = &input{}
 	)
 	// Figure out the prestate alloc
-	if allocStr == stdinSelector || envStr == stdinSelector || txStr == stdinSelector {
+	if allocStr == stdinSelector || envStr == stdinSelector || txStr == stdinSelector || blocksStr == stdinSelector {
 		decoder := json.NewDecoder(os.Stdin)
 		decoder.Decode(inputData)
 	}
@@ -157,6 +161,25 @@ func Main(ctx *cli.Context) error {
 	}
 	prestate.Pre = inputData.Alloc
 
+	vmConfig := vm.Config{
+		Tracer: tracer,
+		Debug:  (tracer != nil),
+	}
+	// Construct the chainconfig
+	var chainConfig *params.ChainConfig
+	if cConf, extraEips, err := tests.GetChainConfig(ctx.String(ForknameFlag.Name)); err != nil {
+		return NewError(ErrorVMConfig, fmt.Errorf("Failed constructing chain configuration: %v", err))
+	} else {
+		chainConfig = cConf
+		vmConfig.ExtraEips = extraEips
+	}
+	// Set the chain id
+	chainConfig.ChainID = big.NewInt(ctx.Int64(ChainIDFlag.Name))
+
+	// Chain the blocks together if a sequence of them was requested
+	if blocksStr != "" {
+		return applyBlocks(ctx, baseDir, inputData, blocksStr, chainConfig, vmConfig, getTracer)
+	}
 	// Set the block environment
 	if envStr != stdinSelector {
 		inFile, err := os.Open(envStr)
@@ -173,21 +196,6 @@ func Main(ctx *cli.Context) error {
 	}
 	prestate.Env = *inputData.Env
 
-	vmConfig := vm.Config{
-		Tracer: tracer,
-		Debug:  (tracer != nil),
-	}
-	// Construct the chainconfig
-	var chainConfig *params.ChainConfig
-	if cConf, extraEips, err := tests.GetChainConfig(ctx.String(ForknameFlag.Name)); err != nil {
-		return NewError(ErrorVMConfig, fmt.Errorf("Failed constructing chain configuration: %v", err))
-	} else {
-		chainConfig = cConf
-		vmConfig.ExtraEips = extraEips
-	}
-	// Set the chain id
-	chainConfig.ChainID = big.NewInt(ctx.Int64(ChainIDFlag.Name))
-
 	var txsWithKeys []*txWithKey
 	if txStr != stdinSelector {
 		inFile, err := os.Open(txStr)
@@ -209,6 +217,13 @@ func Main(ctx *cli.Context) error {
 		return NewError(ErrorJson, fmt.Errorf("Failed signing transactions: %v", err))
 	}
 
+	// Sanity check, to not `panic` in state_transition
+	if chainConfig.IsLondon(big.NewInt(int64(prestate.Env.Number))) {
+		if prestate.Env.BaseFee == nil {
+			return NewError(ErrorVMConfig, errors.New("EIP-1559 config but missing 'currentBaseFee' in env section"))
+		}
+	}
+
 	// Iterate over all the tests, run them and aggregate the results
 
 	// Run the test and aggregate the result
@@ -330,6 +345,15 @@ func saveFile(baseDir, filename string, data interface{}) error {
 func dispatchOutput(ctx *cli.Context, baseDir string, result *ExecutionResult, alloc Alloc, body hexutil.Bytes) error {
 	stdOutObject := make(map[string]interface{})
 	stdErrObject := make(map[string]interface{})
+	if err := collectOutput(ctx, baseDir, result, alloc, body, stdOutObject, stdErrObject); err != nil {
+		return err
+	}
+	return writeStdOutput(stdOutObject, stdErrObject)
+}
+
+// collectOutput saves the output data to the specified files, or adds them to the
+// objects destined to stdout and stderr.
+func collectOutput(ctx *cli.Context, baseDir string, result *ExecutionResult, alloc Alloc, body hexutil.Bytes, stdOutObject, stdErrObject map[string]interface{}) error {
 	dispatch := func(baseDir, fName, name string, obj interface{}) error {
 		switch fName {
 		case "stdout":
@@ -351,9 +375,11 @@ func dispatchOutput(ctx *cli.Context, baseDir string, result *ExecutionResult, a
 	if err := dispatch(baseDir, ctx.String(OutputResultFlag.Name), "result", result); err != nil {
 		return err
 	}
-	if err := dispatch(baseDir, ctx.String(OutputBodyFlag.Name), "body", body); err != nil {
-		return err
-	}
+	return dispatch(baseDir, ctx.String(OutputBodyFlag.Name), "body", body)
+}
+
+// writeStdOutput writes the collected output objects to stdout and stderr.
+func writeStdOutput(stdOutObject, stdErrObject map[string]interface{}) error {
 	if len(stdOutObject) > 0 {
 		b, err := json.MarshalIndent(stdOutObject, "", " ")
 		if err != nil {
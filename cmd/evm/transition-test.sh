@@ -189,3 +189,35 @@ echo ""
 echo "In order to meaningfully chain invocations, one would need to provide meaningful new \`env\`, otherwise the"
 echo "actual blocknumber (exposed to the EVM) would not increase."
 echo ""
+
+echo "### Multiple blocks"
+echo ""
+echo "Instead of a single \`env\` and set of transactions, a sequence of blocks can be given with"
+echo "\`--input.blocks\`, each one being an object with an \`env\` and a list of \`txs\`. The blocks are"
+echo "applied one after the other, every block starting from the post-state of the previous one,"
+echo "including the mining reward. The \`alloc\`, \`result\` and \`body\` of every block are written into"
+echo "a directory named after the position of the block in the sequence, or listed under \`blocks\`"
+echo "if written to \`stdout\` or \`stderr\`."
+echo ""
+cmd="./evm t8n --input.alloc=./testdata/9/alloc.json --input.blocks=./testdata/9/blocks.json --state.fork=London --output.basedir=out"
+tick && echo $cmd && tick
+$cmd 2>/dev/null
+tick && find out -name "*.json" | sort && tick
+echo ""
+echo "With \`--output.blocks\`, the blocks are also assembled into a chain on top of a genesis made"
+echo "of the prestate alloc, and written in the format of \`geth export\`. The genesis is written to"
+echo "\`--output.genesis\`. Block numbers have to be consecutive, starting at \`1\`, and timestamps have"
+echo "to increase. The difficulty and base fee of every block are derived from its parent, and the"
+echo "hashes of the previous blocks are available to \`BLOCKHASH\`. Unless \`--state.reward\` is set,"
+echo "the mining reward of every block is the ethash block reward of its fork."
+echo ""
+echo "The blocks are sealed with ethash proof-of-work if \`--seal.ethash\` is given, which requires"
+echo "generating the ethash dataset, or signed by a single clique signer with \`--seal.clique\`."
+echo "Unsealed blocks can be imported with \`geth --fakepow\`:"
+echo ""
+cmd="./evm t8n --input.alloc=./testdata/9/alloc.json --input.blocks=./testdata/9/blocks.json --state.fork=London --output.basedir=out --output.blocks=blocks.rlp"
+tick
+echo $cmd
+echo "geth --datadir=chain init out/genesis.json"
+echo "geth --datadir=chain --fakepow import out/blocks.rlp"
+tick
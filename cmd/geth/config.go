@@ -227,4 +227,25 @@ func applyMetricConfig(ctx *cli.Context, cfg *gethConfig) {
 	if ctx.GlobalIsSet(utils.MetricsInfluxDBTagsFlag.Name) {
 		cfg.Metrics.InfluxDBTags = ctx.GlobalString(utils.MetricsInfluxDBTagsFlag.Name)
 	}
+	if ctx.GlobalIsSet(utils.MetricsEnableRemoteWriteFlag.Name) {
+		cfg.Metrics.EnableRemoteWrite = ctx.GlobalBool(utils.MetricsEnableRemoteWriteFlag.Name)
+	}
+	if ctx.GlobalIsSet(utils.MetricsRemoteWriteEndpointFlag.Name) {
+		cfg.Metrics.RemoteWriteEndpoint = ctx.GlobalString(utils.MetricsRemoteWriteEndpointFlag.Name)
+	}
+	if ctx.GlobalIsSet(utils.MetricsEnableOTLPFlag.Name) {
+		cfg.Metrics.EnableOTLP = ctx.GlobalBool(utils.MetricsEnableOTLPFlag.Name)
+	}
+	if ctx.GlobalIsSet(utils.MetricsOTLPEndpointFlag.Name) {
+		cfg.Metrics.OTLPEndpoint = ctx.GlobalString(utils.MetricsOTLPEndpointFlag.Name)
+	}
+	if ctx.GlobalIsSet(utils.MetricsPushIntervalFlag.Name) {
+		cfg.Metrics.PushInterval = ctx.GlobalDuration(utils.MetricsPushIntervalFlag.Name)
+	}
+	if ctx.GlobalIsSet(utils.MetricsPushRetriesFlag.Name) {
+		cfg.Metrics.PushRetries = ctx.GlobalInt(utils.MetricsPushRetriesFlag.Name)
+	}
+	if ctx.GlobalIsSet(utils.MetricsPushLabelsFlag.Name) {
+		cfg.Metrics.PushLabels = ctx.GlobalString(utils.MetricsPushLabelsFlag.Name)
+	}
 }
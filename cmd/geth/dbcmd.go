@@ -62,6 +62,7 @@ Remove blockchain and state databases`,
 			dbPutCmd,
 			dbGetSlotsCmd,
 			dbDumpFreezerIndex,
+			dbPruneHistoryCmd,
 		},
 	}
 	dbInspectCmd = cli.Command{
@@ -195,6 +196,25 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 		},
 		Description: "This command displays information about the freezer index.",
 	}
+	dbPruneHistoryCmd = cli.Command{
+		Action:    utils.MigrateFlags(dbPruneHistory),
+		Name:      "prune-history",
+		Usage:     "Delete the block bodies and receipts below a given block",
+		ArgsUsage: "<number>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.SyncModeFlag,
+			utils.MainnetFlag,
+			utils.RopstenFlag,
+			utils.RinkebyFlag,
+			utils.GoerliFlag,
+			utils.BaikalFlag,
+		},
+		Description: `This command deletes the bodies and receipts of all the blocks below the
+given number from the ancient store, retaining their headers. Only blocks already
+moved into the ancient store can be pruned, and the transaction indices of the
+pruned blocks are deleted too. The pruned blocks will no longer be served to peers.`,
+	}
 )
 
 func removeDB(ctx *cli.Context) error {
@@ -507,3 +527,32 @@ func freezerInspect(ctx *cli.Context) error {
 	}
 	return nil
 }
+
+// dbPruneHistory deletes the block bodies and receipts below the given block
+// from the ancient store.
+func dbPruneHistory(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	tail, err := strconv.ParseUint(ctx.Args().Get(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid block number: %v", err)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	if old := rawdb.ReadHistoryTail(db); tail <= old {
+		log.Info("History already pruned", "tail", old)
+		return nil
+	}
+	start := time.Now()
+	log.Info("Pruning chain history", "tail", tail)
+	if err := rawdb.PruneHistory(db, tail, nil); err != nil {
+		return err
+	}
+	log.Info("Pruned chain history", "tail", rawdb.ReadHistoryTail(db), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
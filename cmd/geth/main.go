@@ -60,6 +60,7 @@ var (
 		utils.BootnodesFlag,
 		utils.DataDirFlag,
 		utils.AncientFlag,
+		utils.DBEngineFlag,
 		utils.MinFreeDiskSpaceFlag,
 		utils.KeyStoreDirFlag,
 		utils.ExternalSignerFlag,
@@ -90,6 +91,7 @@ var (
 		utils.ExitWhenSyncedFlag,
 		utils.GCModeFlag,
 		utils.SnapshotFlag,
+		utils.StateSchemeFlag,
 		utils.TxLookupLimitFlag,
 		utils.LightServeFlag,
 		utils.LightIngressFlag,
@@ -144,6 +146,8 @@ var (
 		utils.EthStatsURLFlag,
 		utils.FakePoWFlag,
 		utils.NoCompactionFlag,
+		utils.TracingEnabledFlag,
+		utils.TracingEndpointFlag,
 		utils.GpoBlocksFlag,
 		utils.GpoPercentileFlag,
 		utils.GpoMaxGasPriceFlag,
@@ -172,17 +176,28 @@ var (
 		utils.GraphQLVirtualHostsFlag,
 		utils.HTTPApiFlag,
 		utils.HTTPPathPrefixFlag,
+		utils.HTTPAuthFlag,
 		utils.WSEnabledFlag,
 		utils.WSListenAddrFlag,
 		utils.WSPortFlag,
 		utils.WSApiFlag,
 		utils.WSAllowedOriginsFlag,
 		utils.WSPathPrefixFlag,
+		utils.WSAuthFlag,
+		utils.AuthListenFlag,
+		utils.AuthPortFlag,
+		utils.AuthVirtualHostsFlag,
+		utils.AuthApiFlag,
+		utils.JWTSecretFlag,
 		utils.IPCDisabledFlag,
 		utils.IPCPathFlag,
 		utils.InsecureUnlockAllowedFlag,
 		utils.RPCGlobalGasCapFlag,
 		utils.RPCGlobalTxFeeCapFlag,
+		utils.RPCBatchLimitFlag,
+		utils.RPCBatchResponseLimitFlag,
+		utils.RPCConcurrentCallsFlag,
+		utils.RPCRateLimitFlag,
 		utils.AllowUnprotectedTxs,
 	}
 
@@ -197,6 +212,13 @@ var (
 		utils.MetricsInfluxDBUsernameFlag,
 		utils.MetricsInfluxDBPasswordFlag,
 		utils.MetricsInfluxDBTagsFlag,
+		utils.MetricsEnableRemoteWriteFlag,
+		utils.MetricsRemoteWriteEndpointFlag,
+		utils.MetricsEnableOTLPFlag,
+		utils.MetricsOTLPEndpointFlag,
+		utils.MetricsPushIntervalFlag,
+		utils.MetricsPushRetriesFlag,
+		utils.MetricsPushLabelsFlag,
 	}
 )
 
@@ -210,6 +232,9 @@ func init() {
 		initCommand,
 		importCommand,
 		exportCommand,
+		importHistoryCommand,
+		exportHistoryCommand,
+		verifyHistoryCommand,
 		importPreimagesCommand,
 		exportPreimagesCommand,
 		removedbCommand,
@@ -59,6 +59,8 @@ import (
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/metrics/exp"
 	"github.com/ethereum/go-ethereum/metrics/influxdb"
+	"github.com/ethereum/go-ethereum/metrics/otlp"
+	"github.com/ethereum/go-ethereum/metrics/prometheus"
 	"github.com/ethereum/go-ethereum/miner"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p"
@@ -66,6 +68,7 @@ import (
 	"github.com/ethereum/go-ethereum/p2p/nat"
 	"github.com/ethereum/go-ethereum/p2p/netutil"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
 	pcsclite "github.com/gballet/go-libpcsclite"
 	gopsutil "github.com/shirou/gopsutil/mem"
 	"gopkg.in/urfave/cli.v1"
@@ -117,6 +120,11 @@ var (
 		Name:  "datadir.ancient",
 		Usage: "Data directory for ancient chain segments (default = inside chaindata)",
 	}
+	DBEngineFlag = cli.StringFlag{
+		Name:  "db.engine",
+		Usage: "Backing database implementation to use ('leveldb' or 'pebble')",
+		Value: "",
+	}
 	MinFreeDiskSpaceFlag = DirectoryFlag{
 		Name:  "datadir.minfreedisk",
 		Usage: "Minimum free disk space in MB, once reached triggers auto shut down (default = --cache.gc converted to MB, 0 = disabled)",
@@ -215,6 +223,10 @@ var (
 		Name:  "snapshot",
 		Usage: `Enables snapshot-database mode (default = enable)`,
 	}
+	StateSchemeFlag = cli.StringFlag{
+		Name:  "state.scheme",
+		Usage: `Scheme to use for storing state trie nodes, "hash" or "path" (experimental, full sync only, default = keep the existing one)`,
+	}
 	TxLookupLimitFlag = cli.Uint64Flag{
 		Name:  "txlookuplimit",
 		Usage: "Number of recent blocks to maintain transactions index for (default = about one year, 0 = entire chain)",
@@ -501,6 +513,22 @@ var (
 		Usage: "Sets a cap on transaction fee (in ether) that can be sent via the RPC APIs (0 = no cap)",
 		Value: ethconfig.Defaults.RPCTxFeeCap,
 	}
+	RPCBatchLimitFlag = cli.IntFlag{
+		Name:  "rpc.batchlimit",
+		Usage: "Maximum number of requests in a batch served via HTTP and WS, not applied to the authenticated API (0 = no limit)",
+	}
+	RPCBatchResponseLimitFlag = cli.IntFlag{
+		Name:  "rpc.batchresponselimit",
+		Usage: "Maximum size in bytes of a batch response served via HTTP and WS, not applied to the authenticated API (0 = no limit)",
+	}
+	RPCConcurrentCallsFlag = cli.IntFlag{
+		Name:  "rpc.concurrentcalls",
+		Usage: "Maximum number of concurrently executing calls per WS connection, not applied to the authenticated API (0 = no limit)",
+	}
+	RPCRateLimitFlag = cli.Float64Flag{
+		Name:  "rpc.ratelimit",
+		Usage: "Maximum number of calls per second per WS connection, not applied to the authenticated API (0 = no limit)",
+	}
 	// Logging and debug settings
 	EthStatsURLFlag = cli.StringFlag{
 		Name:  "ethstats",
@@ -557,6 +585,10 @@ var (
 		Usage: "HTTP path path prefix on which JSON-RPC is served. Use '/' to serve on all paths.",
 		Value: "",
 	}
+	HTTPAuthFlag = cli.BoolFlag{
+		Name:  "http.auth",
+		Usage: "Require JWT authentication on the HTTP-RPC server",
+	}
 	GraphQLEnabledFlag = cli.BoolFlag{
 		Name:  "graphql",
 		Usage: "Enable GraphQL on the HTTP-RPC server. Note that GraphQL can only be started if an HTTP server is started as well.",
@@ -600,6 +632,34 @@ var (
 		Usage: "HTTP path prefix on which JSON-RPC is served. Use '/' to serve on all paths.",
 		Value: "",
 	}
+	WSAuthFlag = cli.BoolFlag{
+		Name:  "ws.auth",
+		Usage: "Require JWT authentication on the WS-RPC server",
+	}
+	AuthListenFlag = cli.StringFlag{
+		Name:  "authrpc.addr",
+		Usage: "Listening address for authenticated APIs",
+		Value: node.DefaultConfig.AuthAddr,
+	}
+	AuthPortFlag = cli.IntFlag{
+		Name:  "authrpc.port",
+		Usage: "Listening port for authenticated APIs",
+		Value: node.DefaultConfig.AuthPort,
+	}
+	AuthVirtualHostsFlag = cli.StringFlag{
+		Name:  "authrpc.vhosts",
+		Usage: "Comma separated list of virtual hostnames from which to accept requests (server enforced). Accepts '*' wildcard.",
+		Value: strings.Join(node.DefaultConfig.AuthVirtualHosts, ","),
+	}
+	AuthApiFlag = cli.StringFlag{
+		Name:  "authrpc.api",
+		Usage: "API's offered over the authenticated HTTP and WS interface (disabled if empty)",
+		Value: "",
+	}
+	JWTSecretFlag = cli.StringFlag{
+		Name:  "authrpc.jwtsecret",
+		Usage: "Path to a JWT secret to use for authenticated RPC endpoints",
+	}
 	ExecFlag = cli.StringFlag{
 		Name:  "exec",
 		Usage: "Execute JavaScript statement",
@@ -750,6 +810,50 @@ var (
 		Usage: "Comma-separated InfluxDB tags (key/values) attached to all measurements",
 		Value: metrics.DefaultConfig.InfluxDBTags,
 	}
+	MetricsEnableRemoteWriteFlag = cli.BoolFlag{
+		Name:  "metrics.remotewrite",
+		Usage: "Enable metrics export/push to a Prometheus remote-write endpoint",
+	}
+	MetricsRemoteWriteEndpointFlag = cli.StringFlag{
+		Name:  "metrics.remotewrite.endpoint",
+		Usage: "Prometheus remote-write endpoint to push metrics to",
+		Value: metrics.DefaultConfig.RemoteWriteEndpoint,
+	}
+	MetricsEnableOTLPFlag = cli.BoolFlag{
+		Name:  "metrics.otlp",
+		Usage: "Enable metrics export/push to an OpenTelemetry collector via OTLP/HTTP",
+	}
+	MetricsOTLPEndpointFlag = cli.StringFlag{
+		Name:  "metrics.otlp.endpoint",
+		Usage: "OpenTelemetry collector URL to push metrics to",
+		Value: metrics.DefaultConfig.OTLPEndpoint,
+	}
+	MetricsPushIntervalFlag = cli.DurationFlag{
+		Name:  "metrics.push.interval",
+		Usage: "Interval between the metrics pushes to Prometheus remote-write and OTLP",
+		Value: metrics.DefaultConfig.PushInterval,
+	}
+	MetricsPushRetriesFlag = cli.IntFlag{
+		Name:  "metrics.push.retries",
+		Usage: "Number of times a failed Prometheus remote-write or OTLP push is retried",
+		Value: metrics.DefaultConfig.PushRetries,
+	}
+	MetricsPushLabelsFlag = cli.StringFlag{
+		Name:  "metrics.push.labels",
+		Usage: "Comma-separated labels (key/values) attached to all the metrics pushed to Prometheus remote-write and OTLP",
+		Value: metrics.DefaultConfig.PushLabels,
+	}
+
+	// Tracing flags
+	TracingEnabledFlag = cli.BoolFlag{
+		Name:  "tracing",
+		Usage: "Enable tracing of RPC calls, block imports and state accesses",
+	}
+	TracingEndpointFlag = cli.StringFlag{
+		Name:  "tracing.endpoint",
+		Usage: "OpenTelemetry collector URL to export the tracing spans to via OTLP/HTTP",
+		Value: "http://localhost:4318",
+	}
 	EWASMInterpreterFlag = cli.StringFlag{
 		Name:  "vm.ewasm",
 		Usage: "External ewasm configuration (default = built-in interpreter)",
@@ -965,6 +1069,9 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(HTTPPathPrefixFlag.Name) {
 		cfg.HTTPPathPrefix = ctx.GlobalString(HTTPPathPrefixFlag.Name)
 	}
+	if ctx.GlobalIsSet(HTTPAuthFlag.Name) {
+		cfg.HTTPAuth = ctx.GlobalBool(HTTPAuthFlag.Name)
+	}
 	if ctx.GlobalIsSet(AllowUnprotectedTxs.Name) {
 		cfg.AllowUnprotectedTxs = ctx.GlobalBool(AllowUnprotectedTxs.Name)
 	}
@@ -1005,6 +1112,56 @@ func setWS(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(WSPathPrefixFlag.Name) {
 		cfg.WSPathPrefix = ctx.GlobalString(WSPathPrefixFlag.Name)
 	}
+	if ctx.GlobalIsSet(WSAuthFlag.Name) {
+		cfg.WSAuth = ctx.GlobalBool(WSAuthFlag.Name)
+	}
+}
+
+// setRPCLimits configures the resource limits of the HTTP and WebSocket RPC
+// servers from the set command line flags.
+func setRPCLimits(ctx *cli.Context, cfg *node.Config) {
+	for _, limits := range []*rpc.Limits{&cfg.HTTPLimits, &cfg.WSLimits} {
+		if ctx.GlobalIsSet(RPCBatchLimitFlag.Name) {
+			limits.BatchItemLimit = ctx.GlobalInt(RPCBatchLimitFlag.Name)
+		}
+		if ctx.GlobalIsSet(RPCBatchResponseLimitFlag.Name) {
+			limits.BatchResponseLimit = ctx.GlobalInt(RPCBatchResponseLimitFlag.Name)
+		}
+		if ctx.GlobalIsSet(RPCConcurrentCallsFlag.Name) {
+			limits.ConcurrentCalls = ctx.GlobalInt(RPCConcurrentCallsFlag.Name)
+		}
+		if ctx.GlobalIsSet(RPCRateLimitFlag.Name) {
+			limits.RequestRate = ctx.GlobalFloat64(RPCRateLimitFlag.Name)
+		}
+	}
+}
+
+// setTracing configures the export of tracing spans from the set command line
+// flags. Tracing stays disabled unless explicitly enabled.
+func setTracing(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalBool(TracingEnabledFlag.Name) {
+		cfg.TracingEndpoint = ctx.GlobalString(TracingEndpointFlag.Name)
+	}
+}
+
+// setAuth configures the JWT secret and the authenticated RPC listener from
+// the set command line flags. The listener stays disabled if no API is given.
+func setAuth(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalIsSet(JWTSecretFlag.Name) {
+		cfg.JWTSecret = ctx.GlobalString(JWTSecretFlag.Name)
+	}
+	if ctx.GlobalIsSet(AuthListenFlag.Name) {
+		cfg.AuthAddr = ctx.GlobalString(AuthListenFlag.Name)
+	}
+	if ctx.GlobalIsSet(AuthPortFlag.Name) {
+		cfg.AuthPort = ctx.GlobalInt(AuthPortFlag.Name)
+	}
+	if ctx.GlobalIsSet(AuthVirtualHostsFlag.Name) {
+		cfg.AuthVirtualHosts = SplitAndTrim(ctx.GlobalString(AuthVirtualHostsFlag.Name))
+	}
+	if ctx.GlobalIsSet(AuthApiFlag.Name) {
+		cfg.AuthModules = SplitAndTrim(ctx.GlobalString(AuthApiFlag.Name))
+	}
 }
 
 // setIPC creates an IPC path configuration from the set command line flags,
@@ -1213,6 +1370,9 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	setHTTP(ctx, cfg)
 	setGraphQL(ctx, cfg)
 	setWS(ctx, cfg)
+	setRPCLimits(ctx, cfg)
+	setAuth(ctx, cfg)
+	setTracing(ctx, cfg)
 	setNodeUserIdent(ctx, cfg)
 	setDataDir(ctx, cfg)
 	setSmartCard(ctx, cfg)
@@ -1236,6 +1396,14 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(InsecureUnlockAllowedFlag.Name) {
 		cfg.InsecureUnlockAllowed = ctx.GlobalBool(InsecureUnlockAllowedFlag.Name)
 	}
+	if ctx.GlobalIsSet(DBEngineFlag.Name) {
+		dbEngine := ctx.GlobalString(DBEngineFlag.Name)
+		if dbEngine != rawdb.DBLeveldb && dbEngine != rawdb.DBPebble {
+			Fatalf("Invalid choice for db.engine '%s', allowed 'leveldb' or 'pebble'", dbEngine)
+		}
+		log.Info(fmt.Sprintf("Using %s as db engine", dbEngine))
+		cfg.DBEngine = dbEngine
+	}
 }
 
 func setSmartCard(ctx *cli.Context, cfg *node.Config) {
@@ -1557,6 +1725,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheSnapshotFlag.Name) {
 		cfg.SnapshotCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheSnapshotFlag.Name) / 100
 	}
+	if ctx.GlobalIsSet(StateSchemeFlag.Name) {
+		cfg.StateScheme = ctx.GlobalString(StateSchemeFlag.Name)
+	}
 	if !ctx.GlobalBool(SnapshotFlag.Name) {
 		// If snap-sync is requested, this flag is also required
 		if cfg.SyncMode == downloader.SnapSync {
@@ -1763,6 +1934,29 @@ func SetupMetrics(ctx *cli.Context) {
 			go influxdb.InfluxDBWithTags(metrics.DefaultRegistry, 10*time.Second, endpoint, database, username, password, "geth.", tagsMap)
 		}
 
+		var (
+			interval = ctx.GlobalDuration(MetricsPushIntervalFlag.Name)
+			retries  = ctx.GlobalInt(MetricsPushRetriesFlag.Name)
+			labels   = SplitTagsFlag(ctx.GlobalString(MetricsPushLabelsFlag.Name))
+		)
+		if interval <= 0 && (ctx.GlobalBool(MetricsEnableRemoteWriteFlag.Name) || ctx.GlobalBool(MetricsEnableOTLPFlag.Name)) {
+			Fatalf("Invalid metrics push interval %v, must be positive", interval)
+		}
+		if ctx.GlobalBool(MetricsEnableRemoteWriteFlag.Name) {
+			log.Info("Enabling metrics export to Prometheus remote-write")
+
+			go prometheus.RemoteWrite(metrics.DefaultRegistry, interval, ctx.GlobalString(MetricsRemoteWriteEndpointFlag.Name), labels, retries)
+		}
+		if ctx.GlobalBool(MetricsEnableOTLPFlag.Name) {
+			log.Info("Enabling metrics export to OTLP")
+
+			attrs := map[string]string{"service.name": "geth"}
+			for k, v := range labels {
+				attrs[k] = v
+			}
+			go otlp.OTLP(metrics.DefaultRegistry, interval, ctx.GlobalString(MetricsOTLPEndpointFlag.Name), attrs, retries)
+		}
+
 		if ctx.GlobalIsSet(MetricsHTTPFlag.Name) {
 			address := fmt.Sprintf("%s:%d", ctx.GlobalString(MetricsHTTPFlag.Name), ctx.GlobalInt(MetricsPortFlag.Name))
 			log.Info("Enabling stand-alone metrics HTTP endpoint", "address", address)
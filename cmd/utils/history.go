@@ -0,0 +1,435 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/era"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// historyChecksumsFile is the file within a history directory containing the
+// SHA256 checksums of the archives, in the format used by sha256sum.
+const historyChecksumsFile = "checksums.txt"
+
+// historyNetwork returns the network name used in the history archive file
+// names of the chain with the given genesis. Unknown networks are named after
+// their genesis hash.
+func historyNetwork(genesis common.Hash) string {
+	switch genesis {
+	case params.MainnetGenesisHash:
+		return "mainnet"
+	case params.RopstenGenesisHash:
+		return "ropsten"
+	case params.RinkebyGenesisHash:
+		return "rinkeby"
+	case params.GoerliGenesisHash:
+		return "goerli"
+	case params.BaikalGenesisHash:
+		return "baikal"
+	default:
+		return fmt.Sprintf("%x", genesis[:4])
+	}
+}
+
+// ExportHistory exports the canonical chain history between the first and
+// last blocks into Era1 archives of step blocks each, also maintaining their
+// checksums in the output directory.
+func ExportHistory(bc *core.BlockChain, dir string, first, last, step uint64) error {
+	log.Info("Exporting blockchain history", "dir", dir)
+	if step == 0 || step > era.MaxEra1Size {
+		return fmt.Errorf("invalid archive size %d", step)
+	}
+	if first%step != 0 {
+		return fmt.Errorf("first block %d not at epoch boundary", first)
+	}
+	if head := bc.CurrentFastBlock().NumberU64(); head < last {
+		log.Warn("Last block beyond head, setting last = head", "head", head, "last", last)
+		last = head
+	}
+	if first > last {
+		return fmt.Errorf("invalid block range %d-%d", first, last)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating output directory: %w", err)
+	}
+	checksums, err := readHistoryChecksums(dir)
+	if err != nil {
+		return err
+	}
+	var (
+		network  = historyNetwork(bc.Genesis().Hash())
+		start    = time.Now()
+		reported = time.Now()
+	)
+	for i := first; i <= last; i += step {
+		epoch := int(i / step)
+		name, checksum, err := exportHistoryEpoch(bc, dir, network, epoch, i, last, step)
+		if err != nil {
+			return err
+		}
+		// Drop any stale archive of the same epoch with a different root
+		for old := range checksums {
+			if old != name && strings.HasPrefix(old, fmt.Sprintf("%s-%05d-", network, epoch)) {
+				os.Remove(filepath.Join(dir, old))
+				delete(checksums, old)
+			}
+		}
+		checksums[name] = checksum
+
+		if time.Since(reported) >= 8*time.Second {
+			log.Info("Exporting blocks", "exported", i+step-first, "last", last, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	if err := writeHistoryChecksums(dir, checksums); err != nil {
+		return err
+	}
+	log.Info("Exported blockchain history", "dir", dir, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// exportHistoryEpoch writes the blocks of an epoch starting with the given block
+// into an archive, returning its file name and checksum.
+func exportHistoryEpoch(bc *core.BlockChain, dir string, network string, epoch int, first, last, step uint64) (string, string, error) {
+	tmp := filepath.Join(dir, era.Filename(network, epoch, common.Hash{})+".tmp")
+	f, err := os.Create(tmp)
+	if err != nil {
+		return "", "", fmt.Errorf("could not create era1 file: %w", err)
+	}
+	defer f.Close()
+
+	w := era.NewBuilder(f)
+	for n := first; n <= last && n < first+step; n++ {
+		block := bc.GetBlockByNumber(n)
+		if block == nil {
+			return "", "", fmt.Errorf("export failed on #%d: not found", n)
+		}
+		receipts := bc.GetReceiptsByHash(block.Hash())
+		if receipts == nil {
+			return "", "", fmt.Errorf("export failed on #%d: receipts not found", n)
+		}
+		td := bc.GetTd(block.Hash(), n)
+		if td == nil {
+			return "", "", fmt.Errorf("export failed on #%d: total difficulty not found", n)
+		}
+		if err := w.Add(block, receipts, td); err != nil {
+			return "", "", err
+		}
+	}
+	root, err := w.Finalize()
+	if err != nil {
+		return "", "", fmt.Errorf("export failed to finalize epoch %d: %w", epoch, err)
+	}
+	// Compute the checksum of the entire archive
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", "", err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", "", fmt.Errorf("unable to calculate checksum: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", "", err
+	}
+	// Name the archive after its accumulator root
+	name := era.Filename(network, epoch, root)
+	if err := os.Rename(tmp, filepath.Join(dir, name)); err != nil {
+		return "", "", err
+	}
+	return name, fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// ImportHistory imports the chain history from the Era1 archives in the given
+// directory. The archives are validated against their checksums and the block
+// data against the headers, then the headers, bodies and receipts are inserted
+// into the chain without executing the blocks, similarly to a fast sync.
+func ImportHistory(chain *core.BlockChain, dir string) error {
+	log.Info("Importing blockchain history", "dir", dir)
+
+	network := historyNetwork(chain.Genesis().Hash())
+	entries, err := era.ReadDir(dir, network)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", dir, err)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no %s history archives found in %s", network, dir)
+	}
+	checksums, err := readHistoryChecksums(dir)
+	if err != nil {
+		return err
+	}
+	var (
+		start    = time.Now()
+		reported = time.Now()
+		imported int
+	)
+	for _, name := range entries {
+		err := forEachHistoryBlock(dir, name, checksums[name], func(batch []*types.Block, receipts []types.Receipts, tds []*big.Int) error {
+			// Skip the genesis block, but make sure it is ours
+			if batch[0].NumberU64() == 0 {
+				if batch[0].Hash() != chain.Genesis().Hash() {
+					return fmt.Errorf("genesis mismatch: have %x, want %x", batch[0].Hash(), chain.Genesis().Hash())
+				}
+				batch, receipts, tds = batch[1:], receipts[1:], tds[1:]
+				if len(batch) == 0 {
+					return nil
+				}
+			}
+			headers := make([]*types.Header, len(batch))
+			for i, block := range batch {
+				headers[i] = block.Header()
+			}
+			if _, err := chain.InsertHeaderChain(headers, 100); err != nil {
+				return fmt.Errorf("error inserting headers: %w", err)
+			}
+			if _, err := chain.InsertReceiptChain(batch, receipts, 0); err != nil {
+				return fmt.Errorf("error inserting bodies and receipts: %w", err)
+			}
+			last := batch[len(batch)-1]
+			if td := chain.GetTd(last.Hash(), last.NumberU64()); td == nil || td.Cmp(tds[len(tds)-1]) != 0 {
+				return fmt.Errorf("total difficulty mismatch at #%d: have %v, want %v", last.NumberU64(), td, tds[len(tds)-1])
+			}
+			imported += len(batch)
+			if time.Since(reported) >= 8*time.Second {
+				log.Info("Importing blocks", "imported", imported, "number", last.NumberU64(), "elapsed", common.PrettyDuration(time.Since(start)))
+				reported = time.Now()
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to import %s: %w", name, err)
+		}
+	}
+	log.Info("Imported blockchain history", "dir", dir, "blocks", imported, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// VerifyHistory checks that the Era1 archives in the given directory match the
+// local canonical chain, including the receipts and total difficulties.
+func VerifyHistory(chain *core.BlockChain, dir string) error {
+	log.Info("Verifying blockchain history", "dir", dir)
+
+	network := historyNetwork(chain.Genesis().Hash())
+	entries, err := era.ReadDir(dir, network)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", dir, err)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no %s history archives found in %s", network, dir)
+	}
+	checksums, err := readHistoryChecksums(dir)
+	if err != nil {
+		return err
+	}
+	var (
+		start    = time.Now()
+		verified int
+	)
+	for _, name := range entries {
+		err := forEachHistoryBlock(dir, name, checksums[name], func(batch []*types.Block, receipts []types.Receipts, tds []*big.Int) error {
+			for i, block := range batch {
+				number := block.NumberU64()
+				if hash := chain.GetCanonicalHash(number); hash != block.Hash() {
+					return fmt.Errorf("block #%d mismatch: have %x, local %x", number, block.Hash(), hash)
+				}
+				if td := chain.GetTd(block.Hash(), number); td == nil || td.Cmp(tds[i]) != 0 {
+					return fmt.Errorf("total difficulty mismatch at #%d: have %v, local %v", number, tds[i], td)
+				}
+				local := chain.GetReceiptsByHash(block.Hash())
+				if local == nil {
+					return fmt.Errorf("receipts of block #%d missing locally", number)
+				}
+				if types.DeriveSha(local, trie.NewStackTrie(nil)) != types.DeriveSha(receipts[i], trie.NewStackTrie(nil)) {
+					return fmt.Errorf("receipts mismatch at #%d", number)
+				}
+			}
+			verified += len(batch)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to verify %s: %w", name, err)
+		}
+	}
+	log.Info("Verified blockchain history", "dir", dir, "blocks", verified, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// forEachHistoryBlock opens an Era1 archive, checks its integrity and iterates
+// over its contents in batches. Every block is checked against its header and
+// the accumulator is checked against the contents and the file name.
+func forEachHistoryBlock(dir, name, checksum string, fn func([]*types.Block, []types.Receipts, []*big.Int) error) error {
+	if checksum == "" {
+		return errors.New("checksum missing")
+	}
+	f, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Validate the checksum of the entire archive before reading it
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("unable to calculate checksum: %w", err)
+	}
+	if have := fmt.Sprintf("%x", h.Sum(nil)); have != checksum {
+		return fmt.Errorf("checksum mismatch: have %s, want %s", have, checksum)
+	}
+	e, err := era.From(f)
+	if err != nil {
+		return fmt.Errorf("error opening era: %w", err)
+	}
+	it, err := era.NewIterator(e)
+	if err != nil {
+		return fmt.Errorf("error creating iterator: %w", err)
+	}
+	var (
+		blocks   []*types.Block
+		receipts []types.Receipts
+		tds      []*big.Int
+		hashes   []common.Hash
+	)
+	flush := func() error {
+		if len(blocks) == 0 {
+			return nil
+		}
+		err := fn(blocks, receipts, tds)
+		blocks, receipts, tds = nil, nil, nil
+		return err
+	}
+	var accTds []*big.Int
+	for it.Next() {
+		if err := it.Error(); err != nil {
+			return err
+		}
+		block, rs, err := it.BlockAndReceipts()
+		if err != nil {
+			return fmt.Errorf("error reading block #%d: %w", it.Number(), err)
+		}
+		td, err := it.TotalDifficulty()
+		if err != nil {
+			return fmt.Errorf("error reading total difficulty #%d: %w", it.Number(), err)
+		}
+		if err := verifyHistoryBlock(block, rs); err != nil {
+			return err
+		}
+		blocks, receipts, tds = append(blocks, block), append(receipts, rs), append(tds, td)
+		hashes, accTds = append(hashes, block.Hash()), append(accTds, td)
+
+		if len(blocks) >= importBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	// Verify the accumulator too. Blocks already handed over were checked
+	// against their headers, so this only catches malformed archives.
+	want, err := era.ComputeAccumulator(hashes, accTds)
+	if err != nil {
+		return err
+	}
+	if root, err := e.Accumulator(); err != nil {
+		return fmt.Errorf("error reading accumulator: %w", err)
+	} else if root != want {
+		return fmt.Errorf("accumulator mismatch: have %x, want %x", root, want)
+	}
+	if !strings.HasSuffix(name, fmt.Sprintf("-%x.era1", want[:4])) {
+		return fmt.Errorf("accumulator %x does not match file name", want)
+	}
+	return flush()
+}
+
+// verifyHistoryBlock checks that the body and receipts of a block read from an
+// archive match the commitments in its header.
+func verifyHistoryBlock(block *types.Block, receipts types.Receipts) error {
+	if hash := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); hash != block.TxHash() {
+		return fmt.Errorf("transaction root mismatch at #%d: have %x, want %x", block.NumberU64(), hash, block.TxHash())
+	}
+	if hash := types.CalcUncleHash(block.Uncles()); hash != block.UncleHash() {
+		return fmt.Errorf("uncle root mismatch at #%d: have %x, want %x", block.NumberU64(), hash, block.UncleHash())
+	}
+	if hash := types.DeriveSha(receipts, trie.NewStackTrie(nil)); hash != block.ReceiptHash() {
+		return fmt.Errorf("receipt root mismatch at #%d: have %x, want %x", block.NumberU64(), hash, block.ReceiptHash())
+	}
+	return nil
+}
+
+// readHistoryChecksums reads the archive checksums of a history directory,
+// returning them keyed by file name. A missing checksum file is treated as
+// having no entries.
+func readHistoryChecksums(dir string) (map[string]string, error) {
+	checksums := make(map[string]string)
+
+	f, err := os.Open(filepath.Join(dir, historyChecksumsFile))
+	if os.IsNotExist(err) {
+		return checksums, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("malformed checksum line: %q", line)
+		}
+		checksums[fields[1]] = fields[0]
+	}
+	return checksums, scanner.Err()
+}
+
+// writeHistoryChecksums writes the archive checksums into a history directory.
+func writeHistoryChecksums(dir string, checksums map[string]string) error {
+	names := make([]string, 0, len(checksums))
+	for name := range checksums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s  %s\n", checksums[name], name)
+	}
+	return ioutil.WriteFile(filepath.Join(dir, historyChecksumsFile), []byte(b.String()), 0644)
+}
@@ -0,0 +1,176 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/era"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	historyKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	historyAddress = crypto.PubkeyToAddress(historyKey.PublicKey)
+	historyGenesis = &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  core.GenesisAlloc{historyAddress: {Balance: big.NewInt(1000000000000000000)}},
+	}
+)
+
+// newHistoryChain creates a blockchain with the given number of generated
+// blocks containing transactions and logs.
+func newHistoryChain(t *testing.T, n int) (*core.BlockChain, []*types.Block) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		genesis = historyGenesis.MustCommit(db)
+		signer  = types.LatestSigner(historyGenesis.Config)
+	)
+	blocks, _ := core.GenerateChain(historyGenesis.Config, genesis, ethash.NewFaker(), db, n, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(common.Address{0x01})
+		for j := 0; j < i%3; j++ {
+			// A call to the precompiled identity contract, just to have some data
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(historyAddress), common.BytesToAddress([]byte{0x04}), big.NewInt(1), 50000, big.NewInt(1), []byte{byte(i)}), signer, historyKey)
+			b.AddTx(tx)
+		}
+	})
+	chain, err := core.NewBlockChain(db, nil, historyGenesis.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	return chain, blocks
+}
+
+func TestHistoryExportImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	source, blocks := newHistoryChain(t, 100)
+	defer source.Stop()
+
+	// Export the chain history, in smaller epochs to cover multiple archives
+	if err := ExportHistory(source, dir, 0, 100, 16); err != nil {
+		t.Fatalf("failed to export history: %v", err)
+	}
+	files, err := era.ReadDir(dir, historyNetwork(source.Genesis().Hash()))
+	if err != nil {
+		t.Fatalf("failed to read history dir: %v", err)
+	}
+	if len(files) != 7 {
+		t.Fatalf("archive count mismatch: have %d, want 7", len(files))
+	}
+	if err := VerifyHistory(source, dir); err != nil {
+		t.Fatalf("failed to verify history: %v", err)
+	}
+	// Re-exporting a range should leave the directory consistent
+	if err := ExportHistory(source, dir, 32, 47, 16); err != nil {
+		t.Fatalf("failed to re-export history: %v", err)
+	}
+	if err := VerifyHistory(source, dir); err != nil {
+		t.Fatalf("failed to verify history after re-export: %v", err)
+	}
+	// Import the history into an empty chain
+	db := rawdb.NewMemoryDatabase()
+	historyGenesis.MustCommit(db)
+	chain, _ := core.NewBlockChain(db, nil, historyGenesis.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	defer chain.Stop()
+
+	if err := ImportHistory(chain, dir); err != nil {
+		t.Fatalf("failed to import history: %v", err)
+	}
+	if head := chain.CurrentFastBlock(); head.Hash() != blocks[len(blocks)-1].Hash() {
+		t.Fatalf("head mismatch: have #%d [%x], want #%d", head.NumberU64(), head.Hash(), len(blocks))
+	}
+	for _, block := range blocks {
+		receipts := chain.GetReceiptsByHash(block.Hash())
+		if hash := types.DeriveSha(receipts, trie.NewStackTrie(nil)); hash != block.ReceiptHash() {
+			t.Fatalf("receipts mismatch at #%d", block.NumberU64())
+		}
+		if td, want := chain.GetTd(block.Hash(), block.NumberU64()), source.GetTd(block.Hash(), block.NumberU64()); td.Cmp(want) != 0 {
+			t.Fatalf("total difficulty mismatch at #%d: have %v, want %v", block.NumberU64(), td, want)
+		}
+	}
+	// Importing the same history again should be a noop
+	if err := ImportHistory(chain, dir); err != nil {
+		t.Fatalf("failed to reimport history: %v", err)
+	}
+	if err := VerifyHistory(chain, dir); err != nil {
+		t.Fatalf("failed to verify imported history: %v", err)
+	}
+}
+
+func TestHistoryCorruption(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	source, _ := newHistoryChain(t, 20)
+	defer source.Stop()
+
+	if err := ExportHistory(source, dir, 0, 20, 16); err != nil {
+		t.Fatalf("failed to export history: %v", err)
+	}
+	// Verification against a different chain must fail
+	other, _ := newHistoryChain(t, 10)
+	defer other.Stop()
+
+	if err := VerifyHistory(other, dir); err == nil {
+		t.Fatalf("history of longer chain verified")
+	}
+	// Flip a byte in an archive and make sure it is detected
+	files, _ := era.ReadDir(dir, historyNetwork(source.Genesis().Hash()))
+	path := filepath.Join(dir, files[1])
+	blob, _ := ioutil.ReadFile(path)
+	blob[len(blob)/2] ^= 0xff
+	ioutil.WriteFile(path, blob, 0644)
+
+	if err := VerifyHistory(source, dir); err == nil {
+		t.Fatalf("corrupted history verified")
+	}
+	db := rawdb.NewMemoryDatabase()
+	historyGenesis.MustCommit(db)
+	chain, _ := core.NewBlockChain(db, nil, historyGenesis.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	defer chain.Stop()
+
+	if err := ImportHistory(chain, dir); err == nil {
+		t.Fatalf("corrupted history imported")
+	}
+	// The intact first archive should have been imported nonetheless
+	if head := chain.CurrentFastBlock().NumberU64(); head != 15 {
+		t.Fatalf("head mismatch: have %d, want 15", head)
+	}
+}
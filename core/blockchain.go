@@ -18,6 +18,7 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -39,6 +40,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/internal/tracing"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/params"
@@ -246,6 +248,14 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		engine:         engine,
 		vmConfig:       vmConfig,
 	}
+	// The path-based state scheme only keeps the latest state on disk, which
+	// the snapshot generator can't build upon yet.
+	if cacheConfig.SnapshotLimit > 0 && bc.stateCache.TrieDB().Scheme() == rawdb.PathScheme {
+		log.Warn("Snapshots are not supported in the path-based state scheme, disabling")
+		config := *cacheConfig
+		config.SnapshotLimit = 0
+		bc.cacheConfig = &config
+	}
 	bc.validator = NewBlockValidator(chainConfig, bc, engine)
 	bc.prefetcher = newStatePrefetcher(chainConfig, bc, engine)
 	bc.processor = NewStateProcessor(chainConfig, bc, engine)
@@ -520,6 +530,13 @@ func (bc *BlockChain) SetHeadBeyondRoot(head uint64, root common.Hash) (uint64,
 					if root != (common.Hash{}) && !beyondRoot && newHeadBlock.Root() == root {
 						beyondRoot, rootNumber = true, newHeadBlock.NumberU64()
 					}
+					// In the path-based state scheme, recent states can be
+					// restored from the reverse diffs
+					if !bc.HasState(newHeadBlock.Root()) && bc.stateRecoverable(newHeadBlock.Root()) {
+						if err := bc.stateCache.TrieDB().Recover(newHeadBlock.Root()); err != nil {
+							log.Error("Failed to recover state", "number", newHeadBlock.NumberU64(), "hash", newHeadBlock.Hash(), "err", err)
+						}
+					}
 					if _, err := state.New(newHeadBlock.Root(), bc.stateCache, bc.snaps); err != nil {
 						log.Trace("Block state missing, rewinding further", "number", newHeadBlock.NumberU64(), "hash", newHeadBlock.Hash())
 						if pivot == nil || newHeadBlock.NumberU64() > *pivot {
@@ -844,6 +861,55 @@ func (bc *BlockChain) GetBodyRLP(hash common.Hash) rlp.RawValue {
 	return body
 }
 
+// GetBodiesRLP retrieves the RLP encoded bodies of the blocks with the given
+// hashes, in as few database accesses as possible. The result is aligned with
+// the requested hashes, containing nil for unknown blocks, but is cut short
+// once maxBytes has been exceeded. Runs of consecutive blocks in the ancient
+// store are read in a single batch.
+func (bc *BlockChain) GetBodiesRLP(hashes []common.Hash, maxBytes uint64) []rlp.RawValue {
+	var (
+		bodies    []rlp.RawValue
+		size      uint64
+		frozen, _ = bc.db.Ancients()
+	)
+	for len(bodies) < len(hashes) && size < maxBytes {
+		hash := hashes[len(bodies)]
+		number := bc.hc.GetBlockNumber(hash)
+		if number == nil {
+			bodies = append(bodies, nil)
+			continue
+		}
+		// Find the run of consecutive frozen blocks starting with this one
+		var run int
+		for i := len(bodies); i < len(hashes) && *number+uint64(run) < frozen; i++ {
+			if n := bc.hc.GetBlockNumber(hashes[i]); n == nil || *n != *number+uint64(run) {
+				break
+			}
+			run++
+		}
+		if run > 1 {
+			canon, blobs := rawdb.ReadAncientBodyRange(bc.db, *number, uint64(run), maxBytes-size)
+			var served int
+			for i, blob := range blobs {
+				if canon[i] != hashes[len(bodies)] {
+					break
+				}
+				bodies = append(bodies, blob)
+				size += uint64(len(blob))
+				served++
+			}
+			if served > 0 {
+				continue
+			}
+		}
+		// Not part of a frozen run, retrieve the body individually
+		body := bc.GetBodyRLP(hash)
+		bodies = append(bodies, body)
+		size += uint64(len(body))
+	}
+	return bodies
+}
+
 // HasBlock checks if a block is fully present in the database or not.
 func (bc *BlockChain) HasBlock(hash common.Hash, number uint64) bool {
 	if bc.blockCache.Contains(hash) {
@@ -869,6 +935,12 @@ func (bc *BlockChain) HasState(hash common.Hash) bool {
 	return err == nil
 }
 
+// stateRecoverable checks if the state trie with the given root can be restored
+// from the reverse diffs of the path-based state scheme.
+func (bc *BlockChain) stateRecoverable(root common.Hash) bool {
+	return bc.stateCache.TrieDB().Recoverable(root)
+}
+
 // HasBlockAndState checks if a block and associated state trie is fully present
 // in the database or not, caching it if present.
 func (bc *BlockChain) HasBlockAndState(hash common.Hash, number uint64) bool {
@@ -1011,7 +1083,9 @@ func (bc *BlockChain) Stop() {
 	//  - HEAD:     So we don't need to reprocess any blocks in the general case
 	//  - HEAD-1:   So we don't do large reorgs if our HEAD becomes an uncle
 	//  - HEAD-127: So we have a hard limit on the number of blocks reexecuted
-	if !bc.cacheConfig.TrieDirtyDisabled {
+	//
+	// In the path-based state scheme every state is flushed right away.
+	if !bc.cacheConfig.TrieDirtyDisabled && bc.stateCache.TrieDB().Scheme() != rawdb.PathScheme {
 		triedb := bc.stateCache.TrieDB()
 
 		for _, offset := range []uint64{0, 1, TriesInMemory - 1} {
@@ -1478,8 +1552,8 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	}
 	triedb := bc.stateCache.TrieDB()
 
-	// If we're running an archive node, always flush
-	if bc.cacheConfig.TrieDirtyDisabled {
+	// If we're running an archive node or storing tries by path, always flush
+	if bc.cacheConfig.TrieDirtyDisabled || triedb.Scheme() == rawdb.PathScheme {
 		if err := triedb.Commit(root, false, nil); err != nil {
 			return NonStatTy, err
 		}
@@ -1667,6 +1741,11 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 	if atomic.LoadInt32(&bc.procInterrupt) == 1 {
 		return 0, nil
 	}
+	// Trace the import of the chain segment, the blocks are processed as children
+	traceCtx, span := tracing.Start(context.Background(), "core.BlockChain.insertChain",
+		tracing.Int("blocks", int64(len(chain))), tracing.Uint("first", chain[0].NumberU64()), tracing.Uint("last", chain[len(chain)-1].NumberU64()))
+	defer span.End()
+
 	// Start a parallel signature recovery (signer will fluke on fork transition, minimal perf loss)
 	senderCacher.recoverFromBlocks(types.MakeSigner(bc.chainConfig, chain[0].Number()), chain)
 
@@ -1859,6 +1938,9 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 			}
 		}
 		// Process block using the parent state as reference point
+		if span != nil {
+			statedb.SetTraceContext(traceCtx)
+		}
 		substart := time.Now()
 		receipts, logs, usedGas, err := bc.processor.Process(block, statedb, bc.vmConfig)
 		if err != nil {
@@ -2038,7 +2120,7 @@ func (bc *BlockChain) insertSideChain(block *types.Block, it *insertIterator) (i
 		numbers []uint64
 	)
 	parent := it.previous()
-	for parent != nil && !bc.HasState(parent.Root) {
+	for parent != nil && !bc.HasState(parent.Root) && !bc.stateRecoverable(parent.Root) {
 		hashes = append(hashes, parent.Hash())
 		numbers = append(numbers, parent.Number.Uint64())
 
@@ -2047,6 +2129,21 @@ func (bc *BlockChain) insertSideChain(block *types.Block, it *insertIterator) (i
 	if parent == nil {
 		return it.index, errors.New("missing parent")
 	}
+	// In the path-based state scheme, roll the persisted state back to the
+	// fork point before reimporting the sidechain on top
+	if !bc.HasState(parent.Root) {
+		if err := bc.stateCache.TrieDB().Recover(parent.Root); err != nil {
+			return it.index, err
+		}
+		// The rollback discarded the state of the current head, which needs to be
+		// regenerated if the sidechain doesn't become canonical, e.g. because one
+		// of its blocks turns out to be invalid
+		defer func() {
+			if err := bc.restoreHeadState(); err != nil {
+				log.Error("Failed to restore head state", "err", err)
+			}
+		}()
+	}
 	// Import all the pruned blocks to make the state available
 	var (
 		blocks []*types.Block
@@ -2083,6 +2180,37 @@ func (bc *BlockChain) insertSideChain(block *types.Block, it *insertIterator) (i
 	return 0, nil
 }
 
+// restoreHeadState regenerates the state of the current head if it's missing,
+// by rolling the persisted state back to the closest recoverable ancestor and
+// re-executing the canonical blocks on top.
+func (bc *BlockChain) restoreHeadState() error {
+	var (
+		head   = bc.CurrentBlock()
+		blocks []*types.Block
+	)
+	for block := head; !bc.HasState(block.Root()); {
+		if bc.stateRecoverable(block.Root()) {
+			if err := bc.stateCache.TrieDB().Recover(block.Root()); err != nil {
+				return err
+			}
+			break
+		}
+		blocks = append(blocks, block)
+		if block = bc.GetBlock(block.ParentHash(), block.NumberU64()-1); block == nil {
+			return errors.New("missing parent")
+		}
+	}
+	if len(blocks) == 0 {
+		return nil
+	}
+	for i, j := 0, len(blocks)-1; i < j; i, j = i+1, j-1 {
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	}
+	log.Info("Regenerating head state", "start", blocks[0].NumberU64(), "end", head.NumberU64())
+	_, err := bc.insertChain(blocks, false)
+	return err
+}
+
 // reorg takes two blocks, an old chain and a new chain and will reconstruct the
 // blocks and inserts them to be part of the new canonical chain and accumulates
 // potential missing transactions and post an event about them.
@@ -2432,6 +2560,25 @@ func (bc *BlockChain) HasHeader(hash common.Hash, number uint64) bool {
 	return bc.hc.HasHeader(hash, number)
 }
 
+// HistoryTail returns the number of the first block whose body and receipts are
+// available, all older ones having been pruned.
+func (bc *BlockChain) HistoryTail() uint64 {
+	return rawdb.ReadHistoryTail(bc.db)
+}
+
+// HistoryPruned reports whether the block with the given hash is known, but its
+// body and receipts were pruned.
+func (bc *BlockChain) HistoryPruned(hash common.Hash) bool {
+	number := bc.hc.GetBlockNumber(hash)
+	return number != nil && *number < bc.HistoryTail()
+}
+
+// GetHeadersFrom returns a contiguous segment of canonical headers, in rlp
+// encoded form, starting at 'number' and going towards the genesis.
+func (bc *BlockChain) GetHeadersFrom(number, count uint64) []rlp.RawValue {
+	return bc.hc.GetHeadersFrom(number, count)
+}
+
 // GetCanonicalHash returns the canonical hash for a given block number
 func (bc *BlockChain) GetCanonicalHash(number uint64) common.Hash {
 	return bc.hc.GetCanonicalHash(number)
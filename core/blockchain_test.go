@@ -17,6 +17,7 @@
 package core
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -701,6 +702,38 @@ func TestFastVsFullChains(t *testing.T) {
 			t.Errorf("block #%d: canonical hash mismatch: ancientdb %v, archivedb %v", i, anhash, arhash)
 		}
 	}
+	// Check that ranged retrievals are the same, also across the ancient boundary
+	for _, number := range []uint64{0, 1, 100, 511, 512, 513, 600, 1024, 1100} {
+		anheaders, arheaders := ancient.GetHeadersFrom(number, 128), archive.GetHeadersFrom(number, 128)
+		if len(anheaders) != len(arheaders) {
+			t.Errorf("headers from #%d: count mismatch: ancientdb %d, archivedb %d", number, len(anheaders), len(arheaders))
+			continue
+		}
+		for i := range anheaders {
+			if !bytes.Equal(anheaders[i], arheaders[i]) {
+				t.Errorf("headers from #%d: header %d mismatch", number, i)
+			}
+		}
+	}
+	hashes := make([]common.Hash, 0, len(blocks)+1)
+	for i, block := range blocks {
+		if i == 200 {
+			hashes = append(hashes, common.Hash{0x01}) // Unknown block in a frozen run
+		}
+		hashes = append(hashes, block.Hash())
+	}
+	anbodies, arbodies := ancient.GetBodiesRLP(hashes, 1<<30), archive.GetBodiesRLP(hashes, 1<<30)
+	if len(anbodies) != len(hashes) || len(arbodies) != len(hashes) {
+		t.Fatalf("body count mismatch: ancientdb %d, archivedb %d, want %d", len(anbodies), len(arbodies), len(hashes))
+	}
+	for i := range anbodies {
+		if !bytes.Equal(anbodies[i], arbodies[i]) {
+			t.Errorf("body %d mismatch", i)
+		}
+		if (anbodies[i] == nil) != (i == 200) {
+			t.Errorf("body %d availability mismatch", i)
+		}
+	}
 }
 
 // Tests that various import methods move the chain head pointers to the correct
@@ -3109,3 +3142,143 @@ func TestEIP2718Transition(t *testing.T) {
 
 	}
 }
+
+// Tests that a chain using the path-based state scheme keeps only the head state
+// on disk, and that it can reorg onto a heavier sidechain by rolling the state
+// back to the fork point.
+func TestPathSchemeReorg(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{addr: {Balance: big.NewInt(1000000000000000)}}}
+		signer  = types.LatestSigner(gspec.Config)
+		gendb   = rawdb.NewMemoryDatabase()
+		genesis = gspec.MustCommit(gendb)
+	)
+	// Generate a canonical chain and a heavier fork, both deploying contracts
+	// with storage to exercise the storage tries too
+	makeChain := func(parent *types.Block, n int, seed byte) []*types.Block {
+		blocks, _ := GenerateChain(gspec.Config, parent, ethash.NewFaker(), gendb, n, func(i int, gen *BlockGen) {
+			code := []byte{byte(vm.PUSH1), seed, byte(vm.PUSH1), byte(i), byte(vm.SSTORE)}
+			tx, _ := types.SignTx(types.NewContractCreation(gen.TxNonce(addr), new(big.Int), 100000, gen.header.BaseFee, code), signer, key)
+			gen.AddTx(tx)
+		})
+		return blocks
+	}
+	canon := makeChain(genesis, 10, 1)
+	fork := makeChain(canon[4], 8, 2)
+
+	db := rawdb.NewMemoryDatabase()
+	rawdb.WriteStateScheme(db, rawdb.PathScheme)
+	gspec.MustCommit(db)
+
+	chain, err := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(canon); err != nil {
+		t.Fatalf("failed to insert canonical chain: %v", err)
+	}
+	if !chain.HasState(canon[9].Root()) {
+		t.Fatalf("head state missing")
+	}
+	if chain.HasState(canon[4].Root()) {
+		t.Fatalf("historical state retained")
+	}
+	if !chain.stateRecoverable(canon[4].Root()) {
+		t.Fatalf("historical state not recoverable")
+	}
+	if _, err := chain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != fork[7].Hash() {
+		t.Fatalf("head mismatch: have %d [%x], want %d [%x]", head.Number(), head.Hash(), fork[7].Number(), fork[7].Hash())
+	}
+	statedb, err := chain.State()
+	if err != nil {
+		t.Fatalf("head state missing after reorg: %v", err)
+	}
+	if root := statedb.IntermediateRoot(true); root != fork[7].Root() {
+		t.Fatalf("head state root mismatch: have %x, want %x", root, fork[7].Root())
+	}
+	// Roll the chain back below the fork point, the state should be restored
+	if err := chain.SetHead(3); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	if head := chain.CurrentBlock(); head.NumberU64() != 3 {
+		t.Fatalf("head number mismatch: have %d, want 3", head.NumberU64())
+	}
+	if !chain.HasState(canon[2].Root()) {
+		t.Fatalf("rewound head state missing")
+	}
+}
+
+// Tests that a failed reorg onto an invalid sidechain in the path-based state
+// scheme doesn't leave the canonical head without its state, even though the
+// persisted state had to be rolled back to the fork point for the reimport.
+func TestPathSchemeInvalidSidechain(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{addr: {Balance: big.NewInt(1000000000000000)}}}
+		signer  = types.LatestSigner(gspec.Config)
+		gendb   = rawdb.NewMemoryDatabase()
+		genesis = gspec.MustCommit(gendb)
+	)
+	makeChain := func(parent *types.Block, n int, seed byte) []*types.Block {
+		blocks, _ := GenerateChain(gspec.Config, parent, ethash.NewFaker(), gendb, n, func(i int, gen *BlockGen) {
+			code := []byte{byte(vm.PUSH1), seed, byte(vm.PUSH1), byte(i), byte(vm.SSTORE)}
+			tx, _ := types.SignTx(types.NewContractCreation(gen.TxNonce(addr), new(big.Int), 100000, gen.header.BaseFee, code), signer, key)
+			gen.AddTx(tx)
+		})
+		return blocks
+	}
+	canon := makeChain(genesis, 10, 1)
+
+	// Create a heavier fork whose first block has a bad state root, which can only
+	// be detected by executing it
+	var (
+		fork   = makeChain(canon[4], 8, 2)
+		parent = canon[4].Hash()
+	)
+	for i, block := range fork {
+		header := block.Header()
+		header.ParentHash = parent
+		if i == 0 {
+			header.Root = common.Hash{0x01}
+		}
+		fork[i] = types.NewBlockWithHeader(header).WithBody(block.Transactions(), block.Uncles())
+		parent = fork[i].Hash()
+	}
+	db := rawdb.NewMemoryDatabase()
+	rawdb.WriteStateScheme(db, rawdb.PathScheme)
+	gspec.MustCommit(db)
+
+	chain, err := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(canon); err != nil {
+		t.Fatalf("failed to insert canonical chain: %v", err)
+	}
+	if _, err := chain.InsertChain(fork); err == nil {
+		t.Fatalf("invalid fork inserted")
+	}
+	if head := chain.CurrentBlock(); head.Hash() != canon[9].Hash() {
+		t.Fatalf("head mismatch: have %d [%x], want %d [%x]", head.Number(), head.Hash(), canon[9].Number(), canon[9].Hash())
+	}
+	statedb, err := chain.State()
+	if err != nil {
+		t.Fatalf("head state missing after failed reorg: %v", err)
+	}
+	if root := statedb.IntermediateRoot(true); root != canon[9].Root() {
+		t.Fatalf("head state root mismatch: have %x, want %x", root, canon[9].Root())
+	}
+	if balance := statedb.GetBalance(addr); balance.Sign() == 0 {
+		t.Fatalf("head state unreadable")
+	}
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
 	lru "github.com/hashicorp/golang-lru"
 )
 
@@ -524,6 +525,43 @@ func (hc *HeaderChain) GetHeaderByNumber(number uint64) *types.Header {
 	return hc.GetHeader(hash, number)
 }
 
+// GetHeadersFrom returns a contiguous segment of canonical headers, in rlp
+// encoded form, starting at 'number' and going towards the genesis. The method
+// assumes the caller placed a cap on count.
+func (hc *HeaderChain) GetHeadersFrom(number, count uint64) []rlp.RawValue {
+	// If the request is for future headers, we return only the headers we have
+	if current := hc.CurrentHeader().Number.Uint64(); current < number {
+		if count <= number-current {
+			return nil
+		}
+		count -= number - current
+		number = current
+	}
+	if count > number+1 {
+		count = number + 1
+	}
+	hash := rawdb.ReadCanonicalHash(hc.chainDb, number)
+	if hash == (common.Hash{}) {
+		return nil
+	}
+	// Serve the headers already in the cache, before going to the database
+	var headers []rlp.RawValue
+	for ; count > 0; count-- {
+		header, ok := hc.headerCache.Get(hash)
+		if !ok {
+			break
+		}
+		blob, _ := rlp.EncodeToBytes(header)
+		headers = append(headers, blob)
+		hash = header.(*types.Header).ParentHash
+		number--
+	}
+	if count > 0 {
+		headers = append(headers, rawdb.ReadHeaderRange(hc.chainDb, number, count)...)
+	}
+	return headers
+}
+
 func (hc *HeaderChain) GetCanonicalHash(number uint64) common.Hash {
 	return rawdb.ReadCanonicalHash(hc.chainDb, number)
 }
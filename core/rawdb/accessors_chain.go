@@ -19,6 +19,7 @@ package rawdb
 import (
 	"bytes"
 	"encoding/binary"
+	"math"
 	"math/big"
 	"sort"
 
@@ -294,6 +295,51 @@ func ReadHeaderRLP(db ethdb.Reader, hash common.Hash, number uint64) rlp.RawValu
 	return nil // Can't find the data anywhere.
 }
 
+// ReadHeaderRange returns the rlp-encoded canonical headers, starting at
+// 'number' and going backwards towards genesis. This method assumes that the
+// caller already placed a cap on count, to prevent DoS issues.
+//
+// Since this method operates in head-towards-genesis mode, it will return an
+// empty slice in case the head ('number') is missing. Hence, the caller must
+// ensure that the head ('number') argument is actually an existing header.
+func ReadHeaderRange(db ethdb.Reader, number uint64, count uint64) []rlp.RawValue {
+	var headers []rlp.RawValue
+	if count == 0 {
+		return headers
+	}
+	if count-1 > number {
+		count = number + 1 // It's ok to request block 0, 1 item
+	}
+	// Read the headers still in the key-value store first, following the parent
+	// hashes down from the canonical head of the range
+	i := number
+	if frozen, _ := db.Ancients(); i >= frozen {
+		hash := ReadCanonicalHash(db, number)
+		for ; i >= frozen && count > 0; i-- {
+			data, _ := db.Get(headerKey(i, hash))
+			if len(data) == 0 {
+				break // Maybe got moved to the ancient store
+			}
+			headers = append(headers, data)
+			hash = types.HeaderParentHashFromRLP(data)
+			count--
+		}
+	}
+	if count == 0 {
+		return headers
+	}
+	// Read the remainder from the ancient store in one go. The data is returned
+	// in ascending order, so it needs to be reversed.
+	data, err := db.AncientRange(freezerHeaderTable, i+1-count, count, math.MaxUint64)
+	if err != nil || uint64(len(data)) != count {
+		return headers
+	}
+	for j := len(data) - 1; j >= 0; j-- {
+		headers = append(headers, data[j])
+	}
+	return headers
+}
+
 // HasHeader verifies the existence of a block header corresponding to the hash.
 func HasHeader(db ethdb.Reader, hash common.Hash, number uint64) bool {
 	if has, err := db.Ancient(freezerHashTable, number); err == nil && common.BytesToHash(has) == hash {
@@ -406,6 +452,43 @@ func ReadCanonicalBodyRLP(db ethdb.Reader, number uint64) rlp.RawValue {
 	return data
 }
 
+// ReadAncientBodyRange retrieves the hashes and rlp-encoded bodies of at most
+// 'count' consecutive blocks from the ancient store, starting at 'number'. At
+// least one body is returned if it exists, but otherwise as many as fit into
+// maxBytes. Since the ancient store only contains canonical data, the hashes
+// are the canonical ones.
+func ReadAncientBodyRange(db ethdb.AncientReader, number, count, maxBytes uint64) ([]common.Hash, []rlp.RawValue) {
+	bodies, err := db.AncientRange(freezerBodiesTable, number, count, maxBytes)
+	if err != nil {
+		return nil, nil
+	}
+	blobs, err := db.AncientRange(freezerHashTable, number, uint64(len(bodies)), math.MaxUint64)
+	if err != nil {
+		return nil, nil
+	}
+	// The freezer might have been truncated in between the two reads
+	if len(blobs) < len(bodies) {
+		bodies = bodies[:len(blobs)]
+	}
+	hashes := make([]common.Hash, len(bodies))
+	for i := range hashes {
+		hashes[i] = common.BytesToHash(blobs[i])
+	}
+	result := make([]rlp.RawValue, len(bodies))
+	for i, body := range bodies {
+		result[i] = body
+	}
+	return hashes, result
+}
+
+// ReadHistoryTail retrieves the number of the first block whose body and
+// receipts are still available, all older ones having been pruned from the
+// ancient store.
+func ReadHistoryTail(db ethdb.AncientReader) uint64 {
+	tail, _ := db.AncientTail(freezerBodiesTable)
+	return tail
+}
+
 // WriteBodyRLP stores an RLP encoded block body into the database.
 func WriteBodyRLP(db ethdb.KeyValueWriter, hash common.Hash, number uint64, rlp rlp.RawValue) {
 	if err := db.Put(blockBodyKey(number, hash), rlp); err != nil {
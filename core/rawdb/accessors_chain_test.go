@@ -527,3 +527,89 @@ func TestCanonicalHashIteration(t *testing.T) {
 		}
 	}
 }
+
+// Tests that ranged header and body retrievals work across the boundary of the
+// ancient store and the key-value store.
+func TestRangedChainStorage(t *testing.T) {
+	frdir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp freezer dir: %v", err)
+	}
+	defer os.RemoveAll(frdir)
+
+	db, err := NewDatabaseWithFreezer(NewMemoryDatabase(), frdir, "", false)
+	if err != nil {
+		t.Fatalf("failed to create database with ancient backend")
+	}
+	defer db.Close()
+
+	// Create a chain of blocks, freezing the first half of them
+	var (
+		blocks []*types.Block
+		parent common.Hash
+	)
+	for i := 0; i < 100; i++ {
+		block := types.NewBlockWithHeader(&types.Header{
+			ParentHash:  parent,
+			Number:      big.NewInt(int64(i)),
+			Extra:       []byte{byte(i)},
+			UncleHash:   types.EmptyUncleHash,
+			TxHash:      types.EmptyRootHash,
+			ReceiptHash: types.EmptyRootHash,
+		})
+		if i < 50 {
+			WriteAncientBlock(db, block, nil, big.NewInt(int64(i)))
+		} else {
+			WriteBlock(db, block)
+			WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		}
+		blocks = append(blocks, block)
+		parent = block.Hash()
+	}
+	// Check header ranges inside and across the stores
+	for i, tc := range []struct {
+		number, count uint64
+		want          int
+	}{
+		{0, 0, 0},
+		{0, 1, 1},
+		{0, 10, 1},
+		{10, 5, 5},
+		{49, 50, 50},
+		{60, 5, 5},
+		{60, 20, 20},
+		{99, 100, 100},
+		{99, 200, 100},
+		{100, 10, 0},
+	} {
+		headers := ReadHeaderRange(db, tc.number, tc.count)
+		if len(headers) != tc.want {
+			t.Fatalf("test %d: header count mismatch: have %d, want %d", i, len(headers), tc.want)
+		}
+		for j, header := range headers {
+			want, _ := rlp.EncodeToBytes(blocks[tc.number-uint64(j)].Header())
+			if !bytes.Equal(header, want) {
+				t.Fatalf("test %d: header %d mismatch", i, tc.number-uint64(j))
+			}
+		}
+	}
+	// Check body ranges in the ancient store
+	hashes, bodies := ReadAncientBodyRange(db, 10, 100, 1000000)
+	if len(hashes) != 40 || len(bodies) != 40 {
+		t.Fatalf("body count mismatch: have %d/%d, want 40", len(hashes), len(bodies))
+	}
+	for i := range hashes {
+		if hashes[i] != blocks[10+i].Hash() {
+			t.Fatalf("hash %d mismatch: have %x, want %x", 10+i, hashes[i], blocks[10+i].Hash())
+		}
+		if !bytes.Equal(bodies[i], ReadBodyRLP(db, blocks[10+i].Hash(), uint64(10+i))) {
+			t.Fatalf("body %d mismatch", 10+i)
+		}
+	}
+	if hashes, bodies := ReadAncientBodyRange(db, 10, 100, 1); len(hashes) != 1 || len(bodies) != 1 {
+		t.Fatalf("byte limited body count mismatch: have %d/%d, want 1", len(hashes), len(bodies))
+	}
+	if hashes, _ := ReadAncientBodyRange(db, 50, 10, 1000000); len(hashes) != 0 {
+		t.Fatalf("non-frozen bodies returned: %d", len(hashes))
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// The list of supported trie node storage schemes.
+const (
+	// HashScheme stores trie nodes keyed by their hash. Nodes of stale states
+	// are only removed by reference counting in memory or by offline pruning.
+	HashScheme = "hash"
+
+	// PathScheme stores trie nodes keyed by their owner and path in the trie.
+	// Only the latest state is persisted, older ones are reachable through a
+	// bounded set of reverse diffs.
+	PathScheme = "path"
+)
+
+// ReadStateScheme retrieves the trie node storage scheme of the database,
+// defaulting to the hash scheme for databases created before it was tracked.
+func ReadStateScheme(db ethdb.KeyValueReader) string {
+	data, _ := db.Get(stateSchemeKey)
+	if len(data) == 0 {
+		return HashScheme
+	}
+	return string(data)
+}
+
+// WriteStateScheme stores the trie node storage scheme of the database.
+func WriteStateScheme(db ethdb.KeyValueWriter, scheme string) {
+	if err := db.Put(stateSchemeKey, []byte(scheme)); err != nil {
+		log.Crit("Failed to store state scheme", "err", err)
+	}
+}
+
+// ParseStateScheme checks the requested trie node storage scheme against the
+// one the database was created with, persisting it on a fresh database. An
+// empty request selects whatever the database already uses.
+func ParseStateScheme(provided string, db ethdb.Database) (string, error) {
+	if provided != "" && provided != HashScheme && provided != PathScheme {
+		return "", fmt.Errorf("unknown state scheme %q", provided)
+	}
+	stored, _ := db.Get(stateSchemeKey)
+	switch {
+	case len(stored) != 0:
+		if provided != "" && provided != string(stored) {
+			return "", fmt.Errorf("state scheme mismatch: %s (stored) != %s (requested)", stored, provided)
+		}
+		return string(stored), nil
+
+	case ReadCanonicalHash(db, 0) != (common.Hash{}):
+		// Databases with a chain but without a marker predate the path scheme
+		if provided != "" && provided != HashScheme {
+			return "", fmt.Errorf("state scheme mismatch: %s (stored) != %s (requested)", HashScheme, provided)
+		}
+		return HashScheme, nil
+	}
+	if provided == "" {
+		provided = HashScheme
+	}
+	WriteStateScheme(db, provided)
+	return provided, nil
+}
+
+// ReadAccountTrieNode retrieves the account trie node at the given path.
+func ReadAccountTrieNode(db ethdb.KeyValueReader, path []byte) []byte {
+	data, _ := db.Get(accountTrieNodeKey(path))
+	return data
+}
+
+// HasAccountTrieNode checks if an account trie node is present at the given path.
+func HasAccountTrieNode(db ethdb.KeyValueReader, path []byte) bool {
+	ok, _ := db.Has(accountTrieNodeKey(path))
+	return ok
+}
+
+// WriteAccountTrieNode writes the provided account trie node into the database.
+func WriteAccountTrieNode(db ethdb.KeyValueWriter, path []byte, node []byte) {
+	if err := db.Put(accountTrieNodeKey(path), node); err != nil {
+		log.Crit("Failed to store account trie node", "err", err)
+	}
+}
+
+// DeleteAccountTrieNode deletes the account trie node at the given path.
+func DeleteAccountTrieNode(db ethdb.KeyValueWriter, path []byte) {
+	if err := db.Delete(accountTrieNodeKey(path)); err != nil {
+		log.Crit("Failed to delete account trie node", "err", err)
+	}
+}
+
+// ReadStorageTrieNode retrieves the storage trie node of the given account at
+// the given path.
+func ReadStorageTrieNode(db ethdb.KeyValueReader, accountHash common.Hash, path []byte) []byte {
+	data, _ := db.Get(storageTrieNodeKey(accountHash, path))
+	return data
+}
+
+// WriteStorageTrieNode writes the provided storage trie node into the database.
+func WriteStorageTrieNode(db ethdb.KeyValueWriter, accountHash common.Hash, path []byte, node []byte) {
+	if err := db.Put(storageTrieNodeKey(accountHash, path), node); err != nil {
+		log.Crit("Failed to store storage trie node", "err", err)
+	}
+}
+
+// DeleteStorageTrieNode deletes the storage trie node of the given account at
+// the given path.
+func DeleteStorageTrieNode(db ethdb.KeyValueWriter, accountHash common.Hash, path []byte) {
+	if err := db.Delete(storageTrieNodeKey(accountHash, path)); err != nil {
+		log.Crit("Failed to delete storage trie node", "err", err)
+	}
+}
+
+// IterateStorageTrieNodes returns an iterator over all the storage trie nodes
+// of the given account.
+func IterateStorageTrieNodes(db ethdb.Iteratee, accountHash common.Hash) ethdb.Iterator {
+	return db.NewIterator(storageTrieNodeKey(accountHash, nil), nil)
+}
+
+// isHexPath reports whether the given key suffix is a valid trie node path,
+// i.e. consists of nibbles only and is not longer than a full key.
+func isHexPath(path []byte) bool {
+	if len(path) > 2*common.HashLength {
+		return false
+	}
+	for _, b := range path {
+		if b > 0x0f {
+			return false
+		}
+	}
+	return true
+}
+
+// IsAccountTrieNode reports whether the database key belongs to an account
+// trie node stored in the path-based scheme.
+func IsAccountTrieNode(key []byte) bool {
+	return len(key) > 0 && key[0] == trieNodeAccountPrefix[0] && isHexPath(key[1:])
+}
+
+// IsStorageTrieNode reports whether the database key belongs to a storage
+// trie node stored in the path-based scheme.
+func IsStorageTrieNode(key []byte) bool {
+	prefix := len(trieNodeStoragePrefix) + common.HashLength
+	return len(key) >= prefix && key[0] == trieNodeStoragePrefix[0] && isHexPath(key[prefix:])
+}
+
+// ResolveStorageTrieNode splits a storage trie node key into the owning account
+// hash and the node path.
+func ResolveStorageTrieNode(key []byte) (common.Hash, []byte) {
+	prefix := len(trieNodeStoragePrefix) + common.HashLength
+	return common.BytesToHash(key[len(trieNodeStoragePrefix):prefix]), key[prefix:]
+}
+
+// ReadReverseDiffHead retrieves the id of the latest reverse diff, or zero if
+// none were written yet.
+func ReadReverseDiffHead(db ethdb.KeyValueReader) uint64 {
+	data, _ := db.Get(reverseDiffHeadKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// WriteReverseDiffHead stores the id of the latest reverse diff.
+func WriteReverseDiffHead(db ethdb.KeyValueWriter, id uint64) {
+	if err := db.Put(reverseDiffHeadKey, encodeBlockNumber(id)); err != nil {
+		log.Crit("Failed to store reverse diff head", "err", err)
+	}
+}
+
+// ReadReverseDiff retrieves the RLP encoded reverse diff with the given id.
+func ReadReverseDiff(db ethdb.KeyValueReader, id uint64) []byte {
+	data, _ := db.Get(reverseDiffKey(id))
+	return data
+}
+
+// WriteReverseDiff stores an RLP encoded reverse diff with the given id.
+func WriteReverseDiff(db ethdb.KeyValueWriter, id uint64, diff []byte) {
+	if err := db.Put(reverseDiffKey(id), diff); err != nil {
+		log.Crit("Failed to store reverse diff", "err", err)
+	}
+}
+
+// DeleteReverseDiff deletes the reverse diff with the given id.
+func DeleteReverseDiff(db ethdb.KeyValueWriter, id uint64) {
+	if err := db.Delete(reverseDiffKey(id)); err != nil {
+		log.Crit("Failed to delete reverse diff", "err", err)
+	}
+}
+
+// ReadReverseDiffLookup retrieves the id of the reverse diff which reverts the
+// state to the given root.
+func ReadReverseDiffLookup(db ethdb.KeyValueReader, root common.Hash) *uint64 {
+	data, _ := db.Get(reverseDiffLookupKey(root))
+	if len(data) != 8 {
+		return nil
+	}
+	id := binary.BigEndian.Uint64(data)
+	return &id
+}
+
+// WriteReverseDiffLookup stores the id of the reverse diff which reverts the
+// state to the given root.
+func WriteReverseDiffLookup(db ethdb.KeyValueWriter, root common.Hash, id uint64) {
+	if err := db.Put(reverseDiffLookupKey(root), encodeBlockNumber(id)); err != nil {
+		log.Crit("Failed to store reverse diff lookup", "err", err)
+	}
+}
+
+// DeleteReverseDiffLookup deletes the reverse diff lookup of the given root.
+func DeleteReverseDiffLookup(db ethdb.KeyValueWriter, root common.Hash) {
+	if err := db.Delete(reverseDiffLookupKey(root)); err != nil {
+		log.Crit("Failed to delete reverse diff lookup", "err", err)
+	}
+}
@@ -17,6 +17,8 @@
 package rawdb
 
 import (
+	"errors"
+	"fmt"
 	"runtime"
 	"sync/atomic"
 	"time"
@@ -173,6 +175,10 @@ func iterateTransactions(db ethdb.Database, from uint64, to uint64, reverse bool
 // There is a passed channel, the whole procedure will be interrupted if any
 // signal received.
 func indexTransactions(db ethdb.Database, from uint64, to uint64, interrupt chan struct{}, hook func(uint64) bool) {
+	// Bodies below the history tail were pruned, nothing can be indexed there
+	if tail := ReadHistoryTail(db); from < tail {
+		from = tail
+	}
 	// short circuit for invalid range
 	if from >= to {
 		return
@@ -264,6 +270,10 @@ func indexTransactionsForTesting(db ethdb.Database, from uint64, to uint64, inte
 // There is a passed channel, the whole procedure will be interrupted if any
 // signal received.
 func unindexTransactions(db ethdb.Database, from uint64, to uint64, interrupt chan struct{}, hook func(uint64) bool) {
+	// Bodies below the history tail were pruned, their indices with them
+	if tail := ReadHistoryTail(db); from < tail {
+		from = tail
+	}
 	// short circuit for invalid range
 	if from >= to {
 		return
@@ -345,3 +355,42 @@ func UnindexTransactions(db ethdb.Database, from uint64, to uint64, interrupt ch
 func unindexTransactionsForTesting(db ethdb.Database, from uint64, to uint64, interrupt chan struct{}, hook func(uint64) bool) {
 	unindexTransactions(db, from, to, interrupt, hook)
 }
+
+// PruneHistory deletes the bodies and receipts of all the blocks below the given
+// number from the ancient store, retaining their headers. The transaction
+// indices of the pruned blocks are removed beforehand, since they can only be
+// found through the bodies.
+//
+// There is a passed channel, the whole procedure will be interrupted if any
+// signal received.
+func PruneHistory(db ethdb.Database, tail uint64, interrupt chan struct{}) error {
+	frozen, err := db.Ancients()
+	if err != nil {
+		return err
+	}
+	if tail > frozen {
+		return fmt.Errorf("history beyond the ancient store can't be pruned: tail %d, frozen %d", tail, frozen)
+	}
+	if tail <= ReadHistoryTail(db) {
+		return nil
+	}
+	// Remove the transaction indices of the blocks about to be pruned
+	var from uint64
+	if txTail := ReadTxIndexTail(db); txTail != nil {
+		from = *txTail
+	}
+	if from < tail {
+		unindexTransactions(db, from, tail, interrupt, nil)
+		if txTail := ReadTxIndexTail(db); txTail == nil || *txTail < tail {
+			return errors.New("transaction unindexing interrupted")
+		}
+	}
+	// Drop the bodies and receipts, receipts first so a failure never leaves
+	// receipts without their bodies
+	for _, kind := range []string{freezerReceiptTable, freezerBodiesTable} {
+		if err := db.TruncateAncientTail(kind, tail); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -17,7 +17,9 @@
 package rawdb
 
 import (
+	"io/ioutil"
 	"math/big"
+	"os"
 	"reflect"
 	"sort"
 	"sync"
@@ -206,3 +208,72 @@ func TestIndexTransactions(t *testing.T) {
 	verify(8, 11, true, 8)
 	verify(0, 8, false, 8)
 }
+
+func TestPruneHistory(t *testing.T) {
+	frdir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp freezer dir: %v", err)
+	}
+	defer os.RemoveAll(frdir)
+
+	db, err := NewDatabaseWithFreezer(NewMemoryDatabase(), frdir, "", false)
+	if err != nil {
+		t.Fatalf("failed to create database with ancient backend")
+	}
+	defer db.Close()
+
+	// Freeze a chain of blocks with a transaction each, and index them
+	var (
+		to     = common.BytesToAddress([]byte{0x11})
+		blocks []*types.Block
+	)
+	for i := uint64(0); i < 10; i++ {
+		tx := types.NewTransaction(i, to, big.NewInt(111), 1111, big.NewInt(11111), nil)
+		block := types.NewBlock(&types.Header{Number: new(big.Int).SetUint64(i)}, []*types.Transaction{tx}, nil, nil, newHasher())
+		WriteAncientBlock(db, block, types.Receipts{{CumulativeGasUsed: 1111, Logs: []*types.Log{}}}, big.NewInt(int64(i)))
+		WriteHeaderNumber(db, block.Hash(), i)
+		blocks = append(blocks, block)
+	}
+	IndexTransactions(db, 0, 10, nil)
+
+	if err := PruneHistory(db, 11, nil); err == nil {
+		t.Fatalf("pruning beyond the ancient store succeeded")
+	}
+	if err := PruneHistory(db, 4, nil); err != nil {
+		t.Fatalf("failed to prune history: %v", err)
+	}
+	if tail := ReadHistoryTail(db); tail != 4 {
+		t.Fatalf("history tail mismatch: have %d, want 4", tail)
+	}
+	if tail := ReadTxIndexTail(db); tail == nil || *tail != 4 {
+		t.Fatalf("tx index tail mismatch: have %v, want 4", tail)
+	}
+	for i, block := range blocks {
+		hash, number := block.Hash(), block.NumberU64()
+		if ReadHeader(db, hash, number) == nil {
+			t.Fatalf("block %d: header missing", i)
+		}
+		pruned := i < 4
+		if (ReadBodyRLP(db, hash, number) == nil) != pruned {
+			t.Fatalf("block %d: body availability mismatch, pruned %v", i, pruned)
+		}
+		if (ReadReceiptsRLP(db, hash, number) == nil) != pruned {
+			t.Fatalf("block %d: receipts availability mismatch, pruned %v", i, pruned)
+		}
+		if (ReadTxLookupEntry(db, block.Transactions()[0].Hash()) == nil) != pruned {
+			t.Fatalf("block %d: tx lookup availability mismatch, pruned %v", i, pruned)
+		}
+	}
+	// Indexing transactions must skip the pruned range
+	IndexTransactions(db, 0, 10, nil)
+	if tail := ReadTxIndexTail(db); tail == nil || *tail != 4 {
+		t.Fatalf("tx index tail mismatch after reindexing: have %v, want 4", tail)
+	}
+	// Truncating the chain into the pruned history must fail
+	if err := db.TruncateAncients(2); err == nil {
+		t.Fatalf("truncated ancients below the history tail")
+	}
+	if frozen, _ := db.Ancients(); frozen != 10 {
+		t.Fatalf("ancients changed by failed truncation: have %d, want 10", frozen)
+	}
+}
@@ -15,3 +15,59 @@
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
 package rawdb
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/ethdb/leveldb"
+)
+
+// Tests that the key-value engine is recorded on creation and that reopening a
+// datadir with a different engine is rejected.
+func TestDatabaseEngineCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rawdb-engine-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		kvdir  = filepath.Join(dir, "chaindata")
+		ancdir = filepath.Join(kvdir, "ancient")
+	)
+	db, err := Open(OpenOptions{Type: DBLeveldb, Directory: kvdir, AncientsDirectory: ancdir})
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	if engine := ReadDatabaseEngine(db); engine != DBLeveldb {
+		t.Fatalf("engine mismatch: have %q, want %q", engine, DBLeveldb)
+	}
+	db.Close()
+
+	if engine := PreexistingDatabase(kvdir); engine != DBLeveldb {
+		t.Fatalf("preexisting engine mismatch: have %q, want %q", engine, DBLeveldb)
+	}
+	if _, err := Open(OpenOptions{Type: DBPebble, Directory: kvdir, AncientsDirectory: ancdir}); err == nil {
+		t.Fatalf("opened leveldb datadir with pebble")
+	}
+	// Autodetect the engine, ensuring the datadir is still accessible
+	db, err = Open(OpenOptions{Directory: kvdir, AncientsDirectory: ancdir})
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	db.Close()
+
+	// Corrupt the recorded engine and ensure the freezer setup rejects it
+	kvdb, err := leveldb.New(kvdir, 0, 0, "", false)
+	if err != nil {
+		t.Fatalf("failed to open key-value store: %v", err)
+	}
+	WriteDatabaseEngine(kvdb, DBPebble)
+	if _, err := NewDatabaseWithFreezer(kvdb, ancdir, "", false); err == nil {
+		t.Fatalf("accepted mismatching database engine")
+	}
+	kvdb.Close()
+}
@@ -0,0 +1,50 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build !(arm64 || amd64)
+// +build !arm64,!amd64
+
+package rawdb
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/leveldb"
+)
+
+// errPebbleUnsupported is returned when trying to open a pebble database on a
+// platform it does not support.
+var errPebbleUnsupported = errors.New("pebble is not supported on this platform")
+
+// NewPebbleDBDatabase is unsupported on this platform and always errors.
+func NewPebbleDBDatabase(file string, cache int, handles int, namespace string, readonly bool) (ethdb.Database, error) {
+	return nil, errPebbleUnsupported
+}
+
+// newPebbleDB is unsupported on this platform and always errors.
+func newPebbleDB(file string, cache int, handles int, namespace string, readonly bool) (ethdb.KeyValueStore, error) {
+	return nil, errPebbleUnsupported
+}
+
+// databaseEngine returns the name of the engine backing the given key-value
+// store, or an empty string if it's not a persistent one.
+func databaseEngine(db ethdb.KeyValueStore) string {
+	if _, ok := db.(*leveldb.Database); ok {
+		return DBLeveldb
+	}
+	return ""
+}
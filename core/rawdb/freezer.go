@@ -177,6 +177,26 @@ func (f *freezer) Ancient(kind string, number uint64) ([]byte, error) {
 	return nil, errUnknownTable
 }
 
+// AncientRange retrieves multiple items in sequence, starting from the index
+// 'start'. It will return at most 'count' items, and at least one item even if
+// it exceeds maxBytes, but will otherwise return as many items as fit into
+// maxBytes.
+func (f *freezer) AncientRange(kind string, start, count, maxBytes uint64) ([][]byte, error) {
+	if table := f.tables[kind]; table != nil {
+		return table.RetrieveItems(start, count, maxBytes)
+	}
+	return nil, errUnknownTable
+}
+
+// AncientTail returns the number of the first item still available in the
+// specified category.
+func (f *freezer) AncientTail(kind string) (uint64, error) {
+	if table := f.tables[kind]; table != nil {
+		return table.tail(), nil
+	}
+	return 0, errUnknownTable
+}
+
 // Ancients returns the length of the frozen items.
 func (f *freezer) Ancients() (uint64, error) {
 	return atomic.LoadUint64(&f.frozen), nil
@@ -248,6 +268,12 @@ func (f *freezer) TruncateAncients(items uint64) error {
 	if atomic.LoadUint64(&f.frozen) <= items {
 		return nil
 	}
+	// Refuse to cut into expired history, lest the tables get out of sync
+	for _, table := range f.tables {
+		if items < table.tail() {
+			return errTruncationBelowTail
+		}
+	}
 	for _, table := range f.tables {
 		if err := table.truncate(items); err != nil {
 			return err
@@ -257,6 +283,19 @@ func (f *freezer) TruncateAncients(items uint64) error {
 	return nil
 }
 
+// TruncateAncientTail discards all data of the specified category below the
+// provided threshold number. Item numbers stay stable, the discarded items are
+// simply no longer available.
+func (f *freezer) TruncateAncientTail(kind string, tail uint64) error {
+	if f.readonly {
+		return errReadOnly
+	}
+	if table := f.tables[kind]; table != nil {
+		return table.truncateTail(tail)
+	}
+	return errUnknownTable
+}
+
 // Sync flushes all data tables to disk.
 func (f *freezer) Sync() error {
 	var errs []error
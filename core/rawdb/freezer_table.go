@@ -21,8 +21,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"sync/atomic"
 
@@ -43,6 +46,14 @@ var (
 
 	// errNotSupported is returned if the database doesn't support the required operation.
 	errNotSupported = errors.New("this operation is not supported")
+
+	// errTruncationBelowTail is returned if the freezer table is requested to be
+	// truncated at the head below its tail.
+	errTruncationBelowTail = errors.New("truncation below tail")
+
+	// errTruncationAboveHead is returned if the freezer table is requested to be
+	// truncated at the tail above its head.
+	errTruncationAboveHead = errors.New("truncation above head")
 )
 
 // indexEntry contains the number/id of the file that the data resides in, aswell as the
@@ -74,10 +85,11 @@ func (i *indexEntry) marshallBinary() []byte {
 // It consists of a data file (snappy encoded arbitrary data blobs) and an indexEntry
 // file (uncompressed 64 bit indices into the data file).
 type freezerTable struct {
-	// WARNING: The `items` field is accessed atomically. On 32 bit platforms, only
-	// 64-bit aligned fields can be atomic. The struct is guaranteed to be so aligned,
-	// so take advantage of that (https://golang.org/pkg/sync/atomic/#pkg-note-BUG).
-	items uint64 // Number of items stored in the table (including items removed from tail)
+	// WARNING: The `items` and `itemHidden` fields are accessed atomically. On 32
+	// bit platforms, only 64-bit aligned fields can be atomic. The struct is guaranteed
+	// to be so aligned, so take advantage of that (https://golang.org/pkg/sync/atomic/#pkg-note-BUG).
+	items      uint64 // Number of items stored in the table (including items removed from tail)
+	itemHidden uint64 // Number of items hidden from the tail, at least itemOffset (persisted in the meta file)
 
 	noCompression bool   // if true, disables snappy compression. Note: does not work retroactively
 	maxFileSize   uint32 // Max file size for data-files
@@ -91,7 +103,9 @@ type freezerTable struct {
 	index  *os.File            // File descriptor for the indexEntry file of the table
 
 	// In the case that old items are deleted (from the tail), we use itemOffset
-	// to count how many historic items have gone missing.
+	// to count how many historic items have gone missing. Since data can only be
+	// deleted by whole files, itemHidden tracks the requested tail, hiding the
+	// items still physically stored in the first data file.
 	itemOffset uint32 // Offset (number of discarded items)
 
 	headBytes  uint32        // Number of bytes written to the head file
@@ -240,8 +254,21 @@ func (t *freezerTable) repair() error {
 	t.tailId = firstIndex.filenum
 	t.itemOffset = firstIndex.offset
 
+	// Load the requested tail, which might be above the physical one
+	hidden, err := t.readMeta()
+	if err != nil {
+		return err
+	}
+	if hidden < uint64(t.itemOffset) {
+		hidden = uint64(t.itemOffset)
+	}
+	t.itemHidden = hidden
+
 	t.index.ReadAt(buffer, offsetsSize-indexEntrySize)
 	lastIndex.unmarshalBinary(buffer)
+	if offsetsSize == indexEntrySize {
+		lastIndex.offset = 0 // Index zero holds the tail metadata, not an offset
+	}
 	t.head, err = t.openFile(lastIndex.filenum, openFreezerFileForAppend)
 	if err != nil {
 		return err
@@ -273,6 +300,9 @@ func (t *freezerTable) repair() error {
 			t.index.ReadAt(buffer, offsetsSize-indexEntrySize)
 			var newLastIndex indexEntry
 			newLastIndex.unmarshalBinary(buffer)
+			if offsetsSize == indexEntrySize {
+				newLastIndex.offset = 0 // Index zero holds the tail metadata, not an offset
+			}
 			// We might have slipped back into an earlier head-file here
 			if newLastIndex.filenum != lastIndex.filenum {
 				// Release earlier opened file
@@ -339,6 +369,9 @@ func (t *freezerTable) truncate(items uint64) error {
 	if existing <= items {
 		return nil
 	}
+	if items < atomic.LoadUint64(&t.itemHidden) {
+		return errTruncationBelowTail
+	}
 	// We need to truncate, save the old size for metrics tracking
 	oldSize, err := t.sizeNolock()
 	if err != nil {
@@ -350,16 +383,20 @@ func (t *freezerTable) truncate(items uint64) error {
 		log = t.logger.Warn // Only loud warn if we delete multiple items
 	}
 	log("Truncating freezer table", "items", existing, "limit", items)
-	if err := truncateFreezerFile(t.index, int64(items+1)*indexEntrySize); err != nil {
+	position := items - uint64(t.itemOffset)
+	if err := truncateFreezerFile(t.index, int64(position+1)*indexEntrySize); err != nil {
 		return err
 	}
 	// Calculate the new expected size of the data file and truncate it
 	buffer := make([]byte, indexEntrySize)
-	if _, err := t.index.ReadAt(buffer, int64(items*indexEntrySize)); err != nil {
+	if _, err := t.index.ReadAt(buffer, int64(position*indexEntrySize)); err != nil {
 		return err
 	}
 	var expected indexEntry
 	expected.unmarshalBinary(buffer)
+	if position == 0 {
+		expected.offset = 0 // Index zero holds the tail metadata, not an offset
+	}
 
 	// We might need to truncate back to older files
 	if expected.filenum != t.headId {
@@ -393,6 +430,174 @@ func (t *freezerTable) truncate(items uint64) error {
 	return nil
 }
 
+// truncateTail discards any data below the provided threshold number. As data
+// can only be deleted by whole files, the items sharing the first remaining
+// data file with the new tail are hidden instead. The tail is persisted in the
+// meta file, so item numbers stay stable across restarts.
+func (t *freezerTable) truncateTail(items uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// Ensure the table is still accessible and the tail actually moves up
+	if t.index == nil || t.head == nil {
+		return errClosed
+	}
+	if atomic.LoadUint64(&t.itemHidden) >= items {
+		return nil
+	}
+	existing := atomic.LoadUint64(&t.items)
+	if existing < items {
+		return errTruncationAboveHead
+	}
+	// Persist the new tail before deleting anything, and hide the items
+	if err := t.writeMeta(items); err != nil {
+		return err
+	}
+	atomic.StoreUint64(&t.itemHidden, items)
+
+	// Find the data file containing the first kept item (or the last item, if
+	// all of them were hidden). Any file before that one can be deleted.
+	last := items
+	if last == existing {
+		last--
+	}
+	buffer := make([]byte, indexEntrySize)
+	readIndex := func(position uint64) (indexEntry, error) {
+		var entry indexEntry
+		if _, err := t.index.ReadAt(buffer, int64(position*indexEntrySize)); err != nil {
+			return entry, err
+		}
+		entry.unmarshalBinary(buffer)
+		return entry, nil
+	}
+	position := last - uint64(t.itemOffset) + 1
+	entry, err := readIndex(position)
+	if err != nil {
+		return err
+	}
+	if entry.filenum == t.tailId {
+		return nil
+	}
+	newTailId := entry.filenum
+
+	// Find the first item stored in the new tail file. It is stored in one piece
+	// at the beginning of the file, even if it was crossing a file boundary.
+	var searchErr error
+	first := uint64(sort.Search(int(position), func(i int) bool {
+		entry, err := readIndex(uint64(i) + 1)
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return entry.filenum >= newTailId
+	})) + 1
+	if searchErr != nil {
+		return searchErr
+	}
+	newOffset := uint64(t.itemOffset) + first - 1
+	if newOffset > math.MaxUint32 {
+		return fmt.Errorf("tail offset %d overflows index", newOffset)
+	}
+	oldSize, err := t.sizeNolock()
+	if err != nil {
+		return err
+	}
+	// Rewrite the index with the new tail metadata and the remaining entries,
+	// replacing the old one atomically
+	stat, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	name := t.index.Name()
+	index, err := openFreezerFileTruncated(name + ".tmp")
+	if err != nil {
+		return err
+	}
+	tailIndex := indexEntry{filenum: newTailId, offset: uint32(newOffset)}
+	if _, err := index.Write(tailIndex.marshallBinary()); err != nil {
+		index.Close()
+		return err
+	}
+	remaining := io.NewSectionReader(t.index, int64(first*indexEntrySize), stat.Size()-int64(first*indexEntrySize))
+	if _, err := io.Copy(index, remaining); err != nil {
+		index.Close()
+		return err
+	}
+	if err := index.Sync(); err != nil {
+		index.Close()
+		return err
+	}
+	t.index.Close()
+	if err := os.Rename(name+".tmp", name); err != nil {
+		index.Close()
+		return err
+	}
+	t.index = index
+
+	// Delete the data files below the new tail and update the counters
+	for num := t.tailId; num < newTailId; num++ {
+		t.releaseFile(num)
+		if err := os.Remove(t.fileName(num)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	t.tailId = newTailId
+	t.itemOffset = uint32(newOffset)
+
+	newSize, err := t.sizeNolock()
+	if err != nil {
+		return err
+	}
+	t.sizeGauge.Dec(int64(oldSize - newSize))
+	t.logger.Info("Truncated freezer table tail", "items", items, "deleted", newOffset)
+	return nil
+}
+
+// metaName returns the path of the file persisting the tail of the table.
+func (t *freezerTable) metaName() string {
+	return filepath.Join(t.path, fmt.Sprintf("%s.meta", t.name))
+}
+
+// readMeta loads the persisted tail of the table, returning zero if it was
+// never truncated from the tail.
+func (t *freezerTable) readMeta() (uint64, error) {
+	blob, err := ioutil.ReadFile(t.metaName())
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(blob) != 8 {
+		return 0, fmt.Errorf("invalid freezer table meta length %d", len(blob))
+	}
+	return binary.BigEndian.Uint64(blob), nil
+}
+
+// writeMeta atomically persists the tail of the table.
+func (t *freezerTable) writeMeta(tail uint64) error {
+	blob := make([]byte, 8)
+	binary.BigEndian.PutUint64(blob, tail)
+
+	name := t.metaName()
+	f, err := openFreezerFileTruncated(name + ".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(blob); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(name+".tmp", name)
+}
+
 // Close closes all opened files.
 func (t *freezerTable) Close() error {
 	t.lock.Lock()
@@ -421,13 +626,7 @@ func (t *freezerTable) Close() error {
 func (t *freezerTable) openFile(num uint32, opener func(string) (*os.File, error)) (f *os.File, err error) {
 	var exist bool
 	if f, exist = t.files[num]; !exist {
-		var name string
-		if t.noCompression {
-			name = fmt.Sprintf("%s.%04d.rdat", t.name, num)
-		} else {
-			name = fmt.Sprintf("%s.%04d.cdat", t.name, num)
-		}
-		f, err = opener(filepath.Join(t.path, name))
+		f, err = opener(t.fileName(num))
 		if err != nil {
 			return nil, err
 		}
@@ -436,6 +635,14 @@ func (t *freezerTable) openFile(num uint32, opener func(string) (*os.File, error
 	return f, err
 }
 
+// fileName returns the path of the data file with the given number.
+func (t *freezerTable) fileName(num uint32) string {
+	if t.noCompression {
+		return filepath.Join(t.path, fmt.Sprintf("%s.%04d.rdat", t.name, num))
+	}
+	return filepath.Join(t.path, fmt.Sprintf("%s.%04d.cdat", t.name, num))
+}
+
 // releaseFile closes a file, and removes it from the open file cache.
 // Assumes that the caller holds the write lock
 func (t *freezerTable) releaseFile(num uint32) {
@@ -605,8 +812,8 @@ func (t *freezerTable) retrieve(item uint64) ([]byte, error) {
 	if atomic.LoadUint64(&t.items) <= item {
 		return nil, errOutOfBounds
 	}
-	// Ensure the item was not deleted from the tail either
-	if uint64(t.itemOffset) > item {
+	// Ensure the item was not deleted or hidden from the tail either
+	if atomic.LoadUint64(&t.itemHidden) > item {
 		return nil, errOutOfBounds
 	}
 	startOffset, endOffset, filenum, err := t.getBounds(item - uint64(t.itemOffset))
@@ -626,10 +833,135 @@ func (t *freezerTable) retrieve(item uint64) ([]byte, error) {
 	return blob, nil
 }
 
+// RetrieveItems returns multiple items in sequence, starting from the index
+// 'start'. It will return at most 'count' items, but will abort earlier to
+// respect the 'maxBytes' argument. However, if the 'maxBytes' is smaller than
+// the size of one item, it _will_ return one element and possibly overflow the
+// maxBytes.
+func (t *freezerTable) RetrieveItems(start, count, maxBytes uint64) ([][]byte, error) {
+	// Read the raw, possibly compressed, data in as few disk accesses as possible
+	blobs, err := t.retrieveItems(start, count, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	if t.noCompression {
+		return blobs, nil
+	}
+	// Decompress the items, stopping early if the decoded data overflows
+	var (
+		items = make([][]byte, 0, len(blobs))
+		size  uint64
+	)
+	for i, blob := range blobs {
+		length, err := snappy.DecodedLen(blob)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 && size+uint64(length) > maxBytes {
+			break
+		}
+		item, err := snappy.Decode(nil, blob)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		size += uint64(length)
+	}
+	return items, nil
+}
+
+// retrieveItems reads up to 'count' consecutive items from the table, starting
+// at 'start'. It reads at least one item, but otherwise avoids reading more than
+// maxBytes bytes. Items stored back-to-back in the same data file are read with
+// a single disk access. OBS! This method does not decode compressed data.
+func (t *freezerTable) retrieveItems(start, count, maxBytes uint64) ([][]byte, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	// Ensure the table and the items are accessible
+	if t.index == nil || t.head == nil {
+		return nil, errClosed
+	}
+	items := atomic.LoadUint64(&t.items)
+	if items <= start || atomic.LoadUint64(&t.itemHidden) > start || count == 0 {
+		return nil, errOutOfBounds
+	}
+	if start+count > items || start+count < start {
+		count = items - start
+	}
+	// Read all the index entries delimiting the requested items in one go
+	offset := start - uint64(t.itemOffset)
+	buffer := make([]byte, (count+1)*indexEntrySize)
+	if _, err := t.index.ReadAt(buffer, int64(offset*indexEntrySize)); err != nil {
+		return nil, err
+	}
+	indices := make([]indexEntry, count+1)
+	for i := range indices {
+		indices[i].unmarshalBinary(buffer[i*indexEntrySize:])
+	}
+	// The first index entry of the table carries the tail metadata instead of
+	// an offset, and items crossing a data file boundary are stored in one piece
+	// at the beginning of the next file.
+	if offset == 0 {
+		indices[0] = indexEntry{filenum: indices[1].filenum}
+	}
+	// Determine how many items fit into the byte limit
+	var (
+		n    int
+		size uint64
+	)
+	for ; n < int(count); n++ {
+		length := uint64(indices[n+1].offset)
+		if indices[n].filenum == indices[n+1].filenum {
+			length -= uint64(indices[n].offset)
+		}
+		if n > 0 && size+length > maxBytes {
+			break
+		}
+		size += length
+	}
+	// Read the data of each file in a single batch and slice it up into items
+	blobs := make([][]byte, 0, n)
+	for first := 0; first < n; {
+		last, filenum := first, indices[first+1].filenum
+		for last+1 < n && indices[last+2].filenum == filenum {
+			last++
+		}
+		var from uint32
+		if indices[first].filenum == filenum {
+			from = indices[first].offset
+		}
+		dataFile, exist := t.files[filenum]
+		if !exist {
+			return nil, fmt.Errorf("missing data file %d", filenum)
+		}
+		data := make([]byte, indices[last+1].offset-from)
+		if _, err := dataFile.ReadAt(data, int64(from)); err != nil {
+			return nil, err
+		}
+		for i := first; i <= last; i++ {
+			var begin uint32
+			if indices[i].filenum == filenum {
+				begin = indices[i].offset
+			}
+			end := indices[i+1].offset - from
+			blobs = append(blobs, data[begin-from:end:end])
+		}
+		first = last + 1
+	}
+	t.readMeter.Mark(int64(size) + int64(len(buffer)))
+	return blobs, nil
+}
+
 // has returns an indicator whether the specified number data
 // exists in the freezer table.
 func (t *freezerTable) has(number uint64) bool {
-	return atomic.LoadUint64(&t.items) > number
+	return atomic.LoadUint64(&t.items) > number && atomic.LoadUint64(&t.itemHidden) <= number
+}
+
+// tail returns the number of the first item still available in the table.
+func (t *freezerTable) tail() uint64 {
+	return atomic.LoadUint64(&t.itemHidden)
 }
 
 // size returns the total data size in the freezer table.
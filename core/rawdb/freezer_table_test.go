@@ -595,6 +595,12 @@ func TestOffset(t *testing.T) {
 		} else if exp := getChunk(20, 0x99); !bytes.Equal(got, exp) {
 			t.Fatalf("expected %x got %x", exp, got)
 		}
+		// It should be fine to fetch 4,5,6 in one go
+		if items, err := f.RetrieveItems(numDeleted, 3, 100); err != nil {
+			t.Fatal(err)
+		} else if len(items) != 3 || !bytes.Equal(items[0], getChunk(20, 0xbb)) || !bytes.Equal(items[2], getChunk(20, 0x99)) {
+			t.Fatalf("unexpected ranged read result: %x", items)
+		}
 
 		// It should error at 0, 1,2,3
 		for i := numDeleted - 1; i > numDeleted-10; i-- {
@@ -633,6 +639,218 @@ func TestOffset(t *testing.T) {
 	checkPresent(1000000)
 }
 
+// TestSequentialRead tests that ranged reads return the same items as
+// individual retrievals, across data file boundaries.
+func TestSequentialRead(t *testing.T) {
+	t.Parallel()
+	rm, wm, sg := metrics.NewMeter(), metrics.NewMeter(), metrics.NewGauge()
+
+	for _, noCompression := range []bool{true, false} {
+		fname := fmt.Sprintf("batchread-%d", rand.Uint64())
+		f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 50, noCompression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Write 15 bytes 30 times, resulting in 3 items per file
+		for x := 0; x < 30; x++ {
+			f.Append(uint64(x), getChunk(15, x))
+		}
+		for start := uint64(0); start < 30; start++ {
+			for count := uint64(1); count < 35; count++ {
+				items, err := f.RetrieveItems(start, count, 100000)
+				if err != nil {
+					t.Fatalf("start %d, count %d: %v", start, count, err)
+				}
+				want := count
+				if start+count > 30 {
+					want = 30 - start
+				}
+				if uint64(len(items)) != want {
+					t.Fatalf("start %d, count %d: item count mismatch: have %d, want %d", start, count, len(items), want)
+				}
+				for i, item := range items {
+					if exp := getChunk(15, int(start)+i); !bytes.Equal(item, exp) {
+						t.Fatalf("start %d, count %d: item %d mismatch: have %x, want %x", start, count, i, item, exp)
+					}
+				}
+			}
+		}
+		// Check that reads outside of the table are rejected
+		if _, err := f.RetrieveItems(30, 1, 100000); err != errOutOfBounds {
+			t.Fatalf("out of bounds read: have %v, want %v", err, errOutOfBounds)
+		}
+		if _, err := f.RetrieveItems(0, 0, 100000); err != errOutOfBounds {
+			t.Fatalf("empty read: have %v, want %v", err, errOutOfBounds)
+		}
+		f.Close()
+	}
+}
+
+// TestSequentialReadByteLimit tests that ranged reads respect the byte limit,
+// but always return at least one item.
+func TestSequentialReadByteLimit(t *testing.T) {
+	t.Parallel()
+	rm, wm, sg := metrics.NewMeter(), metrics.NewMeter(), metrics.NewGauge()
+	fname := fmt.Sprintf("batchread-limit-%d", rand.Uint64())
+
+	f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 100, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// Write 10 bytes 30 times, resulting in 10 items per file
+	for x := 0; x < 30; x++ {
+		f.Append(uint64(x), getChunk(10, x))
+	}
+	for i, tc := range []struct {
+		start, count, limit uint64
+		items               int
+	}{
+		{0, 30, 5, 1},
+		{0, 30, 10, 1},
+		{0, 30, 19, 1},
+		{0, 30, 20, 2},
+		{0, 30, 95, 9},
+		{0, 30, 100, 10},
+		{0, 30, 101, 10},
+		{5, 30, 100, 10},
+		{25, 30, 100, 5},
+		{0, 3, 1000, 3},
+	} {
+		items, err := f.RetrieveItems(tc.start, tc.count, tc.limit)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if len(items) != tc.items {
+			t.Fatalf("test %d: item count mismatch: have %d, want %d", i, len(items), tc.items)
+		}
+		for j, item := range items {
+			if exp := getChunk(10, int(tc.start)+j); !bytes.Equal(item, exp) {
+				t.Fatalf("test %d: item %d mismatch: have %x, want %x", i, j, item, exp)
+			}
+		}
+	}
+}
+
+// TestTruncateTail tests that the tail of a table can be truncated, deleting
+// the data files no longer needed and keeping item numbers stable.
+func TestTruncateTail(t *testing.T) {
+	t.Parallel()
+	rm, wm, sg := metrics.NewMeter(), metrics.NewMeter(), metrics.NewGauge()
+	fname := fmt.Sprintf("truncate-tail-%d", rand.Uint64())
+
+	// Fill a table with 7 x 20 bytes, two items per data file
+	f, err := newCustomTable(os.TempDir(), fname, rm, wm, sg, 40, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for x := 0; x < 7; x++ {
+		f.Append(uint64(x), getChunk(20, 0xFF-x))
+	}
+	checkRetrieve := func(f *freezerTable, tail uint64) {
+		t.Helper()
+		for x := uint64(0); x < 7; x++ {
+			blob, err := f.Retrieve(x)
+			if x < tail {
+				if err != errOutOfBounds {
+					t.Fatalf("item %d below tail %d: have %v, want %v", x, tail, err, errOutOfBounds)
+				}
+				if f.has(x) {
+					t.Fatalf("item %d below tail %d reported present", x, tail)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("item %d above tail %d: %v", x, tail, err)
+			}
+			if exp := getChunk(20, 0xFF-int(x)); !bytes.Equal(blob, exp) {
+				t.Fatalf("item %d mismatch: have %x, want %x", x, blob, exp)
+			}
+		}
+		if tail < 7 {
+			items, err := f.RetrieveItems(tail, 7, 1000)
+			if err != nil || uint64(len(items)) != 7-tail {
+				t.Fatalf("ranged read from tail %d: have %d items (%v), want %d", tail, len(items), err, 7-tail)
+			}
+		}
+		if tail > 0 {
+			if _, err := f.RetrieveItems(tail-1, 7, 1000); err != errOutOfBounds {
+				t.Fatalf("ranged read below tail %d: have %v, want %v", tail, err, errOutOfBounds)
+			}
+		}
+		if f.tail() != tail {
+			t.Fatalf("tail mismatch: have %d, want %d", f.tail(), tail)
+		}
+	}
+	exists := func(num int) bool {
+		_, err := os.Stat(filepath.Join(os.TempDir(), fmt.Sprintf("%s.%04d.rdat", fname, num)))
+		return err == nil
+	}
+	// Hide a single item, sharing the first file with a kept one
+	if err := f.truncateTail(1); err != nil {
+		t.Fatal(err)
+	}
+	checkRetrieve(f, 1)
+	if !exists(0) {
+		t.Fatalf("data file with kept items deleted")
+	}
+	// Cut into the third file, the first two should be deleted
+	if err := f.truncateTail(5); err != nil {
+		t.Fatal(err)
+	}
+	checkRetrieve(f, 5)
+	if exists(0) || exists(1) || !exists(2) {
+		t.Fatalf("data files mismatch: 0: %v, 1: %v, 2: %v", exists(0), exists(1), exists(2))
+	}
+	// Moving the tail backwards is a noop, moving it beyond the head an error
+	if err := f.truncateTail(3); err != nil {
+		t.Fatal(err)
+	}
+	checkRetrieve(f, 5)
+	if err := f.truncateTail(8); err != errTruncationAboveHead {
+		t.Fatalf("truncation above head: have %v, want %v", err, errTruncationAboveHead)
+	}
+	f.Close()
+
+	// Reopen the table and ensure the tail survived
+	if f, err = newCustomTable(os.TempDir(), fname, rm, wm, sg, 40, true); err != nil {
+		t.Fatal(err)
+	}
+	checkRetrieve(f, 5)
+
+	// Truncating the head below the tail must be rejected, above it works
+	if err := f.truncate(4); err != errTruncationBelowTail {
+		t.Fatalf("truncation below tail: have %v, want %v", err, errTruncationBelowTail)
+	}
+	if err := f.truncate(6); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Append(6, getChunk(20, 0xFF-6)); err != nil {
+		t.Fatal(err)
+	}
+	checkRetrieve(f, 5)
+
+	// Hide everything and reopen again
+	if err := f.truncateTail(7); err != nil {
+		t.Fatal(err)
+	}
+	checkRetrieve(f, 7)
+	f.Close()
+
+	if f, err = newCustomTable(os.TempDir(), fname, rm, wm, sg, 40, true); err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	checkRetrieve(f, 7)
+	if err := f.Append(7, getChunk(20, 0x01)); err != nil {
+		t.Fatal(err)
+	}
+	if blob, err := f.Retrieve(7); err != nil || !bytes.Equal(blob, getChunk(20, 0x01)) {
+		t.Fatalf("appended item mismatch: %x (%v)", blob, err)
+	}
+}
+
 // TODO (?)
 // - test that if we remove several head-files, aswell as data last data-file,
 //   the index is truncated accordingly
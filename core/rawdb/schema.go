@@ -30,6 +30,16 @@ var (
 	// databaseVersionKey tracks the current database version.
 	databaseVersionKey = []byte("DatabaseVersion")
 
+	// databaseEngineKey tracks the key-value engine the database was created with.
+	databaseEngineKey = []byte("DatabaseEngine")
+
+	// stateSchemeKey tracks the storage scheme of the persisted trie nodes.
+	stateSchemeKey = []byte("StateScheme")
+
+	// reverseDiffHeadKey tracks the id of the latest reverse diff of the path-based
+	// state scheme.
+	reverseDiffHeadKey = []byte("ReverseDiffHead")
+
 	// headHeaderKey tracks the latest known header's hash.
 	headHeaderKey = []byte("LastHeader")
 
@@ -90,6 +100,12 @@ var (
 	SnapshotStoragePrefix = []byte("o") // SnapshotStoragePrefix + account hash + storage hash -> storage trie value
 	CodePrefix            = []byte("c") // CodePrefix + code hash -> account code
 
+	// Path-based trie node scheme.
+	trieNodeAccountPrefix   = []byte("A") // trieNodeAccountPrefix + hexPath -> trie node
+	trieNodeStoragePrefix   = []byte("O") // trieNodeStoragePrefix + accountHash + hexPath -> trie node
+	reverseDiffPrefix       = []byte("R") // reverseDiffPrefix + id (uint64 big endian) -> reverse diff
+	reverseDiffLookupPrefix = []byte("L") // reverseDiffLookupPrefix + state root -> reverse diff id (uint64 big endian)
+
 	preimagePrefix = []byte("secure-key-")      // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
 
@@ -207,6 +223,26 @@ func bloomBitsKey(bit uint, section uint64, hash common.Hash) []byte {
 	return key
 }
 
+// accountTrieNodeKey = trieNodeAccountPrefix + hexPath
+func accountTrieNodeKey(path []byte) []byte {
+	return append(trieNodeAccountPrefix, path...)
+}
+
+// storageTrieNodeKey = trieNodeStoragePrefix + accountHash + hexPath
+func storageTrieNodeKey(accountHash common.Hash, path []byte) []byte {
+	return append(append(trieNodeStoragePrefix, accountHash.Bytes()...), path...)
+}
+
+// reverseDiffKey = reverseDiffPrefix + id (uint64 big endian)
+func reverseDiffKey(id uint64) []byte {
+	return append(reverseDiffPrefix, encodeBlockNumber(id)...)
+}
+
+// reverseDiffLookupKey = reverseDiffLookupPrefix + state root
+func reverseDiffLookupKey(root common.Hash) []byte {
+	return append(reverseDiffLookupPrefix, root.Bytes()...)
+}
+
 // preimageKey = preimagePrefix + hash
 func preimageKey(hash common.Hash) []byte {
 	return append(preimagePrefix, hash.Bytes()...)
@@ -18,6 +18,7 @@ package state
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"fmt"
 	"math"
@@ -32,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/tracing"
 )
 
 // Tests that updating a state trie does not leak any database writes prior to
@@ -915,3 +917,49 @@ func TestStateDBAccessList(t *testing.T) {
 		t.Fatalf("expected empty, got %d", got)
 	}
 }
+
+// traceRecorder collects the spans exported by the tracer.
+type traceRecorder struct {
+	spans []*tracing.Span
+}
+
+func (r *traceRecorder) Export(spans []*tracing.Span) error {
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func TestStateReadTracing(t *testing.T) {
+	// Create a committed state to read from
+	db := NewDatabase(rawdb.NewMemoryDatabase())
+	state, _ := New(common.Hash{}, db, nil)
+	state.SetState(common.Address{0xaa}, common.Hash{0x01}, common.Hash{0x02})
+	root, _ := state.Commit(false)
+
+	recorder := new(traceRecorder)
+	tracing.Enable(recorder)
+	defer tracing.Disable()
+
+	// Reads without a trace context must not be traced
+	state, _ = New(root, db, nil)
+	state.GetState(common.Address{0xaa}, common.Hash{0x01})
+
+	// Reads within a trace context must be children of the context's span
+	state, _ = New(root, db, nil)
+	ctx, parent := tracing.Start(context.Background(), "parent")
+	state.SetTraceContext(ctx)
+	state.GetState(common.Address{0xaa}, common.Hash{0x01})
+	state.GetBalance(common.Address{0xbb})
+	parent.End()
+	tracing.Disable()
+
+	var names []string
+	for _, span := range recorder.spans {
+		if span != parent && span.ParentID != parent.SpanID {
+			t.Errorf("span %s not linked to parent", span.Name)
+		}
+		names = append(names, span.Name)
+	}
+	if want := []string{"trie.account", "trie.storage", "trie.account", "parent"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("traced spans mismatch: have %v, want %v", names, want)
+	}
+}
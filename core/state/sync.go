@@ -27,8 +27,38 @@ import (
 
 // NewStateSync create a new state trie download scheduler.
 func NewStateSync(root common.Hash, database ethdb.KeyValueReader, bloom *trie.SyncBloom, onLeaf func(paths [][]byte, leaf []byte) error) *trie.Sync {
+	var syncer *trie.Sync
+	onAccount, _ := syncCallbacks(&syncer, onLeaf)
+	syncer = trie.NewSync(root, database, onAccount, bloom)
+	return syncer
+}
+
+// ResumeStateSync recreates a state trie download scheduler from the pending
+// requests of a previous one.
+func ResumeStateSync(reqs []trie.SyncRequest, database ethdb.KeyValueReader, bloom *trie.SyncBloom, onLeaf func(paths [][]byte, leaf []byte) error) (*trie.Sync, error) {
+	var syncer *trie.Sync
+	onAccount, onSlot := syncCallbacks(&syncer, onLeaf)
+
+	// Nodes above the account leaves belong to the account trie, everything
+	// deeper is part of a storage trie.
+	callback := func(path []byte) trie.LeafCallback {
+		if len(path) < 2*common.HashLength {
+			return onAccount
+		}
+		return onSlot
+	}
+	syncer, err := trie.ResumeSync(reqs, database, callback, bloom)
+	if err != nil {
+		return nil, err
+	}
+	return syncer, nil
+}
+
+// syncCallbacks creates the leaf callbacks of the account trie and the storage
+// tries of a state sync, which schedule the storage tries and contract codes
+// referenced by accounts on the syncer.
+func syncCallbacks(syncer **trie.Sync, onLeaf func(paths [][]byte, leaf []byte) error) (onAccount, onSlot trie.LeafCallback) {
 	// Register the storage slot callback if the external callback is specified.
-	var onSlot func(paths [][]byte, hexpath []byte, leaf []byte, parent common.Hash) error
 	if onLeaf != nil {
 		onSlot = func(paths [][]byte, hexpath []byte, leaf []byte, parent common.Hash) error {
 			return onLeaf(paths, leaf)
@@ -36,8 +66,7 @@ func NewStateSync(root common.Hash, database ethdb.KeyValueReader, bloom *trie.S
 	}
 	// Register the account callback to connect the state trie and the storage
 	// trie belongs to the contract.
-	var syncer *trie.Sync
-	onAccount := func(paths [][]byte, hexpath []byte, leaf []byte, parent common.Hash) error {
+	onAccount = func(paths [][]byte, hexpath []byte, leaf []byte, parent common.Hash) error {
 		if onLeaf != nil {
 			if err := onLeaf(paths, leaf); err != nil {
 				return err
@@ -47,10 +76,9 @@ func NewStateSync(root common.Hash, database ethdb.KeyValueReader, bloom *trie.S
 		if err := rlp.Decode(bytes.NewReader(leaf), &obj); err != nil {
 			return err
 		}
-		syncer.AddSubTrie(obj.Root, hexpath, parent, onSlot)
-		syncer.AddCodeEntry(common.BytesToHash(obj.CodeHash), hexpath, parent)
+		(*syncer).AddSubTrie(obj.Root, hexpath, parent, onSlot)
+		(*syncer).AddCodeEntry(common.BytesToHash(obj.CodeHash), hexpath, parent)
 		return nil
 	}
-	syncer = trie.NewSync(root, database, onAccount, bloom)
-	return syncer
+	return onAccount, onSlot
 }
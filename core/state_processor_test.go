@@ -79,7 +79,7 @@ func TestStateProcessorErrors(t *testing.T) {
 			txs: []*types.Transaction{
 				makeTx(0, common.Address{}, big.NewInt(1), params.TxGas, nil, nil),
 			},
-			want: "could not apply tx 0 [0x3094b17498940d92b13baccf356ce8bfd6f221e926abc903d642fa1466c5b50e]: insufficient funds for transfer: address 0x71562b71999873DB5b286dF957af199Ec94617F7",
+			want: "could not apply tx 0 [0x3094b17498940d92b13baccf356ce8bfd6f221e926abc903d642fa1466c5b50e]: insufficient funds for gas * price + value: address 0x71562b71999873DB5b286dF957af199Ec94617F7 have 0 want 1",
 		},
 		{
 			txs: []*types.Transaction{
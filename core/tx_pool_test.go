@@ -37,13 +37,23 @@ import (
 	"github.com/ethereum/go-ethereum/trie"
 )
 
-// testTxPoolConfig is a transaction pool configuration without stateful disk
-// sideeffects used during testing.
-var testTxPoolConfig TxPoolConfig
+var (
+	// testTxPoolConfig is a transaction pool configuration without stateful disk
+	// sideeffects used during testing.
+	testTxPoolConfig TxPoolConfig
+
+	// eip1559Config is a chain config with EIP-1559 enabled at block 0.
+	eip1559Config *params.ChainConfig
+)
 
 func init() {
 	testTxPoolConfig = DefaultTxPoolConfig
 	testTxPoolConfig.Journal = ""
+
+	cpy := *params.TestChainConfig
+	eip1559Config = &cpy
+	eip1559Config.BerlinBlock = common.Big0
+	eip1559Config.LondonBlock = common.Big0
 }
 
 type testBlockChain struct {
@@ -87,12 +97,31 @@ func pricedDataTransaction(nonce uint64, gaslimit uint64, gasprice *big.Int, key
 	return tx
 }
 
+func dynamicFeeTx(nonce uint64, gaslimit uint64, gasFee *big.Int, tip *big.Int, key *ecdsa.PrivateKey) *types.Transaction {
+	tx, _ := types.SignNewTx(key, types.LatestSignerForChainID(params.TestChainConfig.ChainID), &types.DynamicFeeTx{
+		ChainID:    params.TestChainConfig.ChainID,
+		Nonce:      nonce,
+		GasTipCap:  tip,
+		GasFeeCap:  gasFee,
+		Gas:        gaslimit,
+		To:         &common.Address{},
+		Value:      big.NewInt(100),
+		Data:       nil,
+		AccessList: nil,
+	})
+	return tx
+}
+
 func setupTxPool() (*TxPool, *ecdsa.PrivateKey) {
+	return setupTxPoolWithConfig(params.TestChainConfig)
+}
+
+func setupTxPoolWithConfig(config *params.ChainConfig) (*TxPool, *ecdsa.PrivateKey) {
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
 	blockchain := &testBlockChain{statedb, 10000000, new(event.Feed)}
 
 	key, _ := crypto.GenerateKey()
-	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain)
+	pool := NewTxPool(testTxPoolConfig, config, blockchain)
 
 	return pool, key
 }
@@ -270,6 +299,79 @@ func TestInvalidTransactions(t *testing.T) {
 	}
 }
 
+// Tests that dynamic fee transactions are only accepted after London, and that
+// their fee fields are validated.
+func TestInvalidDynamicFeeTransactions(t *testing.T) {
+	t.Parallel()
+
+	// Before London, dynamic fee transactions are rejected outright
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	tx := dynamicFeeTx(0, 100000, big.NewInt(2), big.NewInt(1), key)
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(0xffffffffffffff))
+	if err := pool.validateTx(tx, false); !errors.Is(err, ErrTxTypeNotSupported) {
+		t.Error("expected", ErrTxTypeNotSupported, "got", err)
+	}
+	// After London, fee caps must cover the tip and the tip must meet the pool price
+	pool, key = setupTxPoolWithConfig(eip1559Config)
+	defer pool.Stop()
+
+	tx = dynamicFeeTx(0, 100000, big.NewInt(1), big.NewInt(2), key)
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(0xffffffffffffff))
+	if err := pool.AddRemote(tx); !errors.Is(err, ErrTipAboveFeeCap) {
+		t.Error("expected", ErrTipAboveFeeCap, "got", err)
+	}
+	veryBigNumber := new(big.Int).Lsh(big.NewInt(1), 256)
+	tx = dynamicFeeTx(0, 100000, veryBigNumber, big.NewInt(1), key)
+	if err := pool.AddRemote(tx); !errors.Is(err, ErrFeeCapVeryHigh) {
+		t.Error("expected", ErrFeeCapVeryHigh, "got", err)
+	}
+	tx = dynamicFeeTx(0, 100000, big.NewInt(1000), big.NewInt(1), key)
+	pool.gasPrice = big.NewInt(2)
+	if err := pool.AddRemote(tx); err != ErrUnderpriced {
+		t.Error("expected", ErrUnderpriced, "got", err)
+	}
+	if err := pool.AddLocal(tx); err != nil {
+		t.Error("expected", nil, "got", err)
+	}
+}
+
+// Tests that replacing a dynamic fee transaction requires bumping both the fee
+// cap and the tip.
+func TestDynamicFeeTransactionReplacement(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPoolWithConfig(eip1559Config)
+	defer pool.Stop()
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(addr, big.NewInt(0xffffffffffffff))
+
+	tx := dynamicFeeTx(0, 100000, big.NewInt(100), big.NewInt(10), key)
+	if err := pool.addRemoteSync(tx); err != nil {
+		t.Fatalf("failed to add original transaction: %v", err)
+	}
+	// Bumping only the fee cap or only the tip is not enough
+	if err := pool.addRemoteSync(dynamicFeeTx(0, 100000, big.NewInt(200), big.NewInt(10), key)); err != ErrReplaceUnderpriced {
+		t.Fatalf("fee cap only bump: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	if err := pool.addRemoteSync(dynamicFeeTx(0, 100000, big.NewInt(100), big.NewInt(20), key)); err != ErrReplaceUnderpriced {
+		t.Fatalf("tip only bump: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	// Bumping both by the configured price bump succeeds
+	replacement := dynamicFeeTx(0, 100000, big.NewInt(110), big.NewInt(11), key)
+	if err := pool.addRemoteSync(replacement); err != nil {
+		t.Fatalf("failed to replace transaction: %v", err)
+	}
+	if have := pool.Get(replacement.Hash()); have == nil {
+		t.Fatalf("replacement transaction missing from pool")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 func TestTransactionQueue(t *testing.T) {
 	t.Parallel()
 
@@ -216,3 +216,61 @@ func makeBenchBlock() *Block {
 	}
 	return NewBlock(header, txs, uncles, receipts, newHasher())
 }
+
+func TestHeaderParentHashFromRLP(t *testing.T) {
+	header := &Header{ParentHash: common.HexToHash("0x01020304"), Number: big.NewInt(1), Difficulty: big.NewInt(1)}
+	blob, _ := rlp.EncodeToBytes(header)
+	if have := HeaderParentHashFromRLP(blob); have != header.ParentHash {
+		t.Fatalf("parent hash mismatch: have %x, want %x", have, header.ParentHash)
+	}
+	for _, blob := range [][]byte{nil, {0xc0}, {0xc1, 0x80}, blob[:10]} {
+		if have := HeaderParentHashFromRLP(blob); have != (common.Hash{}) {
+			t.Fatalf("invalid header %x: parent hash %x returned", blob, have)
+		}
+	}
+}
+
+// reflectHeader has the fields of Header, but no RLP methods.
+type reflectHeader Header
+
+func TestHeaderGeneratedRLP(t *testing.T) {
+	headers := []*Header{
+		{Number: big.NewInt(1), Difficulty: big.NewInt(131072), GasLimit: 8000000, Extra: []byte("legacy")},
+		{
+			ParentHash: common.HexToHash("0x01"),
+			Coinbase:   common.HexToAddress("0x02"),
+			Difficulty: big.NewInt(1),
+			Number:     big.NewInt(12965000),
+			GasLimit:   30000000,
+			GasUsed:    21000,
+			Time:       1628166822,
+			Nonce:      EncodeNonce(42),
+			BaseFee:    big.NewInt(1000000000),
+		},
+	}
+	for i, h := range headers {
+		have, err := rlp.EncodeToBytes(h)
+		if err != nil {
+			t.Fatalf("header %d: encode error: %v", i, err)
+		}
+		want, err := rlp.EncodeToBytes((*reflectHeader)(h))
+		if err != nil {
+			t.Fatalf("header %d: reflect encode error: %v", i, err)
+		}
+		if !bytes.Equal(have, want) {
+			t.Fatalf("header %d: encoding mismatch\nhave %x\nwant %x", i, have, want)
+		}
+		var dec Header
+		if err := rlp.DecodeBytes(have, &dec); err != nil {
+			t.Fatalf("header %d: decode error: %v", i, err)
+		}
+		if dec.Hash() != h.Hash() {
+			t.Fatalf("header %d: decoded header mismatch", i)
+		}
+	}
+	// Missing required fields must be rejected.
+	short, _ := rlp.EncodeToBytes([]interface{}{common.Hash{}, common.Hash{}})
+	if err := rlp.DecodeBytes(short, new(Header)); err != rlp.ErrTooFewElements {
+		t.Fatalf("wrong error for short header: %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+// Code generated by rlpgen. DO NOT EDIT.
+
+package types
+
+import (
+	"github.com/ethereum/go-ethereum/rlp"
+	"io"
+)
+
+func (obj *Header) EncodeRLP(_w io.Writer) error {
+	w := rlp.NewEncoderBuffer(_w)
+	_tmp0 := obj.BaseFee != nil
+	_tmp1 := w.List()
+	w.WriteBytes(obj.ParentHash[:])
+	w.WriteBytes(obj.UncleHash[:])
+	w.WriteBytes(obj.Coinbase[:])
+	w.WriteBytes(obj.Root[:])
+	w.WriteBytes(obj.TxHash[:])
+	w.WriteBytes(obj.ReceiptHash[:])
+	w.WriteBytes(obj.Bloom[:])
+	if obj.Difficulty == nil {
+		w.Write(rlp.EmptyString)
+	} else {
+		if obj.Difficulty.Sign() == -1 {
+			return rlp.ErrNegativeBigInt
+		}
+		w.WriteBigInt(obj.Difficulty)
+	}
+	if obj.Number == nil {
+		w.Write(rlp.EmptyString)
+	} else {
+		if obj.Number.Sign() == -1 {
+			return rlp.ErrNegativeBigInt
+		}
+		w.WriteBigInt(obj.Number)
+	}
+	w.WriteUint64(obj.GasLimit)
+	w.WriteUint64(obj.GasUsed)
+	w.WriteUint64(obj.Time)
+	w.WriteBytes(obj.Extra)
+	w.WriteBytes(obj.MixDigest[:])
+	w.WriteBytes(obj.Nonce[:])
+	if _tmp0 {
+		if obj.BaseFee == nil {
+			w.Write(rlp.EmptyString)
+		} else {
+			if obj.BaseFee.Sign() == -1 {
+				return rlp.ErrNegativeBigInt
+			}
+			w.WriteBigInt(obj.BaseFee)
+		}
+	}
+	w.ListEnd(_tmp1)
+	return w.Flush()
+}
+
+func (obj *Header) DecodeRLP(dec *rlp.Stream) error {
+	var _tmp2 Header
+	if _, err := dec.List(); err != nil {
+		return err
+	}
+	// ParentHash:
+	if !dec.MoreDataInList() {
+		return rlp.ErrTooFewElements
+	}
+	if err := dec.ReadBytes(_tmp2.ParentHash[:]); err != nil {
+		return err
+	}
+	// UncleHash:
+	if !dec.MoreDataInList() {
+		return rlp.ErrTooFewElements
+	}
+	if err := dec.ReadBytes(_tmp2.UncleHash[:]); err != nil {
+		return err
+	}
+	// Coinbase:
+	if !dec.MoreDataInList() {
+		return rlp.ErrTooFewElements
+	}
+	if err := dec.ReadBytes(_tmp2.Coinbase[:]); err != nil {
+		return err
+	}
+	// Root:
+	if !dec.MoreDataInList() {
+		return rlp.ErrTooFewElements
+	}
+	if err := dec.ReadBytes(_tmp2.Root[:]); err != nil {
+		return err
+	}
+	// TxHash:
+	if !dec.MoreDataInList() {
+		return rlp.ErrTooFewElements
+	}
+	if err := dec.ReadBytes(_tmp2.TxHash[:]); err != nil {
+		return err
+	}
+	// ReceiptHash:
+	if !dec.MoreDataInList() {
+		return rlp.ErrTooFewElements
+	}
+	if err := dec.ReadBytes(_tmp2.ReceiptHash[:]); err != nil {
+		return err
+	}
+	// Bloom:
+	if !dec.MoreDataInList() {
+		return rlp.ErrTooFewElements
+	}
+	if err := dec.ReadBytes(_tmp2.Bloom[:]); err != nil {
+		return err
+	}
+	// Difficulty:
+	if !dec.MoreDataInList() {
+		return rlp.ErrTooFewElements
+	}
+	_tmp3, err := dec.BigInt()
+	if err != nil {
+		return err
+	}
+	_tmp2.Difficulty = _tmp3
+	// Number:
+	if !dec.MoreDataInList() {
+		return rlp.ErrTooFewElements
+	}
+	_tmp4, err := dec.BigInt()
+	if err != nil {
+		return err
+	}
+	_tmp2.Number = _tmp4
+	// GasLimit:
+	if !dec.MoreDataInList() {
+		return rlp.ErrTooFewElements
+	}
+	_tmp5, err := dec.Uint64()
+	if err != nil {
+		return err
+	}
+	_tmp2.GasLimit = _tmp5
+	// GasUsed:
+	if !dec.MoreDataInList() {
+		return rlp.ErrTooFewElements
+	}
+	_tmp6, err := dec.Uint64()
+	if err != nil {
+		return err
+	}
+	_tmp2.GasUsed = _tmp6
+	// Time:
+	if !dec.MoreDataInList() {
+		return rlp.ErrTooFewElements
+	}
+	_tmp7, err := dec.Uint64()
+	if err != nil {
+		return err
+	}
+	_tmp2.Time = _tmp7
+	// Extra:
+	if !dec.MoreDataInList() {
+		return rlp.ErrTooFewElements
+	}
+	_tmp8, err := dec.Bytes()
+	if err != nil {
+		return err
+	}
+	_tmp2.Extra = _tmp8
+	// MixDigest:
+	if !dec.MoreDataInList() {
+		return rlp.ErrTooFewElements
+	}
+	if err := dec.ReadBytes(_tmp2.MixDigest[:]); err != nil {
+		return err
+	}
+	// Nonce:
+	if !dec.MoreDataInList() {
+		return rlp.ErrTooFewElements
+	}
+	if err := dec.ReadBytes(_tmp2.Nonce[:]); err != nil {
+		return err
+	}
+	// BaseFee:
+	if dec.MoreDataInList() {
+		_tmp9, err := dec.BigInt()
+		if err != nil {
+			return err
+		}
+		_tmp2.BaseFee = _tmp9
+	}
+	if err := dec.ListEnd(); err != nil {
+		return err
+	}
+	*obj = _tmp2
+	return nil
+}
@@ -0,0 +1,69 @@
+// Code generated by rlpgen. DO NOT EDIT.
+
+package types
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+	"io"
+)
+
+func (obj *rlpLog) EncodeRLP(_w io.Writer) error {
+	w := rlp.NewEncoderBuffer(_w)
+	_tmp0 := w.List()
+	w.WriteBytes(obj.Address[:])
+	_tmp1 := w.List()
+	for _tmp2 := range obj.Topics {
+		w.WriteBytes(obj.Topics[_tmp2][:])
+	}
+	w.ListEnd(_tmp1)
+	w.WriteBytes(obj.Data)
+	w.ListEnd(_tmp0)
+	return w.Flush()
+}
+
+func (obj *rlpLog) DecodeRLP(dec *rlp.Stream) error {
+	var _tmp3 rlpLog
+	if _, err := dec.List(); err != nil {
+		return err
+	}
+	// Address:
+	if !dec.MoreDataInList() {
+		return rlp.ErrTooFewElements
+	}
+	if err := dec.ReadBytes(_tmp3.Address[:]); err != nil {
+		return err
+	}
+	// Topics:
+	if !dec.MoreDataInList() {
+		return rlp.ErrTooFewElements
+	}
+	if _, err := dec.List(); err != nil {
+		return err
+	}
+	_tmp3.Topics = []common.Hash{}
+	for dec.MoreDataInList() {
+		var _tmp4 common.Hash
+		if err := dec.ReadBytes(_tmp4[:]); err != nil {
+			return err
+		}
+		_tmp3.Topics = append(_tmp3.Topics, _tmp4)
+	}
+	if err := dec.ListEnd(); err != nil {
+		return err
+	}
+	// Data:
+	if !dec.MoreDataInList() {
+		return rlp.ErrTooFewElements
+	}
+	_tmp5, err := dec.Bytes()
+	if err != nil {
+		return err
+	}
+	_tmp3.Data = _tmp5
+	if err := dec.ListEnd(); err != nil {
+		return err
+	}
+	*obj = _tmp3
+	return nil
+}
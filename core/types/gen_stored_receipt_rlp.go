@@ -0,0 +1,78 @@
+// Code generated by rlpgen. DO NOT EDIT.
+
+package types
+
+import (
+	"github.com/ethereum/go-ethereum/rlp"
+	"io"
+)
+
+func (obj *storedReceiptRLP) EncodeRLP(_w io.Writer) error {
+	w := rlp.NewEncoderBuffer(_w)
+	_tmp0 := w.List()
+	w.WriteBytes(obj.PostStateOrStatus)
+	w.WriteUint64(obj.CumulativeGasUsed)
+	_tmp1 := w.List()
+	for _tmp2 := range obj.Logs {
+		if obj.Logs[_tmp2] == nil {
+			w.Write(rlp.EmptyList)
+		} else {
+			if err := obj.Logs[_tmp2].EncodeRLP(w); err != nil {
+				return err
+			}
+		}
+	}
+	w.ListEnd(_tmp1)
+	w.ListEnd(_tmp0)
+	return w.Flush()
+}
+
+func (obj *storedReceiptRLP) DecodeRLP(dec *rlp.Stream) error {
+	var _tmp3 storedReceiptRLP
+	if _, err := dec.List(); err != nil {
+		return err
+	}
+	// PostStateOrStatus:
+	if !dec.MoreDataInList() {
+		return rlp.ErrTooFewElements
+	}
+	_tmp4, err := dec.Bytes()
+	if err != nil {
+		return err
+	}
+	_tmp3.PostStateOrStatus = _tmp4
+	// CumulativeGasUsed:
+	if !dec.MoreDataInList() {
+		return rlp.ErrTooFewElements
+	}
+	_tmp5, err := dec.Uint64()
+	if err != nil {
+		return err
+	}
+	_tmp3.CumulativeGasUsed = _tmp5
+	// Logs:
+	if !dec.MoreDataInList() {
+		return rlp.ErrTooFewElements
+	}
+	if _, err := dec.List(); err != nil {
+		return err
+	}
+	_tmp3.Logs = []*LogForStorage{}
+	for dec.MoreDataInList() {
+		var _tmp6 *LogForStorage
+		_tmp7 := new(LogForStorage)
+		if err := _tmp7.DecodeRLP(dec); err != nil {
+			return err
+		}
+		_tmp6 = _tmp7
+		_tmp3.Logs = append(_tmp3.Logs, _tmp6)
+	}
+	if err := dec.ListEnd(); err != nil {
+		return err
+	}
+	if err := dec.ListEnd(); err != nil {
+		return err
+	}
+	*obj = _tmp3
+	return nil
+}
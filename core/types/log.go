@@ -25,6 +25,7 @@ import (
 )
 
 //go:generate gencodec -type Log -field-override logMarshaling -out gen_log_json.go
+//go:generate go run ../../rlp/rlpgen -type rlpLog -out gen_log_rlp.go
 
 // Log represents a contract log event. These events are generated by the LOG opcode and
 // stored/indexed by the node.
@@ -62,18 +63,16 @@ type logMarshaling struct {
 	Index       hexutil.Uint
 }
 
+// rlpLog is the consensus and storage encoding of a log.
 type rlpLog struct {
 	Address common.Address
 	Topics  []common.Hash
 	Data    []byte
 }
 
-// rlpStorageLog is the storage encoding of a log.
-type rlpStorageLog rlpLog
-
 // EncodeRLP implements rlp.Encoder.
 func (l *Log) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, rlpLog{Address: l.Address, Topics: l.Topics, Data: l.Data})
+	return rlp.Encode(w, &rlpLog{Address: l.Address, Topics: l.Topics, Data: l.Data})
 }
 
 // DecodeRLP implements rlp.Decoder.
@@ -92,7 +91,7 @@ type LogForStorage Log
 
 // EncodeRLP implements rlp.Encoder.
 func (l *LogForStorage) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, rlpStorageLog{
+	return rlp.Encode(w, &rlpLog{
 		Address: l.Address,
 		Topics:  l.Topics,
 		Data:    l.Data,
@@ -103,7 +102,7 @@ func (l *LogForStorage) EncodeRLP(w io.Writer) error {
 //
 // Note some redundant fields(e.g. block number, tx hash etc) will be assembled later.
 func (l *LogForStorage) DecodeRLP(s *rlp.Stream) error {
-	var dec rlpStorageLog
+	var dec rlpLog
 	if err := s.Decode(&dec); err != nil {
 		return err
 	}
@@ -18,8 +18,10 @@ package types
 
 import (
 	"bytes"
+	"encoding/hex"
 	"math"
 	"math/big"
+	"reflect"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -230,3 +232,141 @@ func clearComputedFieldsOnLog(t *testing.T, log *Log) {
 	log.TxIndex = math.MaxUint32
 	log.Index = math.MaxUint32
 }
+
+// Types with the fields of the types with generated RLP methods, but without the
+// methods, so that they are encoded and decoded by reflection.
+type (
+	reflectLog           rlpLog
+	reflectReceipt       receiptRLP
+	reflectStoredReceipt storedReceiptRLP
+)
+
+// checkGeneratedRLP checks that values of a type with generated RLP methods are
+// encoded and decoded like by the reflection based encoder and decoder. The values
+// are pointers to the generated type, plain converts them to pointers to the type
+// without methods, and newValue and newPlain create empty values to decode into.
+// The inputs are decoded in addition to the encodings of the values.
+func checkGeneratedRLP(t *testing.T, values []interface{}, plain func(interface{}) interface{}, newValue, newPlain func() interface{}, inputs []string) {
+	t.Helper()
+
+	var blobs [][]byte
+	for _, input := range inputs {
+		blob, err := hex.DecodeString(input)
+		if err != nil {
+			t.Fatalf("invalid input %s: %v", input, err)
+		}
+		blobs = append(blobs, blob)
+	}
+	for i, v := range values {
+		have, haveErr := rlp.EncodeToBytes(v)
+		want, wantErr := rlp.EncodeToBytes(plain(v))
+		if (haveErr != nil) != (wantErr != nil) || !bytes.Equal(have, want) {
+			t.Fatalf("value %d: encoding mismatch: have %x (%v), want %x (%v)", i, have, haveErr, want, wantErr)
+		}
+		blobs = append(blobs, want)
+	}
+	for _, blob := range blobs {
+		var (
+			have = newValue()
+			want = newPlain()
+		)
+		haveErr := rlp.DecodeBytes(blob, have)
+		wantErr := rlp.DecodeBytes(blob, want)
+		if (haveErr != nil) != (wantErr != nil) {
+			t.Fatalf("input %x: decoding error mismatch: have %v, want %v", blob, haveErr, wantErr)
+		}
+		if haveErr == nil && !reflect.DeepEqual(plain(have), want) {
+			t.Fatalf("input %x: decoding mismatch: have %+v, want %+v", blob, plain(have), want)
+		}
+	}
+}
+
+var (
+	rlpTestLogs = []*Log{
+		{},
+		{Address: common.HexToAddress("0x01"), Topics: []common.Hash{}, Data: []byte{}},
+		{Address: common.HexToAddress("0x02"), Topics: []common.Hash{common.HexToHash("0x03"), common.HexToHash("0x04")}, Data: []byte{0x05, 0x06}},
+	}
+	rlpTestLogInputs = []string{"c0", "d694000000000000000000000000000000000000000080", "d7940000000000000000000000000000000000000000c080", "d7940000000000000000000000000000000000000000c0c0"}
+)
+
+func TestLogGeneratedRLP(t *testing.T) {
+	var values []interface{}
+	for _, log := range rlpTestLogs {
+		values = append(values, &rlpLog{Address: log.Address, Topics: log.Topics, Data: log.Data})
+	}
+	checkGeneratedRLP(t, values,
+		func(v interface{}) interface{} { return (*reflectLog)(v.(*rlpLog)) },
+		func() interface{} { return new(rlpLog) },
+		func() interface{} { return new(reflectLog) },
+		rlpTestLogInputs,
+	)
+	// The consensus and storage encodings of logs must both be the one of rlpLog.
+	for i, log := range rlpTestLogs {
+		want, _ := rlp.EncodeToBytes(&reflectLog{Address: log.Address, Topics: log.Topics, Data: log.Data})
+		if have, _ := rlp.EncodeToBytes(log); !bytes.Equal(have, want) {
+			t.Fatalf("log %d: consensus encoding mismatch: have %x, want %x", i, have, want)
+		}
+		if have, _ := rlp.EncodeToBytes((*LogForStorage)(log)); !bytes.Equal(have, want) {
+			t.Fatalf("log %d: storage encoding mismatch: have %x, want %x", i, have, want)
+		}
+	}
+}
+
+func TestReceiptGeneratedRLP(t *testing.T) {
+	values := []interface{}{
+		&receiptRLP{},
+		&receiptRLP{PostStateOrStatus: receiptStatusSuccessfulRLP, CumulativeGasUsed: 1, Logs: []*Log{}},
+		&receiptRLP{PostStateOrStatus: common.Hash{0x01}.Bytes(), CumulativeGasUsed: 2, Bloom: Bloom{0x01}, Logs: rlpTestLogs},
+		&receiptRLP{Logs: []*Log{nil}},
+	}
+	checkGeneratedRLP(t, values,
+		func(v interface{}) interface{} { return (*reflectReceipt)(v.(*receiptRLP)) },
+		func() interface{} { return new(receiptRLP) },
+		func() interface{} { return new(reflectReceipt) },
+		[]string{"c0", "c3808080", "c4808080c0", "c5808080c1c0"},
+	)
+}
+
+func TestStoredReceiptGeneratedRLP(t *testing.T) {
+	logs := make([]*LogForStorage, len(rlpTestLogs))
+	for i, log := range rlpTestLogs {
+		logs[i] = (*LogForStorage)(log)
+	}
+	values := []interface{}{
+		&storedReceiptRLP{},
+		&storedReceiptRLP{PostStateOrStatus: receiptStatusFailedRLP, CumulativeGasUsed: 1, Logs: []*LogForStorage{}},
+		&storedReceiptRLP{PostStateOrStatus: common.Hash{0x01}.Bytes(), CumulativeGasUsed: 2, Logs: logs},
+		&storedReceiptRLP{Logs: []*LogForStorage{nil}},
+	}
+	checkGeneratedRLP(t, values,
+		func(v interface{}) interface{} { return (*reflectStoredReceipt)(v.(*storedReceiptRLP)) },
+		func() interface{} { return new(storedReceiptRLP) },
+		func() interface{} { return new(reflectStoredReceipt) },
+		[]string{"c0", "c28080", "c38080c0", "c48080c1c0", "c5018080c0"},
+	)
+	// Stored receipts must retain their logs through an encoding round trip.
+	receipt := &Receipt{Status: ReceiptStatusSuccessful, CumulativeGasUsed: 3, Logs: rlpTestLogs}
+	blob, err := rlp.EncodeToBytes((*ReceiptForStorage)(receipt))
+	if err != nil {
+		t.Fatalf("failed to encode stored receipt: %v", err)
+	}
+	var dec ReceiptForStorage
+	if err := rlp.DecodeBytes(blob, &dec); err != nil {
+		t.Fatalf("failed to decode stored receipt: %v", err)
+	}
+	if len(dec.Logs) != len(rlpTestLogs) {
+		t.Fatalf("log count mismatch: have %d, want %d", len(dec.Logs), len(rlpTestLogs))
+	}
+	for i, log := range dec.Logs {
+		want := rlpTestLogs[i]
+		if log.Address != want.Address || len(log.Topics) != len(want.Topics) || !bytes.Equal(log.Data, want.Data) {
+			t.Fatalf("log %d mismatch: have %+v, want %+v", i, log, want)
+		}
+		for j := range log.Topics {
+			if log.Topics[j] != want.Topics[j] {
+				t.Fatalf("log %d topic %d mismatch: have %x, want %x", i, j, log.Topics[j], want.Topics[j])
+			}
+		}
+	}
+}
@@ -16,6 +16,11 @@
 
 package vm
 
+import (
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
 // bitvec is a bit vector which maps bytes in a program.
 // An unset bit means the byte is an opcode, a set bit means
 // it's data (i.e. argument of PUSHxx).
@@ -60,3 +65,149 @@ func codeBitmap(code []byte) bitvec {
 	}
 	return bits
 }
+
+// Superinstructions, fusing common instruction sequences into a single step.
+const (
+	fusedNone        byte = iota
+	fusedPushJump         // PUSHn <jumpdest> JUMP
+	fusedPushJumpi        // PUSHn <jumpdest> JUMPI
+	fusedPushPushAdd      // PUSHn PUSHm ADD
+)
+
+// codeBlock is a basic block of code: a sequence of instructions which is only
+// entered at its first instruction and, barring errors, executed to its end.
+// Blocks end at jumps, halting instructions and instructions which observe the
+// remaining gas, and before JUMPDESTs.
+type codeBlock struct {
+	start, end uint64 // Position of the first instruction and after the last one
+	gas        uint64 // Total constant gas of the instructions
+	minStack   int    // Minimum stack height on entry for no instruction to underflow
+	maxStack   int    // Maximum stack height on entry for no instruction to overflow
+	target     int    // Block jumped to by a fused PUSH+JUMP(I) at the end, -1 if none
+	invalid    bool   // Whether the block is a single undefined instruction
+}
+
+// blockAnalysis is the result of the basic block analysis of a piece of code
+// for a specific jump table.
+type blockAnalysis struct {
+	blocks []codeBlock
+	fused  []byte // Superinstruction starting at each code position
+}
+
+// blockAt returns the index of the block starting at the given position, which
+// must be the start of a block.
+func (a *blockAnalysis) blockAt(pc uint64) int {
+	lo, hi := 0, len(a.blocks)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if a.blocks[mid].start < pc {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// endsBlock reports whether an instruction terminates a basic block. Besides
+// control flow changes, the blocks end at instructions whose behaviour depends
+// on the remaining gas, so the gas is exact when they execute.
+func endsBlock(op OpCode, operation *operation) bool {
+	if operation.jumps || operation.halts || operation.reverts {
+		return true
+	}
+	switch op {
+	case GAS, SSTORE, CALL, CALLCODE, DELEGATECALL, STATICCALL, CREATE, CREATE2:
+		return true
+	}
+	return false
+}
+
+// analyseBlocks splits the code into basic blocks, precomputing their constant
+// gas and stack requirements, and finds the sequences which can be executed as
+// superinstructions. The bitmap of the code is used to validate static jumps.
+func analyseBlocks(code []byte, bits bitvec, jt *JumpTable) *blockAnalysis {
+	var (
+		a       = &blockAnalysis{fused: make([]byte, len(code))}
+		block   = codeBlock{target: -1, maxStack: int(params.StackLimit)}
+		height  int     // Stack height relative to the start of the block
+		targets []int64 // Destination of the fused jump ending each block, -1 if none
+	)
+	account := func(operation *operation) {
+		block.gas += operation.constantGas
+		if need := operation.minStack - height; need > block.minStack {
+			block.minStack = need
+		}
+		if allow := operation.maxStack - height; allow < block.maxStack {
+			block.maxStack = allow
+		}
+		height += int(params.StackLimit) - operation.maxStack
+	}
+	closeBlock := func(end uint64, target int64) {
+		block.end = end
+		a.blocks = append(a.blocks, block)
+		targets = append(targets, target)
+		block, height = codeBlock{start: end, target: -1, maxStack: int(params.StackLimit)}, 0
+	}
+	for pc := uint64(0); pc < uint64(len(code)); {
+		op := OpCode(code[pc])
+		operation := jt[op]
+
+		// JUMPDESTs and undefined instructions start a new block
+		if (op == JUMPDEST || operation == nil) && pc != block.start {
+			closeBlock(pc, -1)
+		}
+		if operation == nil {
+			block.invalid = true
+			closeBlock(pc+1, -1)
+			pc++
+			continue
+		}
+		account(operation)
+
+		next := pc + 1
+		if op >= PUSH1 && op <= PUSH32 {
+			next += uint64(op - PUSH1 + 1)
+		}
+		if endsBlock(op, operation) {
+			closeBlock(next, -1)
+			pc = next
+			continue
+		}
+		// Look for superinstructions starting with a push, their constant gas and
+		// stack requirements are accounted for as of the individual instructions
+		if op >= PUSH1 && op <= PUSH32 && next < uint64(len(code)) {
+			switch follow := OpCode(code[next]); {
+			case (follow == JUMP || follow == JUMPI) && jt[follow] != nil:
+				dest := new(uint256.Int).SetBytes(code[pc+1 : next])
+				if target, overflow := dest.Uint64WithOverflow(); !overflow && target < uint64(len(code)) && OpCode(code[target]) == JUMPDEST && bits.codeSegment(target) {
+					if follow == JUMP {
+						a.fused[pc] = fusedPushJump
+					} else {
+						a.fused[pc] = fusedPushJumpi
+					}
+					account(jt[follow])
+					closeBlock(next+1, int64(target))
+					pc = next + 1
+					continue
+				}
+			case follow >= PUSH1 && follow <= PUSH32 && jt[follow] != nil && jt[ADD] != nil:
+				add := next + 1 + uint64(follow-PUSH1+1)
+				if add < uint64(len(code)) && OpCode(code[add]) == ADD {
+					a.fused[pc] = fusedPushPushAdd
+				}
+			}
+		}
+		pc = next
+	}
+	if block.start < uint64(len(code)) {
+		closeBlock(uint64(len(code)), -1)
+	}
+	// Resolve the blocks targeted by the fused jumps, all JUMPDESTs start a block
+	for i, target := range targets {
+		if target >= 0 {
+			a.blocks[i].target = a.blockAt(uint64(target))
+		}
+	}
+	return a
+}
@@ -53,10 +53,14 @@ type Contract struct {
 	jumpdests map[common.Hash]bitvec // Aggregated result of JUMPDEST analysis.
 	analysis  bitvec                 // Locally cached result of JUMPDEST analysis
 
-	Code     []byte
-	CodeHash common.Hash
-	CodeAddr *common.Address
-	Input    []byte
+	blockdests map[common.Hash]*blockAnalysis // Aggregated result of basic block analysis
+	blocks     *blockAnalysis                 // Locally cached result of basic block analysis
+
+	Code      []byte
+	container []byte // Whole EOF container if Code is its code section
+	CodeHash  common.Hash
+	CodeAddr  *common.Address
+	Input     []byte
 
 	Gas   uint64
 	value *big.Int
@@ -67,10 +71,12 @@ func NewContract(caller ContractRef, object ContractRef, value *big.Int, gas uin
 	c := &Contract{CallerAddress: caller.Address(), caller: caller, self: object}
 
 	if parent, ok := caller.(*Contract); ok {
-		// Reuse JUMPDEST analysis from parent context if available.
+		// Reuse JUMPDEST and block analysis from parent context if available.
 		c.jumpdests = parent.jumpdests
+		c.blockdests = parent.blockdests
 	} else {
 		c.jumpdests = make(map[common.Hash]bitvec)
+		c.blockdests = make(map[common.Hash]*blockAnalysis)
 	}
 
 	// Gas should be a pointer so it can safely be reduced through the run
@@ -129,6 +135,29 @@ func (c *Contract) isCode(udest uint64) bool {
 	return c.analysis.codeSegment(udest)
 }
 
+// blockAnalysis returns the basic block analysis of the contract's code for the
+// given jump table. Similarly to the JUMPDEST analysis, it is cached in the
+// parent context for regular contracts, and locally for initcode.
+func (c *Contract) blockAnalysis(jt *JumpTable) *blockAnalysis {
+	if c.blocks != nil {
+		return c.blocks
+	}
+	if c.CodeHash != (common.Hash{}) {
+		if analysis, exist := c.blockdests[c.CodeHash]; exist {
+			c.blocks = analysis
+			return analysis
+		}
+	}
+	// Make sure the JUMPDEST analysis is available to validate the static jumps
+	c.isCode(0)
+	c.blocks = analyseBlocks(c.Code, c.analysis, jt)
+
+	if c.CodeHash != (common.Hash{}) {
+		c.blockdests[c.CodeHash] = c.blocks
+	}
+	return c.blocks
+}
+
 // AsDelegate sets the contract to be a delegate call and returns the current
 // contract (for chaining calls)
 func (c *Contract) AsDelegate() *Contract {
@@ -182,6 +211,15 @@ func (c *Contract) Value() *big.Int {
 	return c.value
 }
 
+// deployedCode returns the code as stored in the state, which is the whole
+// container if the contract executes the code section of an EOF container.
+func (c *Contract) deployedCode() []byte {
+	if c.container != nil {
+		return c.container
+	}
+	return c.Code
+}
+
 // SetCallCode sets the code of the contract and address of the backing data
 // object
 func (c *Contract) SetCallCode(addr *common.Address, hash common.Hash, code []byte) {
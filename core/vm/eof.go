@@ -0,0 +1,158 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// EVM object format (EIP-3540, EIP-3670) constants.
+const (
+	eofFormat  = 0xEF // First byte of the magic, reserved by EIP-3541
+	eofVersion = 0x01 // Only supported container version
+
+	eofSectionTerminator = 0x00
+	eofSectionCode       = 0x01
+	eofSectionData       = 0x02
+
+	// designatedInvalid is the opcode reserved for aborting execution, which is
+	// allowed in EOF code even though it is not a defined instruction.
+	designatedInvalid = 0xFE
+)
+
+// eofMagic is the prefix identifying an EOF container.
+var eofMagic = []byte{eofFormat, 0x00}
+
+// eofContainer is a parsed EOF container, referencing the sections of the raw
+// container bytes.
+type eofContainer struct {
+	version byte
+	code    []byte // Code section, always present
+	data    []byte // Data section, nil if absent
+}
+
+// hasEOFMagic reports whether the code starts with the EOF magic. Such code is
+// not necessarily a valid container.
+func hasEOFMagic(code []byte) bool {
+	return bytes.HasPrefix(code, eofMagic)
+}
+
+// parseEOF parses and validates the header of an EOF container. A container
+// consists of the magic, a version byte and a list of section headers (a kind
+// byte followed by a 2 byte big endian size), closed by a terminator and
+// followed by the section contents. Exactly one non-empty code section must be
+// present, optionally followed by one non-empty data section.
+func parseEOF(b []byte) (*eofContainer, error) {
+	if !hasEOFMagic(b) {
+		return nil, fmt.Errorf("%w: missing magic", ErrInvalidEOFCode)
+	}
+	if len(b) < len(eofMagic)+1 {
+		return nil, fmt.Errorf("%w: missing version", ErrInvalidEOFCode)
+	}
+	c := &eofContainer{version: b[len(eofMagic)]}
+	if c.version != eofVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidEOFCode, c.version)
+	}
+	var (
+		pos      = len(eofMagic) + 1
+		codeSize = -1
+		dataSize = -1
+	)
+	for {
+		if pos >= len(b) {
+			return nil, fmt.Errorf("%w: truncated section headers", ErrInvalidEOFCode)
+		}
+		kind := b[pos]
+		pos++
+		if kind == eofSectionTerminator {
+			break
+		}
+		if pos+2 > len(b) {
+			return nil, fmt.Errorf("%w: truncated section size", ErrInvalidEOFCode)
+		}
+		size := int(binary.BigEndian.Uint16(b[pos:]))
+		pos += 2
+
+		switch kind {
+		case eofSectionCode:
+			if codeSize != -1 {
+				return nil, fmt.Errorf("%w: multiple code sections", ErrInvalidEOFCode)
+			}
+			codeSize = size
+		case eofSectionData:
+			if codeSize == -1 {
+				return nil, fmt.Errorf("%w: data section before code section", ErrInvalidEOFCode)
+			}
+			if dataSize != -1 {
+				return nil, fmt.Errorf("%w: multiple data sections", ErrInvalidEOFCode)
+			}
+			dataSize = size
+		default:
+			return nil, fmt.Errorf("%w: unknown section kind %d", ErrInvalidEOFCode, kind)
+		}
+		if size == 0 {
+			return nil, fmt.Errorf("%w: empty section", ErrInvalidEOFCode)
+		}
+	}
+	if codeSize == -1 {
+		return nil, fmt.Errorf("%w: missing code section", ErrInvalidEOFCode)
+	}
+	want := pos + codeSize
+	if dataSize != -1 {
+		want += dataSize
+	}
+	if len(b) != want {
+		return nil, fmt.Errorf("%w: container size %d, want %d", ErrInvalidEOFCode, len(b), want)
+	}
+	c.code = b[pos : pos+codeSize]
+	if dataSize != -1 {
+		c.data = b[pos+codeSize:]
+	}
+	return c, nil
+}
+
+// validateEOFCode checks that the code section of a container only contains
+// instructions defined in the jump table and that it does not end in the middle
+// of a PUSH's immediate data.
+func validateEOFCode(code []byte, jt *JumpTable) error {
+	for pc := 0; pc < len(code); {
+		op := OpCode(code[pc])
+		if jt[op] == nil && op != designatedInvalid {
+			return fmt.Errorf("%w: undefined opcode %#x at %d", ErrInvalidEOFCode, byte(op), pc)
+		}
+		pc++
+		if op >= PUSH1 && op <= PUSH32 {
+			pc += int(op - PUSH1 + 1)
+			if pc > len(code) {
+				return fmt.Errorf("%w: truncated %v", ErrInvalidEOFCode, op)
+			}
+		}
+	}
+	return nil
+}
+
+// validateEOF parses the container and validates its code section against the
+// instruction set of the jump table.
+func validateEOF(b []byte, jt *JumpTable) error {
+	c, err := parseEOF(b)
+	if err != nil {
+		return err
+	}
+	return validateEOFCode(c.code, jt)
+}
@@ -0,0 +1,189 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// makeEOF assembles an EOF container out of a code and an optional data section.
+func makeEOF(code, data []byte) []byte {
+	c := []byte{0xEF, 0x00, eofVersion, eofSectionCode, byte(len(code) >> 8), byte(len(code))}
+	if len(data) > 0 {
+		c = append(c, eofSectionData, byte(len(data)>>8), byte(len(data)))
+	}
+	c = append(c, eofSectionTerminator)
+	c = append(c, code...)
+	return append(c, data...)
+}
+
+func TestParseEOF(t *testing.T) {
+	tests := []struct {
+		container string
+		code      string
+		data      string
+		fail      bool
+	}{
+		{container: "0xef000101000100fe", code: "0xfe"},
+		{container: "0xef000101000202000300600001aabb", code: "0x6000", data: "0x01aabb"},
+		{container: "0xef0001010001", fail: true},               // missing terminator
+		{container: "0xef00010100", fail: true},                 // truncated section size
+		{container: "0xef000102000100aa", fail: true},           // data without code
+		{container: "0xef00010100000000", fail: true},           // empty code section
+		{container: "0xef000101000102000000fe", fail: true},     // empty data section
+		{container: "0xef000101000101000100fefe", fail: true},   // multiple code sections
+		{container: "0xef000101000103000100feaa", fail: true},   // unknown section kind
+		{container: "0xef000101000100", fail: true},             // truncated code section
+		{container: "0xef000101000100fefe", fail: true},         // trailing bytes
+		{container: "0xef000201000100fe", fail: true},           // unsupported version
+		{container: "0xef00", fail: true},                       // missing version
+		{container: "0xef010101000100fe", fail: true},           // invalid magic
+		{container: "0xef0001010001020001020001fe", fail: true}, // multiple data sections
+		{container: "0xef000100", fail: true},                   // missing code section
+		{container: "0xef0001010001020001000000fe", fail: true}, // trailing bytes after the data section
+		{container: "0xef000101000102000100fe", fail: true},     // missing data section contents
+		{container: "0xef0001010002020001006000aa", code: "0x6000", data: "0xaa"},
+	}
+	for i, tt := range tests {
+		c, err := parseEOF(hexutil.MustDecode(tt.container))
+		if tt.fail {
+			if err == nil {
+				t.Errorf("test %d: invalid container %s accepted", i, tt.container)
+			} else if !errors.Is(err, ErrInvalidEOFCode) {
+				t.Errorf("test %d: error mismatch: have %v, want %v", i, err, ErrInvalidEOFCode)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: failed to parse container %s: %v", i, tt.container, err)
+			continue
+		}
+		if code := hexutil.Encode(c.code); code != tt.code {
+			t.Errorf("test %d: code section mismatch: have %s, want %s", i, code, tt.code)
+		}
+		if tt.data != "" {
+			if data := hexutil.Encode(c.data); data != tt.data {
+				t.Errorf("test %d: data section mismatch: have %s, want %s", i, data, tt.data)
+			}
+		} else if c.data != nil {
+			t.Errorf("test %d: unexpected data section %x", i, c.data)
+		}
+	}
+}
+
+func TestValidateEOFCode(t *testing.T) {
+	jt := newLondonInstructionSet()
+	tests := []struct {
+		code string
+		fail bool
+	}{
+		{code: "0x00"},
+		{code: "0xfe"}, // designated invalid instruction
+		{code: "0x7f112233445566778899001122334455667788990011223344556677889900112200"},
+		{code: "0x600100"},
+		{code: "0x60", fail: true},     // truncated PUSH1
+		{code: "0x6100", fail: true},   // truncated PUSH2
+		{code: "0x0c", fail: true},     // undefined opcode
+		{code: "0x6000ef", fail: true}, // undefined opcode after a PUSH
+		{code: "0x600c00"},             // undefined opcode as PUSH data
+		{code: "0x5b5b48"},             // BASEFEE is defined in London
+	}
+	for i, tt := range tests {
+		err := validateEOFCode(hexutil.MustDecode(tt.code), &jt)
+		if (err != nil) != tt.fail {
+			t.Errorf("test %d: validation mismatch for %s: have %v, want failure %v", i, tt.code, err, tt.fail)
+		}
+	}
+}
+
+func TestEOFExecution(t *testing.T) {
+	var (
+		// Jump over an invalid instruction, relative to the code section, and
+		// return CODESIZE, which is the size of the whole container.
+		code = []byte{
+			byte(PUSH1), 0x04, byte(JUMP), 0xfe,
+			byte(JUMPDEST), byte(CODESIZE), byte(PUSH1), 0x00, byte(MSTORE),
+			byte(PUSH1), 0x20, byte(PUSH1), 0x00, byte(RETURN),
+		}
+		container = makeEOF(code, []byte{0xaa, 0xbb})
+		invalid   = makeEOF([]byte{0x0c}, nil)
+		sender    = common.BytesToAddress([]byte("sender"))
+	)
+	// deploy creates legacy initcode returning the given code.
+	deploy := func(code []byte) []byte {
+		initcode := []byte{byte(PUSH1) + byte(len(code)-1)}
+		initcode = append(initcode, code...)
+		return append(initcode, byte(PUSH1), 0x00, byte(MSTORE), byte(PUSH1), byte(len(code)), byte(PUSH1), byte(32-len(code)), byte(RETURN))
+	}
+	newEVM := func(eof bool) *EVM {
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		config := *params.AllEthashProtocolChanges
+		config.LondonBlock = big.NewInt(0)
+		if eof {
+			config.EOFBlock = big.NewInt(0)
+		}
+		vmctx := BlockContext{
+			CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+			BlockNumber: new(big.Int),
+		}
+		return NewEVM(vmctx, TxContext{}, statedb, &config, Config{})
+	}
+	tests := []struct {
+		eof      bool
+		initcode []byte
+		err      error
+	}{
+		{eof: true, initcode: deploy(container)},
+		{eof: false, initcode: deploy(container), err: ErrInvalidCode},
+		{eof: true, initcode: deploy(invalid), err: ErrInvalidEOFCode},
+		{eof: true, initcode: deploy([]byte{0xef, 0x01}), err: ErrInvalidCode},
+		{eof: true, initcode: makeEOF(deploy(container), nil)},
+		{eof: true, initcode: makeEOF([]byte{byte(PUSH1), 0x00, byte(DUP1), byte(RETURN)}, nil), err: ErrInvalidEOFCode}, // EOF deploying legacy code
+		{eof: true, initcode: makeEOF([]byte{byte(PUSH1)}, nil), err: ErrInvalidEOFCode},
+	}
+	for i, tt := range tests {
+		evm := newEVM(tt.eof)
+		_, addr, _, err := evm.Create(AccountRef(sender), tt.initcode, 1000000, new(big.Int))
+		if !errors.Is(err, tt.err) {
+			t.Errorf("test %d: create error mismatch: have %v, want %v", i, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if deployed := evm.StateDB.GetCode(addr); !bytes.Equal(deployed, container) {
+			t.Errorf("test %d: deployed code mismatch: have %x, want %x", i, deployed, container)
+		}
+		ret, _, err := evm.Call(AccountRef(sender), addr, nil, 1000000, new(big.Int))
+		if err != nil {
+			t.Errorf("test %d: call failed: %v", i, err)
+		}
+		if size := new(big.Int).SetBytes(ret); size.Uint64() != uint64(len(container)) {
+			t.Errorf("test %d: code size mismatch: have %v, want %d", i, size, len(container))
+		}
+	}
+}
@@ -93,6 +93,7 @@ type BlockContext struct {
 	BlockNumber *big.Int       // Provides information for NUMBER
 	Time        *big.Int       // Provides information for TIME
 	Difficulty  *big.Int       // Provides information for DIFFICULTY
+	BaseFee     *big.Int       // Provides information for BASEFEE
 }
 
 // TxContext provides the EVM with information about a transaction.
@@ -144,6 +145,12 @@ type EVM struct {
 // NewEVM returns a new EVM. The returned EVM is not thread safe and should
 // only ever be used *once*.
 func NewEVM(blockCtx BlockContext, txCtx TxContext, statedb StateDB, chainConfig *params.ChainConfig, vmConfig Config) *EVM {
+	// If basefee tracking is disabled (eth_call, eth_estimateGas, etc), and no
+	// gas prices were specified, lower the basefee to 0 to avoid breaking EVM
+	// invariants (basefee < feecap)
+	if vmConfig.NoBaseFee && blockCtx.BaseFee != nil && (txCtx.GasPrice == nil || txCtx.GasPrice.BitLen() == 0) {
+		blockCtx.BaseFee = new(big.Int)
+	}
 	evm := &EVM{
 		Context:      blockCtx,
 		TxContext:    txCtx,
@@ -461,15 +468,29 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	}
 	start := time.Now()
 
-	ret, err := run(evm, contract, nil, false)
+	// Reject invalid EOF initcode without executing it.
+	var (
+		ret []byte
+		err error
+		eof = evm.chainRules.IsEOF && hasEOFMagic(codeAndHash.code)
+	)
+	if eof {
+		err = evm.validateEOF(codeAndHash.code)
+	}
+	if err == nil {
+		ret, err = run(evm, contract, nil, false)
+	}
 
 	// Check whether the max code size has been exceeded, assign err if the case.
 	if err == nil && evm.chainRules.IsEIP158 && len(ret) > params.MaxCodeSize {
 		err = ErrMaxCodeSizeExceeded
 	}
 
-	// Reject code starting with 0xEF if EIP-3541 is enabled.
-	if err == nil && len(ret) >= 1 && ret[0] == 0xEF && evm.chainRules.IsLondon {
+	// Reject code starting with 0xEF if EIP-3541 is enabled, unless it is a valid
+	// EOF container. EOF initcode may only deploy EOF code.
+	if err == nil && evm.chainRules.IsEOF && (eof || hasEOFMagic(ret)) {
+		err = evm.validateEOF(ret)
+	} else if err == nil && len(ret) >= 1 && ret[0] == 0xEF && evm.chainRules.IsLondon {
 		err = ErrInvalidCode
 	}
 
@@ -502,6 +523,13 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	return ret, address, contract.Gas, err
 }
 
+// validateEOF checks that code is a valid EOF container, containing only the
+// instructions of the active fork.
+func (evm *EVM) validateEOF(code []byte) error {
+	in := evm.interpreter.(*EVMInterpreter)
+	return validateEOF(code, (*JumpTable)(&in.cfg.JumpTable))
+}
+
 // Create creates a new contract using code as deployment code.
 func (evm *EVM) Create(caller ContractRef, code []byte, gas uint64, value *big.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
 	contractAddr = crypto.CreateAddress(caller.Address(), evm.StateDB.GetNonce(caller.Address()))
@@ -520,3 +548,6 @@ func (evm *EVM) Create2(caller ContractRef, code []byte, gas uint64, endowment *
 
 // ChainConfig returns the environment's chain configuration
 func (evm *EVM) ChainConfig() *params.ChainConfig { return evm.chainConfig }
+
+// Config returns the environment's VM configuration.
+func (evm *EVM) Config() Config { return evm.vmConfig }
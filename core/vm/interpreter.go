@@ -23,6 +23,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/holiman/uint256"
 )
 
 // Config are the configuration options for the Interpreter
@@ -30,6 +31,7 @@ type Config struct {
 	Debug                   bool   // Enables debugging
 	Tracer                  Tracer // Opcode logger
 	NoRecursion             bool   // Disables call, callcode, delegate call and create
+	NoBaseFee               bool   // Forces the EIP-1559 baseFee to 0 (needed for 0 price calls)
 	EnablePreimageRecording bool   // Enables recording of SHA3/keccak preimages
 
 	JumpTable [256]*operation // EVM instruction table, automatically populated if unset
@@ -156,13 +158,19 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 	// as every returning call will return new data anyway.
 	in.returnData = nil
 
+	// Only execute the code section of EOF containers, jumps and PC being
+	// relative to its start.
+	if in.evm.chainRules.IsEOF && contract.container == nil && hasEOFMagic(contract.Code) {
+		if c, err := parseEOF(contract.Code); err == nil {
+			contract.container, contract.Code = contract.Code, c.code
+		}
+	}
 	// Don't bother with the execution if there's no code.
 	if len(contract.Code) == 0 {
 		return nil, nil
 	}
 
 	var (
-		op          OpCode        // current opcode
 		mem         = NewMemory() // bound memory
 		stack       = newstack()  // local stack
 		callContext = &ScopeContext{
@@ -170,6 +178,31 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 			Stack:    stack,
 			Contract: contract,
 		}
+	)
+	// The stack is only returned to the pool after the run loop is done, so that
+	// the capturestate-deferred method of the traced loop can still access it
+	defer func() {
+		returnStack(stack)
+	}()
+	contract.Input = input
+
+	// Tracing requires executing the code instruction by instruction, otherwise
+	// it can be executed block by block
+	if in.cfg.Debug {
+		return in.runSteps(callContext)
+	}
+	return in.runBlocks(callContext)
+}
+
+// runSteps is the main run loop of the interpreter if tracing is enabled. It
+// executes the code instruction by instruction, capturing the state before each
+// of them.
+func (in *EVMInterpreter) runSteps(callContext *ScopeContext) (ret []byte, err error) {
+	var (
+		op       OpCode // current opcode
+		contract = callContext.Contract
+		mem      = callContext.Memory
+		stack    = callContext.Stack
 		// For optimisation reason we're using uint64 as the program counter.
 		// It's theoretically possible to go above 2^64. The YP defines the PC
 		// to be uint256. Practically much less so feasible.
@@ -181,39 +214,27 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		logged  bool   // deferred Tracer should ignore already logged steps
 		res     []byte // result of the opcode execution function
 	)
-	// Don't move this deferrred function, it's placed before the capturestate-deferred method,
-	// so that it get's executed _after_: the capturestate needs the stacks before
-	// they are returned to the pools
 	defer func() {
-		returnStack(stack)
-	}()
-	contract.Input = input
-
-	if in.cfg.Debug {
-		defer func() {
-			if err != nil {
-				if !logged {
-					in.cfg.Tracer.CaptureState(in.evm, pcCopy, op, gasCopy, cost, callContext, in.returnData, in.evm.depth, err)
-				} else {
-					in.cfg.Tracer.CaptureFault(in.evm, pcCopy, op, gasCopy, cost, callContext, in.evm.depth, err)
-				}
+		if err != nil {
+			if !logged {
+				in.cfg.Tracer.CaptureState(in.evm, pcCopy, op, gasCopy, cost, callContext, in.returnData, in.evm.depth, err)
+			} else {
+				in.cfg.Tracer.CaptureFault(in.evm, pcCopy, op, gasCopy, cost, callContext, in.evm.depth, err)
 			}
-		}()
-	}
-	// The Interpreter main run loop (contextual). This loop runs until either an
-	// explicit STOP, RETURN or SELFDESTRUCT is executed, an error occurred during
-	// the execution of one of the operations or until the done flag is set by the
-	// parent context.
+		}
+	}()
+	// The Interpreter main run loop (contextual) with tracing. This loop runs until
+	// either an explicit STOP, RETURN or SELFDESTRUCT is executed, an error occurred
+	// during the execution of one of the operations or until the done flag is set by
+	// the parent context.
 	steps := 0
 	for {
 		steps++
 		if steps%1000 == 0 && atomic.LoadInt32(&in.evm.abort) != 0 {
 			break
 		}
-		if in.cfg.Debug {
-			// Capture pre-execution values for tracing.
-			logged, pcCopy, gasCopy = false, pc, contract.Gas
-		}
+		// Capture pre-execution values for tracing.
+		logged, pcCopy, gasCopy = false, pc, contract.Gas
 
 		// Get the operation from the jump table and validate the stack to ensure there are
 		// enough stack items available to perform the operation.
@@ -276,10 +297,8 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 			mem.Resize(memorySize)
 		}
 
-		if in.cfg.Debug {
-			in.cfg.Tracer.CaptureState(in.evm, pc, op, gasCopy, cost, callContext, in.returnData, in.evm.depth, err)
-			logged = true
-		}
+		in.cfg.Tracer.CaptureState(in.evm, pc, op, gasCopy, cost, callContext, in.returnData, in.evm.depth, err)
+		logged = true
 
 		// execute the operation
 		res, err = operation.execute(&pc, in, callContext)
@@ -303,6 +322,158 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 	return nil, nil
 }
 
+// runBlocks is the main run loop of the interpreter if no tracing is needed. It
+// executes the code in basic blocks, validating the stack and charging the
+// constant gas of the whole block on entry, and runs superinstructions.
+//
+// The outcome of the execution is identical to executing the instructions one
+// by one: if a block's requirements are not met on entry, its instructions are
+// executed with the full checks, so errors occur at the same instruction, and
+// the constant gas charged ahead is given back if a dynamic gas charge fails.
+func (in *EVMInterpreter) runBlocks(scope *ScopeContext) ([]byte, error) {
+	var (
+		contract = scope.Contract
+		stack    = scope.Stack
+		mem      = scope.Memory
+		code     = contract.Code
+		analysis = contract.blockAnalysis((*JumpTable)(&in.cfg.JumpTable))
+		blocks   = analysis.blocks
+		pc       uint64 // program counter, at the start of block bi
+		bi       int    // index of the executing block
+		steps    int
+	)
+	for {
+		// Running past the end of the code is an implicit STOP
+		if pc >= uint64(len(code)) {
+			return nil, nil
+		}
+		steps++
+		if steps%1000 == 0 && atomic.LoadInt32(&in.evm.abort) != 0 {
+			return nil, nil
+		}
+		block := &blocks[bi]
+		if block.invalid {
+			return nil, &ErrInvalidOpCode{opcode: OpCode(code[pc])}
+		}
+		// Validate the stack and charge the constant gas for the entire block if
+		// possible, otherwise step through it with the individual checks.
+		var (
+			sLen      = stack.len()
+			charged   = sLen >= block.minStack && sLen <= block.maxStack && contract.Gas >= block.gas
+			unspent   uint64 // Constant gas charged for the instructions yet to run
+			nextBlock = bi + 1
+		)
+		if charged {
+			contract.Gas -= block.gas
+			unspent = block.gas
+		}
+	blockLoop:
+		for pc < block.end {
+			op := OpCode(code[pc])
+			operation := in.cfg.JumpTable[op]
+
+			if charged {
+				switch analysis.fused[pc] {
+				case fusedPushJump:
+					nextBlock = block.target
+					break blockLoop
+				case fusedPushJumpi:
+					if cond := stack.pop(); !cond.IsZero() {
+						nextBlock = block.target
+					}
+					break blockLoop
+				case fusedPushPushAdd:
+					var (
+						x, y  uint256.Int
+						ysize = uint64(op - PUSH1 + 1)
+						xpc   = pc + 1 + ysize
+						xsize = uint64(code[xpc]) - uint64(PUSH1) + 1
+					)
+					y.SetBytes(code[pc+1 : xpc])
+					x.SetBytes(code[xpc+1 : xpc+1+xsize])
+					stack.push(x.Add(&x, &y))
+
+					unspent -= operation.constantGas + in.cfg.JumpTable[code[xpc]].constantGas + in.cfg.JumpTable[ADD].constantGas
+					pc = xpc + 1 + xsize + 1
+					continue
+				}
+				unspent -= operation.constantGas
+			} else {
+				if sLen := stack.len(); sLen < operation.minStack {
+					return nil, &ErrStackUnderflow{stackLen: sLen, required: operation.minStack}
+				} else if sLen > operation.maxStack {
+					return nil, &ErrStackOverflow{stackLen: sLen, limit: operation.maxStack}
+				}
+			}
+			if in.readOnly && in.evm.chainRules.IsByzantium {
+				if operation.writes || (op == CALL && stack.Back(2).Sign() != 0) {
+					return nil, ErrWriteProtection
+				}
+			}
+			if !charged && !contract.UseGas(operation.constantGas) {
+				return nil, ErrOutOfGas
+			}
+			var memorySize uint64
+			if operation.memorySize != nil {
+				memSize, overflow := operation.memorySize(stack)
+				if overflow {
+					return nil, ErrGasUintOverflow
+				}
+				if memorySize, overflow = math.SafeMul(toWordSize(memSize), 32); overflow {
+					return nil, ErrGasUintOverflow
+				}
+			}
+			if operation.dynamicGas != nil {
+				dynamicCost, err := operation.dynamicGas(in.evm, contract, stack, mem, memorySize)
+				if err != nil {
+					return nil, ErrOutOfGas
+				}
+				if !contract.UseGas(dynamicCost) {
+					if !charged {
+						return nil, ErrOutOfGas
+					}
+					// Give back the gas charged ahead and continue step by step, the
+					// charge might only have failed due to the early charging
+					contract.Gas += unspent
+					charged, unspent = false, 0
+					if !contract.UseGas(dynamicCost) {
+						return nil, ErrOutOfGas
+					}
+				}
+			}
+			if memorySize > 0 {
+				mem.Resize(memorySize)
+			}
+			res, err := operation.execute(&pc, in, scope)
+			if operation.returns {
+				in.returnData = common.CopyBytes(res)
+			}
+			switch {
+			case err != nil:
+				return nil, err
+			case operation.reverts:
+				return res, ErrExecutionReverted
+			case operation.halts:
+				return res, nil
+			case operation.jumps:
+				// Jumps end their block, find the block at the destination
+				if pc != block.end {
+					nextBlock = analysis.blockAt(pc)
+				}
+				break blockLoop
+			default:
+				pc++
+			}
+		}
+		if nextBlock < len(blocks) {
+			pc = blocks[nextBlock].start
+		} else {
+			pc = uint64(len(code))
+		}
+		bi = nextBlock
+	}
+}
+
 // CanRun tells if the contract, passed as an argument, can be
 // run by the current interpreter.
 func (in *EVMInterpreter) CanRun(code []byte) bool {
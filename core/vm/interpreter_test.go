@@ -0,0 +1,164 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// nopTracer is a tracer doing nothing, used to force the step by step execution.
+type nopTracer struct{}
+
+func (nopTracer) CaptureStart(*EVM, common.Address, common.Address, bool, []byte, uint64, *big.Int) {
+}
+func (nopTracer) CaptureState(*EVM, uint64, OpCode, uint64, uint64, *ScopeContext, []byte, int, error) {
+}
+func (nopTracer) CaptureFault(*EVM, uint64, OpCode, uint64, uint64, *ScopeContext, int, error) {}
+func (nopTracer) CaptureEnd([]byte, uint64, time.Duration, error)                              {}
+
+// randomCode generates a random program out of instructions likely to form
+// loops, fused sequences, memory expansions and failures at various places.
+func randomCode(rng *rand.Rand, size int) []byte {
+	var code []byte
+	for len(code) < size {
+		switch rng.Intn(16) {
+		case 0:
+			code = append(code, byte(JUMPDEST))
+		case 1:
+			code = append(code, byte(PUSH1), byte(rng.Intn(size)), byte(JUMP))
+		case 2:
+			code = append(code, byte(PUSH1), byte(rng.Intn(2)), byte(PUSH1), byte(rng.Intn(size)), byte(JUMPI))
+		case 3:
+			code = append(code, byte(PUSH1), byte(rng.Intn(256)), byte(PUSH2), byte(rng.Intn(256)), byte(rng.Intn(256)), byte(ADD))
+		case 4:
+			code = append(code, byte(PUSH1), byte(rng.Intn(size)))
+		case 5:
+			code = append(code, byte(PUSH3), byte(rng.Intn(2)), byte(rng.Intn(256)), byte(rng.Intn(256)), byte(MSTORE))
+		case 6:
+			code = append(code, byte(DUP1)+byte(rng.Intn(4)))
+		case 7:
+			code = append(code, byte(SWAP1)+byte(rng.Intn(4)))
+		case 8:
+			code = append(code, []byte{byte(ADD), byte(MUL), byte(SUB), byte(POP), byte(ISZERO), byte(LT)}[rng.Intn(6)])
+		case 9:
+			code = append(code, []byte{byte(SSTORE), byte(SLOAD), byte(MLOAD), byte(GAS), byte(PC), byte(MSIZE)}[rng.Intn(6)])
+		case 10:
+			code = append(code, byte(JUMP))
+		case 11:
+			code = append(code, byte(JUMPI))
+		case 12:
+			code = append(code, []byte{byte(RETURN), byte(REVERT), byte(STOP), 0xfe, 0x0c}[rng.Intn(5)])
+		case 13:
+			// Call the contract itself with a fraction of the gas
+			code = append(code, byte(PUSH1), 0, byte(DUP1), byte(DUP1), byte(DUP1), byte(DUP1), byte(ADDRESS), byte(PUSH2), 0x10, 0x00, byte(CALL))
+		default:
+			code = append(code, byte(PUSH1), byte(rng.Intn(256)))
+		}
+	}
+	return code
+}
+
+// Tests that executing code block by block yields the same results as executing
+// it instruction by instruction.
+func TestBlockExecutionEquivalence(t *testing.T) {
+	var (
+		rng     = rand.New(rand.NewSource(1))
+		address = common.BytesToAddress([]byte("contract"))
+	)
+	run := func(code []byte, gas uint64, debug bool) (string, common.Hash) {
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		statedb.CreateAccount(address)
+		statedb.SetCode(address, code)
+		statedb.SetState(address, common.Hash{}, common.Hash{0x01})
+		statedb.Finalise(true)
+
+		vmctx := BlockContext{
+			CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+			BlockNumber: new(big.Int),
+		}
+		config := Config{}
+		if debug {
+			config = Config{Debug: true, Tracer: nopTracer{}}
+		}
+		evm := NewEVM(vmctx, TxContext{}, statedb, params.AllEthashProtocolChanges, config)
+		statedb.PrepareAccessList(common.Address{}, &address, nil, nil)
+
+		ret, left, err := evm.Call(AccountRef(common.Address{}), address, nil, gas, new(big.Int))
+		return fmt.Sprintf("ret %x, gas %d, err %v, refund %d", ret, left, err, statedb.GetRefund()), statedb.IntermediateRoot(true)
+	}
+	for i := 0; i < 3000; i++ {
+		var (
+			code = randomCode(rng, 16+rng.Intn(128))
+			gas  = uint64(rng.Intn(100000))
+		)
+		want, wantRoot := run(code, gas, true)
+		have, haveRoot := run(code, gas, false)
+		if have != want || haveRoot != wantRoot {
+			t.Fatalf("test %d: result mismatch for code %x, gas %d\nhave: %s, root %x\nwant: %s, root %x", i, code, gas, have, haveRoot, want, wantRoot)
+		}
+	}
+}
+
+func TestBlockAnalysis(t *testing.T) {
+	code := []byte{
+		byte(PUSH1), 0x01, byte(PUSH1), 0x02, byte(ADD), // fused add, stack +1
+		byte(PUSH1), 0x0b, byte(JUMPI), // fused conditional jump, stack -1
+		byte(JUMPDEST), byte(PUSH1), 0x00, // block 1 ends at the next JUMPDEST
+		byte(JUMPDEST), byte(GAS), // block 2 ends at GAS
+		byte(POP), byte(POP), 0xfe, // block 3 is cut by the invalid opcode
+	}
+	var (
+		jt = newLondonInstructionSet()
+		a  = analyseBlocks(code, codeBitmap(code), &jt)
+	)
+	want := []codeBlock{
+		{start: 0, end: 8, gas: 4*GasFastestStep + GasSlowStep, minStack: 0, maxStack: 1022, target: 2},
+		{start: 8, end: 11, gas: params.JumpdestGas + GasFastestStep, minStack: 0, maxStack: 1023, target: -1},
+		{start: 11, end: 13, gas: params.JumpdestGas + GasQuickStep, minStack: 0, maxStack: 1023, target: -1},
+		{start: 13, end: 15, gas: 2 * GasQuickStep, minStack: 2, maxStack: 1024, target: -1},
+		{start: 15, end: 16, minStack: 0, maxStack: 1024, target: -1, invalid: true},
+	}
+	if len(a.blocks) != len(want) {
+		t.Fatalf("block count mismatch: have %d, want %d: %+v", len(a.blocks), len(want), a.blocks)
+	}
+	for i := range want {
+		if a.blocks[i] != want[i] {
+			t.Errorf("block %d mismatch: have %+v, want %+v", i, a.blocks[i], want[i])
+		}
+	}
+	fused := make([]byte, len(code))
+	fused[0], fused[5] = fusedPushPushAdd, fusedPushJumpi
+	if !bytes.Equal(a.fused, fused) {
+		t.Errorf("superinstructions mismatch: have %v, want %v", a.fused, fused)
+	}
+	for i, block := range want {
+		if idx := a.blockAt(block.start); idx != i {
+			t.Errorf("block %d lookup mismatch: have %d", i, idx)
+		}
+	}
+}
@@ -463,6 +463,14 @@ func BenchmarkSimpleLoop(b *testing.B) {
 		byte(vm.JUMP),
 	}
 
+	arithmeticLoop := []byte{
+		byte(vm.JUMPDEST), //
+		byte(vm.PUSH1), 1, byte(vm.PUSH1), 2, byte(vm.ADD),
+		byte(vm.DUP1), byte(vm.MUL), byte(vm.PUSH1), 3, byte(vm.SWAP1), byte(vm.SUB), byte(vm.POP),
+		byte(vm.PUSH1), 0, // jumpdestination
+		byte(vm.JUMP),
+	}
+
 	calllRevertingContractWithInput := []byte{
 		byte(vm.JUMPDEST), //
 		// push args for the call
@@ -489,6 +497,7 @@ func BenchmarkSimpleLoop(b *testing.B) {
 	benchmarkNonModifyingCode(100000000, staticCallIdentity, "staticcall-identity-100M", b)
 	benchmarkNonModifyingCode(100000000, callIdentity, "call-identity-100M", b)
 	benchmarkNonModifyingCode(100000000, loopingCode, "loop-100M", b)
+	benchmarkNonModifyingCode(100000000, arithmeticLoop, "loop-arithmetic-100M", b)
 	benchmarkNonModifyingCode(100000000, callInexistant, "call-nonexist-100M", b)
 	benchmarkNonModifyingCode(100000000, callEOA, "call-EOA-100M", b)
 	benchmarkNonModifyingCode(100000000, calllRevertingContractWithInput, "call-reverting-100M", b)
@@ -33,7 +33,9 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/protocols/snap"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -392,6 +394,144 @@ func (api *PublicDebugAPI) AccountRange(blockNrOrHash rpc.BlockNumberOrHash, sta
 	return stateDb.IteratorDump(nocode, nostorage, incompletes, start, maxResults), nil
 }
 
+// RangeProofDefaultBytes is the size of the leaves returned by a range proof call
+// if not specified.
+const RangeProofDefaultBytes = 512 * 1024
+
+// RangeProofResult is the result of a debug_getAccountRange or debug_getStorageRange
+// API call. It contains consecutive leaves of a trie, with the Merkle proofs of the
+// first and last ones, which can be verified by trie.VerifyRangeProof.
+type RangeProofResult struct {
+	Root   common.Hash     `json:"root"`   // Root of the trie the range is proven against
+	Keys   []common.Hash   `json:"keys"`   // Hashed keys of the leaves, in ascending order
+	Values []hexutil.Bytes `json:"values"` // RLP encoded values of the leaves
+	Proof  []hexutil.Bytes `json:"proof"`  // Nodes proving the range, nil if a storage range is the entire trie
+}
+
+// GetAccountRange returns the accounts of the state at the given block starting
+// at the given account hash, up to roughly maxBytes of data. The accounts are
+// read from the state snapshot, so only recent states are available.
+func (api *PublicDebugAPI) GetAccountRange(blockNrOrHash rpc.BlockNumberOrHash, start common.Hash, maxBytes int) (*RangeProofResult, error) {
+	root, err := api.snapshotRoot(blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if maxBytes <= 0 {
+		maxBytes = RangeProofDefaultBytes
+	}
+	accounts, proof, err := snap.ServiceGetAccountRangeQuery(api.eth.blockchain, &snap.GetAccountRangePacket{
+		Root:   root,
+		Origin: start,
+		Limit:  common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"),
+		Bytes:  uint64(maxBytes),
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := &RangeProofResult{Root: root}
+	for _, account := range accounts {
+		// The snapshot stores accounts in a slim format, but the trie doesn't
+		blob, err := snapshot.FullAccountRLP(account.Body)
+		if err != nil {
+			return nil, err
+		}
+		result.Keys = append(result.Keys, account.Hash)
+		result.Values = append(result.Values, blob)
+	}
+	for _, node := range proof {
+		result.Proof = append(result.Proof, node)
+	}
+	return result, nil
+}
+
+// GetStorageRange returns the storage slots of the account with the given hash in
+// the state at the given block, starting at the given slot hash, up to roughly
+// maxBytes of data. The slots are read from the state snapshot, so only recent
+// states are available.
+func (api *PublicDebugAPI) GetStorageRange(blockNrOrHash rpc.BlockNumberOrHash, account common.Hash, start common.Hash, maxBytes int) (*RangeProofResult, error) {
+	root, err := api.snapshotRoot(blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	layer := api.eth.blockchain.Snapshots().Snapshot(root)
+	if layer == nil {
+		return nil, fmt.Errorf("snapshot of state %x not available", root)
+	}
+	acc, err := layer.Account(account)
+	if err != nil {
+		return nil, err
+	}
+	if acc == nil {
+		return nil, fmt.Errorf("account %x not found", account)
+	}
+	result := &RangeProofResult{Root: types.EmptyRootHash}
+	if len(acc.Root) > 0 {
+		result.Root = common.BytesToHash(acc.Root)
+	}
+	if maxBytes <= 0 {
+		maxBytes = RangeProofDefaultBytes
+	}
+	slots, proof, err := snap.ServiceGetStorageRangesQuery(api.eth.blockchain, &snap.GetStorageRangesPacket{
+		Root:     root,
+		Accounts: []common.Hash{account},
+		Origin:   start[:],
+		Bytes:    uint64(maxBytes),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(slots) > 0 {
+		for _, slot := range slots[0] {
+			result.Keys = append(result.Keys, slot.Hash)
+			result.Values = append(result.Values, slot.Body)
+		}
+	}
+	for _, node := range proof {
+		result.Proof = append(result.Proof, node)
+	}
+	return result, nil
+}
+
+// SnapSyncStatus returns the progress of the current or last snap sync cycle, or
+// nil if the node has not snap synced since it was started.
+func (api *PublicDebugAPI) SnapSyncStatus() *snap.SyncStatus {
+	return api.eth.handler.downloader.SnapSyncer.Status()
+}
+
+// snapshotRoot returns the state root of the given block, ensuring that the
+// snapshot of the state is available.
+func (api *PublicDebugAPI) snapshotRoot(blockNrOrHash rpc.BlockNumberOrHash) (common.Hash, error) {
+	snaps := api.eth.blockchain.Snapshots()
+	if snaps == nil {
+		return common.Hash{}, errors.New("state snapshots are disabled")
+	}
+	var header *types.Header
+	if number, ok := blockNrOrHash.Number(); ok {
+		switch number {
+		case rpc.PendingBlockNumber:
+			return common.Hash{}, errors.New("pending state is not available")
+		case rpc.LatestBlockNumber:
+			header = api.eth.blockchain.CurrentHeader()
+		default:
+			header = api.eth.blockchain.GetHeaderByNumber(uint64(number))
+		}
+		if header == nil {
+			return common.Hash{}, fmt.Errorf("block #%d not found", number)
+		}
+	} else if hash, ok := blockNrOrHash.Hash(); ok {
+		header = api.eth.blockchain.GetHeaderByHash(hash)
+		if header == nil {
+			return common.Hash{}, fmt.Errorf("block %s not found", hash.Hex())
+		}
+	} else {
+		return common.Hash{}, errors.New("either block number or block hash must be specified")
+	}
+	if snaps.Snapshot(header.Root) == nil {
+		return common.Hash{}, fmt.Errorf("snapshot of state %x not available", header.Root)
+	}
+	return header.Root, nil
+}
+
 // StorageRangeResult is the result of a debug_storageRangeAt API call.
 type StorageRangeResult struct {
 	Storage storageMap   `json:"storage"`
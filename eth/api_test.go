@@ -26,9 +26,16 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/light"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
 )
 
 var dumper = spew.ConfigState{Indent: "    "}
@@ -207,3 +214,132 @@ func TestStorageRangeAt(t *testing.T) {
 		}
 	}
 }
+
+func TestRangeProofs(t *testing.T) {
+	t.Parallel()
+
+	// Create a chain with a few hundred accounts, one of them with storage.
+	var (
+		db       = rawdb.NewMemoryDatabase()
+		alloc    = make(core.GenesisAlloc)
+		storage  = make(map[common.Hash]common.Hash)
+		contract = common.Address{0xff}
+	)
+	for i := 0; i < 100; i++ {
+		storage[common.BigToHash(big.NewInt(int64(i)))] = common.BigToHash(big.NewInt(int64(i + 1)))
+	}
+	alloc[contract] = core.GenesisAccount{Balance: big.NewInt(1), Code: []byte{0x00}, Storage: storage}
+	for i := 1; i <= 300; i++ {
+		alloc[common.BigToAddress(big.NewInt(int64(i)))] = core.GenesisAccount{Balance: big.NewInt(int64(i))}
+	}
+	genesis := (&core.Genesis{Config: params.TestChainConfig, Alloc: alloc}).MustCommit(db)
+
+	chain, err := core.NewBlockChain(db, nil, params.TestChainConfig, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	api := NewPublicDebugAPI(&Ethereum{blockchain: chain})
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+
+	// verify checks that the result is a valid proof of a range starting at origin,
+	// returning the next origin to continue from or nil if the range is complete.
+	verify := func(result *RangeProofResult, origin common.Hash) *common.Hash {
+		t.Helper()
+
+		var (
+			keys   = make([][]byte, len(result.Keys))
+			values = make([][]byte, len(result.Values))
+			proof  = light.NewNodeSet()
+		)
+		for i := range result.Keys {
+			keys[i], values[i] = result.Keys[i][:], result.Values[i]
+		}
+		for _, node := range result.Proof {
+			proof.Put(crypto.Keccak256(node), node)
+		}
+		var last []byte
+		if len(keys) > 0 {
+			last = keys[len(keys)-1]
+		}
+		var (
+			cont bool
+			err  error
+		)
+		if len(result.Proof) == 0 {
+			cont, err = trie.VerifyRangeProof(result.Root, nil, nil, keys, values, nil)
+		} else {
+			cont, err = trie.VerifyRangeProof(result.Root, origin[:], last, keys, values, proof)
+		}
+		if err != nil {
+			t.Fatalf("invalid range proof from %x: %v", origin, err)
+		}
+		if !cont {
+			return nil
+		}
+		next := common.BigToHash(new(big.Int).Add(new(big.Int).SetBytes(last), common.Big1))
+		return &next
+	}
+	// Iterate over all the accounts in small chunks
+	var (
+		origin   common.Hash
+		accounts int
+		chunks   int
+	)
+	for {
+		result, err := api.GetAccountRange(latest, origin, 1000)
+		if err != nil {
+			t.Fatalf("failed to retrieve account range: %v", err)
+		}
+		if result.Root != genesis.Root() {
+			t.Fatalf("state root mismatch: have %x, want %x", result.Root, genesis.Root())
+		}
+		accounts += len(result.Keys)
+		chunks++
+
+		next := verify(result, origin)
+		if next == nil {
+			break
+		}
+		origin = *next
+	}
+	if accounts != len(alloc) {
+		t.Errorf("account count mismatch: have %d, want %d", accounts, len(alloc))
+	}
+	if chunks < 2 {
+		t.Errorf("accounts not split into chunks")
+	}
+	// Retrieve the storage of the contract in a single and in multiple chunks
+	account := crypto.Keccak256Hash(contract[:])
+	for _, maxBytes := range []int{0, 500} {
+		var slots int
+		origin = common.Hash{}
+		for {
+			result, err := api.GetStorageRange(latest, account, origin, maxBytes)
+			if err != nil {
+				t.Fatalf("failed to retrieve storage range: %v", err)
+			}
+			slots += len(result.Keys)
+
+			next := verify(result, origin)
+			if next == nil {
+				break
+			}
+			origin = *next
+		}
+		if slots != len(storage) {
+			t.Errorf("maxBytes %d: slot count mismatch: have %d, want %d", maxBytes, slots, len(storage))
+		}
+	}
+	// Requests for unavailable state should fail
+	if _, err := api.GetAccountRange(rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber), common.Hash{}, 0); err == nil {
+		t.Errorf("pending state range served")
+	}
+	if _, err := api.GetAccountRange(rpc.BlockNumberOrHashWithNumber(1), common.Hash{}, 0); err == nil {
+		t.Errorf("missing block range served")
+	}
+	if _, err := api.GetStorageRange(latest, common.Hash{0x01}, common.Hash{}, 0); err == nil {
+		t.Errorf("missing account storage served")
+	}
+}
@@ -131,6 +131,28 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Pick up the trie node storage scheme before anything is written. The
+	// path-based scheme only retains recent states, so it can't serve as an
+	// archive, and the state sync and snapshots rely on hash-keyed nodes.
+	scheme, err := rawdb.ParseStateScheme(config.StateScheme, chainDb)
+	if err != nil {
+		return nil, err
+	}
+	if scheme == rawdb.PathScheme {
+		if config.NoPruning {
+			return nil, errors.New("archive mode is not supported in the path-based state scheme")
+		}
+		if config.SyncMode != downloader.FullSync {
+			return nil, fmt.Errorf("sync mode %v is not supported in the path-based state scheme", config.SyncMode)
+		}
+		if config.SnapshotCache > 0 {
+			log.Warn("Snapshots are not supported in the path-based state scheme, disabling")
+			config.TrieCleanCache += config.SnapshotCache
+			config.SnapshotCache = 0
+		}
+	}
+	log.Info("Initialised state storage scheme", "scheme", scheme)
+
 	chainConfig, genesisHash, genesisErr := core.SetupGenesisBlockWithOverride(chainDb, config.Genesis, config.OverrideLondon)
 	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
 		return nil, genesisErr
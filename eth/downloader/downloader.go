@@ -1391,6 +1391,9 @@ func (d *Downloader) fetchParts(deliveryCh chan dataPack, deliver func(dataPack)
 				if !errors.Is(err, errStaleDelivery) {
 					setIdle(peer, accepted, deliveryTime)
 				}
+				if err == nil && accepted > 0 {
+					peer.reportDelivered()
+				}
 				// Issue a log to the user to see what's going on
 				switch {
 				case err == nil && packet.Items() == 0:
@@ -34,6 +34,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/trie"
 )
 
@@ -529,6 +530,8 @@ func TestCanonicalSynchronisation65Light(t *testing.T) { testCanonSync(t, eth.ET
 func TestCanonicalSynchronisation66Full(t *testing.T)  { testCanonSync(t, eth.ETH66, FullSync) }
 func TestCanonicalSynchronisation66Fast(t *testing.T)  { testCanonSync(t, eth.ETH66, FastSync) }
 func TestCanonicalSynchronisation66Light(t *testing.T) { testCanonSync(t, eth.ETH66, LightSync) }
+func TestCanonicalSynchronisation67Full(t *testing.T)  { testCanonSync(t, eth.ETH67, FullSync) }
+func TestCanonicalSynchronisation67Light(t *testing.T) { testCanonSync(t, eth.ETH67, LightSync) }
 
 func testCanonSync(t *testing.T, protocol uint, mode SyncMode) {
 	t.Parallel()
@@ -1711,3 +1714,40 @@ func testCheckpointEnforcement(t *testing.T, protocol uint, mode SyncMode) {
 		assertOwnChain(t, tester, chain.len())
 	}
 }
+
+// reportingTesterPeer is a test peer which tracks the reputation reports about it.
+type reportingTesterPeer struct {
+	*downloadTesterPeer
+	delivered int32
+}
+
+func (p *reportingTesterPeer) Report(b p2p.PeerBehavior) {
+	if b == p2p.PeerDeliveredData {
+		atomic.AddInt32(&p.delivered, 1)
+	}
+}
+
+// Tests that peers are credited for the data they delivered, once it has been
+// matched against the requested hashes.
+func TestDeliveredDataReports(t *testing.T) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+
+	chain := testChainBase.shorten(blockCacheMaxItems - 15)
+	peer := &reportingTesterPeer{downloadTesterPeer: &downloadTesterPeer{dl: tester, id: "peer", chain: chain}}
+	tester.lock.Lock()
+	tester.peers["peer"] = peer.downloadTesterPeer
+	tester.lock.Unlock()
+	if err := tester.downloader.RegisterPeer("peer", eth.ETH66, peer); err != nil {
+		t.Fatalf("failed to register peer: %v", err)
+	}
+	if err := tester.sync("peer", nil, FastSync); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	assertOwnChain(t, tester, chain.len())
+	if atomic.LoadInt32(&peer.delivered) == 0 {
+		t.Fatalf("peer not credited for delivered data")
+	}
+}
@@ -403,6 +403,9 @@ func (s *stateSync) loop() (err error) {
 				log.Warn("Node data write error", "err", err)
 				return err
 			}
+			if delivered > 0 {
+				req.peer.reportDelivered()
+			}
 		}
 	}
 	return nil
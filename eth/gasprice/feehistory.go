@@ -0,0 +1,304 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	errInvalidPercentile = errors.New("invalid reward percentile")
+	errRequestBeyondHead = errors.New("request beyond head block")
+	errMissingBlockData  = errors.New("block or receipts missing")
+	errReceiptsMismatch  = errors.New("receipt count mismatch")
+)
+
+// maxBlockFetchers is the max number of goroutines to spin up to pull blocks
+// for the fee history calculation (mostly relevant for LES).
+const maxBlockFetchers = 4
+
+// blockFees represents a single block for processing
+type blockFees struct {
+	// set by the caller
+	blockNumber uint64
+	header      *types.Header
+	block       *types.Block // only set if reward percentiles are requested
+	receipts    types.Receipts
+	// filled by processBlock
+	results processedFees
+	err     error
+}
+
+// processedFees contains the results of a processed block and is also used for
+// caching.
+type processedFees struct {
+	reward               []*big.Int
+	baseFee, nextBaseFee *big.Int
+	gasUsedRatio         float64
+}
+
+// historyCacheKey identifies the processed fees of a block for a given set of
+// reward percentiles. Blocks are keyed by hash to stay correct across reorgs.
+type historyCacheKey struct {
+	hash        common.Hash
+	percentiles string
+}
+
+// txGasAndReward is sorted in ascending order based on reward
+type (
+	txGasAndReward struct {
+		gasUsed uint64
+		reward  *big.Int
+	}
+	sortGasAndReward []txGasAndReward
+)
+
+func (s sortGasAndReward) Len() int { return len(s) }
+func (s sortGasAndReward) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+func (s sortGasAndReward) Less(i, j int) bool {
+	return s[i].reward.Cmp(s[j].reward) < 0
+}
+
+// processBlock takes a blockFees structure with the blockNumber, the header and,
+// if reward percentiles are requested, the block and receipts fields filled in
+// and calculates the results from them. The err field is set if the block data
+// needed for the rewards is missing or inconsistent.
+func (oracle *Oracle) processBlock(bf *blockFees, percentiles []float64) {
+	chainconfig := oracle.backend.ChainConfig()
+	if bf.results.baseFee = bf.header.BaseFee; bf.results.baseFee == nil {
+		bf.results.baseFee = new(big.Int)
+	}
+	if chainconfig.IsLondon(new(big.Int).SetUint64(bf.blockNumber + 1)) {
+		bf.results.nextBaseFee = misc.CalcBaseFee(chainconfig, bf.header)
+	} else {
+		bf.results.nextBaseFee = new(big.Int)
+	}
+	bf.results.gasUsedRatio = float64(bf.header.GasUsed) / float64(bf.header.GasLimit)
+	if len(percentiles) == 0 {
+		// rewards were not requested, return null
+		return
+	}
+	if bf.block == nil || (bf.receipts == nil && len(bf.block.Transactions()) != 0) {
+		bf.err = fmt.Errorf("%w: block %d", errMissingBlockData, bf.blockNumber)
+		return
+	}
+	if len(bf.receipts) != len(bf.block.Transactions()) {
+		bf.err = fmt.Errorf("%w: block %d: have %d, want %d", errReceiptsMismatch, bf.blockNumber, len(bf.receipts), len(bf.block.Transactions()))
+		return
+	}
+	bf.results.reward = make([]*big.Int, len(percentiles))
+	if len(bf.block.Transactions()) == 0 {
+		// return an all zero row if there are no transactions to gather data from
+		for i := range bf.results.reward {
+			bf.results.reward[i] = new(big.Int)
+		}
+		return
+	}
+	sorter := make(sortGasAndReward, len(bf.block.Transactions()))
+	for i, tx := range bf.block.Transactions() {
+		reward, _ := tx.EffectiveGasTip(bf.block.BaseFee())
+		sorter[i] = txGasAndReward{gasUsed: bf.receipts[i].GasUsed, reward: reward}
+	}
+	sort.Sort(sorter)
+
+	var txIndex int
+	sumGasUsed := sorter[0].gasUsed
+
+	for i, p := range percentiles {
+		thresholdGasUsed := uint64(float64(bf.block.GasUsed()) * p / 100)
+		for sumGasUsed < thresholdGasUsed && txIndex < len(bf.block.Transactions())-1 {
+			txIndex++
+			sumGasUsed += sorter[txIndex].gasUsed
+		}
+		bf.results.reward[i] = sorter[txIndex].reward
+	}
+}
+
+// resolveBlockRange resolves the specified block range to absolute block numbers
+// while also enforcing backend specific limitations. The pending block is not
+// tracked by the oracle, requests for it are answered from the latest one.
+// Note: an error is only returned if retrieving the head header has failed. If
+// there are no retrievable blocks in the specified range then zero block count
+// is returned with no error.
+func (oracle *Oracle) resolveBlockRange(ctx context.Context, lastBlock rpc.BlockNumber, blocks int) (uint64, int, error) {
+	head, err := oracle.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return 0, 0, err
+	}
+	if head == nil {
+		return 0, 0, nil
+	}
+	headBlock := rpc.BlockNumber(head.Number.Uint64())
+	if lastBlock == rpc.PendingBlockNumber || lastBlock == rpc.LatestBlockNumber {
+		lastBlock = headBlock
+	} else if lastBlock > headBlock {
+		return 0, 0, fmt.Errorf("%w: requested %d, head %d", errRequestBeyondHead, lastBlock, headBlock)
+	}
+	// Ensure not trying to retrieve before genesis
+	if rpc.BlockNumber(blocks) > lastBlock+1 {
+		blocks = int(lastBlock + 1)
+	}
+	return uint64(lastBlock), blocks, nil
+}
+
+// FeeHistory returns data relevant for fee estimation based on the specified range of blocks.
+// The range can be specified either with absolute block numbers or ending with the latest
+// block. The number of blocks is capped at the configured maximum history, which is larger
+// when no reward percentiles are requested. The first block of the actually processed range
+// is returned to avoid ambiguity when parts of the requested range are not available or
+// when the head has changed during processing this request.
+// Three arrays are returned based on the processed blocks:
+//   - reward: the requested percentiles of effective priority fees per gas of transactions in each
+//     block, sorted in ascending order and weighted by gas used.
+//   - baseFee: base fee per gas in the given block
+//   - gasUsedRatio: gasUsed/gasLimit in the given block
+//
+// Note: baseFee includes the next block after the newest of the returned range, because this
+// value can be derived from the newest block.
+func (oracle *Oracle) FeeHistory(ctx context.Context, blocks int, unresolvedLastBlock rpc.BlockNumber, rewardPercentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, error) {
+	if blocks < 1 {
+		return common.Big0, nil, nil, nil, nil // returning with no data and no error means there are no retrievable blocks
+	}
+	maxFeeHistory := oracle.maxHeaderHistory
+	if len(rewardPercentiles) != 0 {
+		maxFeeHistory = oracle.maxBlockHistory
+	}
+	if blocks > maxFeeHistory {
+		log.Warn("Sanitizing fee history length", "requested", blocks, "truncated", maxFeeHistory)
+		blocks = maxFeeHistory
+	}
+	for i, p := range rewardPercentiles {
+		if p < 0 || p > 100 {
+			return common.Big0, nil, nil, nil, fmt.Errorf("%w: %f", errInvalidPercentile, p)
+		}
+		if i > 0 && p < rewardPercentiles[i-1] {
+			return common.Big0, nil, nil, nil, fmt.Errorf("%w: #%d:%f > #%d:%f", errInvalidPercentile, i-1, rewardPercentiles[i-1], i, p)
+		}
+	}
+	lastBlock, blocks, err := oracle.resolveBlockRange(ctx, unresolvedLastBlock, blocks)
+	if err != nil || blocks == 0 {
+		return common.Big0, nil, nil, nil, err
+	}
+	oldestBlock := lastBlock + 1 - uint64(blocks)
+
+	// Processed blocks are cached per requested percentile set
+	percentileKey := make([]byte, 8*len(rewardPercentiles))
+	for i, p := range rewardPercentiles {
+		binary.LittleEndian.PutUint64(percentileKey[i*8:], math.Float64bits(p))
+	}
+	var (
+		next    = oldestBlock
+		results = make(chan *blockFees, blocks)
+	)
+	for i := 0; i < maxBlockFetchers && i < blocks; i++ {
+		go func() {
+			for {
+				// Retrieve the next block number to fetch with this goroutine
+				blockNumber := atomic.AddUint64(&next, 1) - 1
+				if blockNumber > lastBlock {
+					return
+				}
+				fees := &blockFees{blockNumber: blockNumber}
+				oracle.fetchBlockFees(ctx, fees, rewardPercentiles, string(percentileKey))
+				results <- fees
+			}
+		}()
+	}
+	var (
+		reward       = make([][]*big.Int, blocks)
+		baseFee      = make([]*big.Int, blocks+1)
+		gasUsedRatio = make([]float64, blocks)
+		firstMissing = blocks
+	)
+	for ; blocks > 0; blocks-- {
+		fees := <-results
+		if fees.err != nil {
+			return common.Big0, nil, nil, nil, fees.err
+		}
+		i := int(fees.blockNumber - oldestBlock)
+		if fees.results.baseFee != nil {
+			reward[i], baseFee[i], baseFee[i+1], gasUsedRatio[i] = fees.results.reward, fees.results.baseFee, fees.results.nextBaseFee, fees.results.gasUsedRatio
+		} else {
+			// getting no block and no error means we are requesting into the future (might happen because of a reorg)
+			if i < firstMissing {
+				firstMissing = i
+			}
+		}
+	}
+	if firstMissing == 0 {
+		return common.Big0, nil, nil, nil, nil
+	}
+	if len(rewardPercentiles) != 0 {
+		reward = reward[:firstMissing]
+	} else {
+		reward = nil
+	}
+	baseFee, gasUsedRatio = baseFee[:firstMissing+1], gasUsedRatio[:firstMissing]
+	return new(big.Int).SetUint64(oldestBlock), reward, baseFee, gasUsedRatio, nil
+}
+
+// fetchBlockFees retrieves and processes a single block for a fee history query,
+// serving it from the cache if it was already processed for the same percentiles.
+// The results are left empty if the block is not available.
+func (oracle *Oracle) fetchBlockFees(ctx context.Context, fees *blockFees, percentiles []float64, percentileKey string) {
+	fees.header, fees.err = oracle.backend.HeaderByNumber(ctx, rpc.BlockNumber(fees.blockNumber))
+	if fees.header == nil || fees.err != nil {
+		return
+	}
+	key := historyCacheKey{hash: fees.header.Hash(), percentiles: percentileKey}
+	if cached, ok := oracle.historyCache.Get(key); ok {
+		fees.results = cached.(processedFees)
+		return
+	}
+	if len(percentiles) != 0 {
+		fees.block, fees.err = oracle.backend.BlockByNumber(ctx, rpc.BlockNumber(fees.blockNumber))
+		if fees.block == nil || fees.err != nil {
+			return
+		}
+		// The chain might have been reorged since the header was retrieved,
+		// make sure the processed data is consistent
+		fees.header = fees.block.Header()
+		key.hash = fees.block.Hash()
+
+		fees.receipts, fees.err = oracle.backend.GetReceipts(ctx, key.hash)
+		if fees.err != nil {
+			return
+		}
+	}
+	// Receipts might be pruned or not indexed yet, don't cache the results of
+	// blocks whose rewards couldn't be calculated
+	oracle.processBlock(fees, percentiles)
+	if fees.err != nil {
+		return
+	}
+	oracle.historyCache.Add(key, fees.results)
+}
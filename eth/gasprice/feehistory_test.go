@@ -0,0 +1,176 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestFeeHistory(t *testing.T) {
+	var cases = []struct {
+		maxHeader, maxBlock int
+		count               int
+		last                rpc.BlockNumber
+		percent             []float64
+		expFirst            uint64
+		expCount            int
+		expErr              error
+	}{
+		{1000, 1000, 10, 30, nil, 21, 10, nil},
+		{1000, 1000, 10, 30, []float64{0, 10}, 21, 10, nil},
+		{1000, 1000, 10, 30, []float64{20, 10}, 0, 0, errInvalidPercentile},
+		{1000, 1000, 10, 30, []float64{101}, 0, 0, errInvalidPercentile},
+		{1000, 1000, 1000000000, 30, nil, 0, 31, nil},
+		{1000, 1000, 1000000000, rpc.LatestBlockNumber, nil, 0, 33, nil},
+		{1000, 1000, 10, 40, nil, 0, 0, errRequestBeyondHead},
+		{1000, 1000, 10, 40, nil, 0, 0, errRequestBeyondHead},
+		{20, 2, 100, rpc.LatestBlockNumber, nil, 13, 20, nil},
+		{20, 2, 100, rpc.LatestBlockNumber, []float64{0, 10}, 31, 2, nil},
+		{20, 2, 100, 32, []float64{0, 10}, 31, 2, nil},
+		{1000, 1000, 1, rpc.PendingBlockNumber, nil, 32, 1, nil},
+		{1000, 1000, 2, rpc.PendingBlockNumber, []float64{0, 10}, 31, 2, nil},
+		{1000, 1000, 0, rpc.LatestBlockNumber, nil, 0, 0, nil},
+	}
+	backend := newTestBackend(t, big.NewInt(16))
+	for i, c := range cases {
+		config := Config{
+			MaxHeaderHistory: c.maxHeader,
+			MaxBlockHistory:  c.maxBlock,
+		}
+		oracle := NewOracle(backend, config)
+
+		first, reward, baseFee, ratio, err := oracle.FeeHistory(context.Background(), c.count, c.last, c.percent)
+
+		expReward := c.expCount
+		if len(c.percent) == 0 {
+			expReward = 0
+		}
+		expBaseFee := c.expCount
+		if expBaseFee != 0 {
+			expBaseFee++
+		}
+		if first.Uint64() != c.expFirst {
+			t.Fatalf("Test case %d: first block mismatch, want %d, got %d", i, c.expFirst, first)
+		}
+		if len(reward) != expReward {
+			t.Fatalf("Test case %d: reward array length mismatch, want %d, got %d", i, expReward, len(reward))
+		}
+		if len(baseFee) != expBaseFee {
+			t.Fatalf("Test case %d: baseFee array length mismatch, want %d, got %d", i, expBaseFee, len(baseFee))
+		}
+		if len(ratio) != c.expCount {
+			t.Fatalf("Test case %d: gasUsedRatio array length mismatch, want %d, got %d", i, c.expCount, len(ratio))
+		}
+		if err != c.expErr && !errors.Is(err, c.expErr) {
+			t.Fatalf("Test case %d: error mismatch, want %v, got %v", i, c.expErr, err)
+		}
+	}
+}
+
+// Tests that the fee history values match the ones derived from the chain, and
+// that repeated queries are served from the cache.
+func TestFeeHistoryValues(t *testing.T) {
+	backend := newTestBackend(t, big.NewInt(16))
+	oracle := NewOracle(backend, Config{MaxHeaderHistory: 1000, MaxBlockHistory: 1000})
+
+	for run := 0; run < 2; run++ {
+		first, reward, baseFee, ratio, err := oracle.FeeHistory(context.Background(), 20, rpc.LatestBlockNumber, []float64{0, 50, 100})
+		if err != nil {
+			t.Fatalf("run %d: failed to retrieve fee history: %v", run, err)
+		}
+		if first.Uint64() != 13 {
+			t.Fatalf("run %d: first block mismatch: have %d, want 13", run, first)
+		}
+		for i := 0; i < 20; i++ {
+			var (
+				number = first.Uint64() + uint64(i)
+				block  = backend.chain.GetBlockByNumber(number)
+				fee    = block.BaseFee()
+			)
+			if fee == nil {
+				fee = new(big.Int)
+			}
+			if baseFee[i].Cmp(fee) != 0 {
+				t.Fatalf("run %d: block %d base fee mismatch: have %v, want %v", run, number, baseFee[i], fee)
+			}
+			if want := float64(block.GasUsed()) / float64(block.GasLimit()); ratio[i] != want {
+				t.Fatalf("run %d: block %d gas used ratio mismatch: have %v, want %v", run, number, ratio[i], want)
+			}
+			// Every block contains a single legacy transaction paying (n+1) GWei
+			tip := new(big.Int).Sub(big.NewInt(int64(number)*params.GWei), fee)
+			for j := range reward[i] {
+				if reward[i][j].Cmp(tip) != 0 {
+					t.Fatalf("run %d: block %d reward %d mismatch: have %v, want %v", run, number, j, reward[i][j], tip)
+				}
+			}
+		}
+		head := backend.chain.CurrentBlock()
+		if next := misc.CalcBaseFee(backend.ChainConfig(), head.Header()); baseFee[20].Cmp(next) != 0 {
+			t.Fatalf("run %d: next base fee mismatch: have %v, want %v", run, baseFee[20], next)
+		}
+		if have := oracle.historyCache.Len(); have != 20 {
+			t.Fatalf("run %d: cached block count mismatch: have %d, want 20", run, have)
+		}
+	}
+}
+
+// receiptsBackend is a test backend serving the same receipts for all blocks.
+type receiptsBackend struct {
+	*testBackend
+	receipts types.Receipts
+}
+
+func (b *receiptsBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return b.receipts, nil
+}
+
+// Tests that fee history rewards are not served, nor cached, for blocks whose
+// receipts are missing.
+func TestFeeHistoryMissingReceipts(t *testing.T) {
+	backend := &receiptsBackend{testBackend: newTestBackend(t, big.NewInt(16))}
+	oracle := NewOracle(backend, Config{MaxHeaderHistory: 1000, MaxBlockHistory: 1000})
+
+	if _, _, _, _, err := oracle.FeeHistory(context.Background(), 2, rpc.LatestBlockNumber, []float64{50}); !errors.Is(err, errMissingBlockData) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errMissingBlockData)
+	}
+	if have := oracle.historyCache.Len(); have != 0 {
+		t.Fatalf("cached block count mismatch: have %d, want 0", have)
+	}
+}
+
+// Tests that fee history rewards are not served for blocks whose receipts don't
+// match their transactions.
+func TestFeeHistoryReceiptsMismatch(t *testing.T) {
+	backend := &receiptsBackend{testBackend: newTestBackend(t, big.NewInt(16)), receipts: types.Receipts{}}
+	oracle := NewOracle(backend, Config{MaxHeaderHistory: 1000, MaxBlockHistory: 1000})
+
+	if _, _, _, _, err := oracle.FeeHistory(context.Background(), 2, rpc.LatestBlockNumber, []float64{50}); !errors.Is(err, errReceiptsMismatch) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errReceiptsMismatch)
+	}
+	if have := oracle.historyCache.Len(); have != 0 {
+		t.Fatalf("cached block count mismatch: have %d, want 0", have)
+	}
+}
@@ -184,7 +184,8 @@ func newHandler(config *handlerConfig) (*handler, error) {
 	if atomic.LoadUint32(&h.fastSync) == 1 && atomic.LoadUint32(&h.snapSync) == 0 {
 		h.stateBloom = trie.NewSyncBloom(config.BloomCache, config.Database)
 	}
-	h.downloader = downloader.New(h.checkpointNumber, config.Database, h.stateBloom, h.eventMux, h.chain, nil, h.removePeer)
+	// The downloader drops peers which stall or fail to deliver a usable chain.
+	h.downloader = downloader.New(h.checkpointNumber, config.Database, h.stateBloom, h.eventMux, h.chain, nil, h.dropPeer(p2p.PeerUselessResponse))
 
 	// Construct the fetcher (short sync)
 	validator := func(header *types.Header) error {
@@ -219,7 +220,7 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		}
 		return n, err
 	}
-	h.blockFetcher = fetcher.NewBlockFetcher(false, nil, h.chain.GetBlockByHash, validator, h.BroadcastBlock, heighter, nil, inserter, h.removePeer)
+	h.blockFetcher = fetcher.NewBlockFetcher(false, nil, h.chain.GetBlockByHash, validator, h.BroadcastBlock, heighter, nil, inserter, h.dropPeer(p2p.PeerInvalidBlock))
 
 	fetchTx := func(peer string, hashes []common.Hash) error {
 		p := h.peers.peer(peer)
@@ -319,6 +320,7 @@ func (h *handler) runEthPeer(peer *eth.Peer, handler eth.Handler) error {
 		// Start a timer to disconnect if the peer doesn't reply in time
 		p.syncDrop = time.AfterFunc(syncChallengeTimeout, func() {
 			peer.Log().Warn("Checkpoint challenge timed out, dropping", "addr", peer.RemoteAddr(), "type", peer.Name())
+			peer.Report(p2p.PeerTimeout)
 			h.removePeer(peer.ID())
 		})
 		// Make sure it's cleaned up if the peer dies off
@@ -388,6 +390,22 @@ func (h *handler) removePeer(id string) {
 	peer.Peer.Disconnect(p2p.DiscUselessPeer)
 }
 
+// reportPeer records behavior of a peer in its reputation score.
+func (h *handler) reportPeer(id string, b p2p.PeerBehavior) {
+	if peer := h.peers.peer(id); peer != nil {
+		peer.Report(b)
+	}
+}
+
+// dropPeer returns a callback which records the given behavior in the reputation
+// score of a misbehaving peer, then removes it.
+func (h *handler) dropPeer(b p2p.PeerBehavior) func(id string) {
+	return func(id string) {
+		h.reportPeer(id, b)
+		h.removePeer(id)
+	}
+}
+
 func (h *handler) Start(maxPeers int) {
 	h.maxPeers = maxPeers
 
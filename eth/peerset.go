@@ -18,6 +18,7 @@ package eth
 
 import (
 	"errors"
+	"math"
 	"math/big"
 	"sync"
 
@@ -232,6 +233,12 @@ func (ps *peerSet) snapLen() int {
 // peerWithHighestTD retrieves the known peer with the currently highest total
 // difficulty.
 func (ps *peerSet) peerWithHighestTD() *eth.Peer {
+	return ps.peerWithHighestTDUpTo(math.MaxUint32)
+}
+
+// peerWithHighestTDUpTo retrieves the known peer with the currently highest total
+// difficulty among the ones running at most the given eth protocol version.
+func (ps *peerSet) peerWithHighestTDUpTo(version uint) *eth.Peer {
 	ps.lock.RLock()
 	defer ps.lock.RUnlock()
 
@@ -240,6 +247,9 @@ func (ps *peerSet) peerWithHighestTD() *eth.Peer {
 		bestTd   *big.Int
 	)
 	for _, p := range ps.peers {
+		if p.Version() > version {
+			continue
+		}
 		if _, td := p.Head(); bestPeer == nil || td.Cmp(bestTd) > 0 {
 			bestPeer, bestTd = p.Peer, td
 		}
@@ -17,10 +17,12 @@
 package eth
 
 import (
+	"errors"
 	"math"
 	"math/big"
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
@@ -112,6 +114,7 @@ func (b *testBackend) Handle(*Peer, Packet) error {
 // Tests that block headers can be retrieved from a remote chain based on user queries.
 func TestGetBlockHeaders65(t *testing.T) { testGetBlockHeaders(t, ETH65) }
 func TestGetBlockHeaders66(t *testing.T) { testGetBlockHeaders(t, ETH66) }
+func TestGetBlockHeaders67(t *testing.T) { testGetBlockHeaders(t, ETH67) }
 
 func testGetBlockHeaders(t *testing.T, protocol uint) {
 	t.Parallel()
@@ -301,6 +304,7 @@ func testGetBlockHeaders(t *testing.T, protocol uint) {
 // Tests that block contents can be retrieved from a remote chain based on their hashes.
 func TestGetBlockBodies65(t *testing.T) { testGetBlockBodies(t, ETH65) }
 func TestGetBlockBodies66(t *testing.T) { testGetBlockBodies(t, ETH66) }
+func TestGetBlockBodies67(t *testing.T) { testGetBlockBodies(t, ETH67) }
 
 func testGetBlockBodies(t *testing.T, protocol uint) {
 	t.Parallel()
@@ -505,9 +509,37 @@ func testGetNodeData(t *testing.T, protocol uint) {
 	}
 }
 
+// Tests that node data requests are rejected on eth/67, which doesn't have them.
+func TestGetNodeData67(t *testing.T) {
+	t.Parallel()
+
+	backend := newTestBackend(4)
+	defer backend.close()
+
+	peer, errc := newTestPeer("peer", ETH67, backend)
+	defer peer.close()
+
+	p2p.Send(peer.app, GetNodeDataMsg, GetNodeDataPacket66{
+		RequestId:         123,
+		GetNodeDataPacket: []common.Hash{backend.chain.CurrentBlock().Root()},
+	})
+	select {
+	case err := <-errc:
+		if !errors.Is(err, errInvalidMsgCode) {
+			t.Fatalf("wrong error: have %v, want %v", err, errInvalidMsgCode)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("peer not dropped after node data request")
+	}
+	if err := peer.RequestNodeData(nil); !errors.Is(err, errNodeDataUnsupported) {
+		t.Fatalf("wrong node data request error: have %v, want %v", err, errNodeDataUnsupported)
+	}
+}
+
 // Tests that the transaction receipts can be retrieved based on hashes.
 func TestGetBlockReceipts65(t *testing.T) { testGetBlockReceipts(t, ETH65) }
 func TestGetBlockReceipts66(t *testing.T) { testGetBlockReceipts(t, ETH66) }
+func TestGetBlockReceipts67(t *testing.T) { testGetBlockReceipts(t, ETH67) }
 
 func testGetBlockReceipts(t *testing.T, protocol uint) {
 	t.Parallel()
@@ -584,3 +616,111 @@ func testGetBlockReceipts(t *testing.T, protocol uint) {
 		}
 	}
 }
+
+// Tests that requests for pruned block bodies and receipts are answered up to
+// the first pruned block, while the retained headers are still served.
+func TestGetPrunedHistory65(t *testing.T) { testGetPrunedHistory(t, ETH65) }
+func TestGetPrunedHistory66(t *testing.T) { testGetPrunedHistory(t, ETH66) }
+func TestGetPrunedHistory67(t *testing.T) { testGetPrunedHistory(t, ETH67) }
+
+func testGetPrunedHistory(t *testing.T, protocol uint) {
+	t.Parallel()
+
+	// Import a chain with a transaction in each block straight into the ancient
+	// store, and prune the history of the first few blocks
+	db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database with ancient backend: %v", err)
+	}
+	var (
+		gspec  = &core.Genesis{Config: params.TestChainConfig, Alloc: core.GenesisAlloc{testAddr: {Balance: big.NewInt(1000000)}}}
+		signer = types.HomesteadSigner{}
+	)
+	gspec.MustCommit(db)
+
+	gendb := rawdb.NewMemoryDatabase()
+	blocks, receipts := core.GenerateChain(gspec.Config, gspec.MustCommit(gendb), ethash.NewFaker(), gendb, 8, func(i int, block *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(testAddr), common.Address{0x01}, big.NewInt(1), params.TxGas, nil, nil), signer, testKey)
+		block.AddTx(tx)
+	})
+
+	chain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	if n, err := chain.InsertHeaderChain(headers, 1); err != nil {
+		t.Fatalf("failed to insert header %d: %v", n, err)
+	}
+	if n, err := chain.InsertReceiptChain(blocks, receipts, uint64(len(blocks))); err != nil {
+		t.Fatalf("failed to insert receipt %d: %v", n, err)
+	}
+	if err := rawdb.PruneHistory(db, 5, nil); err != nil {
+		t.Fatalf("failed to prune history: %v", err)
+	}
+	txconfig := core.DefaultTxPoolConfig
+	txconfig.Journal = "" // Don't litter the disk with test journals
+
+	backend := &testBackend{
+		db:     db,
+		chain:  chain,
+		txpool: core.NewTxPool(txconfig, params.TestChainConfig, chain),
+	}
+	defer backend.close()
+
+	peer, _ := newTestPeer("peer", protocol, backend)
+	defer peer.close()
+
+	// Request an available block, a pruned one, and another available one
+	var (
+		hashes      = []common.Hash{blocks[5].Hash(), blocks[1].Hash(), blocks[6].Hash()}
+		bodies      = []*BlockBody{{Transactions: blocks[5].Transactions(), Uncles: blocks[5].Uncles()}}
+		receiptsRes = [][]*types.Receipt{receipts[5]}
+		headersRes  = []*types.Header{blocks[1].Header()}
+	)
+	if protocol <= ETH65 {
+		p2p.Send(peer.app, GetBlockBodiesMsg, hashes)
+		if err := p2p.ExpectMsg(peer.app, BlockBodiesMsg, bodies); err != nil {
+			t.Fatalf("bodies mismatch: %v", err)
+		}
+		p2p.Send(peer.app, GetReceiptsMsg, hashes)
+		if err := p2p.ExpectMsg(peer.app, ReceiptsMsg, receiptsRes); err != nil {
+			t.Fatalf("receipts mismatch: %v", err)
+		}
+		p2p.Send(peer.app, GetBlockHeadersMsg, &GetBlockHeadersPacket{Origin: HashOrNumber{Hash: hashes[1]}, Amount: 1})
+		if err := p2p.ExpectMsg(peer.app, BlockHeadersMsg, headersRes); err != nil {
+			t.Fatalf("headers mismatch: %v", err)
+		}
+	} else {
+		p2p.Send(peer.app, GetBlockBodiesMsg, GetBlockBodiesPacket66{
+			RequestId:            123,
+			GetBlockBodiesPacket: hashes,
+		})
+		if err := p2p.ExpectMsg(peer.app, BlockBodiesMsg, BlockBodiesPacket66{
+			RequestId:         123,
+			BlockBodiesPacket: bodies,
+		}); err != nil {
+			t.Fatalf("bodies mismatch: %v", err)
+		}
+		p2p.Send(peer.app, GetReceiptsMsg, GetReceiptsPacket66{
+			RequestId:         123,
+			GetReceiptsPacket: hashes,
+		})
+		if err := p2p.ExpectMsg(peer.app, ReceiptsMsg, ReceiptsPacket66{
+			RequestId:      123,
+			ReceiptsPacket: receiptsRes,
+		}); err != nil {
+			t.Fatalf("receipts mismatch: %v", err)
+		}
+		p2p.Send(peer.app, GetBlockHeadersMsg, GetBlockHeadersPacket66{
+			RequestId:             123,
+			GetBlockHeadersPacket: &GetBlockHeadersPacket{Origin: HashOrNumber{Hash: hashes[1]}, Amount: 1},
+		})
+		if err := p2p.ExpectMsg(peer.app, BlockHeadersMsg, BlockHeadersPacket66{
+			RequestId:          123,
+			BlockHeadersPacket: headersRes,
+		}); err != nil {
+			t.Fatalf("headers mismatch: %v", err)
+		}
+	}
+}
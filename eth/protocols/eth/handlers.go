@@ -21,6 +21,7 @@ import (
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -35,7 +36,7 @@ func handleGetBlockHeaders(backend Backend, msg Decoder, peer *Peer) error {
 		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
 	}
 	response := answerGetBlockHeadersQuery(backend, &query, peer)
-	return peer.SendBlockHeaders(response)
+	return peer.SendBlockHeadersRLP(response)
 }
 
 // handleGetBlockHeaders66 is the eth/66 version of handleGetBlockHeaders
@@ -46,10 +47,18 @@ func handleGetBlockHeaders66(backend Backend, msg Decoder, peer *Peer) error {
 		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
 	}
 	response := answerGetBlockHeadersQuery(backend, query.GetBlockHeadersPacket, peer)
-	return peer.ReplyBlockHeaders(query.RequestId, response)
+	return peer.ReplyBlockHeadersRLP(query.RequestId, response)
 }
 
-func answerGetBlockHeadersQuery(backend Backend, query *GetBlockHeadersPacket, peer *Peer) []*types.Header {
+func answerGetBlockHeadersQuery(backend Backend, query *GetBlockHeadersPacket, peer *Peer) []rlp.RawValue {
+	if query.Skip == 0 {
+		// The fast path: when the request is for a contiguous segment of headers.
+		return serviceContiguousBlockHeaderQuery(backend.Chain(), query)
+	}
+	return serviceNonContiguousBlockHeaderQuery(backend.Chain(), query, peer)
+}
+
+func serviceNonContiguousBlockHeaderQuery(chain *core.BlockChain, query *GetBlockHeadersPacket, peer *Peer) []rlp.RawValue {
 	hashMode := query.Origin.Hash != (common.Hash{})
 	first := true
 	maxNonCanonical := uint64(100)
@@ -57,7 +66,7 @@ func answerGetBlockHeadersQuery(backend Backend, query *GetBlockHeadersPacket, p
 	// Gather headers until the fetch or network limits is reached
 	var (
 		bytes   common.StorageSize
-		headers []*types.Header
+		headers []rlp.RawValue
 		unknown bool
 		lookups int
 	)
@@ -69,20 +78,24 @@ func answerGetBlockHeadersQuery(backend Backend, query *GetBlockHeadersPacket, p
 		if hashMode {
 			if first {
 				first = false
-				origin = backend.Chain().GetHeaderByHash(query.Origin.Hash)
+				origin = chain.GetHeaderByHash(query.Origin.Hash)
 				if origin != nil {
 					query.Origin.Number = origin.Number.Uint64()
 				}
 			} else {
-				origin = backend.Chain().GetHeader(query.Origin.Hash, query.Origin.Number)
+				origin = chain.GetHeader(query.Origin.Hash, query.Origin.Number)
 			}
 		} else {
-			origin = backend.Chain().GetHeaderByNumber(query.Origin.Number)
+			origin = chain.GetHeaderByNumber(query.Origin.Number)
 		}
 		if origin == nil {
 			break
 		}
-		headers = append(headers, origin)
+		rlpData, err := rlp.EncodeToBytes(origin)
+		if err != nil {
+			log.Crit("Unable to encode our own headers", "err", err)
+		}
+		headers = append(headers, rlp.RawValue(rlpData))
 		bytes += estHeaderSize
 
 		// Advance to the next header of the query
@@ -93,7 +106,7 @@ func answerGetBlockHeadersQuery(backend Backend, query *GetBlockHeadersPacket, p
 			if ancestor == 0 {
 				unknown = true
 			} else {
-				query.Origin.Hash, query.Origin.Number = backend.Chain().GetAncestor(query.Origin.Hash, query.Origin.Number, ancestor, &maxNonCanonical)
+				query.Origin.Hash, query.Origin.Number = chain.GetAncestor(query.Origin.Hash, query.Origin.Number, ancestor, &maxNonCanonical)
 				unknown = (query.Origin.Hash == common.Hash{})
 			}
 		case hashMode && !query.Reverse:
@@ -107,9 +120,9 @@ func answerGetBlockHeadersQuery(backend Backend, query *GetBlockHeadersPacket, p
 				peer.Log().Warn("GetBlockHeaders skip overflow attack", "current", current, "skip", query.Skip, "next", next, "attacker", infos)
 				unknown = true
 			} else {
-				if header := backend.Chain().GetHeaderByNumber(next); header != nil {
+				if header := chain.GetHeaderByNumber(next); header != nil {
 					nextHash := header.Hash()
-					expOldHash, _ := backend.Chain().GetAncestor(nextHash, next, query.Skip+1, &maxNonCanonical)
+					expOldHash, _ := chain.GetAncestor(nextHash, next, query.Skip+1, &maxNonCanonical)
 					if expOldHash == query.Origin.Hash {
 						query.Origin.Hash, query.Origin.Number = nextHash, next
 					} else {
@@ -135,6 +148,80 @@ func answerGetBlockHeadersQuery(backend Backend, query *GetBlockHeadersPacket, p
 	return headers
 }
 
+func serviceContiguousBlockHeaderQuery(chain *core.BlockChain, query *GetBlockHeadersPacket) []rlp.RawValue {
+	count := query.Amount
+	if count > maxHeadersServe {
+		count = maxHeadersServe
+	}
+	if count == 0 {
+		return nil
+	}
+	if query.Origin.Hash == (common.Hash{}) {
+		// Number mode, just return the canon chain segment. The backend
+		// delivers in [N, N-1, N-2..] descending order, so we need to
+		// accommodate for that.
+		from := query.Origin.Number
+		if !query.Reverse {
+			if from+count-1 < from {
+				return nil // Overflow, we certainly don't have these headers
+			}
+			from = from + count - 1
+		} else if from > chain.CurrentHeader().Number.Uint64() {
+			return nil // We don't have the origin header
+		}
+		headers := chain.GetHeadersFrom(from, count)
+		if !query.Reverse {
+			for i, j := 0, len(headers)-1; i < j; i, j = i+1, j-1 {
+				headers[i], headers[j] = headers[j], headers[i]
+			}
+		}
+		return headers
+	}
+	// Hash mode
+	var (
+		headers []rlp.RawValue
+		hash    = query.Origin.Hash
+		header  = chain.GetHeaderByHash(hash)
+	)
+	if header == nil {
+		// We don't even have the origin header
+		return headers
+	}
+	rlpData, _ := rlp.EncodeToBytes(header)
+	headers = append(headers, rlpData)
+
+	num := header.Number.Uint64()
+	if !query.Reverse {
+		// Theoretically, we are tasked to deliver header by hash H, and onwards.
+		// However, if H is not canon, we will be unable to deliver any
+		// descendants of H.
+		if chain.GetCanonicalHash(num) != hash {
+			return headers
+		}
+		descendants := chain.GetHeadersFrom(num+count-1, count-1)
+		for i, j := 0, len(descendants)-1; i < j; i, j = i+1, j-1 {
+			descendants[i], descendants[j] = descendants[j], descendants[i]
+		}
+		return append(headers, descendants...)
+	}
+	// Reverse mode, deliver the ancestors of H. If H is canon, its ancestors
+	// can be read in one go, otherwise follow the parent hashes.
+	if chain.GetCanonicalHash(num) == hash {
+		if num == 0 {
+			return headers
+		}
+		return append(headers, chain.GetHeadersFrom(num-1, count-1)...)
+	}
+	for i := uint64(1); i < count; i++ {
+		if header = chain.GetHeader(header.ParentHash, header.Number.Uint64()-1); header == nil {
+			break
+		}
+		rlpData, _ := rlp.EncodeToBytes(header)
+		headers = append(headers, rlpData)
+	}
+	return headers
+}
+
 func handleGetBlockBodies(backend Backend, msg Decoder, peer *Peer) error {
 	// Decode the block body retrieval message
 	var query GetBlockBodiesPacket
@@ -157,19 +244,27 @@ func handleGetBlockBodies66(backend Backend, msg Decoder, peer *Peer) error {
 
 func answerGetBlockBodiesQuery(backend Backend, query GetBlockBodiesPacket, peer *Peer) []rlp.RawValue {
 	// Gather blocks until the fetch or network limits is reached
+	if len(query) > 2*maxBodiesServe {
+		query = query[:2*maxBodiesServe]
+	}
 	var (
-		bytes  int
+		chain  = backend.Chain()
 		bodies []rlp.RawValue
 	)
-	for lookups, hash := range query {
-		if bytes >= softResponseLimit || len(bodies) >= maxBodiesServe ||
-			lookups >= 2*maxBodiesServe {
+	for i, data := range chain.GetBodiesRLP(query, softResponseLimit) {
+		if len(bodies) >= maxBodiesServe {
 			break
 		}
-		if data := backend.Chain().GetBodyRLP(hash); len(data) != 0 {
-			bodies = append(bodies, data)
-			bytes += len(data)
+		if len(data) == 0 {
+			// Pruned history is unavailable, cut the answer short instead of
+			// silently skipping, so the requester knows to look elsewhere
+			if chain.HistoryPruned(query[i]) {
+				peer.Log().Trace("Requested block body pruned", "hash", query[i])
+				break
+			}
+			continue
 		}
+		bodies = append(bodies, data)
 	}
 	return bodies
 }
@@ -258,6 +353,12 @@ func answerGetReceiptsQuery(backend Backend, query GetReceiptsPacket, peer *Peer
 		results := backend.Chain().GetReceiptsByHash(hash)
 		if results == nil {
 			if header := backend.Chain().GetHeaderByHash(hash); header == nil || header.ReceiptHash != types.EmptyRootHash {
+				// Pruned history is unavailable, cut the answer short instead
+				// of silently skipping, so the requester knows to look elsewhere
+				if backend.Chain().HistoryPruned(hash) {
+					peer.Log().Trace("Requested receipts pruned", "hash", hash)
+					break
+				}
 				continue
 			}
 		}
@@ -29,6 +29,7 @@ import (
 // Tests that handshake failures are detected and reported correctly.
 func TestHandshake65(t *testing.T) { testHandshake(t, ETH65) }
 func TestHandshake66(t *testing.T) { testHandshake(t, ETH66) }
+func TestHandshake67(t *testing.T) { testHandshake(t, ETH67) }
 
 func testHandshake(t *testing.T, protocol uint) {
 	t.Parallel()
@@ -359,6 +359,21 @@ func (p *Peer) ReplyBlockHeaders(id uint64, headers []*types.Header) error {
 	})
 }
 
+// SendBlockHeadersRLP sends a batch of block headers to the remote peer from
+// an already RLP encoded format.
+func (p *Peer) SendBlockHeadersRLP(headers []rlp.RawValue) error {
+	return p2p.Send(p.rw, BlockHeadersMsg, headers) // Not packed into BlockHeadersPacket to avoid RLP decoding
+}
+
+// ReplyBlockHeadersRLP is the eth/66 version of SendBlockHeadersRLP.
+func (p *Peer) ReplyBlockHeadersRLP(id uint64, headers []rlp.RawValue) error {
+	// Not packed into BlockHeadersPacket to avoid RLP decoding
+	return p2p.Send(p.rw, BlockHeadersMsg, BlockHeadersRLPPacket66{
+		RequestId:             id,
+		BlockHeadersRLPPacket: headers,
+	})
+}
+
 // SendBlockBodiesRLP sends a batch of block contents to the remote peer from
 // an already RLP encoded format.
 func (p *Peer) SendBlockBodiesRLP(bodies []rlp.RawValue) error {
@@ -497,8 +512,12 @@ func (p *Peer) RequestBodies(hashes []common.Hash) error {
 }
 
 // RequestNodeData fetches a batch of arbitrary data from a node's known state
-// data, corresponding to the specified hashes.
+// data, corresponding to the specified hashes. It is not available on eth/67
+// and later.
 func (p *Peer) RequestNodeData(hashes []common.Hash) error {
+	if p.Version() >= ETH67 {
+		return errNodeDataUnsupported
+	}
 	p.Log().Debug("Fetching batch of state data", "count", len(hashes))
 	if p.Version() >= ETH66 {
 		id := rand.Uint64()
@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
@@ -37,6 +38,7 @@ import (
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/light"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
 	"golang.org/x/crypto/sha3"
@@ -75,6 +77,9 @@ const (
 	// and waste round trip times. If it's too high, we're capping responses and
 	// waste bandwidth.
 	maxTrieRequestCount = 256
+
+	// logInterval is the minimum time between two sync progress reports.
+	logInterval = 8 * time.Second
 )
 
 var (
@@ -344,6 +349,40 @@ type syncProgress struct {
 	BytecodeHealBytes  common.StorageSize // Number of bytecodes persisted to disk
 	BytecodeHealDups   uint64             // Number of bytecodes already processed
 	BytecodeHealNops   uint64             // Number of bytecodes not requested
+	AccountHealed      uint64             // Number of accounts downloaded during the healing stage
+	AccountHealedBytes common.StorageSize // Number of raw account bytes persisted to disk during the healing stage
+	StorageHealed      uint64             // Number of storage slots downloaded during the healing stage
+	StorageHealedBytes common.StorageSize // Number of raw storage bytes persisted to disk during the healing stage
+
+	// The suspended healing phase, only valid for the same state root
+	HealRoot     common.Hash        // State root being healed
+	HealRequests []trie.SyncRequest // Pending trie node and bytecode requests of the healer
+}
+
+// SyncStatus is a summary of the progress of a snap sync, as reported by the
+// debug_snapSyncStatus RPC method.
+type SyncStatus struct {
+	Root    common.Hash `json:"root"`    // State root being synced
+	Healing bool        `json:"healing"` // Whether the sync is in the healing phase
+
+	AccountTasks   hexutil.Uint64 `json:"accountTasks"`   // Number of account ranges left to sync
+	StorageTasks   hexutil.Uint64 `json:"storageTasks"`   // Number of chunked storage ranges left to sync
+	AccountSynced  hexutil.Uint64 `json:"accountSynced"`  // Number of accounts downloaded
+	AccountBytes   hexutil.Uint64 `json:"accountBytes"`   // Number of account trie bytes persisted to disk
+	BytecodeSynced hexutil.Uint64 `json:"bytecodeSynced"` // Number of bytecodes downloaded
+	BytecodeBytes  hexutil.Uint64 `json:"bytecodeBytes"`  // Number of bytecode bytes downloaded
+	StorageSynced  hexutil.Uint64 `json:"storageSynced"`  // Number of storage slots downloaded
+	StorageBytes   hexutil.Uint64 `json:"storageBytes"`   // Number of storage trie bytes persisted to disk
+
+	TrienodeHealSynced hexutil.Uint64 `json:"trienodeHealSynced"` // Number of state trie nodes downloaded
+	TrienodeHealBytes  hexutil.Uint64 `json:"trienodeHealBytes"`  // Number of state trie bytes persisted to disk
+	BytecodeHealSynced hexutil.Uint64 `json:"bytecodeHealSynced"` // Number of bytecodes downloaded
+	BytecodeHealBytes  hexutil.Uint64 `json:"bytecodeHealBytes"`  // Number of bytecodes persisted to disk
+	AccountHealed      hexutil.Uint64 `json:"accountHealed"`      // Number of accounts downloaded during healing
+	StorageHealed      hexutil.Uint64 `json:"storageHealed"`      // Number of storage slots downloaded during healing
+	HealPending        hexutil.Uint64 `json:"healPending"`        // Number of trie nodes and bytecodes waiting to be healed
+
+	ETA hexutil.Uint64 `json:"eta"` // Estimated number of seconds until the current phase is done
 }
 
 // SyncPeer abstracts out the methods required for a peer to be synced against
@@ -373,6 +412,14 @@ type SyncPeer interface {
 	Log() log.Logger
 }
 
+// reportPeer records behavior of a peer in its reputation score, if the peer is
+// backed by a network connection.
+func reportPeer(peer SyncPeer, b p2p.PeerBehavior) {
+	if p, ok := peer.(interface{ Report(p2p.PeerBehavior) }); ok {
+		p.Report(b)
+	}
+}
+
 // Syncer is an Ethereum account and storage trie syncer based on snapshots and
 // the  snap protocol. It's purpose is to download all the accounts and storage
 // slots from remote peers and reassemble chunks of the state trie, on top of
@@ -437,8 +484,13 @@ type Syncer struct {
 	storageHealedBytes common.StorageSize // Number of raw storage bytes persisted to disk during the healing stage
 
 	startTime time.Time // Time instance when snapshot sync started
+	healStart time.Time // Time instance when the healing phase of the sync cycle started
+	healBase  uint64    // Number of trie nodes and bytecodes healed before healStart
 	logTime   time.Time // Time instance when status was last reported
 
+	status     *SyncStatus  // Progress summary of the current sync cycle
+	statusLock sync.RWMutex // Protects the progress summary, read outside of sync
+
 	pend sync.WaitGroup // Tracks network request goroutines for graceful shutdown
 	lock sync.RWMutex   // Protects fields that can change outside of sync (peers, reqs, root)
 }
@@ -545,8 +597,11 @@ func (s *Syncer) Sync(root common.Hash, cancel chan struct{}) error {
 	if s.startTime == (time.Time{}) {
 		s.startTime = time.Now()
 	}
+	s.healStart = time.Time{}
+
 	// Retrieve the previous sync status from LevelDB and abort if already synced
 	s.loadSyncStatus()
+	s.updateStatus()
 	if len(s.tasks) == 0 && s.healer.scheduler.Pending() == 0 {
 		log.Debug("Snapshot sync already completed")
 		return nil
@@ -590,6 +645,10 @@ func (s *Syncer) Sync(root common.Hash, cancel chan struct{}) error {
 	peerDropSub := s.peerDrop.Subscribe(peerDrop)
 	defer peerDropSub.Unsubscribe()
 
+	// Report the progress even if the sync is stalled
+	logTicker := time.NewTicker(logInterval)
+	defer logTicker.Stop()
+
 	// Create a set of unique channels for this sync cycle. We need these to be
 	// ephemeral so a data race doesn't accidentally deliver something stale on
 	// a persistent channel across syncs (yup, this happened)
@@ -619,6 +678,10 @@ func (s *Syncer) Sync(root common.Hash, cancel chan struct{}) error {
 
 		if len(s.tasks) == 0 {
 			// Sync phase done, run heal phase
+			if s.healStart == (time.Time{}) {
+				s.healStart = time.Now()
+				s.healBase = s.trienodeHealSynced + s.bytecodeHealSynced
+			}
 			s.assignTrienodeHealTasks(trienodeHealResps, trienodeHealReqFails, cancel)
 			s.assignBytecodeHealTasks(bytecodeHealResps, bytecodeHealReqFails, cancel)
 		}
@@ -626,6 +689,8 @@ func (s *Syncer) Sync(root common.Hash, cancel chan struct{}) error {
 		select {
 		case <-s.update:
 			// Something happened (new peer, delivery, timeout), recheck tasks
+		case <-logTicker.C:
+			// Nothing might have happened, but report the status anyway
 		case <-peerJoin:
 			// A new peer joined, try to schedule it new tasks
 		case id := <-peerDrop:
@@ -705,8 +770,28 @@ func (s *Syncer) loadSyncStatus() {
 
 			s.trienodeHealSynced = progress.TrienodeHealSynced
 			s.trienodeHealBytes = progress.TrienodeHealBytes
+			s.trienodeHealDups = progress.TrienodeHealDups
+			s.trienodeHealNops = progress.TrienodeHealNops
 			s.bytecodeHealSynced = progress.BytecodeHealSynced
 			s.bytecodeHealBytes = progress.BytecodeHealBytes
+			s.bytecodeHealDups = progress.BytecodeHealDups
+			s.bytecodeHealNops = progress.BytecodeHealNops
+			s.accountHealed = progress.AccountHealed
+			s.accountHealedBytes = progress.AccountHealedBytes
+			s.storageHealed = progress.StorageHealed
+			s.storageHealedBytes = progress.StorageHealedBytes
+
+			// If the healing of the same state root was interrupted, continue
+			// from the pending requests instead of starting over from the root
+			if progress.HealRoot == s.root && len(progress.HealRequests) > 0 {
+				scheduler, err := state.ResumeStateSync(progress.HealRequests, s.db, nil, s.onHealState)
+				if err != nil {
+					log.Error("Failed to resume state heal", "err", err)
+				} else {
+					log.Debug("Resumed state heal", "root", s.root, "pending", scheduler.Pending())
+					s.healer.scheduler = scheduler
+				}
+			}
 			return
 		}
 	}
@@ -718,7 +803,11 @@ func (s *Syncer) loadSyncStatus() {
 	s.bytecodeSynced, s.bytecodeBytes = 0, 0
 	s.storageSynced, s.storageBytes = 0, 0
 	s.trienodeHealSynced, s.trienodeHealBytes = 0, 0
+	s.trienodeHealDups, s.trienodeHealNops = 0, 0
 	s.bytecodeHealSynced, s.bytecodeHealBytes = 0, 0
+	s.bytecodeHealDups, s.bytecodeHealNops = 0, 0
+	s.accountHealed, s.accountHealedBytes = 0, 0
+	s.storageHealed, s.storageHealedBytes = 0, 0
 
 	var next common.Hash
 	step := new(big.Int).Sub(
@@ -779,6 +868,20 @@ func (s *Syncer) saveSyncStatus() {
 		TrienodeHealBytes:  s.trienodeHealBytes,
 		BytecodeHealSynced: s.bytecodeHealSynced,
 		BytecodeHealBytes:  s.bytecodeHealBytes,
+		TrienodeHealDups:   s.trienodeHealDups,
+		TrienodeHealNops:   s.trienodeHealNops,
+		BytecodeHealDups:   s.bytecodeHealDups,
+		BytecodeHealNops:   s.bytecodeHealNops,
+		AccountHealed:      s.accountHealed,
+		AccountHealedBytes: s.accountHealedBytes,
+		StorageHealed:      s.storageHealed,
+		StorageHealedBytes: s.storageHealedBytes,
+	}
+	// Once the snap phase is done, save the pending heal requests, so healing
+	// can continue where it left off.
+	if len(s.tasks) == 0 && s.healer != nil && s.healer.scheduler.Pending() > 0 {
+		progress.HealRoot = s.root
+		progress.HealRequests = s.healer.scheduler.Requests()
 	}
 	status, err := json.Marshal(progress)
 	if err != nil {
@@ -905,6 +1008,7 @@ func (s *Syncer) assignAccountTasks(success chan *accountResponse, fail chan *ac
 		}
 		req.timeout = time.AfterFunc(requestTimeout, func() {
 			peer.Log().Debug("Account range request timed out", "reqid", reqid)
+			reportPeer(peer, p2p.PeerTimeout)
 			s.scheduleRevertAccountRequest(req)
 		})
 		s.accountReqs[reqid] = req
@@ -996,6 +1100,7 @@ func (s *Syncer) assignBytecodeTasks(success chan *bytecodeResponse, fail chan *
 		}
 		req.timeout = time.AfterFunc(requestTimeout, func() {
 			peer.Log().Debug("Bytecode request timed out", "reqid", reqid)
+			reportPeer(peer, p2p.PeerTimeout)
 			s.scheduleRevertBytecodeRequest(req)
 		})
 		s.bytecodeReqs[reqid] = req
@@ -1124,6 +1229,7 @@ func (s *Syncer) assignStorageTasks(success chan *storageResponse, fail chan *st
 		}
 		req.timeout = time.AfterFunc(requestTimeout, func() {
 			peer.Log().Debug("Storage request timed out", "reqid", reqid)
+			reportPeer(peer, p2p.PeerTimeout)
 			s.scheduleRevertStorageRequest(req)
 		})
 		s.storageReqs[reqid] = req
@@ -1243,6 +1349,7 @@ func (s *Syncer) assignTrienodeHealTasks(success chan *trienodeHealResponse, fai
 		}
 		req.timeout = time.AfterFunc(requestTimeout, func() {
 			peer.Log().Debug("Trienode heal request timed out", "reqid", reqid)
+			reportPeer(peer, p2p.PeerTimeout)
 			s.scheduleRevertTrienodeHealRequest(req)
 		})
 		s.trienodeHealReqs[reqid] = req
@@ -1345,6 +1452,7 @@ func (s *Syncer) assignBytecodeHealTasks(success chan *bytecodeHealResponse, fai
 		}
 		req.timeout = time.AfterFunc(requestTimeout, func() {
 			peer.Log().Debug("Bytecode heal request timed out", "reqid", reqid)
+			reportPeer(peer, p2p.PeerTimeout)
 			s.scheduleRevertBytecodeHealRequest(req)
 		})
 		s.bytecodeHealReqs[reqid] = req
@@ -2157,6 +2265,7 @@ func (s *Syncer) OnAccounts(peer SyncPeer, id uint64, hashes []common.Hash, acco
 	if len(hashes) == 0 && len(accounts) == 0 && len(proof) == 0 {
 		logger.Debug("Peer rejected account range request", "root", s.root)
 		s.statelessPeers[peer.ID()] = struct{}{}
+		reportPeer(peer, p2p.PeerUselessResponse)
 		s.lock.Unlock()
 
 		// Signal this request as failed, and ready for rescheduling
@@ -2202,6 +2311,7 @@ func (s *Syncer) OnAccounts(peer SyncPeer, id uint64, hashes []common.Hash, acco
 		accounts: accs,
 		cont:     cont,
 	}
+	reportPeer(peer, p2p.PeerDeliveredData)
 	select {
 	case req.deliver <- response:
 	case <-req.cancel:
@@ -2268,6 +2378,7 @@ func (s *Syncer) onByteCodes(peer SyncPeer, id uint64, bytecodes [][]byte) error
 	if len(bytecodes) == 0 {
 		logger.Debug("Peer rejected bytecode request")
 		s.statelessPeers[peer.ID()] = struct{}{}
+		reportPeer(peer, p2p.PeerUselessResponse)
 		s.lock.Unlock()
 
 		// Signal this request as failed, and ready for rescheduling
@@ -2308,6 +2419,7 @@ func (s *Syncer) onByteCodes(peer SyncPeer, id uint64, bytecodes [][]byte) error
 		hashes: req.hashes,
 		codes:  codes,
 	}
+	reportPeer(peer, p2p.PeerDeliveredData)
 	select {
 	case req.deliver <- response:
 	case <-req.cancel:
@@ -2391,6 +2503,7 @@ func (s *Syncer) OnStorage(peer SyncPeer, id uint64, hashes [][]common.Hash, slo
 	if len(hashes) == 0 {
 		logger.Debug("Peer rejected storage request")
 		s.statelessPeers[peer.ID()] = struct{}{}
+		reportPeer(peer, p2p.PeerUselessResponse)
 		s.lock.Unlock()
 		s.scheduleRevertStorageRequest(req) // reschedule request
 		return nil
@@ -2449,6 +2562,7 @@ func (s *Syncer) OnStorage(peer SyncPeer, id uint64, hashes [][]common.Hash, slo
 		slots:    slots,
 		cont:     cont,
 	}
+	reportPeer(peer, p2p.PeerDeliveredData)
 	select {
 	case req.deliver <- response:
 	case <-req.cancel:
@@ -2502,6 +2616,7 @@ func (s *Syncer) OnTrieNodes(peer SyncPeer, id uint64, trienodes [][]byte) error
 	if len(trienodes) == 0 {
 		logger.Debug("Peer rejected trienode heal request")
 		s.statelessPeers[peer.ID()] = struct{}{}
+		reportPeer(peer, p2p.PeerUselessResponse)
 		s.lock.Unlock()
 
 		// Signal this request as failed, and ready for rescheduling
@@ -2543,6 +2658,7 @@ func (s *Syncer) OnTrieNodes(peer SyncPeer, id uint64, trienodes [][]byte) error
 		paths:  req.paths,
 		nodes:  nodes,
 	}
+	reportPeer(peer, p2p.PeerDeliveredData)
 	select {
 	case req.deliver <- response:
 	case <-req.cancel:
@@ -2596,6 +2712,7 @@ func (s *Syncer) onHealByteCodes(peer SyncPeer, id uint64, bytecodes [][]byte) e
 	if len(bytecodes) == 0 {
 		logger.Debug("Peer rejected bytecode heal request")
 		s.statelessPeers[peer.ID()] = struct{}{}
+		reportPeer(peer, p2p.PeerUselessResponse)
 		s.lock.Unlock()
 
 		// Signal this request as failed, and ready for rescheduling
@@ -2636,6 +2753,7 @@ func (s *Syncer) onHealByteCodes(peer SyncPeer, id uint64, bytecodes [][]byte) e
 		hashes: req.hashes,
 		codes:  codes,
 	}
+	reportPeer(peer, p2p.PeerDeliveredData)
 	select {
 	case req.deliver <- response:
 	case <-req.cancel:
@@ -2676,6 +2794,7 @@ var hashSpace = new(big.Int).Exp(common.Big2, common.Big256, nil)
 
 // report calculates various status reports and provides it to the user.
 func (s *Syncer) report(force bool) {
+	s.updateStatus()
 	if len(s.tasks) > 0 {
 		s.reportSyncProgress(force)
 		return
@@ -2686,30 +2805,15 @@ func (s *Syncer) report(force bool) {
 // reportSyncProgress calculates various status reports and provides it to the user.
 func (s *Syncer) reportSyncProgress(force bool) {
 	// Don't report all the events, just occasionally
-	if !force && time.Since(s.logTime) < 8*time.Second {
+	if !force && time.Since(s.logTime) < logInterval {
 		return
 	}
 	// Don't report anything until we have a meaningful progress
-	synced := s.accountBytes + s.bytecodeBytes + s.storageBytes
-	if synced == 0 {
-		return
-	}
-	accountGaps := new(big.Int)
-	for _, task := range s.tasks {
-		accountGaps.Add(accountGaps, new(big.Int).Sub(task.Last.Big(), task.Next.Big()))
-	}
-	accountFills := new(big.Int).Sub(hashSpace, accountGaps)
-	if accountFills.BitLen() == 0 {
+	synced, estBytes, eta, ok := s.estimateSyncProgress()
+	if !ok {
 		return
 	}
 	s.logTime = time.Now()
-	estBytes := float64(new(big.Int).Div(
-		new(big.Int).Mul(new(big.Int).SetUint64(uint64(synced)), hashSpace),
-		accountFills,
-	).Uint64())
-
-	elapsed := time.Since(s.startTime)
-	estTime := elapsed / time.Duration(synced) * time.Duration(estBytes)
 
 	// Create a mega progress report
 	var (
@@ -2719,13 +2823,13 @@ func (s *Syncer) reportSyncProgress(force bool) {
 		bytecode = fmt.Sprintf("%v@%v", log.FormatLogfmtUint64(s.bytecodeSynced), s.bytecodeBytes.TerminalString())
 	)
 	log.Info("State sync in progress", "synced", progress, "state", synced,
-		"accounts", accounts, "slots", storage, "codes", bytecode, "eta", common.PrettyDuration(estTime-elapsed))
+		"accounts", accounts, "slots", storage, "codes", bytecode, "eta", common.PrettyDuration(eta))
 }
 
 // reportHealProgress calculates various status reports and provides it to the user.
 func (s *Syncer) reportHealProgress(force bool) {
 	// Don't report all the events, just occasionally
-	if !force && time.Since(s.logTime) < 8*time.Second {
+	if !force && time.Since(s.logTime) < logInterval {
 		return
 	}
 	s.logTime = time.Now()
@@ -2738,7 +2842,99 @@ func (s *Syncer) reportHealProgress(force bool) {
 		storage  = fmt.Sprintf("%v@%v", log.FormatLogfmtUint64(s.storageHealed), s.storageHealedBytes.TerminalString())
 	)
 	log.Info("State heal in progress", "accounts", accounts, "slots", storage,
-		"codes", bytecode, "nodes", trienode, "pending", s.healer.scheduler.Pending())
+		"codes", bytecode, "nodes", trienode, "pending", s.healer.scheduler.Pending(),
+		"eta", common.PrettyDuration(s.estimateHealTime()))
+}
+
+// estimateSyncProgress estimates the total size of the state and the remaining
+// time of the snap phase, based on the portion of the account hash space that
+// is already filled. It returns false if there's no meaningful progress yet.
+func (s *Syncer) estimateSyncProgress() (common.StorageSize, float64, time.Duration, bool) {
+	synced := s.accountBytes + s.bytecodeBytes + s.storageBytes
+	if synced == 0 {
+		return 0, 0, 0, false
+	}
+	accountGaps := new(big.Int)
+	for _, task := range s.tasks {
+		accountGaps.Add(accountGaps, new(big.Int).Sub(task.Last.Big(), task.Next.Big()))
+	}
+	accountFills := new(big.Int).Sub(hashSpace, accountGaps)
+	if accountFills.BitLen() == 0 {
+		return 0, 0, 0, false
+	}
+	estBytes := float64(new(big.Int).Div(
+		new(big.Int).Mul(new(big.Int).SetUint64(uint64(synced)), hashSpace),
+		accountFills,
+	).Uint64())
+
+	elapsed := time.Since(s.startTime)
+	estTime := elapsed / time.Duration(synced) * time.Duration(estBytes)
+
+	return synced, estBytes, estTime - elapsed, true
+}
+
+// estimateHealTime roughly estimates the remaining time of the healing phase by
+// extrapolating the rate at which trie nodes and bytecodes were healed during
+// this sync cycle onto the pending ones. Since healing keeps discovering new
+// items, the estimate tends to be optimistic.
+func (s *Syncer) estimateHealTime() time.Duration {
+	if s.healStart == (time.Time{}) {
+		return 0
+	}
+	healed := s.trienodeHealSynced + s.bytecodeHealSynced - s.healBase
+	if healed == 0 {
+		return 0
+	}
+	elapsed := time.Since(s.healStart)
+	return time.Duration(float64(elapsed) / float64(healed) * float64(s.healer.scheduler.Pending()))
+}
+
+// updateStatus refreshes the progress summary returned by Status.
+func (s *Syncer) updateStatus() {
+	status := &SyncStatus{
+		Root:               s.root,
+		Healing:            len(s.tasks) == 0,
+		AccountTasks:       hexutil.Uint64(len(s.tasks)),
+		AccountSynced:      hexutil.Uint64(s.accountSynced),
+		AccountBytes:       hexutil.Uint64(s.accountBytes),
+		BytecodeSynced:     hexutil.Uint64(s.bytecodeSynced),
+		BytecodeBytes:      hexutil.Uint64(s.bytecodeBytes),
+		StorageSynced:      hexutil.Uint64(s.storageSynced),
+		StorageBytes:       hexutil.Uint64(s.storageBytes),
+		TrienodeHealSynced: hexutil.Uint64(s.trienodeHealSynced),
+		TrienodeHealBytes:  hexutil.Uint64(s.trienodeHealBytes),
+		BytecodeHealSynced: hexutil.Uint64(s.bytecodeHealSynced),
+		BytecodeHealBytes:  hexutil.Uint64(s.bytecodeHealBytes),
+		AccountHealed:      hexutil.Uint64(s.accountHealed),
+		StorageHealed:      hexutil.Uint64(s.storageHealed),
+		HealPending:        hexutil.Uint64(s.healer.scheduler.Pending()),
+	}
+	for _, task := range s.tasks {
+		for _, subtasks := range task.SubTasks {
+			status.StorageTasks += hexutil.Uint64(len(subtasks))
+		}
+	}
+	if status.Healing {
+		status.ETA = hexutil.Uint64(s.estimateHealTime() / time.Second)
+	} else if _, _, eta, ok := s.estimateSyncProgress(); ok && eta > 0 {
+		status.ETA = hexutil.Uint64(eta / time.Second)
+	}
+	s.statusLock.Lock()
+	s.status = status
+	s.statusLock.Unlock()
+}
+
+// Status returns a summary of the progress of the current or last sync cycle,
+// or nil if no sync was started yet.
+func (s *Syncer) Status() *SyncStatus {
+	s.statusLock.RLock()
+	defer s.statusLock.RUnlock()
+
+	if s.status == nil {
+		return nil
+	}
+	status := *s.status
+	return &status
 }
 
 // estimateRemainingSlots tries to determine roughly how many slots are left in
@@ -20,10 +20,12 @@ import (
 	"bytes"
 	"crypto/rand"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -746,6 +748,94 @@ func TestSyncWithStorage(t *testing.T) {
 	verifyTrie(syncer.db, sourceAccountTrie.Hash(), t)
 }
 
+// TestSyncHealResume tests that an interrupted healing phase is persisted and
+// resumed from the pending requests instead of being restarted from the root.
+func TestSyncHealResume(t *testing.T) {
+	t.Parallel()
+
+	var (
+		once   sync.Once
+		cancel = make(chan struct{})
+		term   = func() {
+			once.Do(func() {
+				close(cancel)
+			})
+		}
+	)
+	sourceAccountTrie, elems, storageTries, storageElems := makeAccountTrieWithStorage(10, 100, true, false)
+
+	mkSource := func(name string, term func()) *testPeer {
+		source := newTestPeer(name, t, term)
+		source.accountTrie = sourceAccountTrie
+		source.accountValues = elems
+		source.storageTries = storageTries
+		source.storageValues = storageElems
+		return source
+	}
+	// Mark the snap phase done, so the entire state needs to be healed
+	root := sourceAccountTrie.Hash()
+	db := rawdb.NewMemoryDatabase()
+	blob, _ := json.Marshal(new(syncProgress))
+	rawdb.WriteSnapshotSyncStatus(db, blob)
+
+	// Serve the first trie node request only, then abort the sync
+	var served int32
+	source := mkSource("sourceA", term)
+	source.trieRequestHandler = func(t *testPeer, id uint64, root common.Hash, paths []TrieNodePathSet, cap uint64) error {
+		if atomic.AddInt32(&served, 1) > 1 {
+			t.term()
+			return nil
+		}
+		return defaultTrieRequestHandler(t, id, root, paths, cap)
+	}
+	syncer := NewSyncer(db)
+	syncer.Register(source)
+	source.remote = syncer
+
+	if err := syncer.Sync(root, cancel); err != ErrCancelled {
+		t.Fatalf("sync error mismatch: have %v, want %v", err, ErrCancelled)
+	}
+	var progress syncProgress
+	if err := json.Unmarshal(rawdb.ReadSnapshotSyncStatus(db), &progress); err != nil {
+		t.Fatalf("failed to decode sync status: %v", err)
+	}
+	if progress.HealRoot != root {
+		t.Fatalf("heal root mismatch: have %x, want %x", progress.HealRoot, root)
+	}
+	if len(progress.HealRequests) == 0 {
+		t.Fatal("no heal requests persisted")
+	}
+	if progress.TrienodeHealSynced == 0 {
+		t.Fatal("no healed trie nodes persisted")
+	}
+	// Restart the sync, which should continue healing below the root
+	source = mkSource("sourceB", func() {})
+	source.trieRequestHandler = func(t *testPeer, id uint64, root common.Hash, paths []TrieNodePathSet, cap uint64) error {
+		for _, pathset := range paths {
+			if len(pathset) == 1 && bytes.Equal(pathset[0], []byte{0}) { // compact encoded root path
+				t.test.Errorf("state root requested again")
+			}
+		}
+		return defaultTrieRequestHandler(t, id, root, paths, cap)
+	}
+	syncer = NewSyncer(db)
+	syncer.Register(source)
+	source.remote = syncer
+
+	if err := syncer.Sync(root, make(chan struct{})); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	verifyTrie(db, root, t)
+
+	status := syncer.Status()
+	if !status.Healing || status.HealPending != 0 {
+		t.Fatalf("unexpected final status: healing %v, pending %d", status.Healing, status.HealPending)
+	}
+	if uint64(status.TrienodeHealSynced) <= progress.TrienodeHealSynced {
+		t.Fatalf("healed trie nodes not accumulated: have %d, previously %d", status.TrienodeHealSynced, progress.TrienodeHealSynced)
+	}
+}
+
 // TestMultiSyncManyUseless contains one good peer, and many which doesn't return anything valuable at all
 func TestMultiSyncManyUseless(t *testing.T) {
 	t.Parallel()
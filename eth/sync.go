@@ -250,16 +250,22 @@ func (cs *chainSyncer) nextSyncOp() *chainSyncOp {
 	if cs.handler.peers.len() < minPeers {
 		return nil
 	}
-	// We have enough peers, check TD
-	peer := cs.handler.peers.peerWithHighestTD()
-	if peer == nil {
-		return nil
-	}
 	mode, ourTD := cs.modeAndLocalHead()
 	if mode == downloader.FastSync && atomic.LoadUint32(&cs.handler.snapSync) == 1 {
 		// Fast sync via the snap protocol
 		mode = downloader.SnapSync
 	}
+	// We have enough peers, check TD. The eth/67 protocol doesn't serve state
+	// by hash anymore, so fast sync without snap can't use such peers.
+	var peer *eth.Peer
+	if mode == downloader.FastSync {
+		peer = cs.handler.peers.peerWithHighestTDUpTo(eth.ETH66)
+	} else {
+		peer = cs.handler.peers.peerWithHighestTD()
+	}
+	if peer == nil {
+		return nil
+	}
 	op := peerToSyncOp(mode, peer)
 	if op.td.Cmp(ourTD) <= 0 {
 		return nil // We're in sync.
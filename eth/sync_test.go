@@ -78,3 +78,64 @@ func testFastSyncDisabling(t *testing.T, protocol uint) {
 		t.Fatalf("fast sync not disabled after successful synchronisation")
 	}
 }
+
+// Tests that fast sync against eth/67 peers, which don't serve node data, is
+// only done via the snap protocol.
+func TestFastSyncMode65(t *testing.T) { testFastSyncMode(t, eth.ETH65, false, downloader.FastSync) }
+func TestFastSyncMode66(t *testing.T) { testFastSyncMode(t, eth.ETH66, false, downloader.FastSync) }
+func TestFastSyncMode67(t *testing.T) { testFastSyncMode(t, eth.ETH67, true, downloader.SnapSync) }
+
+// Tests that eth/67 peers are not used for fast sync if snap sync is disabled.
+func TestFastSyncSkip67(t *testing.T) {
+	if op := fastSyncOp(t, eth.ETH67, false); op != nil {
+		t.Fatalf("sync operation scheduled: mode %v", op.mode)
+	}
+}
+
+func testFastSyncMode(t *testing.T, protocol uint, snap bool, want downloader.SyncMode) {
+	op := fastSyncOp(t, protocol, snap)
+	if op == nil {
+		t.Fatal("no sync operation scheduled")
+	}
+	if op.mode != want {
+		t.Fatalf("sync mode mismatch: have %v, want %v", op.mode, want)
+	}
+}
+
+// fastSyncOp returns the sync operation a node in fast sync mode schedules with
+// a single peer on the given protocol version.
+func fastSyncOp(t *testing.T, protocol uint, snap bool) *chainSyncOp {
+	t.Parallel()
+
+	// Create an empty handler in fast sync mode and a full one to sync from
+	empty := newTestHandler()
+	defer empty.close()
+
+	full := newTestHandlerWithBlocks(1024)
+	defer full.close()
+
+	emptyPipe, fullPipe := p2p.MsgPipe()
+	defer emptyPipe.Close()
+	defer fullPipe.Close()
+
+	emptyPeer := eth.NewPeer(protocol, p2p.NewPeer(enode.ID{1}, "", nil), emptyPipe, empty.txpool)
+	fullPeer := eth.NewPeer(protocol, p2p.NewPeer(enode.ID{2}, "", nil), fullPipe, full.txpool)
+	defer emptyPeer.Close()
+	defer fullPeer.Close()
+
+	go empty.handler.runEthPeer(emptyPeer, func(peer *eth.Peer) error {
+		return eth.Handle((*ethHandler)(empty.handler), peer)
+	})
+	go full.handler.runEthPeer(fullPeer, func(peer *eth.Peer) error {
+		return eth.Handle((*ethHandler)(full.handler), peer)
+	})
+	// Wait a bit for the above handlers to start
+	time.Sleep(250 * time.Millisecond)
+
+	if snap {
+		atomic.StoreUint32(&empty.handler.snapSync, 1)
+	}
+	cs := newChainSyncer(empty.handler)
+	cs.forced = true
+	return cs.nextSyncOp()
+}
@@ -0,0 +1,332 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+func init() {
+	RegisterNativeTracer("callTracerNative", newCallTracer)
+}
+
+// callFrame is a single call of the call tracer's output. The field order and
+// the omitted fields match the JSON produced by the JavaScript callTracer.
+type callFrame struct {
+	Type    string       `json:"type"`
+	From    string       `json:"from"`
+	To      string       `json:"to,omitempty"`
+	Value   string       `json:"value,omitempty"`
+	Gas     string       `json:"gas,omitempty"`
+	GasUsed string       `json:"gasUsed,omitempty"`
+	Input   string       `json:"input,omitempty"`
+	Output  string       `json:"output,omitempty"`
+	Error   string       `json:"error,omitempty"`
+	Time    string       `json:"time,omitempty"`
+	Calls   []*callFrame `json:"calls,omitempty"`
+
+	// Transient fields used while the call is still executing
+	gasIn   uint64
+	gasCost uint64
+	gas     *uint64 // true allowance inside the call, if it could be retrieved
+	outOff  uint64
+	outLen  uint64
+}
+
+// callTracer is a native Go port of the JavaScript callTracer. It extracts and
+// reports all the internal calls made by a transaction.
+type callTracer struct {
+	callstack []*callFrame
+	descended bool // whether we've just descended into an inner call
+
+	ctx callFrame // outer transaction context, filled by CaptureStart/End
+
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// newCallTracer creates a new native call tracer. The tracer has no options,
+// any configuration is ignored.
+func newCallTracer(cfg json.RawMessage) (NativeTracer, error) {
+	return &callTracer{callstack: []*callFrame{{}}}, nil
+}
+
+// CaptureStart implements the vm.Tracer interface to initialize the tracing operation.
+func (t *callTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.ctx.Type = "CALL"
+	if create {
+		t.ctx.Type = "CREATE"
+	}
+	t.ctx.From = addrToHex(from)
+	t.ctx.To = addrToHex(to)
+	t.ctx.Input = hexutil.Encode(input)
+	t.ctx.Gas = hexutil.EncodeUint64(gas)
+	t.ctx.Value = bigToHex(value)
+}
+
+// CaptureState implements the vm.Tracer interface to trace a single step of VM execution.
+func (t *callTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		return
+	}
+	// Capture any errors immediately
+	if err != nil {
+		t.fault(err)
+		return
+	}
+	switch op {
+	case vm.CREATE, vm.CREATE2:
+		// If a new contract is being created, add to the call stack
+		inOff := peekUint64(scope.Stack, 1)
+		inEnd := inOff + peekUint64(scope.Stack, 2)
+
+		t.callstack = append(t.callstack, &callFrame{
+			Type:    op.String(),
+			From:    addrToHex(scope.Contract.Address()),
+			Input:   hexutil.Encode(memorySlice(scope.Memory, inOff, inEnd)),
+			Value:   bigToHex(peekBig(scope.Stack, 0)),
+			gasIn:   gas,
+			gasCost: cost,
+		})
+		t.descended = true
+		return
+
+	case vm.SELFDESTRUCT:
+		// If a contract is being self destructed, gather that as a subcall too
+		self := scope.Contract.Address()
+		parent := t.callstack[len(t.callstack)-1]
+		parent.Calls = append(parent.Calls, &callFrame{
+			Type:    op.String(),
+			From:    addrToHex(self),
+			To:      addrToHex(common.BigToAddress(peekBig(scope.Stack, 0))),
+			Value:   bigToHex(env.StateDB.GetBalance(self)),
+			gasIn:   gas,
+			gasCost: cost,
+		})
+		return
+
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		// If a new method invocation is being done, add to the call stack, skipping
+		// any pre-compile invocations, those are just fancy opcodes
+		to := common.BigToAddress(peekBig(scope.Stack, 1))
+		if _, ok := vm.PrecompiledContractsIstanbul[to]; ok {
+			return
+		}
+		off := 1
+		if op == vm.DELEGATECALL || op == vm.STATICCALL {
+			off = 0
+		}
+		inOff := peekUint64(scope.Stack, 2+off)
+		inEnd := inOff + peekUint64(scope.Stack, 3+off)
+
+		call := &callFrame{
+			Type:    op.String(),
+			From:    addrToHex(scope.Contract.Address()),
+			To:      addrToHex(to),
+			Input:   hexutil.Encode(memorySlice(scope.Memory, inOff, inEnd)),
+			gasIn:   gas,
+			gasCost: cost,
+			outOff:  peekUint64(scope.Stack, 4+off),
+			outLen:  peekUint64(scope.Stack, 5+off),
+		}
+		if op != vm.DELEGATECALL && op != vm.STATICCALL {
+			call.Value = bigToHex(peekBig(scope.Stack, 2))
+		}
+		t.callstack = append(t.callstack, call)
+		t.descended = true
+		return
+	}
+	// If we've just descended into an inner call, retrieve it's true allowance. We
+	// need to extract if from within the call as there may be funky gas dynamics
+	// with regard to requested and actually given gas (2300 stipend, 63/64 rule).
+	if t.descended {
+		if depth >= len(t.callstack) {
+			allowance := gas
+			t.callstack[len(t.callstack)-1].gas = &allowance
+		}
+		t.descended = false
+	}
+	// If an existing call is returning, pop off the call stack
+	if op == vm.REVERT {
+		t.callstack[len(t.callstack)-1].Error = "execution reverted"
+		return
+	}
+	if depth == len(t.callstack)-1 {
+		// Pop off the last call and get the execution results
+		call := t.callstack[len(t.callstack)-1]
+		t.callstack = t.callstack[:len(t.callstack)-1]
+
+		ret := peekBig(scope.Stack, 0)
+		if call.Type == "CREATE" || call.Type == "CREATE2" {
+			// If the call was a CREATE, retrieve the contract address and output code
+			call.GasUsed = hexutil.EncodeUint64(call.gasIn - call.gasCost - gas)
+
+			if ret.Sign() != 0 {
+				addr := common.BigToAddress(ret)
+				call.To = addrToHex(addr)
+				call.Output = hexutil.Encode(env.StateDB.GetCode(addr))
+			} else if call.Error == "" {
+				call.Error = "internal failure" // TODO(karalabe): surface these faults somehow
+			}
+		} else {
+			// If the call was a contract call, retrieve the gas usage and output
+			if call.gas != nil {
+				call.GasUsed = hexutil.EncodeUint64(call.gasIn - call.gasCost + *call.gas - gas)
+			}
+			if ret.Sign() != 0 {
+				call.Output = hexutil.Encode(memorySlice(scope.Memory, call.outOff, call.outOff+call.outLen))
+			} else if call.Error == "" {
+				call.Error = "internal failure" // TODO(karalabe): surface these faults somehow
+			}
+		}
+		if call.gas != nil {
+			call.Gas = hexutil.EncodeUint64(*call.gas)
+		}
+		// Inject the call into the previous one
+		parent := t.callstack[len(t.callstack)-1]
+		parent.Calls = append(parent.Calls, call)
+	}
+}
+
+// CaptureFault implements the vm.Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *callTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		return
+	}
+	t.fault(err)
+}
+
+// fault is invoked when the actual execution of an opcode fails.
+func (t *callTracer) fault(err error) {
+	// If the topmost call already reverted, don't handle the additional fault again
+	if t.callstack[len(t.callstack)-1].Error != "" {
+		return
+	}
+	// Pop off the just failed call
+	call := t.callstack[len(t.callstack)-1]
+	t.callstack = t.callstack[:len(t.callstack)-1]
+	call.Error = err.Error()
+
+	// Consume all available gas
+	if call.gas != nil {
+		call.Gas = hexutil.EncodeUint64(*call.gas)
+		call.GasUsed = call.Gas
+	}
+	// Flatten the failed call into its parent
+	if len(t.callstack) > 0 {
+		parent := t.callstack[len(t.callstack)-1]
+		parent.Calls = append(parent.Calls, call)
+		return
+	}
+	// Last call failed too, leave it in the stack
+	t.callstack = append(t.callstack, call)
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *callTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) {
+	t.ctx.Output = hexutil.Encode(output)
+	t.ctx.GasUsed = hexutil.EncodeUint64(gasUsed)
+	t.ctx.Time = d.String()
+	if err != nil {
+		t.ctx.Error = err.Error()
+	}
+}
+
+// GetResult returns the JSON encoded call tree, or any accumulated error.
+func (t *callTracer) GetResult() (json.RawMessage, error) {
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		return nil, t.reason
+	}
+	result := t.ctx
+	result.Calls = t.callstack[0].Calls
+	if t.callstack[0].Error != "" {
+		result.Error = t.callstack[0].Error
+	}
+	if result.Error != "" && (result.Error != "execution reverted" || result.Output == "0x") {
+		result.Output = ""
+	}
+	// Avoid HTML escaping to stay byte-identical to the JavaScript JSON encoder
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(&result); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(buf.Bytes()), nil
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *callTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
+
+// addrToHex returns the lowercase hex encoding of an address, the same way the
+// JavaScript tracers' toHex does.
+func addrToHex(addr common.Address) string {
+	return hexutil.Encode(addr[:])
+}
+
+// bigToHex returns the hex encoding of a big integer, treating nil as zero.
+func bigToHex(n *big.Int) string {
+	if n == nil {
+		return "0x0"
+	}
+	return hexutil.EncodeBig(n)
+}
+
+// peekBig returns the nth-from-the-top element of the stack.
+func peekBig(stack *vm.Stack, idx int) *big.Int {
+	if len(stack.Data()) <= idx || idx < 0 {
+		log.Warn("Tracer accessed out of bound stack", "size", len(stack.Data()), "index", idx)
+		return new(big.Int)
+	}
+	return stack.Back(idx).ToBig()
+}
+
+// peekUint64 returns the nth-from-the-top element of the stack, truncated to
+// 64 bits.
+func peekUint64(stack *vm.Stack, idx int) uint64 {
+	if len(stack.Data()) <= idx || idx < 0 {
+		log.Warn("Tracer accessed out of bound stack", "size", len(stack.Data()), "index", idx)
+		return 0
+	}
+	return stack.Back(idx).Uint64()
+}
+
+// memorySlice returns a copy of the requested range of memory, or nil if the
+// range is out of bounds.
+func memorySlice(memory *vm.Memory, begin, end uint64) []byte {
+	if end == begin {
+		return []byte{}
+	}
+	if end < begin || end > uint64(memory.Len()) {
+		log.Warn("Tracer accessed out of bound memory", "available", memory.Len(), "offset", begin, "end", end)
+		return nil
+	}
+	return memory.GetCopy(int64(begin), int64(end-begin))
+}
@@ -0,0 +1,378 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func init() {
+	RegisterNativeTracer("prestateTracerNative", newPrestateTracer)
+}
+
+// prestateAccount is the pre-transaction state of a single account touched by
+// the traced transaction.
+type prestateAccount struct {
+	Balance *big.Int
+	Nonce   int64 // signed, the JavaScript tracer can decrement it below zero
+	Code    []byte
+	Storage map[common.Hash]common.Hash
+
+	slots []common.Hash // storage slots in the order of their first access
+}
+
+// empty returns whether the account is empty according to EIP-161, which in the
+// pre-state means that it did not exist before the transaction.
+func (a *prestateAccount) empty() bool {
+	return a.Balance.Sign() == 0 && a.Nonce == 0 && len(a.Code) == 0
+}
+
+// prestateTracerConfig is the user supplied configuration of the prestate tracer.
+type prestateTracerConfig struct {
+	// DiffMode makes the tracer return the state changes of the transaction
+	// instead of the plain pre-state: the pre and post values of the modified
+	// balances, nonces, code and storage slots of every changed account.
+	DiffMode bool `json:"diffMode"`
+}
+
+// prestateTracer is a native Go port of the JavaScript prestateTracer. It outputs
+// sufficient information to create a local execution of the transaction from a
+// custom assembled genesis block.
+type prestateTracer struct {
+	config   prestateTracerConfig
+	env      *vm.EVM
+	prestate map[common.Address]*prestateAccount
+	accounts []common.Address // accounts in the order of their first access
+
+	create       bool
+	from         common.Address
+	to           common.Address
+	value        *big.Int
+	gasUsed      uint64
+	intrinsicGas uint64
+
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// newPrestateTracer creates a new native prestate tracer.
+func newPrestateTracer(cfg json.RawMessage) (NativeTracer, error) {
+	var config prestateTracerConfig
+	if len(cfg) > 0 {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	return &prestateTracer{
+		config:   config,
+		prestate: make(map[common.Address]*prestateAccount),
+	}, nil
+}
+
+// CaptureStart implements the vm.Tracer interface to initialize the tracing operation.
+func (t *prestateTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.env = env
+	t.create = create
+	t.from = from
+	t.to = to
+	t.value = value
+
+	isHomestead := env.ChainConfig().IsHomestead(env.Context.BlockNumber)
+	isIstanbul := env.ChainConfig().IsIstanbul(env.Context.BlockNumber)
+	if intrinsicGas, err := core.IntrinsicGas(input, nil, create, isHomestead, isIstanbul); err == nil {
+		t.intrinsicGas = intrinsicGas
+	}
+	if t.config.DiffMode {
+		t.captureDiffStart(gas)
+		return
+	}
+	// Add the recipient right away. Its balance will potentially be wrong here,
+	// since this will include the value sent along with the message. We fix that
+	// in GetResult.
+	t.lookupAccount(to)
+}
+
+// CaptureState implements the vm.Tracer interface to trace a single step of VM execution.
+func (t *prestateTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		return
+	}
+	// Whenever new state is accessed, add it to the prestate
+	switch op {
+	case vm.EXTCODECOPY, vm.EXTCODESIZE, vm.BALANCE:
+		t.lookupAccount(common.BigToAddress(peekBig(scope.Stack, 0)))
+
+	case vm.CREATE:
+		from := scope.Contract.Address()
+		t.lookupAccount(crypto.CreateAddress(from, env.StateDB.GetNonce(from)))
+
+	case vm.CREATE2:
+		// stack: salt, size, offset, endowment
+		offset := peekUint64(scope.Stack, 1)
+		size := peekUint64(scope.Stack, 2)
+		salt := common.BigToHash(peekBig(scope.Stack, 3))
+		code := memorySlice(scope.Memory, offset, offset+size)
+		t.lookupAccount(crypto.CreateAddress2(scope.Contract.Address(), salt, crypto.Keccak256(code)))
+
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		t.lookupAccount(common.BigToAddress(peekBig(scope.Stack, 1)))
+
+	case vm.SSTORE, vm.SLOAD:
+		t.lookupStorage(scope.Contract.Address(), common.BigToHash(peekBig(scope.Stack, 0)))
+
+	case vm.SELFDESTRUCT:
+		// The beneficiary is only of interest if the state changes are tracked,
+		// the JavaScript tracer does not include it in the pre-state.
+		if t.config.DiffMode {
+			t.lookupAccount(common.BigToAddress(peekBig(scope.Stack, 0)))
+		}
+	}
+}
+
+// captureDiffStart adds the sender, the recipient and the coinbase to the
+// pre-state when tracking state changes. Contrary to the plain pre-state mode,
+// the sender can't be looked up at the end since the refunds and fees are
+// already applied then, so the purchased gas and the transferred value are
+// reverted right away.
+func (t *prestateTracer) captureDiffStart(gas uint64) {
+	t.lookupAccount(t.from)
+	from := t.prestate[t.from]
+
+	fee := new(big.Int).SetUint64(gas + t.intrinsicGas)
+	fee.Mul(fee, t.env.TxContext.GasPrice)
+	from.Balance.Add(from.Balance, fee)
+	from.Nonce--
+
+	if t.create {
+		// The new contract already has its nonce set, but it can't have had any
+		// code or nonce before, otherwise the creation would have failed.
+		t.prestate[t.to] = &prestateAccount{
+			Balance: new(big.Int).Set(t.env.StateDB.GetBalance(t.to)),
+			Storage: make(map[common.Hash]common.Hash),
+		}
+		t.accounts = append(t.accounts, t.to)
+	} else {
+		t.lookupAccount(t.to)
+	}
+	if t.from != t.to {
+		from.Balance.Add(from.Balance, t.value)
+		to := t.prestate[t.to]
+		to.Balance.Sub(to.Balance, t.value)
+	}
+	t.lookupAccount(t.env.Context.Coinbase)
+}
+
+// CaptureFault implements the vm.Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *prestateTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *prestateTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) {
+	t.gasUsed = gasUsed
+}
+
+// lookupAccount injects the specified account into the prestate if it hasn't
+// been touched yet.
+func (t *prestateTracer) lookupAccount(addr common.Address) {
+	if _, ok := t.prestate[addr]; ok {
+		return
+	}
+	t.prestate[addr] = &prestateAccount{
+		Balance: new(big.Int).Set(t.env.StateDB.GetBalance(addr)),
+		Nonce:   int64(t.env.StateDB.GetNonce(addr)),
+		Code:    t.env.StateDB.GetCode(addr),
+		Storage: make(map[common.Hash]common.Hash),
+	}
+	t.accounts = append(t.accounts, addr)
+}
+
+// lookupStorage injects the specified storage entry of the given account into
+// the prestate if it hasn't been touched yet.
+func (t *prestateTracer) lookupStorage(addr common.Address, key common.Hash) {
+	t.lookupAccount(addr)
+
+	account := t.prestate[addr]
+	if _, ok := account.Storage[key]; ok {
+		return
+	}
+	account.Storage[key] = t.env.StateDB.GetState(addr, key)
+	account.slots = append(account.slots, key)
+}
+
+// GetResult returns the JSON encoded prestate, or any accumulated error. The
+// accounts and storage slots are emitted in the order they were first touched,
+// matching the output of the JavaScript tracer byte for byte.
+func (t *prestateTracer) GetResult() (json.RawMessage, error) {
+	if atomic.LoadUint32(&t.interrupt) > 0 {
+		return nil, t.reason
+	}
+	if t.env == nil {
+		return json.RawMessage("{}"), nil
+	}
+	if t.config.DiffMode {
+		return t.diffResult(), nil
+	}
+	// At this point, we need to deduct the 'value' from the outer transaction,
+	// and move it back to the origin
+	t.lookupAccount(t.from)
+
+	from, to := t.prestate[t.from], t.prestate[t.to]
+	to.Balance = new(big.Int).Sub(to.Balance, t.value)
+
+	fee := new(big.Int).SetUint64(t.gasUsed + t.intrinsicGas)
+	fee.Mul(fee, t.env.TxContext.GasPrice)
+	from.Balance = new(big.Int).Add(from.Balance, t.value)
+	from.Balance.Add(from.Balance, fee)
+
+	// Decrement the caller's nonce, and remove empty create targets
+	from.Nonce--
+	if t.create {
+		// We can blindly delete the contract prestate, as any existing state would
+		// have caused the transaction to be rejected as invalid in the first place.
+		delete(t.prestate, t.to)
+	}
+	// Assemble the allocations in access order
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	for _, addr := range t.accounts {
+		account, ok := t.prestate[addr]
+		if !ok {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+
+		buf.WriteString(`"` + addrToHex(addr) + `":{`)
+		buf.WriteString(`"balance":"` + bigToHex(account.Balance) + `",`)
+		buf.WriteString(`"nonce":` + strconv.FormatInt(account.Nonce, 10) + `,`)
+		buf.WriteString(`"code":"` + hexutil.Encode(account.Code) + `",`)
+		buf.WriteString(`"storage":{`)
+		for i, slot := range account.slots {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(`"` + hexutil.Encode(slot[:]) + `":"` + hexutil.Encode(account.Storage[slot].Bytes()) + `"`)
+		}
+		buf.WriteString("}}")
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// diffResult assembles the pre and post values of all the modified accounts,
+// in access order. Unchanged fields and storage slots are omitted, so are the
+// accounts that did not exist before the transaction from the pre-state and
+// the self-destructed or emptied accounts from the post-state.
+func (t *prestateTracer) diffResult() json.RawMessage {
+	var (
+		db     = t.env.StateDB
+		eip158 = t.env.ChainConfig().IsEIP158(t.env.Context.BlockNumber)
+
+		pre, post   bytes.Buffer
+		npre, npost int
+	)
+	for _, addr := range t.accounts {
+		var (
+			account = t.prestate[addr]
+			deleted = db.HasSuicided(addr) || (eip158 && db.Empty(addr))
+			current = &prestateAccount{Balance: new(big.Int), Storage: make(map[common.Hash]common.Hash)}
+		)
+		if !deleted {
+			current.Balance.Set(db.GetBalance(addr))
+			current.Nonce = int64(db.GetNonce(addr))
+			current.Code = db.GetCode(addr)
+		}
+		// Collect the modified storage slots
+		var slots []common.Hash
+		for _, slot := range account.slots {
+			if !deleted {
+				current.Storage[slot] = db.GetState(addr, slot)
+			}
+			if current.Storage[slot] != account.Storage[slot] {
+				slots = append(slots, slot)
+			}
+		}
+		var (
+			balance = account.Balance.Cmp(current.Balance) != 0
+			nonce   = account.Nonce != current.Nonce
+			code    = !bytes.Equal(account.Code, current.Code)
+		)
+		if !balance && !nonce && !code && len(slots) == 0 {
+			continue
+		}
+		if !account.empty() {
+			writeDiffAccount(&pre, npre, addr, account, true, true, true, slots)
+			npre++
+		}
+		if !deleted {
+			writeDiffAccount(&post, npost, addr, current, balance, nonce, code, slots)
+			npost++
+		}
+	}
+	return json.RawMessage(`{"pre":{` + pre.String() + `},"post":{` + post.String() + `}}`)
+}
+
+// writeDiffAccount writes the requested fields of an account in a state diff,
+// omitting the empty code and storage values and zero nonces.
+func writeDiffAccount(buf *bytes.Buffer, index int, addr common.Address, account *prestateAccount, balance, nonce, code bool, slots []common.Hash) {
+	if index > 0 {
+		buf.WriteByte(',')
+	}
+	var fields []string
+	if balance {
+		fields = append(fields, `"balance":"`+bigToHex(account.Balance)+`"`)
+	}
+	if nonce && account.Nonce != 0 {
+		fields = append(fields, `"nonce":`+strconv.FormatInt(account.Nonce, 10))
+	}
+	if code && len(account.Code) > 0 {
+		fields = append(fields, `"code":"`+hexutil.Encode(account.Code)+`"`)
+	}
+	var storage []string
+	for _, slot := range slots {
+		if value := account.Storage[slot]; value != (common.Hash{}) {
+			storage = append(storage, `"`+hexutil.Encode(slot[:])+`":"`+hexutil.Encode(value[:])+`"`)
+		}
+	}
+	if len(storage) > 0 {
+		fields = append(fields, `"storage":{`+strings.Join(storage, ",")+`}`)
+	}
+	buf.WriteString(`"` + addrToHex(addr) + `":{` + strings.Join(fields, ",") + `}`)
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *prestateTracer) Stop(err error) {
+	t.reason = err
+	atomic.StoreUint32(&t.interrupt, 1)
+}
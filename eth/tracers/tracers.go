@@ -14,18 +14,70 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
-// Package tracers is a collection of JavaScript transaction tracers.
+// Package tracers is a collection of JavaScript and native Go transaction tracers.
 package tracers
 
 import (
+	"encoding/json"
 	"strings"
+	"sync"
 	"unicode"
 
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth/tracers/internal/tracers"
 )
 
-// all contains all the built in JavaScript tracers by name.
-var all = make(map[string]string)
+// NativeTracer is a transaction tracer implemented in Go. Apart from the plain
+// vm.Tracer hooks, it needs to be able to assemble its result into JSON and to
+// be aborted early, the same way the JavaScript tracers are.
+type NativeTracer interface {
+	vm.Tracer
+
+	// GetResult returns the JSON encoded result of the tracing.
+	GetResult() (json.RawMessage, error)
+
+	// Stop terminates execution of the tracer at the first opportune moment.
+	Stop(err error)
+}
+
+var (
+	// all contains all the built in JavaScript tracers by name.
+	all = make(map[string]string)
+
+	// native contains the constructors of all the registered Go tracers by name.
+	native     = make(map[string]func(cfg json.RawMessage) (NativeTracer, error))
+	nativeLock sync.RWMutex
+)
+
+// RegisterNativeTracer makes a Go tracer available by name for the tracing APIs.
+// The constructor receives the user supplied tracer configuration, which may be
+// empty. It panics if a tracer is registered twice under the same name.
+func RegisterNativeTracer(name string, ctor func(cfg json.RawMessage) (NativeTracer, error)) {
+	nativeLock.Lock()
+	defer nativeLock.Unlock()
+
+	if _, ok := native[name]; ok {
+		panic("duplicate native tracer: " + name)
+	}
+	native[name] = ctor
+}
+
+// NewNativeTracer creates a new instance of the native tracer registered under
+// the given name, if any, configured with the given tracer config.
+func NewNativeTracer(name string, cfg json.RawMessage) (NativeTracer, bool, error) {
+	nativeLock.RLock()
+	ctor, ok := native[name]
+	nativeLock.RUnlock()
+
+	if !ok {
+		return nil, false, nil
+	}
+	tracer, err := ctor(cfg)
+	if err != nil {
+		return nil, true, err
+	}
+	return tracer, true, nil
+}
 
 // camel converts a snake cased input string into a camel cased output.
 func camel(str string) string {
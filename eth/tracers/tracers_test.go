@@ -17,6 +17,7 @@
 package tracers
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"crypto/rand"
 	"encoding/json"
@@ -24,6 +25,7 @@ import (
 	"math/big"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -32,6 +34,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -179,7 +182,7 @@ func TestPrestateTracerCreate2(t *testing.T) {
 	}
 	evm := vm.NewEVM(context, txContext, statedb, params.MainnetChainConfig, vm.Config{Debug: true, Tracer: tracer})
 
-	msg, err := tx.AsMessage(signer)
+	msg, err := tx.AsMessage(signer, nil)
 	if err != nil {
 		t.Fatalf("failed to prepare transaction for tracing: %v", err)
 	}
@@ -254,14 +257,31 @@ func TestCallTracer(t *testing.T) {
 			}
 			evm := vm.NewEVM(context, txContext, statedb, test.Genesis.Config, vm.Config{Debug: true, Tracer: tracer})
 
-			msg, err := tx.AsMessage(signer)
+			msg, err := tx.AsMessage(signer, nil)
 			if err != nil {
 				t.Fatalf("failed to prepare transaction for tracing: %v", err)
 			}
 			st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
-			if _, err = st.TransitionDb(); err != nil {
+			result, err := st.TransitionDb()
+			if err != nil {
 				t.Fatalf("failed to execute transaction: %v", err)
 			}
+			// Tracing forces the step by step execution, ensure that executing the
+			// transaction block by block without a tracer yields the same outcome
+			_, plainState := tests.MakePreState(rawdb.NewMemoryDatabase(), test.Genesis.Alloc, false)
+			plainEVM := vm.NewEVM(context, txContext, plainState, test.Genesis.Config, vm.Config{})
+			plain, err := core.ApplyMessage(plainEVM, msg, new(core.GasPool).AddGas(tx.Gas()))
+			if err != nil {
+				t.Fatalf("failed to execute transaction without tracer: %v", err)
+			}
+			if plain.UsedGas != result.UsedGas || !bytes.Equal(plain.ReturnData, result.ReturnData) || (plain.Err == nil) != (result.Err == nil) {
+				t.Fatalf("untraced execution mismatch: have gas %d, return %x, err %v, want gas %d, return %x, err %v",
+					plain.UsedGas, plain.ReturnData, plain.Err, result.UsedGas, result.ReturnData, result.Err)
+			}
+			deleteEmpty := test.Genesis.Config.IsEIP158(context.BlockNumber)
+			if have, want := plainState.IntermediateRoot(deleteEmpty), statedb.IntermediateRoot(deleteEmpty); have != want {
+				t.Fatalf("untraced execution state mismatch: have %x, want %x", have, want)
+			}
 			// Retrieve the trace result and compare against the etalon
 			res, err := tracer.GetResult()
 			if err != nil {
@@ -300,3 +320,334 @@ func jsonEqual(x, y interface{}) bool {
 	}
 	return reflect.DeepEqual(xTrace, yTrace)
 }
+
+// timeField matches the non-deterministic execution time in a call trace.
+var timeField = regexp.MustCompile(`,"time":"[^"]*"`)
+
+// Iterates over all the input-output datasets in the tracer test harness and
+// checks that the native tracers produce byte-identical output to their
+// JavaScript counterparts.
+func TestNativeTracers(t *testing.T) {
+	files, err := ioutil.ReadDir("testdata")
+	if err != nil {
+		t.Fatalf("failed to retrieve tracer test suite: %v", err)
+	}
+	pairs := map[string]string{
+		"callTracer":     "callTracerNative",
+		"prestateTracer": "prestateTracerNative",
+	}
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name(), "call_tracer_") {
+			continue
+		}
+		file := file // capture range variable
+		t.Run(camel(strings.TrimSuffix(strings.TrimPrefix(file.Name(), "call_tracer_"), ".json")), func(t *testing.T) {
+			t.Parallel()
+
+			blob, err := ioutil.ReadFile(filepath.Join("testdata", file.Name()))
+			if err != nil {
+				t.Fatalf("failed to read testcase: %v", err)
+			}
+			test := new(callTracerTest)
+			if err := json.Unmarshal(blob, test); err != nil {
+				t.Fatalf("failed to parse testcase: %v", err)
+			}
+			for js, native := range pairs {
+				want := runTracerTest(t, test, js)
+				have := runTracerTest(t, test, native)
+
+				want, have = timeField.ReplaceAll(want, nil), timeField.ReplaceAll(have, nil)
+				if !bytes.Equal(have, want) {
+					t.Fatalf("%s mismatch: \nhave %s\nwant %s", native, have, want)
+				}
+			}
+		})
+	}
+}
+
+// runTracerTest executes the transaction of a call tracer test with the given
+// native or JavaScript tracer and returns the raw trace result.
+func runTracerTest(t *testing.T, test *callTracerTest, name string) []byte {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(common.FromHex(test.Input), tx); err != nil {
+		t.Fatalf("failed to parse testcase input: %v", err)
+	}
+	signer := types.MakeSigner(test.Genesis.Config, new(big.Int).SetUint64(uint64(test.Context.Number)))
+	origin, _ := signer.Sender(tx)
+	txContext := vm.TxContext{
+		Origin:   origin,
+		GasPrice: tx.GasPrice(),
+	}
+	context := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Coinbase:    test.Context.Miner,
+		BlockNumber: new(big.Int).SetUint64(uint64(test.Context.Number)),
+		Time:        new(big.Int).SetUint64(uint64(test.Context.Time)),
+		Difficulty:  (*big.Int)(test.Context.Difficulty),
+		GasLimit:    uint64(test.Context.GasLimit),
+	}
+	_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), test.Genesis.Alloc, false)
+
+	tracer, ok, err := NewNativeTracer(name, nil)
+	if err != nil {
+		t.Fatalf("failed to create %s: %v", name, err)
+	}
+	if !ok {
+		if tracer, err = New(name, txContext); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+	evm := vm.NewEVM(context, txContext, statedb, test.Genesis.Config, vm.Config{Debug: true, Tracer: tracer})
+
+	msg, err := tx.AsMessage(signer, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+	if _, err = st.TransitionDb(); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve %s result: %v", name, err)
+	}
+	return res
+}
+
+// diffAccount is an account in the output of the prestate tracer in diff mode.
+type diffAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Nonce   *uint64                     `json:"nonce"`
+	Code    hexutil.Bytes               `json:"code"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// diffResult is the output of the prestate tracer in diff mode.
+type diffResult struct {
+	Pre  map[common.Address]*diffAccount `json:"pre"`
+	Post map[common.Address]*diffAccount `json:"post"`
+}
+
+// runDiffTest executes a transaction with the prestate tracer in diff mode and
+// checks that the reported pre values match the state before the transaction,
+// the reported post values match the state after it, and that no account was
+// deleted without it being reported.
+func runDiffTest(t *testing.T, config *params.ChainConfig, context vm.BlockContext, alloc core.GenesisAlloc, tx *types.Transaction, signer types.Signer) *diffResult {
+	t.Helper()
+
+	_, prestate := tests.MakePreState(rawdb.NewMemoryDatabase(), alloc, false)
+	_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), alloc, false)
+
+	msg, err := tx.AsMessage(signer, nil)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	tracer, _, err := NewNativeTracer("prestateTracerNative", json.RawMessage(`{"diffMode": true}`))
+	if err != nil {
+		t.Fatalf("failed to create tracer: %v", err)
+	}
+	evm := vm.NewEVM(context, core.NewEVMTxContext(msg), statedb, config, vm.Config{Debug: true, Tracer: tracer})
+	if _, err = core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(tx.Gas())); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	statedb.Finalise(config.IsEIP158(context.BlockNumber))
+
+	diff := new(diffResult)
+	if err := json.Unmarshal(res, diff); err != nil {
+		t.Fatalf("failed to unmarshal trace result: %v", err)
+	}
+	check := func(kind string, state *state.StateDB, addr common.Address, account *diffAccount) {
+		if account.Balance != nil && state.GetBalance(addr).Cmp(account.Balance.ToInt()) != 0 {
+			t.Errorf("%s balance mismatch for %x: have %v, want %v", kind, addr, account.Balance, state.GetBalance(addr))
+		}
+		if account.Nonce != nil && state.GetNonce(addr) != *account.Nonce {
+			t.Errorf("%s nonce mismatch for %x: have %d, want %d", kind, addr, *account.Nonce, state.GetNonce(addr))
+		}
+		if account.Code != nil && !bytes.Equal(state.GetCode(addr), account.Code) {
+			t.Errorf("%s code mismatch for %x", kind, addr)
+		}
+		for slot, value := range account.Storage {
+			if have := state.GetState(addr, slot); have != value {
+				t.Errorf("%s storage mismatch for %x slot %x: have %x, want %x", kind, addr, slot, value, have)
+			}
+		}
+	}
+	for addr, account := range diff.Pre {
+		if !prestate.Exist(addr) {
+			t.Errorf("non-existent account %x in pre-state", addr)
+		}
+		if account.Balance == nil {
+			t.Errorf("balance missing for %x in pre-state", addr)
+		}
+		check("pre", prestate, addr, account)
+	}
+	for addr, account := range diff.Post {
+		if !statedb.Exist(addr) {
+			t.Errorf("deleted account %x in post-state", addr)
+		}
+		check("post", statedb, addr, account)
+	}
+	for addr := range diff.Pre {
+		if _, ok := diff.Post[addr]; !ok && statedb.Exist(addr) {
+			t.Errorf("account %x missing from post-state", addr)
+		}
+	}
+	return diff
+}
+
+// Tests that the prestate tracer diff mode reports consistent state changes for
+// all the transactions in the tracer test harness.
+func TestPrestateTracerDiffMode(t *testing.T) {
+	files, err := ioutil.ReadDir("testdata")
+	if err != nil {
+		t.Fatalf("failed to retrieve tracer test suite: %v", err)
+	}
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name(), "call_tracer_") {
+			continue
+		}
+		file := file // capture range variable
+		t.Run(camel(strings.TrimSuffix(strings.TrimPrefix(file.Name(), "call_tracer_"), ".json")), func(t *testing.T) {
+			t.Parallel()
+
+			blob, err := ioutil.ReadFile(filepath.Join("testdata", file.Name()))
+			if err != nil {
+				t.Fatalf("failed to read testcase: %v", err)
+			}
+			test := new(callTracerTest)
+			if err := json.Unmarshal(blob, test); err != nil {
+				t.Fatalf("failed to parse testcase: %v", err)
+			}
+			tx := new(types.Transaction)
+			if err := rlp.DecodeBytes(common.FromHex(test.Input), tx); err != nil {
+				t.Fatalf("failed to parse testcase input: %v", err)
+			}
+			context := vm.BlockContext{
+				CanTransfer: core.CanTransfer,
+				Transfer:    core.Transfer,
+				Coinbase:    test.Context.Miner,
+				BlockNumber: new(big.Int).SetUint64(uint64(test.Context.Number)),
+				Time:        new(big.Int).SetUint64(uint64(test.Context.Time)),
+				Difficulty:  (*big.Int)(test.Context.Difficulty),
+				GasLimit:    uint64(test.Context.GasLimit),
+			}
+			signer := types.MakeSigner(test.Genesis.Config, context.BlockNumber)
+			diff := runDiffTest(t, test.Genesis.Config, context, test.Genesis.Alloc, tx, signer)
+
+			// The sender and the coinbase always change
+			origin, _ := signer.Sender(tx)
+			if _, ok := diff.Post[origin]; !ok {
+				t.Errorf("sender missing from post-state")
+			}
+			if _, ok := diff.Post[test.Context.Miner]; !ok {
+				t.Errorf("coinbase missing from post-state")
+			}
+		})
+	}
+}
+
+// Tests that the prestate tracer diff mode handles created, self-destructed and
+// emptied accounts.
+func TestPrestateTracerDiffModeLifecycle(t *testing.T) {
+	var (
+		key, _      = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		origin      = crypto.PubkeyToAddress(key.PublicKey)
+		caller      = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+		destructor  = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+		beneficiary = common.HexToAddress("0x00000000000000000000000000000000000000cc")
+		empty       = common.HexToAddress("0x00000000000000000000000000000000000000dd")
+		coinbase    = common.HexToAddress("0x00000000000000000000000000000000000000ee")
+		signer      = types.LatestSignerForChainID(params.TestChainConfig.ChainID)
+		context     = vm.BlockContext{
+			CanTransfer: core.CanTransfer,
+			Transfer:    core.Transfer,
+			Coinbase:    coinbase,
+			BlockNumber: big.NewInt(1),
+			Time:        big.NewInt(1),
+			Difficulty:  big.NewInt(1),
+			GasLimit:    10000000,
+		}
+	)
+	// The caller overwrites slot 0 and clears slot 1, touches an empty account
+	// with a zero value call, then calls the destructor with 16 wei. The
+	// destructor sends all its funds to a non-existent beneficiary.
+	code := []byte{
+		byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x00, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x01, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0x00, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1),
+		byte(vm.PUSH20),
+	}
+	code = append(code, empty.Bytes()...)
+	code = append(code, byte(vm.GAS), byte(vm.CALL), byte(vm.POP))
+	code = append(code, byte(vm.PUSH1), 0x00, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), byte(vm.PUSH1), 0x10, byte(vm.PUSH20))
+	code = append(code, destructor.Bytes()...)
+	code = append(code, byte(vm.GAS), byte(vm.CALL), byte(vm.POP), byte(vm.STOP))
+
+	alloc := core.GenesisAlloc{
+		origin: {Balance: big.NewInt(params.Ether)},
+		caller: {
+			Balance: big.NewInt(100),
+			Code:    code,
+			Storage: map[common.Hash]common.Hash{{0x00}: {0x05}, common.BigToHash(common.Big1): {0x06}},
+		},
+		destructor: {
+			Balance: big.NewInt(50),
+			Code:    append([]byte{byte(vm.PUSH20)}, append(beneficiary.Bytes(), byte(vm.SELFDESTRUCT))...),
+		},
+		empty: {Balance: new(big.Int)},
+	}
+	tx, _ := types.SignTx(types.NewTransaction(0, caller, big.NewInt(1), 200000, big.NewInt(1), nil), signer, key)
+	diff := runDiffTest(t, params.TestChainConfig, context, alloc, tx, signer)
+
+	if _, ok := diff.Post[destructor]; ok {
+		t.Errorf("self-destructed account in post-state")
+	}
+	if account := diff.Pre[destructor]; account == nil || account.Balance.ToInt().Int64() != 50 {
+		t.Errorf("self-destructed account pre-state mismatch: %v", account)
+	}
+	if _, ok := diff.Pre[beneficiary]; ok {
+		t.Errorf("non-existent beneficiary in pre-state")
+	}
+	if account := diff.Post[beneficiary]; account == nil || account.Balance.ToInt().Int64() != 66 {
+		t.Errorf("beneficiary post-state mismatch: %v", account)
+	}
+	if _, ok := diff.Post[empty]; ok {
+		t.Errorf("touched empty account in post-state")
+	}
+	account := diff.Post[caller]
+	if account == nil || account.Nonce != nil || account.Code != nil || len(account.Storage) != 1 {
+		t.Fatalf("caller post-state mismatch: %v", account)
+	}
+	if account.Balance.ToInt().Int64() != 85 {
+		t.Errorf("caller balance mismatch: have %v, want 85", account.Balance)
+	}
+	if account.Storage[common.Hash{}] != common.BigToHash(common.Big1) {
+		t.Errorf("caller storage mismatch: %v", account.Storage)
+	}
+	if pre := diff.Pre[caller]; len(pre.Storage) != 2 {
+		t.Errorf("caller pre-state storage mismatch: %v", pre.Storage)
+	}
+	if _, ok := diff.Pre[coinbase]; ok {
+		t.Errorf("non-existent coinbase in pre-state")
+	}
+	// A contract creation must report the new account in the post-state only
+	initcode := []byte{byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x00, byte(vm.SSTORE), byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x00, byte(vm.RETURN)}
+	tx, _ = types.SignTx(types.NewContractCreation(0, big.NewInt(7), 200000, big.NewInt(1), initcode), signer, key)
+	diff = runDiffTest(t, params.TestChainConfig, context, alloc, tx, signer)
+
+	created := crypto.CreateAddress(origin, 0)
+	if _, ok := diff.Pre[created]; ok {
+		t.Errorf("created account in pre-state")
+	}
+	if account := diff.Post[created]; account == nil || *account.Nonce != 1 || len(account.Code) != 1 || account.Balance.ToInt().Int64() != 7 || len(account.Storage) != 1 {
+		t.Errorf("created account post-state mismatch: %v", account)
+	}
+	if account := diff.Pre[origin]; account == nil || account.Nonce != nil || account.Balance.ToInt().Cmp(big.NewInt(params.Ether)) != 0 {
+		t.Errorf("sender pre-state mismatch: %v", account)
+	}
+}
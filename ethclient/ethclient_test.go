@@ -49,6 +49,7 @@ var (
 	_ = ethereum.ContractCaller(&Client{})
 	_ = ethereum.GasEstimator(&Client{})
 	_ = ethereum.GasPricer(&Client{})
+	_ = ethereum.FeeHistoryReader(&Client{})
 	_ = ethereum.LogFilterer(&Client{})
 	_ = ethereum.PendingStateReader(&Client{})
 	// _ = ethereum.PendingStateEventer(&Client{})
@@ -431,6 +432,23 @@ func testGetBlock(t *testing.T, client *rpc.Client) {
 	if block.Header().Hash() != headerH.Hash() {
 		t.Fatalf("HeaderByHash returned wrong header: want %v got %v", block.Header().Hash().Hex(), headerH.Hash().Hex())
 	}
+	// Get block receipts by number and by hash
+	for _, id := range []rpc.BlockNumberOrHash{
+		rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(blockNumber)),
+		rpc.BlockNumberOrHashWithHash(block.Hash(), true),
+	} {
+		receipts, err := ec.BlockReceipts(context.Background(), id)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(receipts) != len(block.Transactions()) {
+			t.Fatalf("BlockReceipts returned wrong number of receipts: want %d got %d", len(block.Transactions()), len(receipts))
+		}
+	}
+	// Get block receipts of a non-existent block
+	if _, err := ec.BlockReceipts(context.Background(), rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(blockNumber+1))); err != ethereum.NotFound {
+		t.Fatalf("BlockReceipts returned wrong error: want %v got %v", ethereum.NotFound, err)
+	}
 }
 
 func testStatusFunctions(t *testing.T, client *rpc.Client) {
@@ -460,6 +478,23 @@ func testStatusFunctions(t *testing.T, client *rpc.Client) {
 	if gasPrice.Cmp(big.NewInt(1000000000)) != 0 {
 		t.Fatalf("unexpected gas price: %v", gasPrice)
 	}
+	// FeeHistory
+	history, err := ec.FeeHistory(context.Background(), 1, big.NewInt(1), []float64{95, 99})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if history.OldestBlock.Cmp(big.NewInt(1)) != 0 {
+		t.Fatalf("unexpected oldest block: %v", history.OldestBlock)
+	}
+	if len(history.Reward) != 1 || len(history.Reward[0]) != 2 || history.Reward[0][0].Sign() != 0 || history.Reward[0][1].Sign() != 0 {
+		t.Fatalf("unexpected rewards: %v", history.Reward)
+	}
+	if len(history.BaseFee) != 2 || history.BaseFee[0].Sign() != 0 || history.BaseFee[1].Sign() != 0 {
+		t.Fatalf("unexpected base fees: %v", history.BaseFee)
+	}
+	if !reflect.DeepEqual(history.GasUsedRatio, []float64{0}) {
+		t.Fatalf("unexpected gas used ratios: %v", history.GasUsedRatio)
+	}
 }
 
 func testCallContract(t *testing.T, client *rpc.Client) {
@@ -76,9 +76,19 @@ type AncientReader interface {
 	// Ancient retrieves an ancient binary blob from the append-only immutable files.
 	Ancient(kind string, number uint64) ([]byte, error)
 
+	// AncientRange retrieves multiple items in sequence, starting from the index
+	// 'start'. It will return at most 'count' items, and at least one item even
+	// if it exceeds maxBytes, but will otherwise return as many items as fit
+	// into maxBytes.
+	AncientRange(kind string, start, count, maxBytes uint64) ([][]byte, error)
+
 	// Ancients returns the ancient item numbers in the ancient store.
 	Ancients() (uint64, error)
 
+	// AncientTail returns the number of the first item still available in the
+	// specified category of the ancient store.
+	AncientTail(kind string) (uint64, error)
+
 	// AncientSize returns the ancient size of the specified category.
 	AncientSize(kind string) (uint64, error)
 }
@@ -92,6 +102,10 @@ type AncientWriter interface {
 	// TruncateAncients discards all but the first n ancient data from the ancient store.
 	TruncateAncients(n uint64) error
 
+	// TruncateAncientTail discards the first n ancient data of the specified
+	// category from the ancient store, leaving the item numbers intact.
+	TruncateAncientTail(kind string, n uint64) error
+
 	// Sync flushes all in-memory ancient store data to disk.
 	Sync() error
 }
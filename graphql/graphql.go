@@ -18,6 +18,7 @@
 package graphql
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -736,6 +737,20 @@ func (b *Block) TransactionAt(ctx context.Context, args struct{ Index int32 }) (
 	}, nil
 }
 
+func (b *Block) RawReceipts(ctx context.Context) (*[]hexutil.Bytes, error) {
+	receipts, err := b.resolveReceipts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]hexutil.Bytes, 0, len(receipts))
+	for i := range receipts {
+		buf := new(bytes.Buffer)
+		types.Receipts(receipts).EncodeIndex(i, buf)
+		ret = append(ret, buf.Bytes())
+	}
+	return &ret, nil
+}
+
 func (b *Block) OmmerAt(ctx context.Context, args struct{ Index int32 }) (*Block, error) {
 	block, err := b.resolve(ctx)
 	if err != nil || block == nil {
@@ -50,6 +50,25 @@ var (
 		Name:  "log.json",
 		Usage: "Format logs with JSON",
 	}
+	logstructuredFlag = cli.BoolFlag{
+		Name:  "log.structured",
+		Usage: "Format logs as JSON with a fixed schema (timestamp, level, module, message, attributes)",
+	}
+	logFileFlag = cli.StringFlag{
+		Name:  "log.file",
+		Usage: "Write logs to a file too, rotating it by size",
+		Value: "",
+	}
+	logMaxSizeFlag = cli.IntFlag{
+		Name:  "log.maxsize",
+		Usage: "Maximum size in megabytes of the log file before it gets rotated (0 = never rotate)",
+		Value: 100,
+	}
+	logMaxBackupsFlag = cli.IntFlag{
+		Name:  "log.maxbackups",
+		Usage: "Maximum number of rotated log files to retain",
+		Value: 10,
+	}
 	backtraceAtFlag = cli.StringFlag{
 		Name:  "log.backtrace",
 		Usage: "Request a stack trace at a specific logging statement (e.g. \"block.go:271\")",
@@ -130,6 +149,10 @@ var Flags = []cli.Flag{
 	verbosityFlag,
 	vmoduleFlag,
 	logjsonFlag,
+	logstructuredFlag,
+	logFileFlag,
+	logMaxSizeFlag,
+	logMaxBackupsFlag,
 	backtraceAtFlag,
 	debugFlag,
 	pprofFlag,
@@ -165,15 +188,33 @@ func init() {
 func Setup(ctx *cli.Context) error {
 	var ostream log.Handler
 	output := io.Writer(os.Stderr)
-	if ctx.GlobalBool(logjsonFlag.Name) {
+	switch {
+	case ctx.GlobalBool(logstructuredFlag.Name):
+		ostream = log.StreamHandler(output, log.StructuredFormat())
+	case ctx.GlobalBool(logjsonFlag.Name):
 		ostream = log.StreamHandler(output, log.JSONFormat())
-	} else {
+	default:
 		usecolor := (isatty.IsTerminal(os.Stderr.Fd()) || isatty.IsCygwinTerminal(os.Stderr.Fd())) && os.Getenv("TERM") != "dumb"
 		if usecolor {
 			output = colorable.NewColorableStderr()
 		}
 		ostream = log.StreamHandler(output, log.TerminalFormat(usecolor))
 	}
+	if path := ctx.GlobalString(logFileFlag.Name); path != "" {
+		format := log.TerminalFormat(false)
+		switch {
+		case ctx.GlobalBool(logstructuredFlag.Name):
+			format = log.StructuredFormat()
+		case ctx.GlobalBool(logjsonFlag.Name):
+			format = log.JSONFormat()
+		}
+		maxSize := uint64(ctx.GlobalInt(logMaxSizeFlag.Name)) * 1024 * 1024
+		fstream, err := log.RotatingFileHandler(path, maxSize, ctx.GlobalInt(logMaxBackupsFlag.Name), format)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %v", err)
+		}
+		ostream = log.MultiHandler(ostream, fstream)
+	}
 	glogger.SetHandler(ostream)
 
 	// logging
@@ -0,0 +1,98 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// accumulatorDepth is the depth of the merkle tree the header records of an
+// epoch are accumulated into, 2^accumulatorDepth being MaxEra1Size.
+const accumulatorDepth = 13
+
+// zeroHashes contains the roots of empty subtrees at every level of the
+// accumulator tree.
+var zeroHashes [accumulatorDepth + 1]common.Hash
+
+func init() {
+	for i := 1; i < len(zeroHashes); i++ {
+		zeroHashes[i] = sha256Pair(zeroHashes[i-1], zeroHashes[i-1])
+	}
+}
+
+// ComputeAccumulator calculates the SSZ hash tree root of the Era1 accumulator
+// of header records, where each record is the pair of the block hash and the
+// total difficulty of the chain at that block. The root is computed over a list
+// with a capacity of MaxEra1Size records, so it is directly comparable with the
+// historical accumulator roots published for the pre-merge epochs.
+func ComputeAccumulator(hashes []common.Hash, tds []*big.Int) (common.Hash, error) {
+	if len(hashes) != len(tds) {
+		return common.Hash{}, fmt.Errorf("must have equal number hashes as td values: %d != %d", len(hashes), len(tds))
+	}
+	if len(hashes) > MaxEra1Size {
+		return common.Hash{}, fmt.Errorf("too many records: have %d, max %d", len(hashes), MaxEra1Size)
+	}
+	layer := make([]common.Hash, len(hashes))
+	for i := range hashes {
+		if tds[i].Sign() < 0 || tds[i].BitLen() > 256 {
+			return common.Hash{}, fmt.Errorf("invalid total difficulty for record %d: %v", i, tds[i])
+		}
+		// The total difficulty is an uint256, encoded little endian
+		var td common.Hash
+		copy(td[:], reverse(common.LeftPadBytes(tds[i].Bytes(), 32)))
+		layer[i] = sha256Pair(hashes[i], td)
+	}
+	for depth := 0; depth < accumulatorDepth; depth++ {
+		if len(layer)%2 == 1 {
+			layer = append(layer, zeroHashes[depth])
+		}
+		next := make([]common.Hash, len(layer)/2)
+		for i := range next {
+			next[i] = sha256Pair(layer[2*i], layer[2*i+1])
+		}
+		layer = next
+	}
+	root := zeroHashes[accumulatorDepth]
+	if len(layer) > 0 {
+		root = layer[0]
+	}
+	// Mix in the length of the list
+	var length common.Hash
+	binary.LittleEndian.PutUint64(length[:], uint64(len(hashes)))
+	return sha256Pair(root, length), nil
+}
+
+// sha256Pair returns the SHA256 hash of the concatenation of a and b.
+func sha256Pair(a, b common.Hash) common.Hash {
+	h := sha256.New()
+	h.Write(a[:])
+	h.Write(b[:])
+	return common.BytesToHash(h.Sum(nil))
+}
+
+// reverse reverses the contents of b in place and returns it.
+func reverse(b []byte) []byte {
+	for i := 0; i < len(b)/2; i++ {
+		b[i], b[len(b)-i-1] = b[len(b)-i-1], b[i]
+	}
+	return b
+}
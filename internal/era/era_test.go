@@ -0,0 +1,236 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// testBlocks creates a contiguous series of blocks starting at the given
+// number, each with a single transaction and matching receipt.
+func testBlocks(start uint64, count int) ([]*types.Block, []types.Receipts, []*big.Int) {
+	var (
+		blocks   []*types.Block
+		receipts []types.Receipts
+		tds      []*big.Int
+		parent   common.Hash
+		td       = big.NewInt(int64(start) * 100)
+	)
+	for i := 0; i < count; i++ {
+		header := &types.Header{
+			ParentHash: parent,
+			Number:     new(big.Int).SetUint64(start + uint64(i)),
+			Difficulty: big.NewInt(100),
+			GasLimit:   8_000_000,
+			Extra:      []byte{byte(i)},
+		}
+		tx := types.NewTransaction(uint64(i), common.Address{0xaa}, big.NewInt(1), 21000, big.NewInt(1), nil)
+		receipt := &types.Receipt{
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: 21000,
+			Logs:              []*types.Log{{Address: common.Address{byte(i)}, Topics: []common.Hash{{0x01}}, Data: []byte{byte(i)}}},
+		}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+
+		block := types.NewBlockWithHeader(header).WithBody([]*types.Transaction{tx}, nil)
+		td = new(big.Int).Add(td, header.Difficulty)
+
+		blocks = append(blocks, block)
+		receipts = append(receipts, types.Receipts{receipt})
+		tds = append(tds, td)
+		parent = block.Hash()
+	}
+	return blocks, receipts, tds
+}
+
+func TestEra1Builder(t *testing.T) {
+	f, err := ioutil.TempFile("", "era1-test")
+	if err != nil {
+		t.Fatalf("error creating temp file: %v", err)
+	}
+	defer os.Remove(f.Name())
+
+	var (
+		builder               = NewBuilder(f)
+		blocks, receipts, tds = testBlocks(8192, 128)
+	)
+	for i, block := range blocks {
+		if err := builder.Add(block, receipts[i], tds[i]); err != nil {
+			t.Fatalf("error adding entry: %v", err)
+		}
+	}
+	// Check that non-contiguous blocks are rejected
+	if err := builder.Add(blocks[0], receipts[0], tds[0]); err == nil {
+		t.Fatalf("non-contiguous block accepted")
+	}
+	root, err := builder.Finalize()
+	if err != nil {
+		t.Fatalf("error finalizing era1: %v", err)
+	}
+	hashes := make([]common.Hash, len(blocks))
+	for i, block := range blocks {
+		hashes[i] = block.Hash()
+	}
+	if want, _ := ComputeAccumulator(hashes, tds); root != want {
+		t.Fatalf("accumulator root mismatch: have %x, want %x", root, want)
+	}
+	// Verify Era1 contents.
+	e, err := Open(f.Name())
+	if err != nil {
+		t.Fatalf("failed to open era: %v", err)
+	}
+	defer e.Close()
+
+	if e.Start() != 8192 || e.Count() != 128 {
+		t.Fatalf("metadata mismatch: have start %d count %d, want 8192 and 128", e.Start(), e.Count())
+	}
+	if have, err := e.Accumulator(); err != nil || have != root {
+		t.Fatalf("stored accumulator mismatch: have %x (%v), want %x", have, err, root)
+	}
+	if have, err := e.InitialTD(); err != nil || have.Cmp(big.NewInt(8192*100)) != 0 {
+		t.Fatalf("initial total difficulty mismatch: have %v (%v), want %d", have, err, 8192*100)
+	}
+	// Check random access of blocks, in reverse to avoid relying on order
+	for i := len(blocks) - 1; i >= 0; i-- {
+		block, err := e.GetBlockByNumber(blocks[i].NumberU64())
+		if err != nil {
+			t.Fatalf("error reading block %d: %v", blocks[i].NumberU64(), err)
+		}
+		if block.Hash() != blocks[i].Hash() {
+			t.Fatalf("block %d hash mismatch: have %x, want %x", i, block.Hash(), blocks[i].Hash())
+		}
+		if block.Transactions()[0].Hash() != blocks[i].Transactions()[0].Hash() {
+			t.Fatalf("block %d body mismatch", i)
+		}
+		have, err := e.GetReceiptsByNumber(blocks[i].NumberU64())
+		if err != nil {
+			t.Fatalf("error reading receipts %d: %v", blocks[i].NumberU64(), err)
+		}
+		if types.DeriveSha(have, trie.NewStackTrie(nil)) != types.DeriveSha(receipts[i], trie.NewStackTrie(nil)) {
+			t.Fatalf("block %d receipts mismatch", i)
+		}
+	}
+	for _, n := range []uint64{0, 8191, 8192 + 128} {
+		if _, err := e.GetBlockByNumber(n); err == nil {
+			t.Fatalf("out of range block %d retrieved", n)
+		}
+	}
+	// Iterate over the entire archive
+	it, err := NewIterator(e)
+	if err != nil {
+		t.Fatalf("failed to create iterator: %v", err)
+	}
+	var i int
+	for ; it.Next(); i++ {
+		if err := it.Error(); err != nil {
+			t.Fatalf("iterator error: %v", err)
+		}
+		if it.Number() != blocks[i].NumberU64() {
+			t.Fatalf("iterator number mismatch: have %d, want %d", it.Number(), blocks[i].NumberU64())
+		}
+		block, rs, err := it.BlockAndReceipts()
+		if err != nil {
+			t.Fatalf("error reading block %d: %v", i, err)
+		}
+		if block.Hash() != blocks[i].Hash() {
+			t.Fatalf("block %d hash mismatch", i)
+		}
+		if len(rs) != 1 || !bytes.Equal(rs[0].Logs[0].Data, []byte{byte(i)}) {
+			t.Fatalf("block %d receipts mismatch", i)
+		}
+		td, err := it.TotalDifficulty()
+		if err != nil {
+			t.Fatalf("error reading total difficulty %d: %v", i, err)
+		}
+		if td.Cmp(tds[i]) != 0 {
+			t.Fatalf("block %d total difficulty mismatch: have %v, want %v", i, td, tds[i])
+		}
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("iterator error: %v", err)
+	}
+	if i != len(blocks) {
+		t.Fatalf("iterated block count mismatch: have %d, want %d", i, len(blocks))
+	}
+}
+
+func TestEra1Filename(t *testing.T) {
+	dir, err := ioutil.TempDir("", "era1-dir")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	root := common.HexToHash("0x5ec1ffb8c3b146f42606c74ced973dc16ec5a107c0345858c343fc94780b4218")
+	if have, want := Filename("mainnet", 1, root), "mainnet-00001-5ec1ffb8.era1"; have != want {
+		t.Fatalf("filename mismatch: have %s, want %s", have, want)
+	}
+	for _, name := range []string{"mainnet-00000-aaaaaaaa.era1", "mainnet-00001-bbbbbbbb.era1", "goerli-00000-cccccccc.era1", "checksums.txt"} {
+		ioutil.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+	files, err := ReadDir(dir, "mainnet")
+	if err != nil {
+		t.Fatalf("failed to read dir: %v", err)
+	}
+	if len(files) != 2 || files[0] != "mainnet-00000-aaaaaaaa.era1" || files[1] != "mainnet-00001-bbbbbbbb.era1" {
+		t.Fatalf("era1 files mismatch: %v", files)
+	}
+	ioutil.WriteFile(filepath.Join(dir, "mainnet-00003-dddddddd.era1"), nil, 0644)
+	if _, err := ReadDir(dir, "mainnet"); err == nil {
+		t.Fatalf("missing epoch not detected")
+	}
+}
+
+func TestAccumulator(t *testing.T) {
+	// An empty accumulator is the root of the empty list
+	empty, err := ComputeAccumulator(nil, nil)
+	if err != nil {
+		t.Fatalf("failed to compute empty accumulator: %v", err)
+	}
+	if empty != sha256Pair(zeroHashes[accumulatorDepth], common.Hash{}) {
+		t.Fatalf("empty accumulator mismatch: %x", empty)
+	}
+	// A single record is hashed with all the empty siblings
+	hash, td := common.Hash{0x01}, big.NewInt(0x0203)
+	root, err := ComputeAccumulator([]common.Hash{hash}, []*big.Int{td})
+	if err != nil {
+		t.Fatalf("failed to compute accumulator: %v", err)
+	}
+	node := sha256Pair(hash, common.Hash{0x03, 0x02})
+	for i := 0; i < accumulatorDepth; i++ {
+		node = sha256Pair(node, zeroHashes[i])
+	}
+	if want := sha256Pair(node, common.Hash{0x01}); root != want {
+		t.Fatalf("accumulator mismatch: have %x, want %x", root, want)
+	}
+	// Invalid inputs are rejected
+	if _, err := ComputeAccumulator([]common.Hash{hash}, nil); err == nil {
+		t.Fatalf("mismatching inputs accepted")
+	}
+	if _, err := ComputeAccumulator(make([]common.Hash, MaxEra1Size+1), make([]*big.Int, MaxEra1Size+1)); err == nil {
+		t.Fatalf("oversized accumulator accepted")
+	}
+}
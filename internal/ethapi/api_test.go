@@ -0,0 +1,255 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	// counterAddr holds a contract which increments the value of slot 0 on each
+	// call and returns the new value.
+	counterAddr = common.HexToAddress("0x1000")
+	counterCode = common.FromHex("0x6000546001018060005560005260206000f3")
+
+	// blockAddr holds a contract returning the block number and timestamp.
+	blockAddr = common.HexToAddress("0x2000")
+	blockCode = common.FromHex("0x436000524260205260406000f3")
+
+	// returnDataAddr holds a contract using RETURNDATASIZE, which is only
+	// available from Byzantium onwards.
+	returnDataAddr = common.HexToAddress("0x3000")
+	returnDataCode = common.FromHex("0x3d60005260206000f3")
+
+	// balanceAddr holds a contract returning the balance of the caller.
+	balanceAddr = common.HexToAddress("0x4000")
+	balanceCode = common.FromHex("0x333160005260206000f3")
+
+	// senderAddr is a funded account to send calls from.
+	senderAddr    = common.HexToAddress("0x5000")
+	senderBalance = big.NewInt(1000000000)
+
+	// txSenderKey is the key of a funded account to sign transactions with.
+	txSenderKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	txSenderAddr   = crypto.PubkeyToAddress(txSenderKey.PublicKey)
+)
+
+// testBackend is a minimal Backend implementation, serving calls on top of a
+// block chain containing only the genesis block.
+type testBackend struct {
+	Backend // unimplemented methods panic
+
+	chain *core.BlockChain
+}
+
+// newTestBackend creates a backend for a chain which activates Byzantium at
+// block 10.
+func newTestBackend(t *testing.T) *testBackend {
+	return newTestBackendWithConfig(t, &params.ChainConfig{
+		ChainID:             big.NewInt(1),
+		HomesteadBlock:      big.NewInt(0),
+		EIP150Block:         big.NewInt(0),
+		EIP155Block:         big.NewInt(0),
+		EIP158Block:         big.NewInt(0),
+		ByzantiumBlock:      big.NewInt(10),
+		ConstantinopleBlock: big.NewInt(10),
+		PetersburgBlock:     big.NewInt(10),
+		IstanbulBlock:       big.NewInt(10),
+		MuirGlacierBlock:    big.NewInt(10),
+		Ethash:              new(params.EthashConfig),
+	})
+}
+
+// newTestBackendWithConfig creates a backend for a chain with the given config.
+func newTestBackendWithConfig(t *testing.T, config *params.ChainConfig) *testBackend {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		genesis = &core.Genesis{
+			Config: config,
+			Alloc: core.GenesisAlloc{
+				counterAddr:    {Balance: new(big.Int), Code: counterCode},
+				blockAddr:      {Balance: new(big.Int), Code: blockCode},
+				returnDataAddr: {Balance: new(big.Int), Code: returnDataCode},
+				balanceAddr:    {Balance: new(big.Int), Code: balanceCode},
+				senderAddr:     {Balance: senderBalance},
+				txSenderAddr:   {Balance: senderBalance},
+			},
+		}
+	)
+	genesis.MustCommit(db)
+
+	chain, err := core.NewBlockChain(db, nil, config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	t.Cleanup(chain.Stop)
+	return &testBackend{chain: chain}
+}
+
+func (b *testBackend) ChainConfig() *params.ChainConfig { return b.chain.Config() }
+func (b *testBackend) RPCGasCap() uint64                { return 25000000 }
+
+func (b *testBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	header := b.chain.CurrentHeader()
+	statedb, err := b.chain.StateAt(header.Root)
+	return statedb, header, err
+}
+
+func (b *testBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmConfig *vm.Config) (*vm.EVM, func() error, error) {
+	txContext := core.NewEVMTxContext(msg)
+	context := core.NewEVMBlockContext(header, b.chain, nil)
+	return vm.NewEVM(context, txContext, state, b.chain.Config(), *vmConfig), func() error { return nil }, nil
+}
+
+func callMany(t *testing.T, calls []CallArgs, overrides *BlockOverrides) []*BundleCallResult {
+	t.Helper()
+
+	api := NewPublicBlockChainAPI(newTestBackend(t))
+	results, err := api.CallMany(context.Background(), calls, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil, overrides)
+	if err != nil {
+		t.Fatalf("failed to execute calls: %v", err)
+	}
+	if len(results) != len(calls) {
+		t.Fatalf("result count mismatch: have %d, want %d", len(results), len(calls))
+	}
+	return results
+}
+
+// Tests that the state changes of each call are visible to the subsequent ones.
+func TestCallManyStateCarryOver(t *testing.T) {
+	calls := []CallArgs{{To: &counterAddr}, {To: &counterAddr}, {To: &counterAddr}}
+	for i, res := range callMany(t, calls, nil) {
+		if res.Error != "" {
+			t.Fatalf("call %d failed: %v", i, res.Error)
+		}
+		want := common.LeftPadBytes([]byte{byte(i + 1)}, 32)
+		if !bytes.Equal(res.ReturnData, want) {
+			t.Errorf("call %d: return data mismatch: have %x, want %x", i, res.ReturnData, want)
+		}
+	}
+}
+
+// Tests that the block number and timestamp overrides are visible to the calls.
+func TestCallManyBlockOverrides(t *testing.T) {
+	var (
+		number    = (*hexutil.Big)(big.NewInt(1234))
+		timestamp = hexutil.Uint64(5678)
+	)
+	res := callMany(t, []CallArgs{{To: &blockAddr}}, &BlockOverrides{Number: number, Time: &timestamp})[0]
+	if res.Error != "" {
+		t.Fatalf("call failed: %v", res.Error)
+	}
+	want := append(common.LeftPadBytes([]byte{0x04, 0xd2}, 32), common.LeftPadBytes([]byte{0x16, 0x2e}, 32)...)
+	if !bytes.Equal(res.ReturnData, want) {
+		t.Fatalf("return data mismatch: have %x, want %x", res.ReturnData, want)
+	}
+}
+
+// Tests that the chain rules are derived from the overridden block number.
+func TestCallManyBlockOverridesRules(t *testing.T) {
+	calls := []CallArgs{{To: &returnDataAddr}}
+
+	if res := callMany(t, calls, nil)[0]; res.Error == "" {
+		t.Fatalf("RETURNDATASIZE available before Byzantium")
+	}
+	number := (*hexutil.Big)(big.NewInt(10))
+	if res := callMany(t, calls, &BlockOverrides{Number: number})[0]; res.Error != "" {
+		t.Fatalf("RETURNDATASIZE unavailable after overriding to Byzantium: %v", res.Error)
+	}
+}
+
+// Tests that the gas bought by a call failing outright is not charged to the
+// sender in the subsequent calls.
+func TestCallManyFailedCallReverted(t *testing.T) {
+	var (
+		gas      = hexutil.Uint64(params.TxGas - 1)
+		gasPrice = (*hexutil.Big)(big.NewInt(1))
+	)
+	calls := []CallArgs{
+		{From: &senderAddr, To: &counterAddr, Gas: &gas, GasPrice: gasPrice}, // intrinsic gas too low
+		{From: &senderAddr, To: &balanceAddr},
+	}
+	results := callMany(t, calls, nil)
+	if results[0].Error == "" {
+		t.Fatalf("call with insufficient intrinsic gas succeeded")
+	}
+	if results[1].Error != "" {
+		t.Fatalf("balance query failed: %v", results[1].Error)
+	}
+	if balance := new(big.Int).SetBytes(results[1].ReturnData); balance.Cmp(senderBalance) != 0 {
+		t.Fatalf("sender balance mismatch: have %v, want %v", balance, senderBalance)
+	}
+}
+
+// Tests that a bundle is aborted if its context is done, even before any of the
+// calls started executing.
+func TestCallManyCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := []bundleCall{{args: &CallArgs{To: &counterAddr}}, {args: &CallArgs{To: &counterAddr}}}
+	if _, err := doCallMany(ctx, newTestBackend(t), calls, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil, nil, 0, 25000000); err == nil {
+		t.Fatalf("cancelled bundle executed")
+	}
+}
+
+// Tests that signed transactions in a bundle are subject to the base fee, unlike
+// unsigned calls.
+func TestCallBundleBaseFee(t *testing.T) {
+	config := *params.TestChainConfig
+	config.BerlinBlock = big.NewInt(0)
+	config.LondonBlock = big.NewInt(0)
+
+	var (
+		api    = NewPublicBlockChainAPI(newTestBackendWithConfig(t, &config))
+		signer = types.LatestSigner(&config)
+		latest = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	)
+	tx, _ := types.SignTx(types.NewTransaction(0, counterAddr, new(big.Int), 100000, new(big.Int), nil), signer, txSenderKey)
+	blob, _ := tx.MarshalBinary()
+
+	results, err := api.CallBundle(context.Background(), []hexutil.Bytes{blob}, latest, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to execute bundle: %v", err)
+	}
+	if !strings.Contains(results[0].Error, core.ErrFeeCapTooLow.Error()) {
+		t.Fatalf("underpriced transaction error mismatch: have %q, want %q", results[0].Error, core.ErrFeeCapTooLow)
+	}
+	results, err = api.CallMany(context.Background(), []CallArgs{{From: &txSenderAddr, To: &counterAddr}}, latest, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to execute calls: %v", err)
+	}
+	if results[0].Error != "" {
+		t.Fatalf("call without fees failed: %v", results[0].Error)
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package tracing implements lightweight distributed tracing, recording the time
+// spent in operations crossing multiple subsystems (e.g. an RPC call executing
+// the EVM and reading the state) as a tree of spans.
+//
+// Spans follow the OpenTelemetry data model and are handed over in batches to a
+// pluggable Exporter. Tracing is disabled by default, in which case starting a
+// span returns nil and costs next to nothing.
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	batchSize     = 512             // Maximum number of spans to export at once
+	batchInterval = 5 * time.Second // Maximum time to hold back finished spans
+	queueSize     = 4096            // Number of finished spans to buffer before dropping
+)
+
+// TraceID is the unique identifier of a trace, shared by all of its spans.
+type TraceID [16]byte
+
+// String returns the hex encoding of the trace identifier.
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanID is the identifier of a span, unique within its trace.
+type SpanID [8]byte
+
+// String returns the hex encoding of the span identifier.
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// Attribute is a key-value pair describing a span.
+type Attribute struct {
+	Key   string
+	Value interface{} // string, bool, int64, uint64 or float64
+}
+
+// String creates a string attribute.
+func String(key, value string) Attribute { return Attribute{key, value} }
+
+// Bool creates a boolean attribute.
+func Bool(key string, value bool) Attribute { return Attribute{key, value} }
+
+// Int creates an integer attribute.
+func Int(key string, value int64) Attribute { return Attribute{key, value} }
+
+// Uint creates an unsigned integer attribute.
+func Uint(key string, value uint64) Attribute { return Attribute{key, value} }
+
+// Span is a single timed operation within a trace. Spans are not safe for
+// concurrent use. A nil span is valid and ignores all operations, which allows
+// instrumented code to skip checking whether tracing is enabled.
+type Span struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	ParentID   SpanID // Zero for the root span of a trace
+	Name       string
+	StartTime  time.Time
+	EndTime    time.Time
+	Attributes []Attribute
+	Error      string // Failure of the operation, empty if it succeeded
+}
+
+// SetAttributes adds attributes to the span.
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.Attributes = append(s.Attributes, attrs...)
+}
+
+// SetError marks the operation of the span failed. Nil errors are ignored.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.Error = err.Error()
+}
+
+// End finishes the span and queues it for export.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.EndTime = time.Now()
+	if p := active.Load().(*processor); p != nil {
+		p.submit(s)
+	}
+}
+
+// Exporter is the interface of the tracing backends.
+type Exporter interface {
+	// Export sends a batch of finished spans to the backend.
+	Export(spans []*Span) error
+}
+
+type spanKey struct{}
+
+var (
+	active = new(atomic.Value) // Running span processor, nil if tracing is disabled
+	lock   sync.Mutex          // Lock protecting enabling and disabling the tracing
+
+	idLock sync.Mutex
+	idRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+func init() {
+	active.Store((*processor)(nil))
+}
+
+// Enabled returns whether tracing is enabled.
+func Enabled() bool {
+	return active.Load().(*processor) != nil
+}
+
+// Enable starts tracing, exporting the finished spans to the given exporter. If
+// tracing was already enabled, the previous exporter is flushed and replaced.
+func Enable(exporter Exporter) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if p := active.Load().(*processor); p != nil {
+		p.close()
+	}
+	active.Store(newProcessor(exporter))
+}
+
+// Disable stops tracing, exporting the spans finished so far.
+func Disable() {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if p := active.Load().(*processor); p != nil {
+		active.Store((*processor)(nil))
+		p.close()
+	}
+}
+
+// Start starts a new span with the given name. The span is the child of the
+// span carried by ctx or, if there is none, the root of a new trace. It returns
+// a context carrying the new span, to be passed to the nested operations.
+//
+// If tracing is disabled, the original context is returned with a nil span.
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	if !Enabled() {
+		return ctx, nil
+	}
+	span := &Span{
+		Name:       name,
+		StartTime:  time.Now(),
+		Attributes: attrs,
+	}
+	idLock.Lock()
+	if parent := FromContext(ctx); parent != nil {
+		span.TraceID, span.ParentID = parent.TraceID, parent.SpanID
+	} else {
+		idRand.Read(span.TraceID[:])
+	}
+	idRand.Read(span.SpanID[:])
+	idLock.Unlock()
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// FromContext returns the span carried by the context, or nil if there is none.
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// processor collects the finished spans and exports them in batches.
+type processor struct {
+	exporter Exporter
+	queue    chan *Span
+	quit     chan chan struct{}
+	dropped  uint64 // Number of spans dropped since the last export due to a full queue
+}
+
+func newProcessor(exporter Exporter) *processor {
+	p := &processor{
+		exporter: exporter,
+		queue:    make(chan *Span, queueSize),
+		quit:     make(chan chan struct{}),
+	}
+	go p.loop()
+	return p
+}
+
+// submit queues a finished span for export, dropping it if the exporter can't
+// keep up instead of blocking the traced operation.
+func (p *processor) submit(span *Span) {
+	select {
+	case p.queue <- span:
+	default:
+		atomic.AddUint64(&p.dropped, 1)
+	}
+}
+
+// close stops the processor after exporting all the queued spans.
+func (p *processor) close() {
+	done := make(chan struct{})
+	p.quit <- done
+	<-done
+}
+
+func (p *processor) loop() {
+	var (
+		batch  = make([]*Span, 0, batchSize)
+		ticker = time.NewTicker(batchInterval)
+	)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case span := <-p.queue:
+			if batch = append(batch, span); len(batch) == batchSize {
+				batch = p.export(batch)
+			}
+		case <-ticker.C:
+			batch = p.export(batch)
+
+		case done := <-p.quit:
+			for len(p.queue) > 0 {
+				batch = append(batch, <-p.queue)
+			}
+			p.export(batch)
+			close(done)
+			return
+		}
+	}
+}
+
+// export sends a batch of spans to the exporter, returning the emptied batch.
+func (p *processor) export(batch []*Span) []*Span {
+	if dropped := atomic.SwapUint64(&p.dropped, 0); dropped > 0 {
+		log.Warn("Dropped tracing spans", "count", dropped)
+	}
+	if len(batch) == 0 {
+		return batch
+	}
+	if err := p.exporter.Export(batch); err != nil {
+		log.Warn("Failed to export tracing spans", "count", len(batch), "err", err)
+	}
+	return make([]*Span, 0, batchSize)
+}
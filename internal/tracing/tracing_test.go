@@ -0,0 +1,158 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingExporter collects all the exported spans.
+type recordingExporter struct {
+	lock  sync.Mutex
+	spans []*Span
+}
+
+func (e *recordingExporter) Export(spans []*Span) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func TestDisabledTracing(t *testing.T) {
+	ctx := context.Background()
+	if have, span := Start(ctx, "noop"); have != ctx || span != nil {
+		t.Fatalf("disabled tracing started span")
+	}
+	// Operations on nil spans must not panic
+	var span *Span
+	span.SetAttributes(String("key", "value"))
+	span.SetError(errors.New("failure"))
+	span.End()
+}
+
+func TestSpanHierarchy(t *testing.T) {
+	exporter := new(recordingExporter)
+	Enable(exporter)
+
+	ctx, root := Start(context.Background(), "root", Int("int", -1))
+	_, child := Start(ctx, "child")
+	child.SetAttributes(Bool("bool", true))
+	child.SetError(errors.New("failure"))
+	child.End()
+	root.End()
+
+	_, other := Start(context.Background(), "other")
+	other.End()
+
+	Disable()
+	if Enabled() {
+		t.Fatalf("tracing enabled after disabling")
+	}
+	if len(exporter.spans) != 3 {
+		t.Fatalf("exported span count mismatch: have %d, want 3", len(exporter.spans))
+	}
+	if exporter.spans[0] != child || exporter.spans[1] != root || exporter.spans[2] != other {
+		t.Fatalf("exported spans mismatch")
+	}
+	if root.ParentID != (SpanID{}) || other.ParentID != (SpanID{}) {
+		t.Errorf("root spans have parents")
+	}
+	if child.TraceID != root.TraceID || child.ParentID != root.SpanID {
+		t.Errorf("child not linked to parent: trace %v, parent %v", child.TraceID, child.ParentID)
+	}
+	if other.TraceID == root.TraceID {
+		t.Errorf("independent spans share trace")
+	}
+	if child.Error != "failure" || len(child.Attributes) != 1 {
+		t.Errorf("child details mismatch: error %q, attributes %v", child.Error, child.Attributes)
+	}
+	if root.EndTime.Before(child.EndTime) {
+		t.Errorf("end times out of order")
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var (
+		path string
+		req  otlpRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		blob, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(blob, &req); err != nil {
+			t.Errorf("invalid request: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	exporter, err := NewOTLPExporter(server.URL, "geth")
+	if err != nil {
+		t.Fatalf("failed to create exporter: %v", err)
+	}
+	span := &Span{
+		TraceID:    TraceID{0x01, 0x02},
+		SpanID:     SpanID{0x03},
+		ParentID:   SpanID{0x04},
+		Name:       "test",
+		StartTime:  time.Unix(1, 2),
+		EndTime:    time.Unix(3, 4),
+		Attributes: []Attribute{String("s", "x"), Uint("u", 5), Bool("b", true)},
+		Error:      "failure",
+	}
+	if err := exporter.Export([]*Span{span}); err != nil {
+		t.Fatalf("failed to export spans: %v", err)
+	}
+	if path != "/v1/traces" {
+		t.Errorf("request path mismatch: have %s, want /v1/traces", path)
+	}
+	if len(req.ResourceSpans) != 1 || len(req.ResourceSpans[0].ScopeSpans) != 1 || len(req.ResourceSpans[0].ScopeSpans[0].Spans) != 1 {
+		t.Fatalf("request structure mismatch: %+v", req)
+	}
+	if service := req.ResourceSpans[0].Resource.Attributes[0]; service.Key != "service.name" || *service.Value.StringValue != "geth" {
+		t.Errorf("service mismatch: %+v", service)
+	}
+	have := req.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	if have.TraceID != "01020000000000000000000000000000" || have.SpanID != "0300000000000000" || have.ParentSpanID != "0400000000000000" {
+		t.Errorf("identifiers mismatch: %+v", have)
+	}
+	if have.StartTimeUnixNano != "1000000002" || have.EndTimeUnixNano != "3000000004" {
+		t.Errorf("timestamps mismatch: %+v", have)
+	}
+	if len(have.Attributes) != 3 || *have.Attributes[0].Value.StringValue != "x" || *have.Attributes[1].Value.IntValue != "5" || !*have.Attributes[2].Value.BoolValue {
+		t.Errorf("attributes mismatch: %+v", have.Attributes)
+	}
+	if have.Status == nil || have.Status.Code != otlpStatusError || have.Status.Message != "failure" {
+		t.Errorf("status mismatch: %+v", have.Status)
+	}
+	// Failures of the collector must be reported
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	if err := exporter.Export([]*Span{span}); err == nil {
+		t.Fatalf("collector failure not reported")
+	}
+}
@@ -885,6 +885,13 @@ func (p *clientPeer) replyBlockHeaders(reqID uint64, headers []*types.Header) *r
 	return &reply{p.rw, BlockHeadersMsg, reqID, data}
 }
 
+// replyBlockHeadersRLP creates a reply with a batch of block headers from an
+// already RLP encoded format.
+func (p *clientPeer) replyBlockHeadersRLP(reqID uint64, headers []rlp.RawValue) *reply {
+	data, _ := rlp.EncodeToBytes(headers)
+	return &reply{p.rw, BlockHeadersMsg, reqID, data}
+}
+
 // replyBlockBodiesRLP creates a reply with a batch of block contents from
 // an already RLP encoded format.
 func (p *clientPeer) replyBlockBodiesRLP(reqID uint64, bodies []rlp.RawValue) *reply {
@@ -1053,7 +1060,7 @@ func (p *clientPeer) Handshake(td *big.Int, head common.Hash, headNum uint64, ge
 		// Add some information which services server can offer.
 		if !server.config.UltraLightOnlyAnnounce {
 			*lists = (*lists).add("serveHeaders", nil)
-			*lists = (*lists).add("serveChainSince", uint64(0))
+			*lists = (*lists).add("serveChainSince", server.handler.blockchain.HistoryTail())
 			*lists = (*lists).add("serveStateSince", uint64(0))
 
 			// If local ethereum node is running in archive mode, advertise ourselves we have
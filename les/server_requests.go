@@ -154,6 +154,10 @@ func handleGetBlockHeaders(msg Decoder) (serveRequestFn, uint64, uint64, error)
 		return nil, 0, 0, err
 	}
 	return func(backend serverBackend, p *clientPeer, waitOrStop func() bool) *reply {
+		if r.Query.Skip == 0 {
+			// The fast path: when the request is for a contiguous segment of headers
+			return p.replyBlockHeadersRLP(r.ReqID, serveContiguousBlockHeaders(backend.BlockChain(), &r.Query))
+		}
 		// Gather headers until the fetch or network limits is reached
 		var (
 			bc              = backend.BlockChain()
@@ -240,6 +244,62 @@ func handleGetBlockHeaders(msg Decoder) (serveRequestFn, uint64, uint64, error)
 	}, r.ReqID, r.Query.Amount, nil
 }
 
+// serveContiguousBlockHeaders retrieves a contiguous segment of headers, reading
+// as much as possible of the canonical chain in one go.
+func serveContiguousBlockHeaders(bc *core.BlockChain, query *GetBlockHeadersData) []rlp.RawValue {
+	count := query.Amount
+	if count > MaxHeaderFetch {
+		count = MaxHeaderFetch
+	}
+	if count == 0 {
+		return nil
+	}
+	// Resolve the origin to a number, serving only the origin itself if it is
+	// not canonical and descendants are requested
+	var (
+		headers []rlp.RawValue
+		number  = query.Origin.Number
+	)
+	if query.Origin.Hash != (common.Hash{}) {
+		header := bc.GetHeaderByHash(query.Origin.Hash)
+		if header == nil {
+			return nil
+		}
+		number = header.Number.Uint64()
+		if bc.GetCanonicalHash(number) != query.Origin.Hash {
+			blob, _ := rlp.EncodeToBytes(header)
+			headers = append(headers, blob)
+			if !query.Reverse {
+				return headers
+			}
+			// Follow the parent hashes towards the genesis block
+			for i := uint64(1); i < count; i++ {
+				if header = bc.GetHeader(header.ParentHash, header.Number.Uint64()-1); header == nil {
+					break
+				}
+				blob, _ := rlp.EncodeToBytes(header)
+				headers = append(headers, blob)
+			}
+			return headers
+		}
+	}
+	if query.Reverse {
+		if number > bc.CurrentHeader().Number.Uint64() {
+			return nil // We don't have the origin header
+		}
+		return bc.GetHeadersFrom(number, count)
+	}
+	if number+count-1 < number {
+		return nil // Overflow, we certainly don't have these headers
+	}
+	// The chain delivers in descending order, reverse for rising queries
+	headers = bc.GetHeadersFrom(number+count-1, count)
+	for i, j := 0, len(headers)-1; i < j; i, j = i+1, j-1 {
+		headers[i], headers[j] = headers[j], headers[i]
+	}
+	return headers
+}
+
 // handleGetBlockBodies handles a block body request
 func handleGetBlockBodies(msg Decoder) (serveRequestFn, uint64, uint64, error) {
 	var r GetBlockBodiesPacket
@@ -248,24 +308,21 @@ func handleGetBlockBodies(msg Decoder) (serveRequestFn, uint64, uint64, error) {
 	}
 	return func(backend serverBackend, p *clientPeer, waitOrStop func() bool) *reply {
 		var (
-			bytes  int
+			bc     = backend.BlockChain()
 			bodies []rlp.RawValue
 		)
-		bc := backend.BlockChain()
-		for i, hash := range r.Hashes {
+		for i, body := range bc.GetBodiesRLP(r.Hashes, softResponseLimit) {
 			if i != 0 && !waitOrStop() {
 				return nil
 			}
-			if bytes >= softResponseLimit {
-				break
-			}
-			body := bc.GetBodyRLP(hash)
 			if body == nil {
-				p.bumpInvalid()
+				// Pruned history is unavailable, but the request was legit
+				if !bc.HistoryPruned(r.Hashes[i]) {
+					p.bumpInvalid()
+				}
 				continue
 			}
 			bodies = append(bodies, body)
-			bytes += len(body)
 		}
 		return p.replyBlockBodiesRLP(r.ReqID, bodies)
 	}, r.ReqID, uint64(len(r.Hashes)), nil
@@ -348,7 +405,10 @@ func handleGetReceipts(msg Decoder) (serveRequestFn, uint64, uint64, error) {
 			results := bc.GetReceiptsByHash(hash)
 			if results == nil {
 				if header := bc.GetHeaderByHash(hash); header == nil || header.ReceiptHash != types.EmptyRootHash {
-					p.bumpInvalid()
+					// Pruned history is unavailable, but the request was legit
+					if !bc.HistoryPruned(hash) {
+						p.bumpInvalid()
+					}
 					continue
 				}
 			}
@@ -0,0 +1,21 @@
+package log
+
+import "context"
+
+type loggerKey struct{}
+
+// NewContext returns a copy of the parent context carrying the given logger.
+// It is meant to pass child loggers, holding fields like a request or peer
+// identifier, down a call chain.
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger carried by the context, or the root logger if
+// the context doesn't have one.
+func FromContext(ctx context.Context) Logger {
+	if l, ok := ctx.Value(loggerKey{}).(Logger); ok {
+		return l
+	}
+	return root
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
 	"math/big"
 	"reflect"
 	"strconv"
@@ -288,6 +289,93 @@ func JSONFormatEx(pretty, lineSeparated bool) Format {
 	})
 }
 
+// structuredRecord is the fixed schema of the records emitted by StructuredFormat.
+type structuredRecord struct {
+	Timestamp  string                 `json:"timestamp"`
+	Level      string                 `json:"level"`
+	Severity   int                    `json:"severity"`
+	Module     string                 `json:"module,omitempty"`
+	Message    string                 `json:"message"`
+	Caller     string                 `json:"caller"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// StructuredFormat formats log records as JSON objects separated by newlines,
+// following a fixed schema modeled after the OpenTelemetry log data model:
+//
+//	{"timestamp":"2021-06-01T10:00:00.000000001Z","level":"INFO","severity":9,
+//	 "module":"eth","message":"Imported new chain segment","caller":"blockchain.go:1790",
+//	 "attributes":{"blocks":1,"number":12345}}
+//
+// The module is taken from the "module" context key set by NewModule, all other
+// context pairs are reported as attributes. Numbers, booleans and nil values keep
+// their JSON type, everything else is rendered as a string.
+func StructuredFormat() Format {
+	return FormatFunc(func(r *Record) []byte {
+		rec := structuredRecord{
+			Timestamp: r.Time.UTC().Format(time.RFC3339Nano),
+			Level:     strings.TrimSpace(r.Lvl.AlignedString()),
+			Severity:  r.Lvl.severity(),
+			Message:   r.Msg,
+			Caller:    fmt.Sprint(r.Call),
+		}
+		for i := 0; i+1 < len(r.Ctx); i += 2 {
+			k, v := r.Ctx[i], formatStructuredValue(r.Ctx[i+1])
+			key, ok := k.(string)
+			if !ok {
+				key, v = errorKey, fmt.Sprintf("%+v is not a string key", k)
+			}
+			if key == moduleKey {
+				rec.Module = fmt.Sprint(v)
+				continue
+			}
+			if rec.Attributes == nil {
+				rec.Attributes = make(map[string]interface{})
+			}
+			rec.Attributes[key] = v
+		}
+		b, err := json.Marshal(rec)
+		if err != nil {
+			b, _ = json.Marshal(map[string]string{
+				errorKey: err.Error(),
+			})
+		}
+		return append(b, '\n')
+	})
+}
+
+// formatStructuredValue converts a context value into an attribute of the
+// structured format, retaining the JSON type of numbers and booleans.
+func formatStructuredValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, bool, string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return v
+	case float32:
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return fmt.Sprint(v)
+		}
+		return v
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Sprint(v)
+		}
+		return v
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case time.Duration:
+		return v.String()
+	case *big.Int:
+		if v == nil {
+			return nil
+		}
+		return v.String()
+	}
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil
+	}
+	return formatJSONValue(value)
+}
+
 func formatShared(value interface{}) (result interface{}) {
 	defer func() {
 		if err := recover(); err != nil {
@@ -1,10 +1,15 @@
 package log
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"math"
 	"math/big"
 	"math/rand"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestPrettyInt64(t *testing.T) {
@@ -93,3 +98,56 @@ func BenchmarkPrettyUint64Logfmt(b *testing.B) {
 		sink = FormatLogfmtUint64(rand.Uint64())
 	}
 }
+
+func TestStructuredFormat(t *testing.T) {
+	var buf bytes.Buffer
+
+	l := NewModule("eth", "peer", "abcd")
+	l.SetHandler(StreamHandler(&buf, StructuredFormat()))
+	l.New("reqid", 7).Warn("Something happened",
+		"count", 3, "ok", true, "ratio", 0.5, "nan", math.NaN(), "big", big.NewInt(-42),
+		"err", errors.New("boom"), "elapsed", 1500*time.Millisecond, "nilbig", (*big.Int)(nil), "nil", nil)
+
+	var rec map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("invalid record %q: %v", buf.String(), err)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, rec["timestamp"].(string)); err != nil {
+		t.Errorf("invalid timestamp: %v", err)
+	}
+	if caller := rec["caller"].(string); !strings.HasPrefix(caller, "format_test.go:") {
+		t.Errorf("caller mismatch: have %s, want format_test.go", caller)
+	}
+	for key, want := range map[string]interface{}{
+		"level":    "WARN",
+		"severity": float64(13),
+		"module":   "eth",
+		"message":  "Something happened",
+	} {
+		if rec[key] != want {
+			t.Errorf("field %s mismatch: have %v, want %v", key, rec[key], want)
+		}
+	}
+	want := map[string]interface{}{
+		"peer":    "abcd",
+		"reqid":   float64(7),
+		"count":   float64(3),
+		"ok":      true,
+		"ratio":   0.5,
+		"nan":     "NaN",
+		"big":     "-42",
+		"err":     "boom",
+		"elapsed": "1.5s",
+		"nilbig":  nil,
+		"nil":     nil,
+	}
+	attrs := rec["attributes"].(map[string]interface{})
+	if len(attrs) != len(want) {
+		t.Errorf("attribute count mismatch: have %d, want %d", len(attrs), len(want))
+	}
+	for key, value := range want {
+		if have, ok := attrs[key]; !ok || have != value {
+			t.Errorf("attribute %s mismatch: have %v, want %v", key, have, value)
+		}
+	}
+}
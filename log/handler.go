@@ -70,6 +70,87 @@ func FileHandler(path string, fmtr Format) (Handler, error) {
 	return closingHandler{f, StreamHandler(f, fmtr)}, nil
 }
 
+// RotatingFileHandler returns a handler which writes log records to the given
+// file using the given format, like FileHandler. Once the file would grow beyond
+// maxSize bytes, it is renamed to path.1, previous backups are shifted to path.2,
+// path.3 and so on, and a new file is started. At most maxBackups old files are
+// retained, older ones are deleted. Records are never split across files.
+func RotatingFileHandler(path string, maxSize uint64, maxBackups int, fmtr Format) (Handler, error) {
+	w := &rotatingWriter{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return closingHandler{w, StreamHandler(w, fmtr)}, nil
+}
+
+// rotatingWriter is a file writer rotating the file once it reaches its maximum
+// size. It is not safe for concurrent use, StreamHandler serializes the writes.
+type rotatingWriter struct {
+	path       string
+	maxSize    uint64
+	maxBackups int
+
+	file *os.File
+	size uint64
+}
+
+// open opens the current log file for appending.
+func (w *rotatingWriter) open() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file, w.size = f, uint64(stat.Size())
+	return nil
+}
+
+// rotate closes the current log file, shifts the backups and starts a new file.
+// The log file is reopened even if shifting the backups fails, so that logging
+// can continue.
+func (w *rotatingWriter) rotate() error {
+	w.file.Close()
+
+	err := w.shift()
+	if openErr := w.open(); openErr != nil {
+		return openErr
+	}
+	return err
+}
+
+// shift moves the current log file and the existing backups one position up,
+// dropping the oldest backup.
+func (w *rotatingWriter) shift() error {
+	if w.maxBackups == 0 {
+		return os.Remove(w.path)
+	}
+	for i := w.maxBackups - 1; i > 0; i-- {
+		if err := os.Rename(fmt.Sprintf("%s.%d", w.path, i), fmt.Sprintf("%s.%d", w.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(w.path, w.path+".1")
+}
+
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	if w.maxSize > 0 && w.size > 0 && w.size+uint64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += uint64(n)
+	return n, err
+}
+
+func (w *rotatingWriter) Close() error {
+	return w.file.Close()
+}
+
 // NetHandler opens a socket to the given address and writes records
 // over the connection.
 func NetHandler(network, addr string, fmtr Format) (Handler, error) {
@@ -0,0 +1,90 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFileHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log-rotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Every record is 10 bytes, so the files rotate after every 3 records
+	path := filepath.Join(dir, "geth.log")
+	format := FormatFunc(func(r *Record) []byte {
+		return []byte(r.Msg + "\n")
+	})
+	h, err := RotatingFileHandler(path, 35, 2, format)
+	if err != nil {
+		t.Fatalf("failed to create handler: %v", err)
+	}
+	l := New()
+	l.SetHandler(h)
+	for i := 0; i < 10; i++ {
+		l.Info(fmt.Sprintf("record-%03d", i))
+	}
+	h.(closingHandler).WriteCloser.Close()
+
+	for file, want := range map[string]string{
+		"geth.log":   "record-009\n",
+		"geth.log.1": "record-006\nrecord-007\nrecord-008\n",
+		"geth.log.2": "record-003\nrecord-004\nrecord-005\n",
+	} {
+		have, err := ioutil.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", file, err)
+		}
+		if string(have) != want {
+			t.Errorf("file %s content mismatch: have %q, want %q", file, have, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("backup beyond limit retained: %v", err)
+	}
+	// Reopening must append to the existing file and account for its size
+	h, err = RotatingFileHandler(path, 35, 2, format)
+	if err != nil {
+		t.Fatalf("failed to reopen handler: %v", err)
+	}
+	l.SetHandler(h)
+	for i := 10; i < 13; i++ {
+		l.Info(fmt.Sprintf("record-%03d", i))
+	}
+	h.(closingHandler).WriteCloser.Close()
+
+	if have, _ := ioutil.ReadFile(path); string(have) != "record-012\n" {
+		t.Errorf("reopened file content mismatch: have %q", have)
+	}
+	if have, _ := ioutil.ReadFile(path + ".1"); !strings.HasPrefix(string(have), "record-009\n") {
+		t.Errorf("reopened backup content mismatch: have %q", have)
+	}
+}
+
+func TestContextLogger(t *testing.T) {
+	if FromContext(context.Background()) != Root() {
+		t.Fatalf("empty context didn't return the root logger")
+	}
+	var recs []*Record
+	l := New("reqid", 1)
+	l.SetHandler(FuncHandler(func(r *Record) error {
+		recs = append(recs, r)
+		return nil
+	}))
+	ctx := NewContext(context.Background(), l)
+	FromContext(ctx).Info("hello", "key", "value")
+
+	if len(recs) != 1 {
+		t.Fatalf("record count mismatch: have %d, want 1", len(recs))
+	}
+	if have := fmt.Sprint(recs[0].Ctx); have != "[reqid 1 key value]" {
+		t.Fatalf("context mismatch: have %s", have)
+	}
+}
@@ -12,6 +12,7 @@ const timeKey = "t"
 const lvlKey = "lvl"
 const msgKey = "msg"
 const ctxKey = "ctx"
+const moduleKey = "module"
 const errorKey = "LOG15_ERROR"
 const skipLevel = 2
 
@@ -46,6 +47,26 @@ func (l Lvl) AlignedString() string {
 	}
 }
 
+// severity returns the OpenTelemetry severity number of a Lvl.
+func (l Lvl) severity() int {
+	switch l {
+	case LvlTrace:
+		return 1
+	case LvlDebug:
+		return 5
+	case LvlInfo:
+		return 9
+	case LvlWarn:
+		return 13
+	case LvlError:
+		return 17
+	case LvlCrit:
+		return 21
+	default:
+		panic("bad level")
+	}
+}
+
 // Strings returns the name of a Lvl.
 func (l Lvl) String() string {
 	switch l {
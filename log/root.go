@@ -20,6 +20,13 @@ func New(ctx ...interface{}) Logger {
 	return root.New(ctx...)
 }
 
+// NewModule returns a new logger for the named module with the given context.
+// The module name is stored in the context under the "module" key, which the
+// structured format reports as a dedicated field.
+func NewModule(name string, ctx ...interface{}) Logger {
+	return root.New(append([]interface{}{moduleKey, name}, ctx...)...)
+}
+
 // Root returns the root logger
 func Root() Logger {
 	return root
@@ -16,6 +16,8 @@
 
 package metrics
 
+import "time"
+
 // Config contains the configuration for the metric collection.
 type Config struct {
 	Enabled          bool   `toml:",omitempty"`
@@ -28,6 +30,14 @@ type Config struct {
 	InfluxDBUsername string `toml:",omitempty"`
 	InfluxDBPassword string `toml:",omitempty"`
 	InfluxDBTags     string `toml:",omitempty"`
+
+	EnableRemoteWrite   bool          `toml:",omitempty"`
+	RemoteWriteEndpoint string        `toml:",omitempty"`
+	EnableOTLP          bool          `toml:",omitempty"`
+	OTLPEndpoint        string        `toml:",omitempty"`
+	PushInterval        time.Duration `toml:",omitempty"`
+	PushRetries         int           `toml:",omitempty"`
+	PushLabels          string        `toml:",omitempty"`
 }
 
 // DefaultConfig is the default config for metrics used in go-ethereum.
@@ -42,4 +52,12 @@ var DefaultConfig = Config{
 	InfluxDBUsername: "test",
 	InfluxDBPassword: "test",
 	InfluxDBTags:     "host=localhost",
+
+	EnableRemoteWrite:   false,
+	RemoteWriteEndpoint: "http://localhost:9090/api/v1/write",
+	EnableOTLP:          false,
+	OTLPEndpoint:        "http://localhost:4318",
+	PushInterval:        10 * time.Second,
+	PushRetries:         3,
+	PushLabels:          "host=localhost",
 }
@@ -0,0 +1,334 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package otlp pushes go-metrics to an OpenTelemetry collector, using the OTLP/HTTP
+// protocol with JSON encoding.
+package otlp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	metricsPath = "/v1/metrics" // Default path of the metrics service of a collector
+	scopeName   = "github.com/ethereum/go-ethereum"
+	timeout     = 10 * time.Second
+	retryDelay  = 500 * time.Millisecond // Delay before the first retry, doubled after each
+
+	temporalityCumulative = 2 // AGGREGATION_TEMPORALITY_CUMULATIVE
+)
+
+type reporter struct {
+	reg      metrics.Registry
+	endpoint string
+	labels   map[string]string
+	retries  int
+	start    time.Time // Start of the cumulative sums
+	client   *http.Client
+}
+
+// OTLP starts an OTLP reporter which pushes the metrics of the given registry to
+// the collector at each d interval. The labels are reported as the attributes of
+// the resource, and failed pushes are retried up to retries times. The call blocks
+// forever, unless the reporter cannot be started, e.g. if d is not positive.
+func OTLP(r metrics.Registry, d time.Duration, endpoint string, labels map[string]string, retries int) {
+	if d <= 0 {
+		log.Warn("Invalid OTLP metrics push interval", "interval", d)
+		return
+	}
+	rep, err := newReporter(r, endpoint, labels, retries)
+	if err != nil {
+		log.Warn("Unable to create OTLP metrics reporter", "endpoint", endpoint, "err", err)
+		return
+	}
+	for range time.Tick(d) {
+		if err := rep.push(); err != nil {
+			log.Warn("Unable to push metrics to OTLP collector", "err", err)
+		}
+	}
+}
+
+// OTLPOnce pushes the metrics of the given registry to an OTLP collector once.
+func OTLPOnce(r metrics.Registry, endpoint string, labels map[string]string, retries int) error {
+	rep, err := newReporter(r, endpoint, labels, retries)
+	if err != nil {
+		return err
+	}
+	return rep.push()
+}
+
+// newReporter creates a reporter pushing to the collector at the given URL (e.g.
+// http://localhost:4318). If the URL has no path, the default metrics path of the
+// collector is used.
+func newReporter(r metrics.Registry, endpoint string, labels map[string]string, retries int) (*reporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported OTLP endpoint scheme %q", u.Scheme)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = metricsPath
+	}
+	return &reporter{
+		reg:      r,
+		endpoint: u.String(),
+		labels:   labels,
+		retries:  retries,
+		start:    time.Now(),
+		client:   &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// push sends the current values of the metrics to the collector, retrying with
+// an exponential backoff if the failure is transient.
+func (r *reporter) push() error {
+	body, err := json.Marshal(r.encode(time.Now()))
+	if err != nil {
+		return err
+	}
+	delay := retryDelay
+	for attempt := 0; ; attempt++ {
+		err := r.post(body)
+		if err == nil {
+			return nil
+		}
+		if attempt >= r.retries || !isRetryable(err) {
+			return err
+		}
+		log.Debug("Retrying OTLP metrics push", "attempt", attempt+1, "err", err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// post sends a single encoded export request to the collector.
+func (r *reporter) post(body []byte) error {
+	res, err := r.client.Post(r.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode/100 != 2 {
+		return &statusError{code: res.StatusCode, status: res.Status}
+	}
+	return nil
+}
+
+// statusError is returned if the collector rejects a request.
+type statusError struct {
+	code   int
+	status string
+}
+
+func (err *statusError) Error() string {
+	return fmt.Sprintf("collector responded with %s", err.status)
+}
+
+// isRetryable reports whether a failed request may succeed if sent again. As per
+// the OTLP specification, only throttling and unavailability responses are
+// retried, the other rejections are permanent.
+func isRetryable(err error) bool {
+	if err, ok := err.(*statusError); ok {
+		switch err.code {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+// The types below are the JSON mapping of the OTLP metrics service request.
+
+type exportRequest struct {
+	ResourceMetrics []resourceMetrics `json:"resourceMetrics"`
+}
+
+type resourceMetrics struct {
+	Resource     resource       `json:"resource"`
+	ScopeMetrics []scopeMetrics `json:"scopeMetrics"`
+}
+
+type resource struct {
+	Attributes []attribute `json:"attributes"`
+}
+
+type attribute struct {
+	Key   string         `json:"key"`
+	Value attributeValue `json:"value"`
+}
+
+type attributeValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type scopeMetrics struct {
+	Scope   scope    `json:"scope"`
+	Metrics []metric `json:"metrics"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type metric struct {
+	Name    string   `json:"name"`
+	Gauge   *gauge   `json:"gauge,omitempty"`
+	Sum     *sum     `json:"sum,omitempty"`
+	Summary *summary `json:"summary,omitempty"`
+}
+
+type gauge struct {
+	DataPoints []numberDataPoint `json:"dataPoints"`
+}
+
+type sum struct {
+	DataPoints             []numberDataPoint `json:"dataPoints"`
+	AggregationTemporality int               `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+}
+
+type summary struct {
+	DataPoints []summaryDataPoint `json:"dataPoints"`
+}
+
+type numberDataPoint struct {
+	StartTimeUnixNano string   `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string   `json:"timeUnixNano"`
+	AsInt             *string  `json:"asInt,omitempty"` // 64 bit integers are encoded as strings
+	AsDouble          *float64 `json:"asDouble,omitempty"`
+}
+
+type summaryDataPoint struct {
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	Count             string          `json:"count"`
+	Sum               float64         `json:"sum"`
+	QuantileValues    []quantileValue `json:"quantileValues"`
+}
+
+type quantileValue struct {
+	Quantile float64 `json:"quantile"`
+	Value    float64 `json:"value"`
+}
+
+// encode converts the metrics of the registry into an OTLP export request.
+// Counters and meters are reported as cumulative sums, histograms and timers as
+// summaries.
+func (r *reporter) encode(now time.Time) *exportRequest {
+	var (
+		start = strconv.FormatInt(r.start.UnixNano(), 10)
+		ts    = strconv.FormatInt(now.UnixNano(), 10)
+		names []string
+	)
+	r.reg.Each(func(name string, i interface{}) {
+		names = append(names, name)
+	})
+	sort.Strings(names)
+
+	intPoint := func(v int64) numberDataPoint {
+		s := strconv.FormatInt(v, 10)
+		return numberDataPoint{TimeUnixNano: ts, AsInt: &s}
+	}
+	cumulative := func(v int64, monotonic bool) *sum {
+		point := intPoint(v)
+		point.StartTimeUnixNano = start
+		return &sum{DataPoints: []numberDataPoint{point}, AggregationTemporality: temporalityCumulative, IsMonotonic: monotonic}
+	}
+	quantiles := func(count int64, total float64, qs []float64, ps []float64) *summary {
+		point := summaryDataPoint{
+			StartTimeUnixNano: start,
+			TimeUnixNano:      ts,
+			Count:             strconv.FormatInt(count, 10),
+			Sum:               total,
+		}
+		for i := range qs {
+			point.QuantileValues = append(point.QuantileValues, quantileValue{Quantile: qs[i], Value: ps[i]})
+		}
+		return &summary{DataPoints: []summaryDataPoint{point}}
+	}
+	pv := []float64{0.5, 0.75, 0.95, 0.99, 0.999, 0.9999}
+
+	sm := scopeMetrics{Scope: scope{Name: scopeName}}
+	for _, name := range names {
+		m := metric{Name: name}
+		switch i := r.reg.Get(name).(type) {
+		case metrics.Counter:
+			// Counters may be decremented, so they are not monotonic
+			m.Sum = cumulative(i.Count(), false)
+		case metrics.Gauge:
+			m.Gauge = &gauge{DataPoints: []numberDataPoint{intPoint(i.Value())}}
+		case metrics.GaugeFloat64:
+			v := i.Value()
+			m.Gauge = &gauge{DataPoints: []numberDataPoint{{TimeUnixNano: ts, AsDouble: &v}}}
+		case metrics.Histogram:
+			ms := i.Snapshot()
+			m.Summary = quantiles(ms.Count(), float64(ms.Sum()), pv, ms.Percentiles(pv))
+		case metrics.Meter:
+			m.Sum = cumulative(i.Count(), true)
+		case metrics.Timer:
+			ms := i.Snapshot()
+			m.Summary = quantiles(ms.Count(), float64(ms.Sum()), pv, ms.Percentiles(pv))
+		case metrics.ResettingTimer:
+			ms := i.Snapshot()
+			if len(ms.Values()) == 0 {
+				continue
+			}
+			var total float64
+			for _, v := range ms.Values() {
+				total += float64(v)
+			}
+			var ps []float64
+			for _, p := range ms.Percentiles([]float64{50, 95, 99}) {
+				ps = append(ps, float64(p))
+			}
+			m.Summary = quantiles(int64(len(ms.Values())), total, []float64{0.5, 0.95, 0.99}, ps)
+		default:
+			continue
+		}
+		sm.Metrics = append(sm.Metrics, m)
+	}
+	// Report the labels as the attributes of the resource, in a stable order
+	keys := make([]string, 0, len(r.labels))
+	for key := range r.labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	res := resource{Attributes: []attribute{}}
+	for _, key := range keys {
+		res.Attributes = append(res.Attributes, attribute{Key: key, Value: attributeValue{StringValue: r.labels[key]}})
+	}
+	return &exportRequest{
+		ResourceMetrics: []resourceMetrics{{Resource: res, ScopeMetrics: []scopeMetrics{sm}}},
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package otlp
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+func TestMain(m *testing.M) {
+	metrics.Enabled = true
+	os.Exit(m.Run())
+}
+
+func TestOTLP(t *testing.T) {
+	reg := metrics.NewRegistry()
+	metrics.NewRegisteredCounter("test/counter", reg).Inc(12345)
+	metrics.NewRegisteredGauge("test/gauge", reg).Update(23456)
+	metrics.NewRegisteredGaugeFloat64("test/gauge_float64", reg).Update(1.5)
+	metrics.NewRegisteredMeter("test/meter", reg).Mark(7)
+	metrics.NewRegisteredHistogram("test/histogram", reg, metrics.NewUniformSample(10)).Update(8)
+
+	var (
+		path string
+		req  exportRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		blob, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(blob, &req); err != nil {
+			t.Errorf("invalid request: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	if err := OTLPOnce(reg, server.URL, map[string]string{"service.name": "geth", "host": "localhost"}, 0); err != nil {
+		t.Fatalf("failed to push metrics: %v", err)
+	}
+	if path != metricsPath {
+		t.Errorf("request path mismatch: have %s, want %s", path, metricsPath)
+	}
+	if len(req.ResourceMetrics) != 1 || len(req.ResourceMetrics[0].ScopeMetrics) != 1 {
+		t.Fatalf("request structure mismatch: %+v", req)
+	}
+	attrs := req.ResourceMetrics[0].Resource.Attributes
+	if len(attrs) != 2 || attrs[0].Key != "host" || attrs[1].Key != "service.name" || attrs[1].Value.StringValue != "geth" {
+		t.Errorf("resource attributes mismatch: %+v", attrs)
+	}
+	ms := req.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	if len(ms) != 5 {
+		t.Fatalf("metric count mismatch: have %d, want 5", len(ms))
+	}
+	if m := ms[0]; m.Name != "test/counter" || m.Sum == nil || m.Sum.IsMonotonic || *m.Sum.DataPoints[0].AsInt != "12345" {
+		t.Errorf("counter mismatch: %+v", m)
+	}
+	if m := ms[1]; m.Name != "test/gauge" || m.Gauge == nil || *m.Gauge.DataPoints[0].AsInt != "23456" {
+		t.Errorf("gauge mismatch: %+v", m)
+	}
+	if m := ms[2]; m.Name != "test/gauge_float64" || m.Gauge == nil || *m.Gauge.DataPoints[0].AsDouble != 1.5 {
+		t.Errorf("float gauge mismatch: %+v", m)
+	}
+	if m := ms[3]; m.Name != "test/histogram" || m.Summary == nil {
+		t.Errorf("histogram mismatch: %+v", m)
+	} else if p := m.Summary.DataPoints[0]; p.Count != "1" || p.Sum != 8 || len(p.QuantileValues) != 6 || p.QuantileValues[0].Value != 8 {
+		t.Errorf("histogram summary mismatch: %+v", p)
+	}
+	if m := ms[4]; m.Name != "test/meter" || m.Sum == nil || !m.Sum.IsMonotonic || m.Sum.AggregationTemporality != temporalityCumulative || *m.Sum.DataPoints[0].AsInt != "7" {
+		t.Errorf("meter mismatch: %+v", m)
+	}
+}
+
+func TestOTLPRetries(t *testing.T) {
+	var (
+		requests int
+		statuses []int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statuses[requests])
+		requests++
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		statuses []int
+		retries  int
+		fail     bool
+	}{
+		{statuses: []int{503, 429, 200}, retries: 2, fail: false},
+		{statuses: []int{503, 503}, retries: 1, fail: true},
+		{statuses: []int{400}, retries: 2, fail: true}, // permanent failures are not retried
+	}
+	for i, tt := range tests {
+		requests, statuses = 0, tt.statuses
+		err := OTLPOnce(metrics.NewRegistry(), server.URL, nil, tt.retries)
+		if (err != nil) != tt.fail {
+			t.Errorf("test %d: failure mismatch: have %v, want failure %v", i, err, tt.fail)
+		}
+		if requests != len(tt.statuses) {
+			t.Errorf("test %d: request count mismatch: have %d, want %d", i, requests, len(tt.statuses))
+		}
+	}
+	if err := OTLPOnce(metrics.NewRegistry(), "udp://localhost:4318", nil, 0); err == nil {
+		t.Errorf("invalid endpoint scheme accepted")
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package prometheus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/golang/snappy"
+)
+
+const (
+	remoteWriteTimeout    = 10 * time.Second
+	remoteWriteRetryDelay = 500 * time.Millisecond // Delay before the first retry, doubled after each
+)
+
+// remoteWriter pushes the metrics of a registry to a Prometheus remote-write
+// endpoint.
+type remoteWriter struct {
+	reg      metrics.Registry
+	endpoint string
+	labels   map[string]string
+	retries  int
+	client   *http.Client
+}
+
+// RemoteWrite starts a Prometheus remote-write reporter which pushes the metrics
+// of the given registry to the endpoint at each d interval. All the series are
+// labelled with the given labels, and failed pushes are retried up to retries
+// times. The call blocks forever, unless d is not positive.
+func RemoteWrite(r metrics.Registry, d time.Duration, endpoint string, labels map[string]string, retries int) {
+	if d <= 0 {
+		log.Warn("Invalid Prometheus remote-write push interval", "interval", d)
+		return
+	}
+	w := newRemoteWriter(r, endpoint, labels, retries)
+	for range time.Tick(d) {
+		if err := w.push(); err != nil {
+			log.Warn("Unable to push metrics via Prometheus remote-write", "err", err)
+		}
+	}
+}
+
+// RemoteWriteOnce pushes the metrics of the given registry to a Prometheus
+// remote-write endpoint once.
+func RemoteWriteOnce(r metrics.Registry, endpoint string, labels map[string]string, retries int) error {
+	return newRemoteWriter(r, endpoint, labels, retries).push()
+}
+
+func newRemoteWriter(r metrics.Registry, endpoint string, labels map[string]string, retries int) *remoteWriter {
+	return &remoteWriter{
+		reg:      r,
+		endpoint: endpoint,
+		labels:   labels,
+		retries:  retries,
+		client:   &http.Client{Timeout: remoteWriteTimeout},
+	}
+}
+
+// push sends the current values of the metrics to the endpoint, retrying with
+// an exponential backoff if the failure is transient.
+func (w *remoteWriter) push() error {
+	body := snappy.Encode(nil, encodeWriteRequest(w.series(time.Now())))
+
+	delay := remoteWriteRetryDelay
+	for attempt := 0; ; attempt++ {
+		err := w.post(body)
+		if err == nil {
+			return nil
+		}
+		if attempt >= w.retries || !isRetryable(err) {
+			return err
+		}
+		log.Debug("Retrying Prometheus remote-write", "attempt", attempt+1, "err", err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// post sends a single encoded write request to the endpoint.
+func (w *remoteWriter) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode/100 != 2 {
+		return &statusError{code: res.StatusCode, status: res.Status}
+	}
+	return nil
+}
+
+// statusError is returned if the remote-write endpoint rejects a request.
+type statusError struct {
+	code   int
+	status string
+}
+
+func (err *statusError) Error() string {
+	return fmt.Sprintf("remote-write endpoint responded with %s", err.status)
+}
+
+// isRetryable reports whether a failed request may succeed if sent again. As per
+// the remote-write specification, only server errors and throttling responses are
+// retried, the other rejections are permanent.
+func isRetryable(err error) bool {
+	if err, ok := err.(*statusError); ok {
+		return err.code/100 == 5 || err.code == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// sample is a single value of a time series.
+type sample struct {
+	labels []label // Sorted by name, including the metric name
+	value  float64
+	time   int64 // Milliseconds since the epoch
+}
+
+type label struct {
+	name, value string
+}
+
+// series converts the metrics of the registry into remote-write samples, using
+// the same naming as the scrape handler.
+func (w *remoteWriter) series(now time.Time) []sample {
+	var (
+		names   []string
+		samples []sample
+		ts      = now.UnixNano() / int64(time.Millisecond)
+	)
+	w.reg.Each(func(name string, i interface{}) {
+		names = append(names, name)
+	})
+	sort.Strings(names)
+
+	add := func(name string, value float64, extra ...label) {
+		labels := append([]label{{"__name__", mutateKey(name)}}, extra...)
+		for k, v := range w.labels {
+			labels = append(labels, label{k, v})
+		}
+		sort.Slice(labels, func(i, j int) bool { return labels[i].name < labels[j].name })
+		samples = append(samples, sample{labels: labels, value: value, time: ts})
+	}
+	addSummary := func(name string, count int64, sum float64, qs []float64, ps []float64) {
+		add(name+"_count", float64(count))
+		add(name+"_sum", sum)
+		for i := range qs {
+			add(name, ps[i], label{"quantile", strconv.FormatFloat(qs[i], 'f', -1, 64)})
+		}
+	}
+	pv := []float64{0.5, 0.75, 0.95, 0.99, 0.999, 0.9999}
+
+	for _, name := range names {
+		switch m := w.reg.Get(name).(type) {
+		case metrics.Counter:
+			add(name, float64(m.Count()))
+		case metrics.Gauge:
+			add(name, float64(m.Value()))
+		case metrics.GaugeFloat64:
+			add(name, m.Value())
+		case metrics.Histogram:
+			ms := m.Snapshot()
+			addSummary(name, ms.Count(), float64(ms.Sum()), pv, ms.Percentiles(pv))
+		case metrics.Meter:
+			add(name, float64(m.Count()))
+		case metrics.Timer:
+			ms := m.Snapshot()
+			addSummary(name, ms.Count(), float64(ms.Sum()), pv, ms.Percentiles(pv))
+		case metrics.ResettingTimer:
+			ms := m.Snapshot()
+			if len(ms.Values()) > 0 {
+				var total float64
+				for _, v := range ms.Values() {
+					total += float64(v)
+				}
+				var ps []float64
+				for _, p := range ms.Percentiles([]float64{50, 95, 99}) {
+					ps = append(ps, float64(p))
+				}
+				addSummary(name, int64(len(ms.Values())), total, []float64{0.5, 0.95, 0.99}, ps)
+			}
+		}
+	}
+	return samples
+}
+
+// encodeWriteRequest encodes the samples as a remote-write WriteRequest protobuf
+// message, each sample being a time series of its own:
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label        { string name = 1; string value = 2; }
+//	message Sample       { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(samples []sample) []byte {
+	var req, series, msg []byte
+	for _, s := range samples {
+		series = series[:0]
+		for _, l := range s.labels {
+			msg = appendProtoBytes(msg[:0], 1, []byte(l.name))
+			msg = appendProtoBytes(msg, 2, []byte(l.value))
+			series = appendProtoBytes(series, 1, msg)
+		}
+		var value [8]byte
+		binary.LittleEndian.PutUint64(value[:], math.Float64bits(s.value))
+
+		msg = appendProtoKey(msg[:0], 1, 1) // 64 bit
+		msg = append(msg, value[:]...)
+		msg = appendProtoKey(msg, 2, 0) // varint
+		msg = appendUvarint(msg, uint64(s.time))
+		series = appendProtoBytes(series, 2, msg)
+
+		req = appendProtoBytes(req, 1, series)
+	}
+	return req
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var enc [binary.MaxVarintLen64]byte
+	return append(b, enc[:binary.PutUvarint(enc[:], v)]...)
+}
+
+func appendProtoKey(b []byte, field int, wiretype int) []byte {
+	return appendUvarint(b, uint64(field<<3|wiretype))
+}
+
+func appendProtoBytes(b []byte, field int, data []byte) []byte {
+	b = appendProtoKey(b, field, 2) // length-delimited
+	b = appendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
@@ -0,0 +1,158 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package prometheus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/golang/snappy"
+)
+
+// decodeProto splits a protobuf message into its fields, supporting only the
+// wire types used by the remote-write messages.
+func decodeProto(t *testing.T, msg []byte) (fields []int, values [][]byte) {
+	for len(msg) > 0 {
+		key, n := binary.Uvarint(msg)
+		msg = msg[n:]
+
+		var size int
+		switch key & 7 {
+		case 0:
+			_, size = binary.Uvarint(msg)
+		case 1:
+			size = 8
+		case 2:
+			length, n := binary.Uvarint(msg)
+			msg = msg[n:]
+			size = int(length)
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+		fields = append(fields, int(key>>3))
+		values = append(values, msg[:size])
+		msg = msg[size:]
+	}
+	return fields, values
+}
+
+// decodeWriteRequest decodes a remote-write request into a map of series, keyed
+// by their labels in the text exposition format.
+func decodeWriteRequest(t *testing.T, req []byte) map[string]float64 {
+	series := make(map[string]float64)
+
+	_, values := decodeProto(t, req)
+	for _, ts := range values {
+		var (
+			labels []string
+			value  float64
+		)
+		fields, values := decodeProto(t, ts)
+		for i, field := range fields {
+			switch field {
+			case 1:
+				_, kv := decodeProto(t, values[i])
+				labels = append(labels, fmt.Sprintf("%s=%q", kv[0], kv[1]))
+			case 2:
+				_, sample := decodeProto(t, values[i])
+				value = math.Float64frombits(binary.LittleEndian.Uint64(sample[0]))
+			}
+		}
+		series["{"+strings.Join(labels, ",")+"}"] = value
+	}
+	return series
+}
+
+func TestRemoteWrite(t *testing.T) {
+	reg := metrics.NewRegistry()
+	metrics.NewRegisteredCounter("test/counter", reg).Inc(12345)
+	metrics.NewRegisteredGaugeFloat64("test/gauge", reg).Update(1.5)
+	metrics.NewRegisteredHistogram("test/histogram", reg, metrics.NewUniformSample(10)).Update(7)
+
+	var series map[string]float64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if enc := r.Header.Get("Content-Encoding"); enc != "snappy" {
+			t.Errorf("content encoding mismatch: have %q, want snappy", enc)
+		}
+		blob, _ := ioutil.ReadAll(r.Body)
+		req, err := snappy.Decode(nil, blob)
+		if err != nil {
+			t.Errorf("invalid snappy payload: %v", err)
+		}
+		series = decodeWriteRequest(t, req)
+	}))
+	defer server.Close()
+
+	if err := RemoteWriteOnce(reg, server.URL, map[string]string{"host": "localhost"}, 0); err != nil {
+		t.Fatalf("failed to push metrics: %v", err)
+	}
+	want := map[string]float64{
+		`{__name__="test_counter",host="localhost"}`:                     12345,
+		`{__name__="test_gauge",host="localhost"}`:                       1.5,
+		`{__name__="test_histogram_count",host="localhost"}`:             1,
+		`{__name__="test_histogram_sum",host="localhost"}`:               7,
+		`{__name__="test_histogram",host="localhost",quantile="0.5"}`:    7,
+		`{__name__="test_histogram",host="localhost",quantile="0.75"}`:   7,
+		`{__name__="test_histogram",host="localhost",quantile="0.95"}`:   7,
+		`{__name__="test_histogram",host="localhost",quantile="0.99"}`:   7,
+		`{__name__="test_histogram",host="localhost",quantile="0.999"}`:  7,
+		`{__name__="test_histogram",host="localhost",quantile="0.9999"}`: 7,
+	}
+	if !reflect.DeepEqual(series, want) {
+		t.Fatalf("series mismatch:\nhave %v\nwant %v", series, want)
+	}
+}
+
+func TestRemoteWriteRetries(t *testing.T) {
+	var (
+		requests int
+		statuses []int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statuses[requests])
+		requests++
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		statuses []int
+		retries  int
+		fail     bool
+	}{
+		{statuses: []int{500, 429, 200}, retries: 2, fail: false},
+		{statuses: []int{500, 503}, retries: 1, fail: true},
+		{statuses: []int{400}, retries: 2, fail: true}, // permanent failures are not retried
+	}
+	for i, tt := range tests {
+		requests, statuses = 0, tt.statuses
+		err := RemoteWriteOnce(metrics.NewRegistry(), server.URL, nil, tt.retries)
+		if (err != nil) != tt.fail {
+			t.Errorf("test %d: failure mismatch: have %v, want failure %v", i, err, tt.fail)
+		}
+		if requests != len(tt.statuses) {
+			t.Errorf("test %d: request count mismatch: have %d, want %d", i, requests, len(tt.statuses))
+		}
+	}
+}
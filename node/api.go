@@ -185,6 +185,10 @@ func (api *privateAdminAPI) StartHTTP(host *string, port *int, cors *string, api
 		CorsAllowedOrigins: api.node.config.HTTPCors,
 		Vhosts:             api.node.config.HTTPVirtualHosts,
 		Modules:            api.node.config.HTTPModules,
+		Limits:             api.node.config.HTTPLimits,
+	}
+	if api.node.config.HTTPAuth {
+		config.jwtSecret = api.node.jwtSecret
 	}
 	if cors != nil {
 		config.CorsAllowedOrigins = nil
@@ -258,8 +262,12 @@ func (api *privateAdminAPI) StartWS(host *string, port *int, allowedOrigins *str
 	config := wsConfig{
 		Modules: api.node.config.WSModules,
 		Origins: api.node.config.WSOrigins,
+		Limits:  api.node.config.WSLimits,
 		// ExposeAll: api.node.config.WSExposeAll,
 	}
+	if api.node.config.WSAuth {
+		config.jwtSecret = api.node.jwtSecret
+	}
 	if apis != nil {
 		config.Modules = nil
 		for _, m := range strings.Split(*apis, ",") {
@@ -311,6 +319,15 @@ func (api *publicAdminAPI) Peers() ([]*p2p.PeerInfo, error) {
 	return server.PeersInfo(), nil
 }
 
+// PeerScores retrieves the reputation scores of all known nodes, lowest first.
+func (api *publicAdminAPI) PeerScores() ([]*p2p.PeerScoreInfo, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	return server.PeerScores(), nil
+}
+
 // NodeInfo retrieves all the information we know about the host node at the
 // protocol granularity.
 func (api *publicAdminAPI) NodeInfo() (*p2p.NodeInfo, error) {
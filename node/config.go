@@ -45,6 +45,7 @@ const (
 	datadirStaticNodes     = "static-nodes.json"  // Path within the datadir to the static node list
 	datadirTrustedNodes    = "trusted-nodes.json" // Path within the datadir to the trusted node list
 	datadirNodeDatabase    = "nodes"              // Path within the datadir to store the node infos
+	datadirJWTKey          = "jwtsecret"          // Path within the datadir to the node's jwt secret
 )
 
 // Config represents a small collection of configuration values to fine tune the
@@ -70,6 +71,11 @@ type Config struct {
 	// in memory.
 	DataDir string
 
+	// DBEngine is the key-value engine backing the node's databases, either
+	// "leveldb" or "pebble". If empty, the engine of any pre-existing database
+	// in the data directory is used, falling back to leveldb for new ones.
+	DBEngine string `toml:",omitempty"`
+
 	// Configuration of peer-to-peer networking.
 	P2P p2p.Config
 
@@ -107,6 +113,9 @@ type Config struct {
 	// relative), then that specific path is enforced. An empty path disables IPC.
 	IPCPath string
 
+	// IPCLimits configures the resource limits enforced on the IPC connections.
+	IPCLimits rpc.Limits
+
 	// HTTPHost is the host interface on which to start the HTTP RPC server. If this
 	// field is empty, no HTTP API endpoint will be started.
 	HTTPHost string
@@ -142,6 +151,9 @@ type Config struct {
 	// HTTPPathPrefix specifies a path prefix on which http-rpc is to be served.
 	HTTPPathPrefix string `toml:",omitempty"`
 
+	// HTTPLimits configures the resource limits enforced on the HTTP RPC requests.
+	HTTPLimits rpc.Limits
+
 	// WSHost is the host interface on which to start the websocket RPC server. If
 	// this field is empty, no websocket API endpoint will be started.
 	WSHost string
@@ -171,6 +183,42 @@ type Config struct {
 	// private APIs to untrusted users is a major security risk.
 	WSExposeAll bool `toml:",omitempty"`
 
+	// WSLimits configures the resource limits enforced on the websocket RPC
+	// connections.
+	WSLimits rpc.Limits
+
+	// HTTPAuth requires a valid JWT token on every request made to the HTTP RPC
+	// interface. The shared secret is loaded from JWTSecret.
+	HTTPAuth bool `toml:",omitempty"`
+
+	// WSAuth requires a valid JWT token on every connection made to the websocket
+	// RPC interface. The shared secret is loaded from JWTSecret.
+	WSAuth bool `toml:",omitempty"`
+
+	// AuthAddr is the listening address on which authenticated APIs are provided.
+	AuthAddr string `toml:",omitempty"`
+
+	// AuthPort is the port number on which authenticated APIs are provided.
+	AuthPort int `toml:",omitempty"`
+
+	// AuthVirtualHosts is the list of virtual hostnames which are allowed on incoming requests
+	// for the authenticated api. This is by default {'localhost'}.
+	AuthVirtualHosts []string `toml:",omitempty"`
+
+	// AuthModules is a list of API modules to expose via the authenticated RPC
+	// interface. Both HTTP and websocket requests are served on the same port.
+	// If the module list is empty, no authenticated endpoint will be started.
+	//
+	// The HTTP and WS limits are deliberately not enforced on this endpoint. It
+	// only serves the consensus client holding the JWT secret, and throttling it
+	// could stall block processing.
+	AuthModules []string `toml:",omitempty"`
+
+	// JWTSecret is the path to the hex-encoded 32 byte secret used to authenticate
+	// RPC requests. If the file does not exist, a fresh secret is generated into it.
+	// If empty, the secret is stored in the instance directory.
+	JWTSecret string `toml:",omitempty"`
+
 	// GraphQLCors is the Cross-Origin Resource Sharing header to send to requesting
 	// clients. Please be aware that CORS is a browser enforced security, it's fully
 	// useless for custom HTTP clients.
@@ -194,6 +242,10 @@ type Config struct {
 
 	// AllowUnprotectedTxs allows non EIP-155 protected transactions to be send over RPC.
 	AllowUnprotectedTxs bool `toml:",omitempty"`
+
+	// TracingEndpoint is the URL of the OpenTelemetry collector to export tracing
+	// spans to via OTLP/HTTP. Tracing is disabled if empty.
+	TracingEndpoint string `toml:",omitempty"`
 }
 
 // IPCEndpoint resolves an IPC endpoint based on a configured value, taking into
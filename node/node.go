@@ -17,8 +17,10 @@
 package node
 
 import (
+	crand "crypto/rand"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -27,9 +29,12 @@ import (
 	"sync"
 
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/internal/tracing"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -48,12 +53,15 @@ type Node struct {
 	server        *p2p.Server       // Currently running P2P networking layer
 	startStopLock sync.Mutex        // Start/Stop are protected by an additional lock
 	state         int               // Tracks state of node lifecycle
+	tracing       bool              // Whether the node enabled exporting tracing spans
 
 	lock          sync.Mutex
 	lifecycles    []Lifecycle // All registered backends, services, and auxiliary services that have a lifecycle
 	rpcAPIs       []rpc.API   // List of APIs currently provided by the node
 	http          *httpServer //
 	ws            *httpServer //
+	auth          *httpServer // Serves the authenticated modules over HTTP and WebSocket
+	jwtSecret     []byte      // Shared secret authenticating RPC requests, if any
 	ipc           *ipcServer  // Stores information about the ipc http server
 	inprocHandler *rpc.Server // In-process RPC request handler to process the API requests
 
@@ -146,7 +154,8 @@ func New(conf *Config) (*Node, error) {
 	// Configure RPC servers.
 	node.http = newHTTPServer(node.log, conf.HTTPTimeouts)
 	node.ws = newHTTPServer(node.log, rpc.DefaultHTTPTimeouts)
-	node.ipc = newIPCServer(node.log, conf.IPCEndpoint())
+	node.auth = newHTTPServer(node.log, rpc.DefaultHTTPTimeouts)
+	node.ipc = newIPCServer(node.log, conf.IPCEndpoint(), conf.IPCLimits)
 
 	return node, nil
 }
@@ -167,6 +176,12 @@ func (n *Node) Start() error {
 		return ErrNodeStopped
 	}
 	n.state = runningState
+	// start exporting tracing spans if requested
+	if err := n.startTracing(); err != nil {
+		n.lock.Unlock()
+		n.doClose(nil)
+		return err
+	}
 	// open networking and RPC endpoints
 	err := n.openEndpoints()
 	lifecycles := make([]Lifecycle, len(n.lifecycles))
@@ -230,6 +245,8 @@ func (n *Node) doClose(errs []error) error {
 	errs = append(errs, n.closeDatabases()...)
 	n.lock.Unlock()
 
+	n.stopTracing()
+
 	if err := n.accman.Close(); err != nil {
 		errs = append(errs, err)
 	}
@@ -282,6 +299,30 @@ func containsLifecycle(lfs []Lifecycle, l Lifecycle) bool {
 	return false
 }
 
+// startTracing enables tracing with the configured OTLP collector as the exporter.
+func (n *Node) startTracing() error {
+	if n.config.TracingEndpoint == "" {
+		return nil
+	}
+	exporter, err := tracing.NewOTLPExporter(n.config.TracingEndpoint, n.config.Name)
+	if err != nil {
+		return fmt.Errorf("invalid tracing endpoint: %v", err)
+	}
+	tracing.Enable(exporter)
+	n.tracing = true
+	n.log.Info("Enabled tracing", "endpoint", n.config.TracingEndpoint)
+	return nil
+}
+
+// stopTracing disables tracing if it was enabled by the node, exporting the
+// pending spans.
+func (n *Node) stopTracing() {
+	if n.tracing {
+		tracing.Disable()
+		n.tracing = false
+	}
+}
+
 // stopServices terminates running services, RPC and p2p networking.
 // It is the inverse of Start.
 func (n *Node) stopServices(running []Lifecycle) error {
@@ -348,14 +389,27 @@ func (n *Node) startRPC() error {
 		}
 	}
 
+	// Load the JWT secret if any endpoint requires authentication.
+	if n.config.HTTPAuth || n.config.WSAuth || len(n.config.AuthModules) > 0 {
+		secret, err := n.obtainJWTSecret(n.config.JWTSecret)
+		if err != nil {
+			return err
+		}
+		n.jwtSecret = secret
+	}
+
 	// Configure HTTP.
 	if n.config.HTTPHost != "" {
 		config := httpConfig{
 			CorsAllowedOrigins: n.config.HTTPCors,
 			Vhosts:             n.config.HTTPVirtualHosts,
 			Modules:            n.config.HTTPModules,
+			Limits:             n.config.HTTPLimits,
 			prefix:             n.config.HTTPPathPrefix,
 		}
+		if n.config.HTTPAuth {
+			config.jwtSecret = n.jwtSecret
+		}
 		if err := n.http.setListenAddr(n.config.HTTPHost, n.config.HTTPPort); err != nil {
 			return err
 		}
@@ -370,8 +424,12 @@ func (n *Node) startRPC() error {
 		config := wsConfig{
 			Modules: n.config.WSModules,
 			Origins: n.config.WSOrigins,
+			Limits:  n.config.WSLimits,
 			prefix:  n.config.WSPathPrefix,
 		}
+		if n.config.WSAuth {
+			config.jwtSecret = n.jwtSecret
+		}
 		if err := server.setListenAddr(n.config.WSHost, n.config.WSPort); err != nil {
 			return err
 		}
@@ -380,10 +438,72 @@ func (n *Node) startRPC() error {
 		}
 	}
 
+	// Configure the authenticated listener, serving both HTTP and WebSocket.
+	if len(n.config.AuthModules) > 0 {
+		if err := n.auth.setListenAddr(n.config.AuthAddr, n.config.AuthPort); err != nil {
+			return err
+		}
+		httpConfig := httpConfig{
+			Vhosts:    n.config.AuthVirtualHosts,
+			Modules:   n.config.AuthModules,
+			jwtSecret: n.jwtSecret,
+		}
+		if err := n.auth.enableRPC(n.rpcAPIs, httpConfig); err != nil {
+			return err
+		}
+		wsConfig := wsConfig{
+			Modules:   n.config.AuthModules,
+			Origins:   n.config.AuthVirtualHosts,
+			jwtSecret: n.jwtSecret,
+		}
+		if err := n.auth.enableWS(n.rpcAPIs, wsConfig); err != nil {
+			return err
+		}
+	}
+
 	if err := n.http.start(); err != nil {
 		return err
 	}
-	return n.ws.start()
+	if err := n.ws.start(); err != nil {
+		return err
+	}
+	return n.auth.start()
+}
+
+// obtainJWTSecret loads the jwt-secret, either from the provided config,
+// or from the default location. If neither of those are present, it generates
+// a new secret and stores to the default location.
+func (n *Node) obtainJWTSecret(cliParam string) ([]byte, error) {
+	fileName := cliParam
+	if len(fileName) == 0 {
+		// no path provided, use default
+		fileName = n.ResolvePath(datadirJWTKey)
+	}
+	// try reading from file
+	if data, err := ioutil.ReadFile(fileName); err == nil {
+		jwtSecret := common.FromHex(strings.TrimSpace(string(data)))
+		if len(jwtSecret) == 32 {
+			n.log.Info("Loaded JWT secret file", "path", fileName)
+			return jwtSecret, nil
+		}
+		n.log.Error("Invalid JWT secret", "path", fileName, "length", len(jwtSecret))
+		return nil, ErrInvalidJWT
+	}
+	// Need to generate one
+	jwtSecret := make([]byte, 32)
+	if _, err := crand.Read(jwtSecret); err != nil {
+		return nil, err
+	}
+	// if we're running an ephemeral node, don't bother saving, just show it
+	if fileName == "" {
+		n.log.Info("Generated ephemeral JWT secret", "secret", hexutil.Encode(jwtSecret))
+		return jwtSecret, nil
+	}
+	if err := ioutil.WriteFile(fileName, []byte(hexutil.Encode(jwtSecret)), 0600); err != nil {
+		return nil, err
+	}
+	n.log.Info("Generated JWT secret", "path", fileName)
+	return jwtSecret, nil
 }
 
 func (n *Node) wsServerForPort(port int) *httpServer {
@@ -396,6 +516,7 @@ func (n *Node) wsServerForPort(port int) *httpServer {
 func (n *Node) stopRPC() {
 	n.http.stop()
 	n.ws.stop()
+	n.auth.stop()
 	n.ipc.stop()
 	n.stopInProc()
 }
@@ -559,7 +680,14 @@ func (n *Node) OpenDatabase(name string, cache, handles int, namespace string, r
 	if n.config.DataDir == "" {
 		db = rawdb.NewMemoryDatabase()
 	} else {
-		db, err = rawdb.NewLevelDBDatabase(n.ResolvePath(name), cache, handles, namespace, readonly)
+		db, err = rawdb.Open(rawdb.OpenOptions{
+			Type:      n.config.DBEngine,
+			Directory: n.ResolvePath(name),
+			Namespace: namespace,
+			Cache:     cache,
+			Handles:   handles,
+			ReadOnly:  readonly,
+		})
 	}
 
 	if err == nil {
@@ -592,7 +720,15 @@ func (n *Node) OpenDatabaseWithFreezer(name string, cache, handles int, freezer,
 		case !filepath.IsAbs(freezer):
 			freezer = n.ResolvePath(freezer)
 		}
-		db, err = rawdb.NewLevelDBDatabaseWithFreezer(root, cache, handles, freezer, namespace, readonly)
+		db, err = rawdb.Open(rawdb.OpenOptions{
+			Type:              n.config.DBEngine,
+			Directory:         root,
+			AncientsDirectory: freezer,
+			Namespace:         namespace,
+			Cache:             cache,
+			Handles:           handles,
+			ReadOnly:          readonly,
+		})
 	}
 
 	if err == nil {
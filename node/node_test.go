@@ -17,6 +17,7 @@
 package node
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -24,14 +25,18 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/golang-jwt/jwt/v4"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -485,6 +490,64 @@ func TestWebsocketHTTPOnSeparatePort_WSRequest(t *testing.T) {
 	}
 }
 
+// Tests that the authenticated listener only serves the configured modules,
+// and only to callers presenting a token signed with the node's JWT secret.
+func TestNodeAuthListener(t *testing.T) {
+	datadir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(datadir)
+
+	node, err := New(&Config{
+		Name:             "test",
+		DataDir:          datadir,
+		AuthAddr:         "127.0.0.1",
+		AuthPort:         0,
+		AuthVirtualHosts: []string{"*"},
+		AuthModules:      []string{"web3"},
+	})
+	if err != nil {
+		t.Fatal("can't create node:", err)
+	}
+	defer node.Close()
+	if err := node.Start(); err != nil {
+		t.Fatal("can't start node:", err)
+	}
+	// The secret should have been generated into the data directory.
+	blob, err := ioutil.ReadFile(filepath.Join(datadir, "test", datadirJWTKey))
+	if err != nil {
+		t.Fatal("missing jwt secret:", err)
+	}
+	secret := common.FromHex(string(blob))
+	if !bytes.Equal(secret, node.jwtSecret) {
+		t.Fatalf("jwt secret mismatch: have %x, want %x", node.jwtSecret, secret)
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+	}).SignedString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := "http://" + node.auth.listenAddr()
+
+	if resp := rpcRequest(t, url); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated request: have status %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	resp := rpcRequest(t, url, "Authorization", "Bearer "+token)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("authenticated request: have status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), `"web3"`) || strings.Contains(string(body), `"admin"`) {
+		t.Fatalf("wrong modules served: %s", body)
+	}
+	if err := wsRequest(t, "ws://"+node.auth.listenAddr(), "Authorization", "Bearer "+token); err != nil {
+		t.Fatalf("authenticated websocket request failed: %v", err)
+	}
+}
+
 type rpcPrefixTest struct {
 	httpPrefix, wsPrefix string
 	// These lists paths on which JSON-RPC should be served / not served.
@@ -577,13 +640,13 @@ func (test rpcPrefixTest) check(t *testing.T, node *Node) {
 		}
 	}
 	for _, path := range test.wantWS {
-		err := wsRequest(t, wsBase+path, "")
+		err := wsRequest(t, wsBase+path)
 		if err != nil {
 			t.Errorf("Error: %s: WebSocket connection failed: %v", path, err)
 		}
 	}
 	for _, path := range test.wantNoWS {
-		err := wsRequest(t, wsBase+path, "")
+		err := wsRequest(t, wsBase+path)
 		if err == nil {
 			t.Errorf("Error: %s: WebSocket connection succeeded for path in wantNoWS", path)
 		}
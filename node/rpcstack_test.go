@@ -24,10 +24,12 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/internal/testlog"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 )
@@ -58,6 +60,68 @@ func TestVhosts(t *testing.T) {
 	assert.Equal(t, resp2.StatusCode, http.StatusForbidden)
 }
 
+// TestJWT makes sure that JWT authentication is enforced on both the http and
+// the websocket handlers when a secret is configured.
+func TestJWT(t *testing.T) {
+	var secret = []byte("secret")
+	issueToken := func(secret []byte, method jwt.SigningMethod, input map[string]interface{}) string {
+		if method == nil {
+			method = jwt.SigningMethodHS256
+		}
+		ss, _ := jwt.NewWithClaims(method, jwt.MapClaims(input)).SignedString(secret)
+		return ss
+	}
+	srv := createAndStartServer(t, &httpConfig{jwtSecret: secret}, true, &wsConfig{Origins: []string{"*"}, jwtSecret: secret})
+	defer srv.stop()
+	wsUrl := "ws://" + srv.listenAddr()
+	htUrl := "http://" + srv.listenAddr()
+
+	expOk := []string{
+		fmt.Sprintf("Bearer %v", issueToken(secret, nil, map[string]interface{}{"iat": time.Now().Unix()})),
+		fmt.Sprintf("Bearer %v", issueToken(secret, nil, map[string]interface{}{"iat": time.Now().Unix() + 4})),
+		fmt.Sprintf("Bearer %v", issueToken(secret, nil, map[string]interface{}{"iat": time.Now().Unix() - 4})),
+		fmt.Sprintf("Bearer %v", issueToken(secret, nil, map[string]interface{}{
+			"iat": time.Now().Unix(),
+			"exp": time.Now().Unix() + 2,
+		})),
+	}
+	for i, token := range expOk {
+		if err := wsRequest(t, wsUrl, "Authorization", token); err != nil {
+			t.Errorf("test %d-ws, token '%v': expected ok, got %v", i, token, err)
+		}
+		if resp := rpcRequest(t, htUrl, "Authorization", token); resp.StatusCode != http.StatusOK {
+			t.Errorf("test %d-http, token '%v': expected ok, got %v", i, token, resp.StatusCode)
+		}
+	}
+	expFail := []string{
+		// future
+		fmt.Sprintf("Bearer %v", issueToken(secret, nil, map[string]interface{}{"iat": time.Now().Unix() + int64(jwtExpiryTimeout.Seconds()) + 1})),
+		// stale
+		fmt.Sprintf("Bearer %v", issueToken(secret, nil, map[string]interface{}{"iat": time.Now().Unix() - int64(jwtExpiryTimeout.Seconds()) - 1})),
+		// wrong algo
+		fmt.Sprintf("Bearer %v", issueToken(secret, jwt.SigningMethodHS512, map[string]interface{}{"iat": time.Now().Unix() + 4})),
+		// expired
+		fmt.Sprintf("Bearer %v", issueToken(secret, nil, map[string]interface{}{"iat": time.Now().Unix(), "exp": time.Now().Unix() - 1})),
+		// missing iat
+		fmt.Sprintf("Bearer %v", issueToken(secret, nil, map[string]interface{}{})),
+		// wrong secret
+		fmt.Sprintf("Bearer %v", issueToken([]byte("wrong"), nil, map[string]interface{}{"iat": time.Now().Unix()})),
+		// missing token
+		"Bearer ",
+		"",
+		// wrong scheme
+		fmt.Sprintf("Basic %v", issueToken(secret, nil, map[string]interface{}{"iat": time.Now().Unix()})),
+	}
+	for i, token := range expFail {
+		if err := wsRequest(t, wsUrl, "Authorization", token); err == nil {
+			t.Errorf("tc %d-ws, token '%v': expected not to allow, got ok", i, token)
+		}
+		if resp := rpcRequest(t, htUrl, "Authorization", token); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("tc %d-http, token '%v': expected not to allow, got %v", i, token, resp.StatusCode)
+		}
+	}
+}
+
 type originTest struct {
 	spec    string
 	expOk   []string
@@ -146,12 +210,12 @@ func TestWebsocketOrigins(t *testing.T) {
 		srv := createAndStartServer(t, &httpConfig{}, true, &wsConfig{Origins: splitAndTrim(tc.spec)})
 		url := fmt.Sprintf("ws://%v", srv.listenAddr())
 		for _, origin := range tc.expOk {
-			if err := wsRequest(t, url, origin); err != nil {
+			if err := wsRequest(t, url, "Origin", origin); err != nil {
 				t.Errorf("spec '%v', origin '%v': expected ok, got %v", tc.spec, origin, err)
 			}
 		}
 		for _, origin := range tc.expFail {
-			if err := wsRequest(t, url, origin); err == nil {
+			if err := wsRequest(t, url, "Origin", origin); err == nil {
 				t.Errorf("spec '%v', origin '%v': expected not to allow,  got ok", tc.spec, origin)
 			}
 		}
@@ -243,13 +307,18 @@ func createAndStartServer(t *testing.T, conf *httpConfig, ws bool, wsConf *wsCon
 }
 
 // wsRequest attempts to open a WebSocket connection to the given URL.
-func wsRequest(t *testing.T, url, browserOrigin string) error {
+func wsRequest(t *testing.T, url string, extraHeaders ...string) error {
 	t.Helper()
-	t.Logf("checking WebSocket on %s (origin %q)", url, browserOrigin)
+	t.Logf("checking WebSocket on %s %v", url, extraHeaders)
 
+	if len(extraHeaders)%2 != 0 {
+		panic("odd extraHeaders length")
+	}
 	headers := make(http.Header)
-	if browserOrigin != "" {
-		headers.Set("Origin", browserOrigin)
+	for i := 0; i < len(extraHeaders); i += 2 {
+		if key, value := extraHeaders[i], extraHeaders[i+1]; value != "" {
+			headers.Set(key, value)
+		}
 	}
 	conn, _, err := websocket.DefaultDialer.Dial(url, headers)
 	if conn != nil {
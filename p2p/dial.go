@@ -58,6 +58,10 @@ type nodeResolver interface {
 	Resolve(*enode.Node) *enode.Node
 }
 
+type nodeScorer interface {
+	score(enode.ID) float64
+}
+
 // tcpDialer implements NodeDialer using real TCP connections.
 type tcpDialer struct {
 	d *net.Dialer
@@ -79,6 +83,7 @@ var (
 	errRecentlyDialed   = errors.New("recently dialed")
 	errNotWhitelisted   = errors.New("not contained in netrestrict whitelist")
 	errNoPort           = errors.New("node does not provide TCP port")
+	errLowScore         = errors.New("reputation score too low")
 )
 
 // dialer creates outbound connections and submits them into Server.
@@ -135,6 +140,7 @@ type dialConfig struct {
 	maxActiveDials int              // maximum number of active dials
 	netRestrict    *netutil.Netlist // IP whitelist, disabled if nil
 	resolver       nodeResolver
+	scorer         nodeScorer // reputation scores, disabled if nil
 	dialer         NodeDialer
 	log            log.Logger
 	clock          mclock.Clock
@@ -242,7 +248,7 @@ loop:
 
 		select {
 		case node := <-nodesCh:
-			if err := d.checkDial(node); err != nil {
+			if err := d.checkDynDial(node); err != nil {
 				d.log.Trace("Discarding dial candidate", "id", node.ID(), "ip", node.IP(), "reason", err)
 			} else {
 				d.startDial(newDialTask(node, dynDialedConn))
@@ -410,6 +416,19 @@ func (d *dialScheduler) checkDial(n *enode.Node) error {
 	return nil
 }
 
+// checkDynDial returns an error if discovered node n should not be dialed. In addition
+// to the checks performed for all dials, nodes with bad reputation are skipped. This
+// check doesn't apply to static nodes because they are configured explicitly.
+func (d *dialScheduler) checkDynDial(n *enode.Node) error {
+	if err := d.checkDial(n); err != nil {
+		return err
+	}
+	if d.scorer != nil && d.scorer.score(n.ID()) < dialScoreThreshold {
+		return errLowScore
+	}
+	return nil
+}
+
 // startStaticDials starts n static dial tasks.
 func (d *dialScheduler) startStaticDials(n int) (started int) {
 	for started = 0; started < n && len(d.staticPool) > 0; started++ {
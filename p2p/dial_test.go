@@ -152,6 +152,36 @@ func TestDialSchedNetRestrict(t *testing.T) {
 	})
 }
 
+// This test checks that discovered nodes with low reputation are not dialed.
+func TestDialSchedScore(t *testing.T) {
+	t.Parallel()
+
+	nodes := []*enode.Node{
+		newNode(uintID(0x01), "127.0.0.1:30303"),
+		newNode(uintID(0x02), "127.0.0.2:30303"),
+		newNode(uintID(0x03), "127.0.0.3:30303"),
+		newNode(uintID(0x04), "127.0.0.4:30303"),
+	}
+	config := dialConfig{
+		scorer: dialTestScorer{
+			uintID(0x01): dialScoreThreshold - 1,
+			uintID(0x02): dialScoreThreshold,
+			uintID(0x03): -1000,
+		},
+		maxActiveDials: 10,
+		maxDialPeers:   10,
+	}
+	runDialTest(t, config, []dialTestRound{
+		{
+			update: func(d *dialScheduler) {
+				d.addStatic(nodes[2])
+			},
+			discovered:   nodes,
+			wantNewDials: nodes[1:],
+		},
+	})
+}
+
 // This test checks that static dials work and obey the limits.
 func TestDialSchedStaticDial(t *testing.T) {
 	t.Parallel()
@@ -486,6 +516,13 @@ func runDialTest(t *testing.T, config dialConfig, rounds []dialTestRound) {
 	}
 }
 
+// dialTestScorer is a nodeScorer with fixed scores.
+type dialTestScorer map[enode.ID]float64
+
+func (s dialTestScorer) score(id enode.ID) float64 {
+	return s[id]
+}
+
 // dialTestIterator is the input iterator for dialer tests. This works a bit like a channel
 // with infinite buffer: nodes are added to the buffer with addNodes, which unblocks Next
 // and returns them from the iterator.
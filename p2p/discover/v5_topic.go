@@ -0,0 +1,572 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	crand "crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/discover/v5wire"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/netutil"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	topicAdLifetime  = 15 * time.Minute // how long an advertisement stays in the topic table
+	topicQueueLimit  = 100              // max advertisements per topic
+	topicTableLimit  = 5000             // max advertisements across all topics
+	topicRegWindow   = 10 * time.Second // how long a ticket can be used after its wait time
+	topicRegistrars  = 8                // number of nodes to register a topic with
+	topicRegInterval = 1 * time.Minute  // delay between lookups for registrars
+	topicSearchDelay = 10 * time.Second // delay between lookups during topic search
+)
+
+var (
+	errInvalidTicket   = errors.New("invalid ticket")
+	errTicketWaitTime  = errors.New("ticket wait time too long")
+	errTopicMismatch   = errors.New("topic mismatch in registration confirmation")
+	errRegtopicNoENR   = errors.New("missing node record")
+	errRegtopicWrongIP = errors.New("node record IP doesn't match sender")
+	errRegtopicWrongID = errors.New("node record doesn't match sender")
+)
+
+// TopicID identifies a topic. It is the Keccak256 hash of the topic name.
+type TopicID [32]byte
+
+// NewTopicID returns the ID of the topic with the given name.
+func NewTopicID(name string) TopicID {
+	return TopicID(crypto.Keccak256Hash([]byte(name)))
+}
+
+// String returns the topic ID as a hex string.
+func (t TopicID) String() string {
+	return fmt.Sprintf("%x", t[:])
+}
+
+// TerminalString returns a shortened hex string for terminal logging.
+func (t TopicID) TerminalString() string {
+	return hex.EncodeToString(t[:8])
+}
+
+// RegisterTopic starts advertising the local node under the given topic. The node
+// registers itself with the nodes closest to the topic ID and renews the
+// registrations until StopRegisterTopic is called or the transport is closed.
+func (t *UDPv5) RegisterTopic(topic TopicID) {
+	t.topicRegLock.Lock()
+	defer t.topicRegLock.Unlock()
+
+	if _, ok := t.topicRegs[topic]; ok {
+		return
+	}
+	ctx, cancel := context.WithCancel(t.closeCtx)
+	t.topicRegs[topic] = cancel
+	go t.topicRegLoop(ctx, topic)
+}
+
+// StopRegisterTopic stops advertising the local node under the given topic.
+// Existing advertisements are not withdrawn, they expire on the registrars.
+func (t *UDPv5) StopRegisterTopic(topic TopicID) {
+	t.topicRegLock.Lock()
+	defer t.topicRegLock.Unlock()
+
+	if cancel, ok := t.topicRegs[topic]; ok {
+		cancel()
+		delete(t.topicRegs, topic)
+	}
+}
+
+// TopicSearch returns an iterator over the nodes advertising the given topic. The
+// iterator runs lookups toward the topic ID and asks every node it encounters for
+// advertisements. Each advertised node is returned once.
+func (t *UDPv5) TopicSearch(topic TopicID) enode.Iterator {
+	ctx, cancel := context.WithCancel(t.closeCtx)
+	return &topicSearchIterator{
+		t:      t,
+		topic:  topic,
+		ctx:    ctx,
+		cancel: cancel,
+		seen:   make(map[enode.ID]bool),
+	}
+}
+
+// topicRegLoop keeps the local node registered under a topic. It looks up the nodes
+// closest to the topic ID and registers with each of them. When all registrars have
+// stopped responding, the lookup is repeated.
+func (t *UDPv5) topicRegLoop(ctx context.Context, topic TopicID) {
+	for {
+		registrars := t.newLookup(ctx, enode.ID(topic)).run()
+		if len(registrars) > topicRegistrars {
+			registrars = registrars[:topicRegistrars]
+		}
+		var wg sync.WaitGroup
+		for _, n := range registrars {
+			wg.Add(1)
+			go func(n *enode.Node) {
+				defer wg.Done()
+				t.topicRegister(ctx, n, topic)
+			}(n)
+		}
+		wg.Wait()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.clock.After(topicRegInterval):
+		}
+	}
+}
+
+// topicRegister registers the local node under a topic with a single registrar. It
+// waits for tickets as instructed by the registrar and renews the registration when
+// the advertisement expires. It returns when the registrar stops responding.
+func (t *UDPv5) topicRegister(ctx context.Context, n *enode.Node, topic TopicID) {
+	var ticket []byte
+	for {
+		newTicket, wait, err := t.regtopic(n, topic, ticket)
+		if err != nil {
+			t.log.Debug("Topic registration failed", "topic", topic, "id", n.ID(), "err", err)
+			return
+		}
+		if newTicket == nil {
+			t.log.Trace("Registered topic", "topic", topic, "id", n.ID())
+			wait = topicAdLifetime
+		}
+		ticket = newTicket
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.clock.After(wait):
+		}
+	}
+}
+
+// regtopic calls REGTOPIC on a node. If the node did not register us yet, it returns
+// the ticket and the time to wait before using it. On success the ticket is nil.
+func (t *UDPv5) regtopic(n *enode.Node, topic TopicID, ticket []byte) ([]byte, time.Duration, error) {
+	req := &v5wire.Regtopic{Topic: topic, ENR: t.localNode.Node().Record(), Ticket: ticket}
+	resp := t.call(n, v5wire.TicketMsg, req)
+	defer t.callDone(resp)
+
+	select {
+	case respMsg := <-resp.ch:
+		switch respMsg := respMsg.(type) {
+		case *v5wire.Regconfirmation:
+			if respMsg.Topic != topic {
+				return nil, 0, errTopicMismatch
+			}
+			return nil, 0, nil
+		case *v5wire.Ticket:
+			wait := time.Duration(respMsg.WaitTime) * time.Second
+			if len(respMsg.Ticket) == 0 {
+				return nil, 0, errInvalidTicket
+			}
+			if wait > topicAdLifetime {
+				return nil, 0, errTicketWaitTime
+			}
+			return respMsg.Ticket, wait, nil
+		default:
+			panic(fmt.Sprintf("unexpected response %s to REGTOPIC", respMsg.Name()))
+		}
+	case err := <-resp.err:
+		return nil, 0, err
+	}
+}
+
+// topicQuery calls TOPICQUERY on a node and waits for responses.
+func (t *UDPv5) topicQuery(n *enode.Node, topic TopicID) ([]*enode.Node, error) {
+	resp := t.call(n, v5wire.NodesMsg, &v5wire.TopicQuery{Topic: topic})
+	return t.waitForNodes(resp, nil)
+}
+
+// handleRegtopic admits the sender into a topic queue, or hands out a ticket if it
+// has to wait.
+func (t *UDPv5) handleRegtopic(p *v5wire.Regtopic, fromID enode.ID, fromAddr *net.UDPAddr) {
+	n, err := t.regtopicNode(p, fromID, fromAddr)
+	if err != nil {
+		t.log.Debug("Invalid "+p.Name(), "id", fromID, "addr", fromAddr, "err", err)
+		return
+	}
+	ticket, wait, err := t.topics.register(n, fromAddr.IP, p.Topic, p.Ticket)
+	if err != nil {
+		t.log.Debug("Invalid "+p.Name(), "id", fromID, "addr", fromAddr, "err", err)
+		return
+	}
+	if ticket == nil {
+		t.sendResponse(fromID, fromAddr, &v5wire.Regconfirmation{ReqID: p.ReqID, Topic: p.Topic})
+		return
+	}
+	// Round the wait time up, so the registrant doesn't come back too early.
+	secs := uint((wait + time.Second - 1) / time.Second)
+	t.sendResponse(fromID, fromAddr, &v5wire.Ticket{ReqID: p.ReqID, Ticket: ticket, WaitTime: secs})
+}
+
+// regtopicNode verifies the node record in a REGTOPIC request.
+func (t *UDPv5) regtopicNode(p *v5wire.Regtopic, fromID enode.ID, fromAddr *net.UDPAddr) (*enode.Node, error) {
+	if p.ENR == nil {
+		return nil, errRegtopicNoENR
+	}
+	n, err := enode.New(t.validSchemes, p.ENR)
+	if err != nil {
+		return nil, err
+	}
+	if n.ID() != fromID {
+		return nil, errRegtopicWrongID
+	}
+	if !n.IP().Equal(fromAddr.IP) {
+		return nil, errRegtopicWrongIP
+	}
+	return n, nil
+}
+
+// handleTopicQuery returns the nodes advertising a topic to the requester.
+func (t *UDPv5) handleTopicQuery(p *v5wire.TopicQuery, fromID enode.ID, fromAddr *net.UDPAddr) {
+	var nodes []*enode.Node
+	for _, n := range t.topics.nodes(p.Topic, findnodeResultLimit) {
+		if netutil.CheckRelayIP(fromAddr.IP, n.IP()) == nil {
+			nodes = append(nodes, n)
+		}
+	}
+	for _, resp := range packNodes(p.ReqID, nodes) {
+		t.sendResponse(fromID, fromAddr, resp)
+	}
+}
+
+// topicSearchIterator runs lookups toward a topic ID and queries the nodes found by
+// the lookups for advertisements of the topic.
+type topicSearchIterator struct {
+	t      *UDPv5
+	topic  TopicID
+	ctx    context.Context
+	cancel func()
+
+	lookup *lookup
+	queue  []*enode.Node     // nodes to query for advertisements
+	buffer []*enode.Node     // advertised nodes not yet returned by Next
+	seen   map[enode.ID]bool // advertised nodes already returned
+}
+
+// Node returns the current node.
+func (it *topicSearchIterator) Node() *enode.Node {
+	if len(it.buffer) == 0 {
+		return nil
+	}
+	return it.buffer[0]
+}
+
+// Next moves to the next node.
+func (it *topicSearchIterator) Next() bool {
+	// Consume next node in buffer.
+	if len(it.buffer) > 0 {
+		it.buffer = it.buffer[1:]
+	}
+	// Query the nodes found by lookups to refill the buffer.
+	for len(it.buffer) == 0 {
+		if it.ctx.Err() != nil {
+			it.lookup = nil
+			it.queue = nil
+			it.buffer = nil
+			return false
+		}
+		switch {
+		case len(it.queue) > 0:
+			n := it.queue[0]
+			it.queue = it.queue[1:]
+			it.query(n)
+		case it.lookup == nil:
+			it.lookup = it.t.newLookup(it.ctx, enode.ID(it.topic))
+		case it.lookup.advance():
+			it.queue = append(it.queue, unwrapNodes(it.lookup.replyBuffer)...)
+		default:
+			// The lookup is done. Wait a bit before starting the next one, it's
+			// likely to find the same nodes.
+			it.lookup = nil
+			select {
+			case <-it.ctx.Done():
+			case <-it.t.clock.After(topicSearchDelay):
+			}
+		}
+	}
+	return true
+}
+
+// query asks a node for advertisements and adds any new nodes to the buffer.
+func (it *topicSearchIterator) query(n *enode.Node) {
+	nodes, err := it.t.topicQuery(n, it.topic)
+	if err != nil {
+		it.t.log.Trace("TOPICQUERY failed", "id", n.ID(), "topic", it.topic, "err", err)
+	}
+	for _, adn := range nodes {
+		if !it.seen[adn.ID()] && adn.ID() != it.t.Self().ID() {
+			it.seen[adn.ID()] = true
+			it.buffer = append(it.buffer, adn)
+		}
+	}
+}
+
+// Close ends the iterator.
+func (it *topicSearchIterator) Close() {
+	it.cancel()
+}
+
+// topicTable stores the advertisements of other nodes, i.e. it is the registrar side
+// of topic advertisement.
+//
+// Nodes are admitted into a topic queue when it has a free slot. When the queue is
+// full, registrants receive a ticket for the next slot that isn't promised to other
+// ticket holders yet, along with the time to wait until that slot is free. Freed slots
+// are reserved for ticket holders, so registrants are admitted in the order they
+// arrived.
+//
+// The table is accessed by the UDPv5 dispatch loop only and isn't safe for concurrent
+// use.
+type topicTable struct {
+	clock  mclock.Clock
+	queues map[TopicID]*topicQueue
+	count  int         // number of advertisements across all queues
+	aead   cipher.AEAD // seals tickets
+}
+
+// topicQueue holds the advertisements of a single topic.
+type topicQueue struct {
+	ads     []*topicAd         // ordered by expiration time
+	tickets []topicReservation // outstanding tickets, ascending by due time
+}
+
+// topicReservation is a queue slot promised to the holder of a ticket.
+type topicReservation struct {
+	node enode.ID
+	due  mclock.AbsTime
+}
+
+// topicAd is an advertisement in a topic queue.
+type topicAd struct {
+	node    *enode.Node
+	expires mclock.AbsTime
+}
+
+// topicTicket is the content of a ticket. Tickets are opaque to registrants, they
+// are encrypted with a key only known to the issuing node.
+type topicTicket struct {
+	Node  enode.ID
+	IP    net.IP
+	Topic TopicID
+	Due   uint64 // mclock.AbsTime when the ticket can be used
+}
+
+func newTopicTable(clock mclock.Clock) *topicTable {
+	key := make([]byte, 16)
+	crand.Read(key)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic("can't create block cipher: " + err.Error())
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic("can't create GCM: " + err.Error())
+	}
+	return &topicTable{
+		clock:  clock,
+		queues: make(map[TopicID]*topicQueue),
+		aead:   aead,
+	}
+}
+
+// register attempts to admit n into the queue of the given topic. If the node can't
+// be admitted, it returns a ticket and the time to wait before using it. The ticket
+// is nil when the node was admitted.
+func (tt *topicTable) register(n *enode.Node, ip net.IP, topic TopicID, ticket []byte) ([]byte, time.Duration, error) {
+	tt.expire()
+
+	now := tt.clock.Now()
+	q := tt.queues[topic]
+	if q == nil {
+		q = new(topicQueue)
+		tt.queues[topic] = q
+	}
+	// Nodes already in the queue have to wait until their advertisement expires.
+	for _, ad := range q.ads {
+		if ad.node.ID() == n.ID() {
+			return tt.issueTicket(n.ID(), ip, topic, ad.expires), time.Duration(ad.expires - now), nil
+		}
+	}
+	// Check the ticket. Tickets presented too early are returned as is, and late
+	// tickets are treated like an initial registration attempt.
+	var hasTicket bool
+	if len(ticket) > 0 {
+		tk, err := tt.openTicket(ticket)
+		if err != nil {
+			return nil, 0, err
+		}
+		if tk.Node != n.ID() || tk.Topic != topic || !tk.IP.Equal(ip) {
+			return nil, 0, errInvalidTicket
+		}
+		due := mclock.AbsTime(tk.Due)
+		if now < due {
+			return ticket, time.Duration(due - now), nil
+		}
+		if now <= due+mclock.AbsTime(topicRegWindow) {
+			hasTicket = q.removeTicket(n.ID(), due)
+		}
+	}
+	// Nodes holding a reservation get their ticket re-issued, so that a single node
+	// can't reserve multiple slots by repeating its registration attempts.
+	if !hasTicket {
+		if due, ok := q.reservation(n.ID()); ok {
+			var wait time.Duration
+			if now < due {
+				wait = time.Duration(due - now)
+			}
+			return tt.issueTicket(n.ID(), ip, topic, due), wait, nil
+		}
+	}
+	// Admit the node if there is space. Ticket holders may use any free slot, others
+	// only the slots not promised to ticket holders.
+	free := topicQueueLimit - len(q.ads)
+	if !hasTicket {
+		free -= len(q.tickets)
+	}
+	if free > 0 && tt.count < topicTableLimit {
+		q.ads = append(q.ads, &topicAd{node: n, expires: now + mclock.AbsTime(topicAdLifetime)})
+		tt.count++
+		return nil, 0, nil
+	}
+	// Hand out a ticket for the next slot that isn't promised yet.
+	var due mclock.AbsTime
+	if free <= 0 {
+		if next := len(q.ads) + len(q.tickets) - topicQueueLimit; next < len(q.ads) {
+			due = q.ads[next].expires
+			q.tickets = append(q.tickets, topicReservation{node: n.ID(), due: due})
+		} else {
+			due = now + mclock.AbsTime(topicAdLifetime)
+		}
+	} else {
+		// The table is full, wait until any advertisement expires.
+		due = mclock.AbsTime(math.MaxInt64)
+		for _, other := range tt.queues {
+			if len(other.ads) > 0 && other.ads[0].expires < due {
+				due = other.ads[0].expires
+			}
+		}
+	}
+	return tt.issueTicket(n.ID(), ip, topic, due), time.Duration(due - now), nil
+}
+
+// nodes returns up to limit randomly chosen nodes advertising the given topic.
+func (tt *topicTable) nodes(topic TopicID, limit int) []*enode.Node {
+	tt.expire()
+
+	q := tt.queues[topic]
+	if q == nil {
+		return nil
+	}
+	nodes := make([]*enode.Node, len(q.ads))
+	for i, ad := range q.ads {
+		nodes[i] = ad.node
+	}
+	rand.Shuffle(len(nodes), func(i, j int) { nodes[i], nodes[j] = nodes[j], nodes[i] })
+	if len(nodes) > limit {
+		nodes = nodes[:limit]
+	}
+	return nodes
+}
+
+// expire removes expired advertisements and tickets.
+func (tt *topicTable) expire() {
+	now := tt.clock.Now()
+	for topic, q := range tt.queues {
+		n := 0
+		for n < len(q.ads) && q.ads[n].expires <= now {
+			n++
+		}
+		q.ads = append(q.ads[:0], q.ads[n:]...)
+		tt.count -= n
+
+		n = 0
+		for n < len(q.tickets) && q.tickets[n].due+mclock.AbsTime(topicRegWindow) < now {
+			n++
+		}
+		q.tickets = append(q.tickets[:0], q.tickets[n:]...)
+
+		if len(q.ads) == 0 && len(q.tickets) == 0 {
+			delete(tt.queues, topic)
+		}
+	}
+}
+
+// reservation returns the due time of the outstanding ticket of the given node.
+func (q *topicQueue) reservation(id enode.ID) (mclock.AbsTime, bool) {
+	for _, r := range q.tickets {
+		if r.node == id {
+			return r.due, true
+		}
+	}
+	return 0, false
+}
+
+// removeTicket removes the outstanding ticket of a node with the given due time. It
+// reports whether such a ticket was found.
+func (q *topicQueue) removeTicket(id enode.ID, due mclock.AbsTime) bool {
+	for i, r := range q.tickets {
+		if r.node == id && r.due == due {
+			q.tickets = append(q.tickets[:i], q.tickets[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// issueTicket creates a ticket which can be used after the given time.
+func (tt *topicTable) issueTicket(id enode.ID, ip net.IP, topic TopicID, due mclock.AbsTime) []byte {
+	enc, err := rlp.EncodeToBytes(&topicTicket{Node: id, IP: ip, Topic: topic, Due: uint64(due)})
+	if err != nil {
+		panic("can't encode ticket: " + err.Error())
+	}
+	nonce := make([]byte, tt.aead.NonceSize())
+	crand.Read(nonce)
+	return tt.aead.Seal(nonce, nonce, enc, nil)
+}
+
+// openTicket decrypts and decodes a ticket issued by the table.
+func (tt *topicTable) openTicket(ticket []byte) (*topicTicket, error) {
+	size := tt.aead.NonceSize()
+	if len(ticket) < size {
+		return nil, errInvalidTicket
+	}
+	enc, err := tt.aead.Open(nil, ticket[:size], ticket[size:], nil)
+	if err != nil {
+		return nil, errInvalidTicket
+	}
+	tk := new(topicTicket)
+	if err := rlp.DecodeBytes(enc, tk); err != nil {
+		return nil, errInvalidTicket
+	}
+	return tk, nil
+}
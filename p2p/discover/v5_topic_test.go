@@ -0,0 +1,240 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/p2p/discover/v5wire"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+func TestTopicTable_register(t *testing.T) {
+	var (
+		clock = new(mclock.Simulated)
+		tt    = newTopicTable(clock)
+		topic = NewTopicID("test")
+		ip    = net.IP{127, 0, 0, 1}
+		nodes = nodesAtDistance(enode.ID{}, 256, topicQueueLimit+2)
+	)
+	// Fill the queue, spreading out the expiration times.
+	for _, n := range nodes[:topicQueueLimit] {
+		if ticket, _, err := tt.register(n, ip, topic, nil); ticket != nil || err != nil {
+			t.Fatalf("node not registered: ticket %x, err %v", ticket, err)
+		}
+		clock.Run(time.Second)
+	}
+	if got := len(tt.nodes(topic, 2*topicQueueLimit)); got != topicQueueLimit {
+		t.Fatalf("wrong number of nodes in queue: %d", got)
+	}
+	// Registering again gives a ticket for the expiration of the existing ad.
+	_, wait, _ := tt.register(nodes[0], ip, topic, nil)
+	if want := topicAdLifetime - topicQueueLimit*time.Second; wait != want {
+		t.Fatalf("wrong wait time for registered node: %v, want %v", wait, want)
+	}
+	// The next nodes have to wait until the first and second ad expire.
+	ticketA, waitA, err := tt.register(nodes[topicQueueLimit], ip, topic, nil)
+	if ticketA == nil || err != nil {
+		t.Fatalf("no ticket for full queue: err %v", err)
+	}
+	ticketB, waitB, _ := tt.register(nodes[topicQueueLimit+1], ip, topic, nil)
+	if waitB != waitA+time.Second {
+		t.Fatalf("wrong wait time for second ticket: %v, want %v", waitB, waitA+time.Second)
+	}
+	// Repeated attempts without a ticket get the same ticket re-issued, instead of
+	// reserving further slots.
+	for i := 0; i < 3; i++ {
+		if _, wait, _ := tt.register(nodes[topicQueueLimit], ip, topic, nil); wait != waitA {
+			t.Fatalf("wrong wait time for repeated attempt: %v, want %v", wait, waitA)
+		}
+	}
+	if n := len(tt.queues[topic].tickets); n != 2 {
+		t.Fatalf("wrong number of reserved slots: %d, want 2", n)
+	}
+	// Tickets can't be used by other nodes.
+	if _, _, err := tt.register(nodes[topicQueueLimit+1], ip, topic, ticketA); err != errInvalidTicket {
+		t.Fatalf("wrong error for stolen ticket: %v", err)
+	}
+	// Tickets used too early are returned with the remaining wait time.
+	clock.Run(waitA / 2)
+	ticket, wait, _ := tt.register(nodes[topicQueueLimit], ip, topic, ticketA)
+	if !bytes.Equal(ticket, ticketA) || wait != waitA-waitA/2 {
+		t.Fatalf("wrong early ticket response: wait %v", wait)
+	}
+	// When the first ad expires, the slot is reserved for the ticket holder.
+	clock.Run(waitA - waitA/2)
+	if ticket, _, _ := tt.register(nodes[0], ip, topic, nil); ticket == nil {
+		t.Fatal("node without ticket registered in reserved slot")
+	}
+	if ticket, _, err := tt.register(nodes[topicQueueLimit], ip, topic, ticketA); ticket != nil || err != nil {
+		t.Fatalf("ticket holder not registered: err %v", err)
+	}
+	// Slots reserved for tickets which aren't used in time are released, and late
+	// tickets are treated like initial registration attempts.
+	clock.Run(2*time.Second + topicRegWindow + time.Second)
+	tt.expire()
+	if n := len(tt.queues[topic].tickets); n != 0 {
+		t.Fatalf("%d reserved slots after ticket expiration", n)
+	}
+	if ticket, _, err := tt.register(nodes[topicQueueLimit+1], ip, topic, ticketB); ticket != nil || err != nil {
+		t.Fatalf("node with late ticket not registered: err %v", err)
+	}
+	// All ads expire eventually.
+	clock.Run(topicAdLifetime)
+	if got := len(tt.nodes(topic, topicQueueLimit)); got != 0 {
+		t.Fatalf("%d nodes in queue after expiration", got)
+	}
+	if tt.count != 0 || len(tt.queues) != 0 {
+		t.Fatalf("table not empty after expiration: %d ads, %d queues", tt.count, len(tt.queues))
+	}
+}
+
+// This test checks that incoming REGTOPIC and TOPICQUERY calls are handled correctly.
+func TestUDPv5_topicHandling(t *testing.T) {
+	t.Parallel()
+	test := newUDPV5Test(t)
+	defer test.close()
+
+	var (
+		topic  = NewTopicID("test")
+		remote = test.getNode(test.remotekey, test.remoteaddr).Node()
+	)
+	// No nodes are advertised initially.
+	test.packetIn(&v5wire.TopicQuery{ReqID: []byte{0}, Topic: topic})
+	test.expectNodes([]byte{0}, 1, nil)
+
+	// Registration with a record that isn't the sender's fails.
+	other := test.getNode(newkey(), &net.UDPAddr{IP: net.IP{10, 0, 1, 100}, Port: 30303}).Node()
+	test.packetIn(&v5wire.Regtopic{ReqID: []byte{1}, Topic: topic, ENR: other.Record()})
+
+	// Registration with the sender's record succeeds.
+	test.packetIn(&v5wire.Regtopic{ReqID: []byte{2}, Topic: topic, ENR: remote.Record()})
+	test.waitPacketOut(func(p *v5wire.Regconfirmation, addr *net.UDPAddr, _ v5wire.Nonce) {
+		if !bytes.Equal(p.ReqID, []byte{2}) {
+			t.Error("wrong request ID in response:", p.ReqID)
+		}
+		if p.Topic != topic {
+			t.Errorf("wrong topic %x in response", p.Topic)
+		}
+	})
+	test.packetIn(&v5wire.TopicQuery{ReqID: []byte{3}, Topic: topic})
+	test.expectNodes([]byte{3}, 1, []*enode.Node{remote})
+
+	// Registering again yields a ticket.
+	test.packetIn(&v5wire.Regtopic{ReqID: []byte{4}, Topic: topic, ENR: remote.Record()})
+	test.waitPacketOut(func(p *v5wire.Ticket, addr *net.UDPAddr, _ v5wire.Nonce) {
+		if len(p.Ticket) == 0 {
+			t.Error("empty ticket in response")
+		}
+		if p.WaitTime != uint(topicAdLifetime/time.Second) {
+			t.Errorf("wrong wait time %d in response", p.WaitTime)
+		}
+	})
+}
+
+// This test checks that outgoing REGTOPIC calls work.
+func TestUDPv5_regtopicCall(t *testing.T) {
+	t.Parallel()
+	test := newUDPV5Test(t)
+	defer test.close()
+
+	var (
+		topic  = NewTopicID("test")
+		remote = test.getNode(test.remotekey, test.remoteaddr).Node()
+		done   = make(chan error, 1)
+		ticket []byte
+		wait   time.Duration
+	)
+	// The first registration attempt gets a ticket.
+	go func() {
+		var err error
+		ticket, wait, err = test.udp.regtopic(remote, topic, nil)
+		done <- err
+	}()
+	test.waitPacketOut(func(p *v5wire.Regtopic, addr *net.UDPAddr, _ v5wire.Nonce) {
+		if p.Topic != topic {
+			t.Errorf("wrong topic %x in request", p.Topic)
+		}
+		if len(p.Ticket) != 0 {
+			t.Errorf("non-empty ticket in initial request")
+		}
+		test.packetIn(&v5wire.Ticket{ReqID: p.ReqID, Ticket: []byte("ticket"), WaitTime: 10})
+	})
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if string(ticket) != "ticket" || wait != 10*time.Second {
+		t.Fatalf("wrong ticket %q or wait time %v", ticket, wait)
+	}
+	// The second attempt presents the ticket and succeeds.
+	go func() {
+		var err error
+		ticket, _, err = test.udp.regtopic(remote, topic, ticket)
+		done <- err
+	}()
+	test.waitPacketOut(func(p *v5wire.Regtopic, addr *net.UDPAddr, _ v5wire.Nonce) {
+		if string(p.Ticket) != "ticket" {
+			t.Errorf("wrong ticket %q in request", p.Ticket)
+		}
+		test.packetIn(&v5wire.Regconfirmation{ReqID: p.ReqID, Topic: topic})
+	})
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if ticket != nil {
+		t.Fatalf("ticket %q returned for successful registration", ticket)
+	}
+}
+
+// Real sockets, real crypto: this test checks that a node can find another node
+// advertising a topic.
+func TestUDPv5_topicE2E(t *testing.T) {
+	t.Parallel()
+
+	const N = 5
+	var nodes []*UDPv5
+	for i := 0; i < N; i++ {
+		var cfg Config
+		if len(nodes) > 0 {
+			bn := nodes[0].Self()
+			cfg.Bootnodes = []*enode.Node{bn}
+		}
+		node := startLocalhostV5(t, cfg)
+		nodes = append(nodes, node)
+		defer node.Close()
+	}
+	topic := NewTopicID("test")
+	nodes[1].RegisterTopic(topic)
+	defer nodes[1].StopRegisterTopic(topic)
+
+	it := nodes[N-1].TopicSearch(topic)
+	defer it.Close()
+	go func() {
+		<-time.After(20 * time.Second)
+		it.Close()
+	}()
+	if !it.Next() {
+		t.Fatal("topic search ended without results")
+	}
+	if it.Node().ID() != nodes[1].Self().ID() {
+		t.Fatalf("wrong node found: %v", it.Node())
+	}
+}
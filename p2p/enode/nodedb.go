@@ -21,6 +21,7 @@ import (
 	"crypto/rand"
 	"encoding/binary"
 	"fmt"
+	"math"
 	"net"
 	"os"
 	"sync"
@@ -40,6 +41,7 @@ const (
 	dbVersionKey   = "version" // Version of the database to flush if changes
 	dbNodePrefix   = "n:"      // Identifier to prefix node entries with
 	dbLocalPrefix  = "local:"
+	dbScorePrefix  = "score:" // Reputation scores are keyed "score:<ID>"
 	dbDiscoverRoot = "v4"
 	dbDiscv5Root   = "v5"
 
@@ -315,6 +317,7 @@ func (db *DB) expirer() {
 		select {
 		case <-tick.C:
 			db.expireNodes()
+			db.expireScores()
 		case <-db.quit:
 			return
 		}
@@ -427,6 +430,78 @@ func (db *DB) UpdateFindFailsV5(id ID, ip net.IP, fails int) error {
 	return db.storeInt64(v5Key(id, ip, dbNodeFindFails), int64(fails))
 }
 
+// NodeScore is the reputation score of a node, as last stored in the database.
+type NodeScore struct {
+	Value   float64
+	Updated time.Time
+}
+
+// scoreKey returns the database key of a node's reputation score.
+func scoreKey(id ID) []byte {
+	return append([]byte(dbScorePrefix), id[:]...)
+}
+
+// decodeScore decodes a score entry. Scores are stored as the IEEE 754 bits of the
+// value followed by the unix time of the last update, both big endian.
+func decodeScore(blob []byte) (NodeScore, bool) {
+	if len(blob) != 16 {
+		return NodeScore{}, false
+	}
+	return NodeScore{
+		Value:   math.Float64frombits(binary.BigEndian.Uint64(blob[:8])),
+		Updated: time.Unix(int64(binary.BigEndian.Uint64(blob[8:])), 0),
+	}, true
+}
+
+// Score retrieves the reputation score of a node. The zero score is returned for
+// unknown nodes.
+func (db *DB) Score(id ID) NodeScore {
+	blob, err := db.lvl.Get(scoreKey(id), nil)
+	if err != nil {
+		return NodeScore{}
+	}
+	score, _ := decodeScore(blob)
+	return score
+}
+
+// UpdateScore stores the reputation score of a node.
+func (db *DB) UpdateScore(id ID, score NodeScore) error {
+	blob := make([]byte, 16)
+	binary.BigEndian.PutUint64(blob[:8], math.Float64bits(score.Value))
+	binary.BigEndian.PutUint64(blob[8:], uint64(score.Updated.Unix()))
+	return db.lvl.Put(scoreKey(id), blob, nil)
+}
+
+// Scores retrieves the reputation scores of all nodes in the database.
+func (db *DB) Scores() map[ID]NodeScore {
+	it := db.lvl.NewIterator(util.BytesPrefix([]byte(dbScorePrefix)), nil)
+	defer it.Release()
+
+	scores := make(map[ID]NodeScore)
+	for it.Next() {
+		var id ID
+		if len(it.Key()) != len(dbScorePrefix)+len(id) {
+			continue
+		}
+		copy(id[:], it.Key()[len(dbScorePrefix):])
+		if score, ok := decodeScore(it.Value()); ok {
+			scores[id] = score
+		}
+	}
+	return scores
+}
+
+// expireScores deletes all reputation scores which have not been updated for
+// some time.
+func (db *DB) expireScores() {
+	threshold := time.Now().Add(-dbNodeExpiration)
+	for id, score := range db.Scores() {
+		if score.Updated.Before(threshold) {
+			db.lvl.Delete(scoreKey(id), nil)
+		}
+	}
+}
+
 // LocalSeq retrieves the local record sequence counter.
 func (db *DB) localSeq(id ID) uint64 {
 	return db.fetchUint64(localItemKey(id, dbLocalSeq))
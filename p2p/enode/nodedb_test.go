@@ -473,3 +473,33 @@ func TestDBExpireV5(t *testing.T) {
 	db.UpdateFindFailsV5(ID{}, ip, 4)
 	db.expireNodes()
 }
+
+// This test checks that reputation scores are stored and expired.
+func TestDBScores(t *testing.T) {
+	db, _ := OpenDB("")
+	defer db.Close()
+
+	var (
+		now   = time.Now().Truncate(time.Second)
+		fresh = NodeScore{Value: -12.5, Updated: now}
+		stale = NodeScore{Value: 40, Updated: now.Add(-dbNodeExpiration - time.Hour)}
+	)
+	if score := db.Score(ID{1}); score.Value != 0 {
+		t.Fatalf("non-zero score %v for unknown node", score.Value)
+	}
+	db.UpdateScore(ID{1}, fresh)
+	db.UpdateScore(ID{2}, stale)
+	if score := db.Score(ID{1}); score != fresh {
+		t.Fatalf("wrong score %v, want %v", score, fresh)
+	}
+	if scores := db.Scores(); len(scores) != 2 || scores[ID{2}] != stale {
+		t.Fatalf("wrong scores %v", scores)
+	}
+	// Scores must not confuse node expiration.
+	db.expireNodes()
+
+	db.expireScores()
+	if scores := db.Scores(); len(scores) != 1 || scores[ID{1}] != fresh {
+		t.Fatalf("wrong scores after expiration: %v", scores)
+	}
+}
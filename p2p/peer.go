@@ -116,6 +116,13 @@ type Peer struct {
 
 	// events receives message send / receive events if set
 	events *event.Feed
+
+	// reputation receives behavior reports if set
+	reputation *reputation
+
+	// evicted is set when the server disconnects the peer because of its score.
+	// It is accessed by the server's run loop only.
+	evicted bool
 }
 
 // NewPeer returns a peer for testing purposes.
@@ -191,6 +198,14 @@ func (p *Peer) Disconnect(reason DiscReason) {
 	}
 }
 
+// Report records behavior of the peer in its reputation score. Scores are used to
+// select peers when dialing and when the peer limit is reached.
+func (p *Peer) Report(b PeerBehavior) {
+	if p.reputation != nil {
+		p.reputation.report(p.ID(), b)
+	}
+}
+
 // String implements fmt.Stringer.
 func (p *Peer) String() string {
 	id := p.ID()
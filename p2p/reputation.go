@@ -0,0 +1,159 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+const (
+	// Scores decay exponentially towards zero, halving every scoreHalfLife.
+	scoreHalfLife = time.Hour
+
+	// Scores are clamped to this range. The upper bound is low so that a peer
+	// which served us well for a long time can still lose its good standing.
+	maxScore = 100
+	minScore = -1000
+
+	// Discovered nodes scoring below dialScoreThreshold are not dialed.
+	dialScoreThreshold = -50
+
+	// When the peer limit is reached, connected peers scoring below
+	// evictScoreThreshold may be disconnected to make room for better ones.
+	evictScoreThreshold = -20
+)
+
+// PeerBehavior is an event reported by a protocol about a remote peer, via
+// Peer.Report.
+type PeerBehavior uint8
+
+const (
+	// PeerUselessResponse means the peer answered a request without providing
+	// the requested data.
+	PeerUselessResponse PeerBehavior = iota
+
+	// PeerTimeout means the peer did not answer a request in time.
+	PeerTimeout
+
+	// PeerInvalidBlock means the peer sent a block or header that failed validation.
+	PeerInvalidBlock
+
+	// PeerDeliveredData means the peer answered a request with useful data.
+	PeerDeliveredData
+)
+
+var peerBehaviorToString = map[PeerBehavior]string{
+	PeerUselessResponse: "useless response",
+	PeerTimeout:         "timeout",
+	PeerInvalidBlock:    "invalid block",
+	PeerDeliveredData:   "delivered data",
+}
+
+func (b PeerBehavior) String() string {
+	if str, ok := peerBehaviorToString[b]; ok {
+		return str
+	}
+	return fmt.Sprintf("unknown behavior %d", b)
+}
+
+// weight returns the score adjustment of the behavior.
+func (b PeerBehavior) weight() float64 {
+	switch b {
+	case PeerUselessResponse:
+		return -5
+	case PeerTimeout:
+		return -10
+	case PeerInvalidBlock:
+		return -100
+	case PeerDeliveredData:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// PeerScoreInfo represents the reputation of a node.
+type PeerScoreInfo struct {
+	ID      string    `json:"id"`      // Unique node identifier
+	Score   float64   `json:"score"`   // Current score, after decay
+	Updated time.Time `json:"updated"` // Time of the last report
+}
+
+// reputation tracks peer scores. The scores are stored in the node database so
+// they persist across restarts.
+type reputation struct {
+	db  *enode.DB
+	log log.Logger
+	now func() time.Time // for testing
+
+	mu sync.Mutex // serializes score updates
+}
+
+func newReputation(db *enode.DB, log log.Logger) *reputation {
+	return &reputation{db: db, log: log, now: time.Now}
+}
+
+// decay returns the value of s at the given time.
+func decay(s enode.NodeScore, now time.Time) float64 {
+	elapsed := now.Sub(s.Updated)
+	if elapsed <= 0 {
+		return s.Value
+	}
+	return s.Value * math.Exp2(-float64(elapsed)/float64(scoreHalfLife))
+}
+
+// score returns the current score of a node.
+func (r *reputation) score(id enode.ID) float64 {
+	return decay(r.db.Score(id), r.now())
+}
+
+// report applies the weight of a behavior to the score of a node.
+func (r *reputation) report(id enode.ID, b PeerBehavior) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	score := decay(r.db.Score(id), now) + b.weight()
+	score = math.Max(minScore, math.Min(maxScore, score))
+	if err := r.db.UpdateScore(id, enode.NodeScore{Value: score, Updated: now}); err != nil {
+		r.log.Debug("Failed to store peer score", "id", id, "err", err)
+		return
+	}
+	r.log.Trace("Updated peer score", "id", id, "behavior", b, "score", score)
+}
+
+// scores returns the current scores of all known nodes, lowest first.
+func (r *reputation) scores() []*PeerScoreInfo {
+	var (
+		now   = r.now()
+		infos []*PeerScoreInfo
+	)
+	for id, s := range r.db.Scores() {
+		infos = append(infos, &PeerScoreInfo{ID: id.String(), Score: decay(s, now), Updated: s.Updated})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Score < infos[j].Score
+	})
+	return infos
+}
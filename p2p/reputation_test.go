@@ -0,0 +1,74 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/internal/testlog"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+func TestReputation(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	var (
+		now  = time.Unix(1600000000, 0)
+		rep  = newReputation(db, testlog.Logger(t, log.LvlTrace))
+		good = enode.ID{1}
+		bad  = enode.ID{2}
+	)
+	rep.now = func() time.Time { return now }
+
+	// Reports accumulate, within bounds.
+	for i := 0; i < 2*maxScore; i++ {
+		rep.report(good, PeerDeliveredData)
+	}
+	rep.report(bad, PeerTimeout)
+	rep.report(bad, PeerInvalidBlock)
+	if score := rep.score(good); score != maxScore {
+		t.Fatalf("wrong score %v for good node, want %v", score, maxScore)
+	}
+	if score := rep.score(bad); score != -110 {
+		t.Fatalf("wrong score %v for bad node, want -110", score)
+	}
+	// Scores decay over time.
+	now = now.Add(scoreHalfLife)
+	if score := rep.score(bad); score != -55 {
+		t.Fatalf("wrong score %v after decay, want -55", score)
+	}
+	rep.report(bad, PeerDeliveredData)
+	if score := rep.score(bad); score != -54 {
+		t.Fatalf("wrong score %v after report, want -54", score)
+	}
+	// Scores persist in the database.
+	rep = newReputation(db, rep.log)
+	rep.now = func() time.Time { return now }
+	infos := rep.scores()
+	if len(infos) != 2 {
+		t.Fatalf("wrong number of scores: %d", len(infos))
+	}
+	if infos[0].ID != bad.String() || infos[0].Score != -54 {
+		t.Errorf("wrong first score: %+v", infos[0])
+	}
+	if infos[1].ID != good.String() || infos[1].Score != maxScore/2 {
+		t.Errorf("wrong second score: %+v", infos[1])
+	}
+}
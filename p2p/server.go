@@ -23,6 +23,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"math"
 	"net"
 	"sort"
 	"sync"
@@ -178,12 +179,13 @@ type Server struct {
 	peerFeed     event.Feed
 	log          log.Logger
 
-	nodedb    *enode.DB
-	localnode *enode.LocalNode
-	ntab      *discover.UDPv4
-	DiscV5    *discover.UDPv5
-	discmix   *enode.FairMix
-	dialsched *dialScheduler
+	nodedb     *enode.DB
+	reputation *reputation
+	localnode  *enode.LocalNode
+	ntab       *discover.UDPv4
+	DiscV5     *discover.UDPv5
+	discmix    *enode.FairMix
+	dialsched  *dialScheduler
 
 	// Channels into the run loop.
 	quit                    chan struct{}
@@ -503,6 +505,7 @@ func (srv *Server) setupLocalNode() error {
 		return err
 	}
 	srv.nodedb = db
+	srv.reputation = newReputation(db, srv.log)
 	srv.localnode = enode.NewLocalNode(db, srv.PrivateKey)
 	srv.localnode.SetFallbackIP(net.IP{127, 0, 0, 1})
 	// TODO: check conflicts
@@ -623,6 +626,7 @@ func (srv *Server) setupDialScheduler() {
 		netRestrict:    srv.NetRestrict,
 		dialer:         srv.Dialer,
 		clock:          srv.clock,
+		scorer:         srv.reputation,
 	}
 	if srv.ntab != nil {
 		config.resolver = srv.ntab
@@ -747,7 +751,13 @@ running:
 				c.flags |= trustedConn
 			}
 			// TODO: track in-progress inbound node IDs (pre-Peer) to avoid dialing them.
-			c.cont <- srv.postHandshakeChecks(peers, inboundCount, c)
+			err := srv.postHandshakeChecks(peers, inboundCount, c)
+			if err == DiscTooManyPeers && !c.is(trustedConn) {
+				// Make room for a better peer. The connection is still rejected,
+				// see evictPeer.
+				srv.evictPeer(peers, inboundCount, c)
+			}
+			c.cont <- err
 
 		case c := <-srv.checkpointAddPeer:
 			// At this point the connection is past the protocol handshake.
@@ -815,6 +825,35 @@ func (srv *Server) postHandshakeChecks(peers map[enode.ID]*Peer, inboundCount in
 	}
 }
 
+// evictPeer disconnects the lowest-scored peer if its score is bad enough for it to be
+// replaced by c, which was rejected because the peer limit is reached. Trusted peers
+// and static dials are never evicted.
+//
+// Note that c itself is still rejected. The evicted peer only releases its slot once
+// it has shut down, and admitting c before that would exceed the peer limit. The
+// freed slot therefore goes to whichever connection passes the checks next, which
+// may be c again if it redials, or any other node.
+func (srv *Server) evictPeer(peers map[enode.ID]*Peer, inboundCount int, c *conn) {
+	var (
+		worst       *Peer
+		worstScore  = math.Min(evictScoreThreshold, srv.reputation.score(c.node.ID()))
+		inboundOnly = c.is(inboundConn) && inboundCount >= srv.maxInboundConns() && len(peers) < srv.MaxPeers
+	)
+	for _, p := range peers {
+		if p.evicted || p.rw.is(trustedConn|staticDialedConn) || (inboundOnly && !p.Inbound()) {
+			continue
+		}
+		if score := srv.reputation.score(p.ID()); score < worstScore {
+			worst, worstScore = p, score
+		}
+	}
+	if worst != nil {
+		worst.log.Debug("Evicting low-scored peer", "score", worstScore)
+		worst.evicted = true
+		worst.Disconnect(DiscUselessPeer)
+	}
+}
+
 func (srv *Server) addPeerChecks(peers map[enode.ID]*Peer, inboundCount int, c *conn) error {
 	// Drop connections with no matching protocols.
 	if len(srv.Protocols) > 0 && countMatchingProtocols(srv.Protocols, c.caps) == 0 {
@@ -1014,6 +1053,7 @@ func (srv *Server) checkpoint(c *conn, stage chan<- *conn) error {
 
 func (srv *Server) launchPeer(c *conn) *Peer {
 	p := newPeer(srv.log, c, srv.Protocols)
+	p.reputation = srv.reputation
 	if srv.EnableMsgEvents {
 		// If message events are enabled, pass the peerFeed
 		// to the peer.
@@ -1119,3 +1159,13 @@ func (srv *Server) PeersInfo() []*PeerInfo {
 	}
 	return infos
 }
+
+// PeerScores returns the reputation scores of all known nodes, lowest first.
+func (srv *Server) PeerScores() []*PeerScoreInfo {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	if !srv.running {
+		return nil
+	}
+	return srv.reputation.scores()
+}
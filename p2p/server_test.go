@@ -300,6 +300,69 @@ func TestServerAtCap(t *testing.T) {
 	}
 }
 
+// This test checks that low-scored peers are evicted when the peer limit is reached.
+func TestServerEvictLowScore(t *testing.T) {
+	remoteKey := newkey()
+	srv := &Server{
+		Config: Config{
+			PrivateKey:  newkey(),
+			MaxPeers:    3,
+			NoDial:      true,
+			NoDiscovery: true,
+			Logger:      testlog.Logger(t, log.LvlTrace),
+		},
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start: %v", err)
+	}
+	defer srv.Stop()
+
+	newconn := func(id enode.ID) *conn {
+		fd, _ := net.Pipe()
+		tx := newTestTransport(&remoteKey.PublicKey, fd, nil)
+		node := enode.SignNull(new(enr.Record), id)
+		return &conn{fd: fd, transport: tx, flags: inboundConn, node: node, cont: make(chan error)}
+	}
+
+	// Fill up the peer set.
+	ids := []enode.ID{randomID(), randomID(), randomID()}
+	for i, id := range ids {
+		if err := srv.checkpoint(newconn(id), srv.checkpointAddPeer); err != nil {
+			t.Fatalf("could not add conn %d: %v", i, err)
+		}
+	}
+	// A slightly bad peer isn't evicted.
+	srv.reputation.report(ids[0], PeerUselessResponse)
+	if err := srv.checkpoint(newconn(randomID()), srv.checkpointPostHandshake); err != DiscTooManyPeers {
+		t.Fatal("wrong error for insert:", err)
+	}
+	if srv.PeerCount() != 3 {
+		t.Fatal("peer evicted with score", srv.reputation.score(ids[0]))
+	}
+	// A peer which sent an invalid block is evicted, but the connection
+	// is still rejected.
+	srv.reputation.report(ids[1], PeerInvalidBlock)
+	if err := srv.checkpoint(newconn(randomID()), srv.checkpointPostHandshake); err != DiscTooManyPeers {
+		t.Fatal("wrong error for insert:", err)
+	}
+	for start := time.Now(); srv.PeerCount() != 2; {
+		if time.Since(start) > time.Second {
+			t.Fatal("low-scored peer not evicted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, p := range srv.Peers() {
+		if p.ID() == ids[1] {
+			t.Fatal("wrong peer evicted")
+		}
+	}
+	// The scores are available.
+	scores := srv.PeerScores()
+	if len(scores) != 2 || scores[0].ID != ids[1].String() {
+		t.Fatalf("wrong scores: %+v", scores)
+	}
+}
+
 func TestServerPeerLimits(t *testing.T) {
 	srvkey := newkey()
 	clientkey := newkey()
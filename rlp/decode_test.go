@@ -255,6 +255,90 @@ func TestStreamList(t *testing.T) {
 	}
 }
 
+func TestStreamTypedDecoding(t *testing.T) {
+	s := NewStream(bytes.NewReader(unhex("DB7F81808201008401000000830102038901000000000000000002C0")), 0)
+	if _, err := s.List(); err != nil {
+		t.Fatalf("List error: %v", err)
+	}
+	if v, err := s.Uint8(); err != nil || v != 0x7f {
+		t.Errorf("Uint8 mismatch: have %d (%v), want 127", v, err)
+	}
+	if v, err := s.Uint8(); err != nil || v != 0x80 {
+		t.Errorf("Uint8 mismatch: have %d (%v), want 128", v, err)
+	}
+	if _, err := s.Uint8(); err != errUintOverflow {
+		t.Errorf("Uint8 error mismatch: have %v, want %v", err, errUintOverflow)
+	}
+	if v, err := s.Uint16(); err != nil || v != 0x100 {
+		t.Errorf("Uint16 mismatch: have %d (%v), want 256", v, err)
+	}
+	if v, err := s.Uint32(); err != nil || v != 0x01000000 {
+		t.Errorf("Uint32 mismatch: have %#x (%v), want 0x01000000", v, err)
+	}
+	var arr [3]byte
+	if err := s.ReadBytes(arr[:]); err != nil || arr != [3]byte{1, 2, 3} {
+		t.Errorf("ReadBytes mismatch: have %x (%v), want 010203", arr, err)
+	}
+	if !s.MoreDataInList() {
+		t.Errorf("MoreDataInList returned false before the end of the list")
+	}
+	if v, err := s.BigInt(); err != nil || v.Cmp(new(big.Int).Lsh(big.NewInt(1), 64)) != 0 {
+		t.Errorf("BigInt mismatch: have %v (%v), want 2^64", v, err)
+	}
+	if v, err := s.Uint64(); err != nil || v != 2 {
+		t.Errorf("Uint64 mismatch: have %d (%v), want 2", v, err)
+	}
+	// A value peeked by Kind is still pending
+	if _, _, err := s.Kind(); err != nil {
+		t.Fatalf("Kind error: %v", err)
+	}
+	if !s.MoreDataInList() {
+		t.Errorf("MoreDataInList returned false with a pending value")
+	}
+	if _, err := s.List(); err != nil {
+		t.Fatalf("List error: %v", err)
+	}
+	if s.MoreDataInList() {
+		t.Errorf("MoreDataInList returned true in an empty list")
+	}
+	if err := s.ListEnd(); err != nil {
+		t.Fatalf("ListEnd error: %v", err)
+	}
+	if s.MoreDataInList() {
+		t.Errorf("MoreDataInList returned true at the end of the list")
+	}
+	if err := s.ListEnd(); err != nil {
+		t.Fatalf("ListEnd error: %v", err)
+	}
+	if s.MoreDataInList() {
+		t.Errorf("MoreDataInList returned true outside of a list")
+	}
+}
+
+func TestStreamReadBytesErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		size  int
+		err   string
+	}{
+		{"820102", 3, "rlp: input value has wrong size 2, want 3"},
+		{"01", 2, "rlp: input value has wrong size 1, want 2"},
+		{"8101", 1, ErrCanonSize.Error()},
+		{"C0", 0, ErrExpectedString.Error()},
+	}
+	for i, tt := range tests {
+		s := NewStream(bytes.NewReader(unhex(tt.input)), 0)
+		err := s.ReadBytes(make([]byte, tt.size))
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %s", i, err, tt.err)
+		}
+	}
+	s := NewStream(bytes.NewReader(unhex("820001")), 0)
+	if _, err := s.BigInt(); err != ErrCanonInt {
+		t.Errorf("BigInt error mismatch: have %v, want %v", err, ErrCanonInt)
+	}
+}
+
 func TestStreamRaw(t *testing.T) {
 	tests := []struct {
 		input  string
@@ -454,6 +538,8 @@ var decodeTests = []decodeTest{
 	// byte arrays
 	{input: "02", ptr: new([1]byte), value: [1]byte{2}},
 	{input: "8180", ptr: new([1]byte), value: [1]byte{128}},
+	{input: "00", ptr: new([1]byte), value: [1]byte{0}},
+	{input: "C20001", ptr: new([2][1]byte), value: [2][1]byte{{0}, {1}}},
 	{input: "850102030405", ptr: new([5]byte), value: [5]byte{1, 2, 3, 4, 5}},
 
 	// byte array errors
@@ -0,0 +1,130 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rlp
+
+import (
+	"io"
+	"math/big"
+)
+
+// EncoderBuffer is a buffer for incremental encoding. It is used by the
+// EncodeRLP methods generated by rlpgen, but can be used by hand-written
+// encoders as well.
+//
+// The zero value is not ready for use, create buffers with NewEncoderBuffer.
+type EncoderBuffer struct {
+	buf       *encbuf
+	dst       io.Writer
+	ownBuffer bool
+}
+
+// NewEncoderBuffer creates an encoder buffer writing to dst. If dst is the writer
+// passed to an EncodeRLP method by this package, the encoded data is appended to
+// the outer encoding directly.
+func NewEncoderBuffer(dst io.Writer) EncoderBuffer {
+	var w EncoderBuffer
+	if outer := encbufFromWriter(dst); outer != nil {
+		w.buf = outer
+	} else {
+		w.buf = encbufPool.Get().(*encbuf)
+		w.buf.reset()
+		w.dst = dst
+		w.ownBuffer = true
+	}
+	return w
+}
+
+// Flush writes the encoded data to the destination writer and releases the
+// buffer. The buffer must not be used after calling Flush.
+func (w *EncoderBuffer) Flush() error {
+	var err error
+	if w.ownBuffer {
+		if w.dst != nil {
+			err = w.buf.toWriter(w.dst)
+		}
+		encbufPool.Put(w.buf)
+	}
+	*w = EncoderBuffer{}
+	return err
+}
+
+// Write appends b directly to the encoder output.
+func (w EncoderBuffer) Write(b []byte) (int, error) {
+	return w.buf.Write(b)
+}
+
+// WriteBool writes b as the integer 0 (false) or 1 (true).
+func (w EncoderBuffer) WriteBool(b bool) {
+	if b {
+		w.buf.str = append(w.buf.str, 0x01)
+	} else {
+		w.buf.str = append(w.buf.str, 0x80)
+	}
+}
+
+// WriteUint64 encodes an unsigned integer.
+func (w EncoderBuffer) WriteUint64(i uint64) {
+	w.buf.encodeUint(i)
+}
+
+// WriteBigInt encodes a big.Int as an RLP string. Note: unlike with Encode, the
+// sign of i is ignored.
+func (w EncoderBuffer) WriteBigInt(i *big.Int) {
+	w.buf.encodeBigInt(i)
+}
+
+// WriteBytes encodes b as an RLP string.
+func (w EncoderBuffer) WriteBytes(b []byte) {
+	w.buf.encodeString(b)
+}
+
+// WriteString encodes s as an RLP string.
+func (w EncoderBuffer) WriteString(s string) {
+	if len(s) == 1 && s[0] <= 0x7f {
+		// fits single byte, no string header
+		w.buf.str = append(w.buf.str, s[0])
+	} else {
+		w.buf.encodeStringHeader(len(s))
+		w.buf.str = append(w.buf.str, s...)
+	}
+}
+
+// List starts a list. It returns an internal index. Call ListEnd with this
+// index after encoding the content of the list.
+func (w EncoderBuffer) List() int {
+	return w.buf.list()
+}
+
+// ListEnd finishes the given list.
+func (w EncoderBuffer) ListEnd(index int) {
+	w.buf.listEnd(index)
+}
+
+// encbufFromWriter returns the encoding buffer underlying w, if w was created
+// by this package.
+func encbufFromWriter(w io.Writer) *encbuf {
+	switch w := w.(type) {
+	case *encbuf:
+		return w
+	case EncoderBuffer:
+		return w.buf
+	case *EncoderBuffer:
+		return w.buf
+	default:
+		return nil
+	}
+}
@@ -17,6 +17,7 @@
 package rlp
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -31,6 +32,8 @@ var (
 	EmptyList   = []byte{0xC0}
 )
 
+var ErrNegativeBigInt = errors.New("rlp: cannot encode negative *big.Int")
+
 // Encoder is implemented by types that require custom
 // encoding rules or want to encode private fields.
 type Encoder interface {
@@ -51,7 +54,7 @@ type Encoder interface {
 //
 // Please see package-level documentation of encoding rules.
 func Encode(w io.Writer, val interface{}) error {
-	if outer, ok := w.(*encbuf); ok {
+	if outer := encbufFromWriter(w); outer != nil {
 		// Encode was called by some type's EncodeRLP.
 		// Avoid copying by writing to the outer encbuf directly.
 		return outer.encode(val)
@@ -399,12 +402,18 @@ const wordBytes = (32 << (uint64(^big.Word(0)) >> 63)) / 8
 
 func writeBigInt(i *big.Int, w *encbuf) error {
 	if i.Sign() == -1 {
-		return fmt.Errorf("rlp: cannot encode negative *big.Int")
+		return ErrNegativeBigInt
 	}
+	w.encodeBigInt(i)
+	return nil
+}
+
+// encodeBigInt encodes a non-negative integer.
+func (w *encbuf) encodeBigInt(i *big.Int) {
 	bitlen := i.BitLen()
 	if bitlen <= 64 {
 		w.encodeUint(i.Uint64())
-		return nil
+		return
 	}
 	// Integer is larger than 64 bits, encode from i.Bits().
 	// The minimal byte length is bitlen rounded up to the next
@@ -421,7 +430,6 @@ func writeBigInt(i *big.Int, w *encbuf) error {
 			d >>= 8
 		}
 	}
-	return nil
 }
 
 func writeBytes(val reflect.Value, w *encbuf) error {
@@ -426,6 +426,81 @@ func TestEncodeToReaderPiecewise(t *testing.T) {
 	})
 }
 
+// bufferEncoder encodes itself using EncoderBuffer, mirroring the
+// encoding of the equivalent struct with reflection.
+type bufferEncoder struct {
+	A uint64
+	B *big.Int
+	C []byte
+	D string
+	E bool
+	F []*bufferEncoder
+}
+
+func (e *bufferEncoder) EncodeRLP(_w io.Writer) error {
+	w := NewEncoderBuffer(_w)
+	list := w.List()
+	w.WriteUint64(e.A)
+	if e.B == nil {
+		w.Write(EmptyString)
+	} else {
+		w.WriteBigInt(e.B)
+	}
+	w.WriteBytes(e.C)
+	w.WriteString(e.D)
+	w.WriteBool(e.E)
+	inner := w.List()
+	for _, f := range e.F {
+		if err := f.EncodeRLP(w); err != nil {
+			return err
+		}
+	}
+	w.ListEnd(inner)
+	w.ListEnd(list)
+	return w.Flush()
+}
+
+// plainBufferEncoder has the fields of bufferEncoder, but no EncodeRLP method.
+type plainBufferEncoder struct {
+	A uint64
+	B *big.Int
+	C []byte
+	D string
+	E bool
+	F []*plainBufferEncoder
+}
+
+func TestEncoderBuffer(t *testing.T) {
+	val := &bufferEncoder{
+		A: 1024, B: new(big.Int).Lsh(big.NewInt(1), 100), C: []byte{0x01}, D: "hello", E: true,
+		F: []*bufferEncoder{{C: make([]byte, 60)}, {D: "x"}},
+	}
+	want, err := EncodeToBytes(&plainBufferEncoder{
+		A: val.A, B: val.B, C: val.C, D: val.D, E: val.E,
+		F: []*plainBufferEncoder{{C: make([]byte, 60)}, {D: "x"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Encoding at the top level writes to the destination on Flush
+	buf := new(bytes.Buffer)
+	if err := val.EncodeRLP(buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("top-level output mismatch:\ngot  %x\nwant %x", buf.Bytes(), want)
+	}
+	// Encoding within reflection writes to the outer buffer directly
+	have, err := EncodeToBytes([]interface{}{val, uint(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantList, _ := EncodeToBytes([]interface{}{RawValue(want), uint(1)})
+	if !bytes.Equal(have, wantList) {
+		t.Errorf("nested output mismatch:\ngot  %x\nwant %x", have, wantList)
+	}
+}
+
 // This is a regression test verifying that encReader
 // returns its encbuf to the pool only once.
 func TestEncodeToReaderReturnToPool(t *testing.T) {
@@ -0,0 +1,641 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"sort"
+)
+
+// buildContext holds the state of generating the methods of a single type.
+type buildContext struct {
+	pkg     *types.Package // package the code is generated for
+	topType *types.Named   // type the methods are generated for
+
+	imports map[string]string // import path -> package name
+	names   map[string]string // package name -> import path
+	tmp     int               // counter of temporary variables
+}
+
+func newBuildContext(pkg *types.Package, typ *types.Named) *buildContext {
+	ctx := &buildContext{
+		pkg:     pkg,
+		topType: typ,
+		imports: make(map[string]string),
+		names:   make(map[string]string),
+	}
+	// Reserve the names of all package level identifiers and the variables
+	// of the generated code, so that imports don't clash with them.
+	for _, name := range pkg.Scope().Names() {
+		ctx.names[name] = ""
+	}
+	for _, name := range []string{"obj", "w", "_w", "dec", "err"} {
+		ctx.names[name] = ""
+	}
+	return ctx
+}
+
+// temp returns the name of a new temporary variable.
+func (ctx *buildContext) temp() string {
+	v := fmt.Sprintf("_tmp%d", ctx.tmp)
+	ctx.tmp++
+	return v
+}
+
+// importPackage adds an import for the package at path, returning the name
+// it can be referred to in the generated code.
+func (ctx *buildContext) importPackage(path, name string) string {
+	if name, ok := ctx.imports[path]; ok {
+		return name
+	}
+	for i := 1; ; i++ {
+		candidate := name
+		if i > 1 {
+			candidate = fmt.Sprintf("%s%d", name, i)
+		}
+		if _, taken := ctx.names[candidate]; !taken {
+			name = candidate
+			break
+		}
+	}
+	ctx.imports[path] = name
+	ctx.names[name] = path
+	return name
+}
+
+// typeString returns the Go syntax of typ in the generated file.
+func (ctx *buildContext) typeString(typ types.Type) string {
+	return types.TypeString(typ, func(p *types.Package) string {
+		if p == ctx.pkg {
+			return ""
+		}
+		return ctx.importPackage(p.Path(), p.Name())
+	})
+}
+
+// rlp returns the qualified name of an identifier in package rlp.
+func (ctx *buildContext) rlp(name string) string {
+	return ctx.importPackage(rlpPackage, "rlp") + "." + name
+}
+
+// isEncoder reports whether the EncodeRLP method of typ is used for encoding it.
+func (ctx *buildContext) isEncoder(typ types.Type) bool {
+	return types.Identical(typ, ctx.topType) || hasMethod(typ, "EncodeRLP")
+}
+
+// isDecoder reports whether the DecodeRLP method of typ is used for decoding it.
+func (ctx *buildContext) isDecoder(typ types.Type) bool {
+	return types.Identical(typ, ctx.topType) || hasMethod(typ, "DecodeRLP")
+}
+
+// generate creates the source of a file containing the RLP methods of the type.
+func (ctx *buildContext) generate(encoder, decoder bool) ([]byte, error) {
+	st, ok := ctx.topType.Underlying().(*types.Struct)
+	if !ok {
+		return nil, fmt.Errorf("type %s is not a struct", ctx.topType.Obj().Name())
+	}
+	var body bytes.Buffer
+	if encoder {
+		if hasMethod(ctx.topType, "EncodeRLP") {
+			return nil, fmt.Errorf("type %s already has an EncodeRLP method", ctx.topType.Obj().Name())
+		}
+		if err := ctx.genEncoder(&body, st); err != nil {
+			return nil, err
+		}
+	}
+	if decoder {
+		if hasMethod(ctx.topType, "DecodeRLP") {
+			return nil, fmt.Errorf("type %s already has a DecodeRLP method", ctx.topType.Obj().Name())
+		}
+		if err := ctx.genDecoder(&body, st); err != nil {
+			return nil, err
+		}
+	}
+	var out bytes.Buffer
+	fmt.Fprintln(&out, "// Code generated by rlpgen. DO NOT EDIT.")
+	fmt.Fprintln(&out)
+	fmt.Fprintf(&out, "package %s\n\n", ctx.pkg.Name())
+
+	paths := make([]string, 0, len(ctx.imports))
+	for path := range ctx.imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	fmt.Fprintln(&out, "import (")
+	for _, path := range paths {
+		if name := ctx.imports[path]; name != defaultPackageName(path) {
+			fmt.Fprintf(&out, "%s %q\n", name, path)
+		} else {
+			fmt.Fprintf(&out, "%q\n", path)
+		}
+	}
+	fmt.Fprintln(&out, ")")
+	out.Write(body.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("can't format generated code: %v\n%s", err, out.Bytes())
+	}
+	return src, nil
+}
+
+// defaultPackageName returns the name a package is imported as by default,
+// assuming that it matches the last element of the import path.
+func defaultPackageName(path string) string {
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i] == '/' {
+			return path[i+1:]
+		}
+	}
+	return path
+}
+
+// genEncoder writes the EncodeRLP method of the type.
+func (ctx *buildContext) genEncoder(b *bytes.Buffer, st *types.Struct) error {
+	var (
+		name = ctx.typeString(ctx.topType)
+		io   = ctx.importPackage("io", "io")
+		code bytes.Buffer
+	)
+	if err := ctx.encodeStruct(&code, st, "obj"); err != nil {
+		return err
+	}
+	fmt.Fprintf(b, "\nfunc (obj *%s) EncodeRLP(_w %s.Writer) error {\n", name, io)
+	fmt.Fprintf(b, "w := %s(_w)\n", ctx.rlp("NewEncoderBuffer"))
+	b.Write(code.Bytes())
+	fmt.Fprintf(b, "return w.Flush()\n")
+	fmt.Fprintf(b, "}\n")
+	return nil
+}
+
+// encode writes the code encoding the value v of type typ. The expression v
+// must be addressable.
+func (ctx *buildContext) encode(b *bytes.Buffer, typ types.Type, v string, tags rlpTags) error {
+	switch {
+	case isRawValue(typ):
+		fmt.Fprintf(b, "w.Write(%s)\n", v)
+		return nil
+
+	case isBigIntPtr(typ):
+		fmt.Fprintf(b, "if %s == nil {\n", v)
+		fmt.Fprintf(b, "w.Write(%s)\n", ctx.rlp("EmptyString"))
+		fmt.Fprintf(b, "} else {\n")
+		fmt.Fprintf(b, "if %s.Sign() == -1 {\n", v)
+		fmt.Fprintf(b, "return %s\n", ctx.rlp("ErrNegativeBigInt"))
+		fmt.Fprintf(b, "}\n")
+		fmt.Fprintf(b, "w.WriteBigInt(%s)\n", v)
+		fmt.Fprintf(b, "}\n")
+		return nil
+
+	case isBigInt(typ):
+		fmt.Fprintf(b, "if %s.Sign() == -1 {\n", v)
+		fmt.Fprintf(b, "return %s\n", ctx.rlp("ErrNegativeBigInt"))
+		fmt.Fprintf(b, "}\n")
+		fmt.Fprintf(b, "w.WriteBigInt(&%s)\n", v)
+		return nil
+	}
+	if ptr, ok := typ.Underlying().(*types.Pointer); ok {
+		kind := defaultNilKind(ptr.Elem())
+		if tags.nilOK {
+			kind = tags.nilKind
+		}
+		empty := ctx.rlp("EmptyList")
+		if kind == nilString {
+			empty = ctx.rlp("EmptyString")
+		}
+		fmt.Fprintf(b, "if %s == nil {\n", v)
+		fmt.Fprintf(b, "w.Write(%s)\n", empty)
+		fmt.Fprintf(b, "} else {\n")
+		var err error
+		if ctx.isEncoder(ptr.Elem()) {
+			ctx.encodeWithMethod(b, v)
+		} else {
+			err = ctx.encode(b, ptr.Elem(), deref(ptr.Elem(), v), rlpTags{})
+		}
+		fmt.Fprintf(b, "}\n")
+		return err
+	}
+	if ctx.isEncoder(typ) {
+		ctx.encodeWithMethod(b, v)
+		return nil
+	}
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case isUint(t):
+			fmt.Fprintf(b, "w.WriteUint64(%s)\n", convert(typ, types.Typ[types.Uint64], v))
+		case t.Kind() == types.Bool:
+			fmt.Fprintf(b, "w.WriteBool(%s)\n", convert(typ, types.Typ[types.Bool], v))
+		case t.Kind() == types.String:
+			fmt.Fprintf(b, "w.WriteString(%s)\n", convert(typ, types.Typ[types.String], v))
+		default:
+			return fmt.Errorf("type %s is not RLP-serializable", typ)
+		}
+
+	case *types.Slice:
+		if isByte(t.Elem()) {
+			fmt.Fprintf(b, "w.WriteBytes(%s)\n", v)
+			return nil
+		}
+		return ctx.encodeList(b, t.Elem(), v, tags.tail)
+
+	case *types.Array:
+		if isByte(t.Elem()) {
+			fmt.Fprintf(b, "w.WriteBytes(%s[:])\n", v)
+			return nil
+		}
+		return ctx.encodeList(b, t.Elem(), v, false)
+
+	case *types.Struct:
+		return ctx.encodeStruct(b, t, v)
+
+	default:
+		return fmt.Errorf("type %s is not supported", typ)
+	}
+	return nil
+}
+
+// encodeWithMethod writes the code calling the EncodeRLP method of v.
+func (ctx *buildContext) encodeWithMethod(b *bytes.Buffer, v string) {
+	fmt.Fprintf(b, "if err := %s.EncodeRLP(w); err != nil {\n", v)
+	fmt.Fprintf(b, "return err\n")
+	fmt.Fprintf(b, "}\n")
+}
+
+// encodeList writes the code encoding the elements of the slice or array v as a
+// list. If tail is set, the elements are written without a list header.
+func (ctx *buildContext) encodeList(b *bytes.Buffer, elem types.Type, v string, tail bool) error {
+	var list string
+	if !tail {
+		list = ctx.temp()
+		fmt.Fprintf(b, "%s := w.List()\n", list)
+	}
+	// Elements are accessed by index, so that they are addressable.
+	index := ctx.temp()
+	fmt.Fprintf(b, "for %s := range %s {\n", index, v)
+	if err := ctx.encode(b, elem, fmt.Sprintf("%s[%s]", v, index), rlpTags{}); err != nil {
+		return err
+	}
+	fmt.Fprintf(b, "}\n")
+	if !tail {
+		fmt.Fprintf(b, "w.ListEnd(%s)\n", list)
+	}
+	return nil
+}
+
+// encodeStruct writes the code encoding the fields of the struct v as a list.
+func (ctx *buildContext) encodeStruct(b *bytes.Buffer, st *types.Struct, v string) error {
+	fields, err := structFields(st)
+	if err != nil {
+		return err
+	}
+	// Optional fields are encoded up to the last non-zero one, so compute
+	// which of them are set before starting the list.
+	var nonZero []string
+	for _, f := range fields {
+		if !f.tags.optional {
+			continue
+		}
+		check, err := ctx.nonZero(f.typ, v+"."+f.name)
+		if err != nil {
+			return fmt.Errorf("field %s: %v", f.name, err)
+		}
+		set := ctx.temp()
+		fmt.Fprintf(b, "%s := %s\n", set, check)
+		nonZero = append(nonZero, set)
+	}
+	list := ctx.temp()
+	fmt.Fprintf(b, "%s := w.List()\n", list)
+
+	optional := 0
+	for _, f := range fields {
+		field := v + "." + f.name
+		if !f.tags.optional {
+			if err := ctx.encode(b, f.typ, field, f.tags); err != nil {
+				return fmt.Errorf("field %s: %v", f.name, err)
+			}
+			continue
+		}
+		fmt.Fprintf(b, "if %s {\n", joinOr(nonZero[optional:]))
+		if err := ctx.encode(b, f.typ, field, f.tags); err != nil {
+			return fmt.Errorf("field %s: %v", f.name, err)
+		}
+		fmt.Fprintf(b, "}\n")
+		optional++
+	}
+	fmt.Fprintf(b, "w.ListEnd(%s)\n", list)
+	return nil
+}
+
+// nonZero returns an expression reporting whether v is not the zero value of its
+// type, matching reflect.Value.IsZero.
+func (ctx *buildContext) nonZero(typ types.Type, v string) (string, error) {
+	if isBigInt(typ) {
+		// A big.Int is zero if it has never been set.
+		return v + ".Bits() != nil", nil
+	}
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case t.Kind() == types.Bool:
+			return v, nil
+		case t.Kind() == types.String:
+			return v + ` != ""`, nil
+		case t.Info()&types.IsNumeric != 0:
+			return v + " != 0", nil
+		}
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return v + " != nil", nil
+	case *types.Array, *types.Struct:
+		if types.Comparable(typ) {
+			return fmt.Sprintf("%s != (%s{})", v, ctx.typeString(typ)), nil
+		}
+	}
+	return "", fmt.Errorf("can't check if optional value of type %s is zero", typ)
+}
+
+// convert returns the expression v of type typ converted to the basic type to,
+// omitting the conversion if it isn't needed.
+func convert(typ types.Type, to *types.Basic, v string) string {
+	if types.Identical(typ, to) {
+		return v
+	}
+	return to.Name() + "(" + v + ")"
+}
+
+// deref returns an expression for the value pointed to by the pointer p, which
+// has element type elem. Fields and elements of structs and arrays can be
+// accessed through the pointer directly.
+func deref(elem types.Type, p string) string {
+	if isBigInt(elem) {
+		return "(*" + p + ")"
+	}
+	switch elem.Underlying().(type) {
+	case *types.Struct, *types.Array:
+		return p
+	case *types.Slice:
+		return "(*" + p + ")"
+	}
+	return "*" + p
+}
+
+// joinOr returns the disjunction of the given conditions.
+func joinOr(conds []string) string {
+	var b bytes.Buffer
+	for i, c := range conds {
+		if i > 0 {
+			b.WriteString(" || ")
+		}
+		b.WriteString(c)
+	}
+	return b.String()
+}
+
+// genDecoder writes the DecodeRLP method of the type.
+func (ctx *buildContext) genDecoder(b *bytes.Buffer, st *types.Struct) error {
+	var (
+		name = ctx.typeString(ctx.topType)
+		val  = ctx.temp()
+		code bytes.Buffer
+	)
+	if err := ctx.decodeStruct(&code, st, val); err != nil {
+		return err
+	}
+	fmt.Fprintf(b, "\nfunc (obj *%s) DecodeRLP(dec *%s) error {\n", name, ctx.rlp("Stream"))
+	fmt.Fprintf(b, "var %s %s\n", val, name)
+	b.Write(code.Bytes())
+	fmt.Fprintf(b, "*obj = %s\n", val)
+	fmt.Fprintf(b, "return nil\n")
+	fmt.Fprintf(b, "}\n")
+	return nil
+}
+
+// decode writes the code decoding a value of type typ into v. The expression v
+// must be addressable and hold the zero value of its type.
+func (ctx *buildContext) decode(b *bytes.Buffer, typ types.Type, v string, tags rlpTags) error {
+	switch {
+	case isRawValue(typ):
+		tmp := ctx.temp()
+		fmt.Fprintf(b, "%s, err := dec.Raw()\n", tmp)
+		ctx.checkErr(b)
+		fmt.Fprintf(b, "%s = %s\n", v, tmp)
+		return nil
+
+	case isBigIntPtr(typ):
+		tmp := ctx.temp()
+		fmt.Fprintf(b, "%s, err := dec.BigInt()\n", tmp)
+		ctx.checkErr(b)
+		fmt.Fprintf(b, "%s = %s\n", v, tmp)
+		return nil
+
+	case isBigInt(typ):
+		tmp := ctx.temp()
+		fmt.Fprintf(b, "%s, err := dec.BigInt()\n", tmp)
+		ctx.checkErr(b)
+		fmt.Fprintf(b, "%s.Set(%s)\n", v, tmp)
+		return nil
+	}
+	if ptr, ok := typ.Underlying().(*types.Pointer); ok {
+		if !tags.nilOK {
+			return ctx.decodePtr(b, typ, ptr.Elem(), v)
+		}
+		// Empty values decode as a nil pointer, which v already holds.
+		kind, size := ctx.temp(), ctx.temp()
+		fmt.Fprintf(b, "%s, %s, err := dec.Kind()\n", kind, size)
+		ctx.checkErr(b)
+		fmt.Fprintf(b, "if %s != %s && %s == 0 {\n", kind, ctx.rlp("Byte"), size)
+		if tags.nilKind == nilString {
+			fmt.Fprintf(b, "if _, err := dec.Bytes(); err != nil {\n")
+			fmt.Fprintf(b, "return err\n")
+			fmt.Fprintf(b, "}\n")
+		} else {
+			fmt.Fprintf(b, "if _, err := dec.List(); err != nil {\n")
+			fmt.Fprintf(b, "return err\n")
+			fmt.Fprintf(b, "}\n")
+			ctx.listEnd(b)
+		}
+		fmt.Fprintf(b, "} else {\n")
+		if err := ctx.decodePtr(b, typ, ptr.Elem(), v); err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "}\n")
+		return nil
+	}
+	if ctx.isDecoder(typ) {
+		fmt.Fprintf(b, "if err := %s.DecodeRLP(dec); err != nil {\n", v)
+		fmt.Fprintf(b, "return err\n")
+		fmt.Fprintf(b, "}\n")
+		return nil
+	}
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		// decoded is the type returned by the stream method
+		var (
+			tmp     = ctx.temp()
+			decoded types.Type
+		)
+		switch {
+		case isUint(t):
+			var bits int
+			bits, decoded = streamUint(t)
+			fmt.Fprintf(b, "%s, err := dec.Uint%d()\n", tmp, bits)
+		case t.Kind() == types.Bool:
+			fmt.Fprintf(b, "%s, err := dec.Bool()\n", tmp)
+			decoded = types.Typ[types.Bool]
+		case t.Kind() == types.String:
+			fmt.Fprintf(b, "%s, err := dec.Bytes()\n", tmp)
+			decoded = types.NewSlice(types.Typ[types.Byte])
+		default:
+			return fmt.Errorf("type %s is not RLP-serializable", typ)
+		}
+		ctx.checkErr(b)
+		if types.Identical(typ, decoded) {
+			fmt.Fprintf(b, "%s = %s\n", v, tmp)
+		} else {
+			fmt.Fprintf(b, "%s = %s(%s)\n", v, ctx.typeString(typ), tmp)
+		}
+
+	case *types.Slice:
+		if isByte(t.Elem()) {
+			tmp := ctx.temp()
+			fmt.Fprintf(b, "%s, err := dec.Bytes()\n", tmp)
+			ctx.checkErr(b)
+			fmt.Fprintf(b, "%s = %s\n", v, tmp)
+			return nil
+		}
+		if !tags.tail {
+			ctx.list(b)
+		}
+		fmt.Fprintf(b, "%s = %s{}\n", v, ctx.typeString(typ))
+		fmt.Fprintf(b, "for dec.MoreDataInList() {\n")
+		elem := ctx.temp()
+		fmt.Fprintf(b, "var %s %s\n", elem, ctx.typeString(t.Elem()))
+		if err := ctx.decode(b, t.Elem(), elem, rlpTags{}); err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "%s = append(%s, %s)\n", v, v, elem)
+		fmt.Fprintf(b, "}\n")
+		if !tags.tail {
+			ctx.listEnd(b)
+		}
+
+	case *types.Array:
+		if isByte(t.Elem()) {
+			fmt.Fprintf(b, "if err := dec.ReadBytes(%s[:]); err != nil {\n", v)
+			fmt.Fprintf(b, "return err\n")
+			fmt.Fprintf(b, "}\n")
+			return nil
+		}
+		ctx.list(b)
+		index := ctx.temp()
+		fmt.Fprintf(b, "for %s := range %s {\n", index, v)
+		ctx.requireElement(b)
+		if err := ctx.decode(b, t.Elem(), fmt.Sprintf("%s[%s]", v, index), rlpTags{}); err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "}\n")
+		ctx.listEnd(b)
+
+	case *types.Struct:
+		return ctx.decodeStruct(b, t, v)
+
+	default:
+		return fmt.Errorf("type %s is not supported", typ)
+	}
+	return nil
+}
+
+// decodePtr writes the code decoding a value of type elem into a new pointer,
+// which is assigned to v.
+func (ctx *buildContext) decodePtr(b *bytes.Buffer, typ, elem types.Type, v string) error {
+	tmp := ctx.temp()
+	fmt.Fprintf(b, "%s := new(%s)\n", tmp, ctx.typeString(elem))
+	if ctx.isDecoder(elem) {
+		fmt.Fprintf(b, "if err := %s.DecodeRLP(dec); err != nil {\n", tmp)
+		fmt.Fprintf(b, "return err\n")
+		fmt.Fprintf(b, "}\n")
+	} else if err := ctx.decode(b, elem, deref(elem, tmp), rlpTags{}); err != nil {
+		return err
+	}
+	if _, named := typ.(*types.Named); named {
+		fmt.Fprintf(b, "%s = %s(%s)\n", v, ctx.typeString(typ), tmp)
+	} else {
+		fmt.Fprintf(b, "%s = %s\n", v, tmp)
+	}
+	return nil
+}
+
+// decodeStruct writes the code decoding the list of struct fields into v.
+func (ctx *buildContext) decodeStruct(b *bytes.Buffer, st *types.Struct, v string) error {
+	fields, err := structFields(st)
+	if err != nil {
+		return err
+	}
+	ctx.list(b)
+	for _, f := range fields {
+		field := v + "." + f.name
+		fmt.Fprintf(b, "// %s:\n", f.name)
+		switch {
+		case f.tags.optional:
+			fmt.Fprintf(b, "if dec.MoreDataInList() {\n")
+			if err := ctx.decode(b, f.typ, field, f.tags); err != nil {
+				return fmt.Errorf("field %s: %v", f.name, err)
+			}
+			fmt.Fprintf(b, "}\n")
+		case f.tags.tail:
+			if err := ctx.decode(b, f.typ, field, f.tags); err != nil {
+				return fmt.Errorf("field %s: %v", f.name, err)
+			}
+		default:
+			ctx.requireElement(b)
+			if err := ctx.decode(b, f.typ, field, f.tags); err != nil {
+				return fmt.Errorf("field %s: %v", f.name, err)
+			}
+		}
+	}
+	ctx.listEnd(b)
+	return nil
+}
+
+// requireElement writes a check that the current list has more elements, so
+// that missing elements are not mistaken for the end of an enclosing list.
+func (ctx *buildContext) requireElement(b *bytes.Buffer) {
+	fmt.Fprintf(b, "if !dec.MoreDataInList() {\n")
+	fmt.Fprintf(b, "return %s\n", ctx.rlp("ErrTooFewElements"))
+	fmt.Fprintf(b, "}\n")
+}
+
+func (ctx *buildContext) list(b *bytes.Buffer) {
+	fmt.Fprintf(b, "if _, err := dec.List(); err != nil {\n")
+	fmt.Fprintf(b, "return err\n")
+	fmt.Fprintf(b, "}\n")
+}
+
+func (ctx *buildContext) listEnd(b *bytes.Buffer) {
+	fmt.Fprintf(b, "if err := dec.ListEnd(); err != nil {\n")
+	fmt.Fprintf(b, "return err\n")
+	fmt.Fprintf(b, "}\n")
+}
+
+func (ctx *buildContext) checkErr(b *bytes.Buffer) {
+	fmt.Fprintf(b, "if err != nil {\n")
+	fmt.Fprintf(b, "return err\n")
+	fmt.Fprintf(b, "}\n")
+}
@@ -0,0 +1,306 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// checkSources type-checks a package consisting of the given source files.
+func checkSources(srcs ...[]byte) (*types.Package, error) {
+	var (
+		fset  = token.NewFileSet()
+		files []*ast.File
+	)
+	for _, src := range srcs {
+		f, err := parser.ParseFile(fset, "", src, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return checkPackage(fset, ".", files)
+}
+
+var tests = []string{"uints", "basic", "bigint", "lists", "optional", "tail", "nil", "encoder"}
+
+func TestOutput(t *testing.T) {
+	for _, test := range tests {
+		test := test
+		t.Run(test, func(t *testing.T) {
+			input, err := ioutil.ReadFile(filepath.Join("testdata", test+".in.txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := ioutil.ReadFile(filepath.Join("testdata", test+".out.txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			pkg, err := checkSources(input)
+			if err != nil {
+				t.Fatal("can't load input:", err)
+			}
+			output, err := generate(pkg, "Test", true, true)
+			if err != nil {
+				t.Fatal("generation failed:", err)
+			}
+			if !bytes.Equal(output, want) {
+				t.Fatalf("output mismatch, want:\n%s\ngot:\n%s", want, output)
+			}
+			// The generated code must compile along with the input.
+			if _, err := checkSources(input, output); err != nil {
+				t.Fatal("generated code doesn't compile:", err)
+			}
+		})
+	}
+}
+
+// roundTripTests holds values of the testdata types, and encodings to decode in
+// addition to the ones of the values. Values are Go expressions of type Test,
+// encodings are hex strings.
+var roundTripTests = map[string]struct {
+	values []string
+	inputs []string
+}{
+	"uints": {
+		values: []string{`Test{}`, `Test{A: 1, B: 0x100, C: 0x10000, D: 1 << 40, E: 1 << 63}`},
+		inputs: []string{"c0", "c58080808080", "c50080808080", "c6820100808080", "c5ff80808080"},
+	},
+	"basic": {
+		values: []string{
+			`Test{}`,
+			`Test{Flag: true, Name: "name", Bytes: []byte{1, 2}, Hash: [32]byte{1}, Single: [1]byte{1}, Raw: rlp.RawValue{0xc1, 0x01}, hidden: 1, Skip: 2}`,
+		},
+		inputs: []string{"c0", "e880808080a00000000000000000000000000000000000000000000000000000000000000000008080", "c50280808080"},
+	},
+	"bigint": {
+		values: []string{`Test{}`, `Test{Int: big.NewInt(1), IntNoPtr: *big.NewInt(0x1234)}`},
+		inputs: []string{"c0", "c28080", "c3820001" + "80", "c28000"},
+	},
+	"lists": {
+		values: []string{
+			`Test{}`,
+			`Test{Uints: []uint64{1, 2}, Hashes: [][32]byte{{1}}, Structs: []Inner{{X: 1}}, Ptrs: []*Inner{{Y: []byte{1}}}, Triple: [3]uint16{1, 2, 3}}`,
+		},
+		inputs: []string{"c0", "c8c0c0c0c0c3808080c2c0c0", "c8c0c0c0c0c2808080c2c0c0"},
+	},
+	"optional": {
+		values: []string{
+			`Test{}`,
+			`Test{Required: 1}`,
+			`Test{Uint: 1}`,
+			`Test{Slice: []byte{}}`,
+			`Test{Array: [4]byte{1}}`,
+			`Test{Int: big.NewInt(0)}`,
+			`Test{String: "s"}`,
+		},
+		inputs: []string{"c0", "c101", "c20180", "c3018080", "c7018080840000000080", "c9018080840000000080807f"},
+	},
+	"tail": {
+		values: []string{`Test{}`, `Test{Version: 1, Rest: []string{}}`, `Test{Version: 1, Rest: []string{"a", "b"}}`},
+		inputs: []string{"c0", "c101", "c30161c0"},
+	},
+	"nil": {
+		values: []string{
+			`Test{}`,
+			`Test{Uint: new(uint64), Struct: new(Inner), NilUint: new(uint64), NilBytes: new([4]byte), NilStruct: new(Inner), NilListUint: new(uint64), NilStringPtr: new(Inner)}`,
+			`Test{NilUint: &[]uint64{1}[0], NilBytes: &[4]byte{1}, NilStruct: &Inner{A: 1}, NilListUint: &[]uint64{1}[0], NilStringPtr: &Inner{A: 1}}`,
+		},
+		inputs: []string{"c0", "c780c08080c0c080", "c780c0c080c08080", "c780c08080c080c0", "c78080808080c080"},
+	},
+	"encoder": {
+		values: []string{`Test{}`, `Test{Ptr: new(Custom), List: []Custom{{}}, Self: &Test{}, Others: []*Test{{}}}`},
+		inputs: []string{"c0", "c3c0c0c0", "c4c0c0c0c0", "c5c0c0c080c0"},
+	},
+}
+
+// roundTripTemplate is a test checking that the generated methods of type Test
+// produce the same results as the reflection based encoder and decoder.
+const roundTripTemplate = `package test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var _ = big.NewInt
+
+// plain has the same fields as Test, but not its generated methods.
+type plain Test
+
+var values = []Test{
+	%s,
+}
+
+var inputs = []string{%s}
+
+func TestRoundTrip(t *testing.T) {
+	for i := range values {
+		gen, genErr := rlp.EncodeToBytes(&values[i])
+		ref, refErr := rlp.EncodeToBytes((*plain)(&values[i]))
+		if (genErr != nil) != (refErr != nil) || !bytes.Equal(gen, ref) {
+			t.Errorf("value %%d: encoding mismatch: have %%x (%%v), want %%x (%%v)", i, gen, genErr, ref, refErr)
+		}
+		inputs = append(inputs, hex.EncodeToString(ref))
+	}
+	for _, input := range inputs {
+		blob, _ := hex.DecodeString(input)
+
+		var (
+			gen Test
+			ref plain
+		)
+		genErr := rlp.DecodeBytes(blob, &gen)
+		refErr := rlp.DecodeBytes(blob, &ref)
+		if (genErr != nil) != (refErr != nil) {
+			t.Errorf("input %%s: decoding error mismatch: have %%v, want %%v", input, genErr, refErr)
+		} else if genErr == nil && !reflect.DeepEqual(gen, Test(ref)) {
+			t.Errorf("input %%s: decoding mismatch: have %%+v, want %%+v", input, gen, ref)
+		}
+	}
+}
+`
+
+// TestRoundTrip checks that the generated code of the testdata types encodes and
+// decodes values like the reflection based encoder and decoder of package rlp.
+func TestRoundTrip(t *testing.T) {
+	// The generated code can only run as part of a package built by the go tool,
+	// assemble a package for every test case and run their tests.
+	root, err := ioutil.TempDir("testdata", "roundtrip-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for _, test := range tests {
+		input, err := ioutil.ReadFile(filepath.Join("testdata", test+".in.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg, err := checkSources(input)
+		if err != nil {
+			t.Fatal("can't load input:", err)
+		}
+		output, err := generate(pkg, "Test", true, true)
+		if err != nil {
+			t.Fatal("generation failed:", err)
+		}
+		rt, ok := roundTripTests[test]
+		if !ok {
+			t.Fatalf("no round trip values for test %s", test)
+		}
+		var inputs []string
+		for _, input := range rt.inputs {
+			inputs = append(inputs, fmt.Sprintf("%q", input))
+		}
+		code := fmt.Sprintf(roundTripTemplate, strings.Join(rt.values, ",\n\t"), strings.Join(inputs, ", "))
+
+		dir := filepath.Join(root, test)
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		files := map[string][]byte{"types.go": input, "gen.go": output, "roundtrip_test.go": []byte(code)}
+		for name, content := range files {
+			if err := ioutil.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	cmd := exec.Command("go", "test", "./...")
+	cmd.Dir = root
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("round trip tests failed: %v\n%s", err, out)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{
+			input: "type Test struct { A int }",
+			err:   "field A: type int is not RLP-serializable",
+		},
+		{
+			input: "type Test struct { A map[string]uint }",
+			err:   "field A: type map[string]uint is not supported",
+		},
+		{
+			input: "type Test struct { A interface{} }",
+			err:   "field A: type interface{} is not supported",
+		},
+		{
+			input: "type Test struct { A uint `rlp:\"optional\"`; B uint }",
+			err:   `struct field B needs "optional" tag`,
+		},
+		{
+			input: "type Test struct { A []uint `rlp:\"tail\"`; B uint }",
+			err:   `invalid struct tag "tail" for field A (must be on last field)`,
+		},
+		{
+			input: "type Test struct { A uint `rlp:\"nil\"` }",
+			err:   `invalid struct tag "nil" for field A (field is not a pointer)`,
+		},
+		{
+			input: "type Test struct { A uint `rlp:\"foo\"` }",
+			err:   `unknown struct tag "foo" on field A`,
+		},
+		{
+			input: "type Test struct { A [2]func() `rlp:\"optional\"` }",
+			err:   "field A: can't check if optional value of type [2]func() is zero",
+		},
+		{
+			input: "type Test []uint",
+			err:   "type Test is not a struct",
+		},
+		{
+			input: "type Test struct{}\nfunc (*Test) DecodeRLP(*rlp.Stream) error { return nil }",
+			err:   "type Test already has a DecodeRLP method",
+		},
+	}
+	for i, test := range tests {
+		src := "package test\n"
+		if strings.Contains(test.input, "rlp.") {
+			src += "import \"github.com/ethereum/go-ethereum/rlp\"\n"
+		}
+		pkg, err := checkSources([]byte(src + test.input))
+		if err != nil {
+			t.Fatalf("test %d: can't load input: %v", i, err)
+		}
+		_, err = generate(pkg, "Test", true, true)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("test %d: error mismatch: have %v, want %s", i, err, test.err)
+		}
+	}
+}
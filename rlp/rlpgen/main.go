@@ -0,0 +1,224 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// rlpgen generates EncodeRLP and DecodeRLP methods for struct types. The
+// generated code produces the same encoding as the reflection based encoder of
+// package rlp and honors its struct tags, but avoids the reflection overhead.
+//
+// Usage, typically in a go:generate directive:
+//
+//     rlpgen -type MyStruct -out gen_mystruct_rlp.go
+//
+// Unlike package rlp, the generated decoder always decodes into a fresh value,
+// replacing any content of the destination, including fields ignored by the
+// encoding.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+func main() {
+	var (
+		pkgdir     = flag.String("dir", ".", "input package directory")
+		output     = flag.String("out", "-", "output file (default is stdout)")
+		typename   = flag.String("type", "", "type to generate methods for")
+		genEncoder = flag.Bool("encoder", true, "generate EncodeRLP")
+		genDecoder = flag.Bool("decoder", true, "generate DecodeRLP")
+	)
+	flag.Parse()
+
+	if *typename == "" {
+		fatal("missing -type")
+	}
+	var exclude string
+	if *output != "-" {
+		exclude = *output
+	}
+	pkg, err := loadPackage(*pkgdir, exclude)
+	if err != nil {
+		fatal(err)
+	}
+	code, err := generate(pkg, *typename, *genEncoder, *genDecoder)
+	if err != nil {
+		fatal(err)
+	}
+	if *output == "-" {
+		os.Stdout.Write(code)
+	} else if err := ioutil.WriteFile(*output, code, 0644); err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(args ...interface{}) {
+	fmt.Fprintln(os.Stderr, args...)
+	os.Exit(1)
+}
+
+// generate creates the source of the RLP methods of the named type.
+func generate(pkg *types.Package, typename string, encoder, decoder bool) ([]byte, error) {
+	obj := pkg.Scope().Lookup(typename)
+	if obj == nil {
+		return nil, fmt.Errorf("no such type %s in package %s", typename, pkg.Path())
+	}
+	if _, ok := obj.(*types.TypeName); !ok {
+		return nil, fmt.Errorf("%s is not a type", typename)
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a named type", typename)
+	}
+	return newBuildContext(pkg, named).generate(encoder, decoder)
+}
+
+// loadPackage parses and type-checks the package in dir. The file named exclude,
+// usually the output of a previous run, is left out, so stale generated code
+// doesn't get in the way.
+func loadPackage(dir string, exclude string) (*types.Package, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		fset  = token.NewFileSet()
+		files []*ast.File
+	)
+	for _, name := range append(bp.GoFiles, bp.CgoFiles...) {
+		if exclude != "" && filepath.Base(exclude) == name {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return checkPackage(fset, dir, files)
+}
+
+// checkPackage type-checks the given files of the package in dir. Imported
+// packages are loaded from the export data produced by the go tool.
+func checkPackage(fset *token.FileSet, dir string, files []*ast.File) (*types.Package, error) {
+	var imports []string
+	for _, f := range files {
+		for _, spec := range f.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				return nil, err
+			}
+			if path != "C" && path != "unsafe" {
+				imports = append(imports, path)
+			}
+		}
+	}
+	self, pkgs, err := listPackages(dir, imports)
+	if err != nil {
+		return nil, err
+	}
+	exports := make(map[string]string)
+	for _, pkg := range pkgs {
+		if pkg.Export == "" {
+			return nil, fmt.Errorf("can't load package %s: %s", pkg.ImportPath, pkg.errorString())
+		}
+		exports[pkg.ImportPath] = pkg.Export
+	}
+	lookup := func(path string) (io.ReadCloser, error) {
+		file, ok := exports[path]
+		if !ok {
+			return nil, fmt.Errorf("no export data for package %s", path)
+		}
+		return os.Open(file)
+	}
+	conf := types.Config{
+		Importer:    importer.ForCompiler(fset, "gc", lookup),
+		FakeImportC: true,
+	}
+	return conf.Check(self.ImportPath, fset, files, nil)
+}
+
+// listedPackage is the output of go list for a package.
+type listedPackage struct {
+	Dir        string
+	ImportPath string
+	Export     string
+	Error      *struct{ Err string }
+}
+
+func (p *listedPackage) errorString() string {
+	if p.Error == nil {
+		return "no export data"
+	}
+	return p.Error.Err
+}
+
+// listPackages runs go list in dir, returning the package in dir and building
+// the export data of the given imported packages.
+func listPackages(dir string, imports []string) (*listedPackage, []*listedPackage, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	args := append([]string{"list", "-e", "-export", "-json", "."}, imports...)
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, nil, fmt.Errorf("go list failed: %v\n%s", err, stderr.Bytes())
+	}
+	var (
+		self   *listedPackage
+		listed = make(map[string]*listedPackage)
+	)
+	for dec := json.NewDecoder(&stdout); dec.More(); {
+		pkg := new(listedPackage)
+		if err := dec.Decode(pkg); err != nil {
+			return nil, nil, err
+		}
+		if pkg.Dir == abs {
+			self = pkg
+		}
+		listed[pkg.ImportPath] = pkg
+	}
+	if self == nil {
+		return nil, nil, fmt.Errorf("package in %s not listed", dir)
+	}
+	pkgs := make([]*listedPackage, 0, len(imports))
+	for _, path := range imports {
+		pkg, ok := listed[path]
+		if !ok {
+			return nil, nil, fmt.Errorf("package %s not listed", path)
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return self, pkgs, nil
+}
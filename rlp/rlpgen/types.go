@@ -0,0 +1,215 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"go/types"
+	"reflect"
+	"strings"
+)
+
+const rlpPackage = "github.com/ethereum/go-ethereum/rlp"
+
+// nilKind is the kind of empty value a nil pointer encodes to.
+type nilKind int
+
+const (
+	nilString nilKind = iota
+	nilList
+)
+
+// rlpTags represents the rlp struct tags of a field. It mirrors the tags type
+// in package rlp.
+type rlpTags struct {
+	nilOK    bool
+	nilKind  nilKind
+	optional bool
+	tail     bool
+	ignored  bool
+}
+
+// rlpField is a struct field which takes part in the encoding.
+type rlpField struct {
+	name string
+	typ  types.Type
+	tags rlpTags
+}
+
+// structFields returns the encoded fields of a struct, checking the struct tags
+// the same way package rlp does.
+func structFields(typ *types.Struct) ([]rlpField, error) {
+	lastPublic := 0
+	for i := 0; i < typ.NumFields(); i++ {
+		if typ.Field(i).Exported() {
+			lastPublic = i
+		}
+	}
+	var (
+		fields      []rlpField
+		anyOptional bool
+	)
+	for i := 0; i < typ.NumFields(); i++ {
+		f := typ.Field(i)
+		if !f.Exported() {
+			continue
+		}
+		tags, err := parseTag(f, reflect.StructTag(typ.Tag(i)).Get("rlp"), i == lastPublic)
+		if err != nil {
+			return nil, err
+		}
+		if tags.ignored {
+			continue
+		}
+		// If any field has the "optional" tag, subsequent fields must also have it.
+		if tags.optional || tags.tail {
+			anyOptional = true
+		} else if anyOptional {
+			return nil, fmt.Errorf(`struct field %s needs "optional" tag`, f.Name())
+		}
+		fields = append(fields, rlpField{name: f.Name(), typ: f.Type(), tags: tags})
+	}
+	return fields, nil
+}
+
+// parseTag parses the rlp struct tag of a field.
+func parseTag(f *types.Var, tag string, last bool) (rlpTags, error) {
+	var ts rlpTags
+	for _, t := range strings.Split(tag, ",") {
+		switch t = strings.TrimSpace(t); t {
+		case "":
+		case "-":
+			ts.ignored = true
+		case "nil", "nilString", "nilList":
+			ts.nilOK = true
+			ptr, ok := f.Type().Underlying().(*types.Pointer)
+			if !ok {
+				return ts, fmt.Errorf("invalid struct tag %q for field %s (field is not a pointer)", t, f.Name())
+			}
+			switch t {
+			case "nil":
+				ts.nilKind = defaultNilKind(ptr.Elem())
+			case "nilString":
+				ts.nilKind = nilString
+			case "nilList":
+				ts.nilKind = nilList
+			}
+		case "optional":
+			ts.optional = true
+			if ts.tail {
+				return ts, fmt.Errorf(`invalid struct tag %q for field %s (also has "tail" tag)`, t, f.Name())
+			}
+		case "tail":
+			ts.tail = true
+			if !last {
+				return ts, fmt.Errorf("invalid struct tag %q for field %s (must be on last field)", t, f.Name())
+			}
+			if ts.optional {
+				return ts, fmt.Errorf(`invalid struct tag %q for field %s (also has "optional" tag)`, t, f.Name())
+			}
+			if _, ok := f.Type().Underlying().(*types.Slice); !ok {
+				return ts, fmt.Errorf("invalid struct tag %q for field %s (field type is not slice)", t, f.Name())
+			}
+		default:
+			return ts, fmt.Errorf("unknown struct tag %q on field %s", t, f.Name())
+		}
+	}
+	return ts, nil
+}
+
+// defaultNilKind determines whether a nil pointer to typ encodes/decodes
+// as an empty string or empty list.
+func defaultNilKind(typ types.Type) nilKind {
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		if isUint(t) || t.Kind() == types.Bool || t.Kind() == types.String {
+			return nilString
+		}
+	case *types.Array:
+		if isByte(t.Elem()) {
+			return nilString
+		}
+	case *types.Slice:
+		if isByte(t.Elem()) {
+			return nilString
+		}
+	}
+	return nilList
+}
+
+// isNamed reports whether typ is the named type pkg.name.
+func isNamed(typ types.Type, pkg, name string) bool {
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == pkg && obj.Name() == name
+}
+
+// isBigInt reports whether typ is big.Int.
+func isBigInt(typ types.Type) bool {
+	return isNamed(typ, "math/big", "Int")
+}
+
+// isBigIntPtr reports whether typ is *big.Int.
+func isBigIntPtr(typ types.Type) bool {
+	ptr, ok := typ.(*types.Pointer)
+	return ok && isBigInt(ptr.Elem())
+}
+
+// isRawValue reports whether typ is rlp.RawValue.
+func isRawValue(typ types.Type) bool {
+	return isNamed(typ, rlpPackage, "RawValue")
+}
+
+// isUint reports whether t is an unsigned integer type.
+func isUint(t *types.Basic) bool {
+	switch t.Kind() {
+	case types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64, types.Uintptr:
+		return true
+	}
+	return false
+}
+
+// isByte reports whether typ is the predeclared byte type. Unlike package rlp,
+// named byte types are not supported as elements of byte arrays.
+func isByte(typ types.Type) bool {
+	basic, ok := typ.(*types.Basic)
+	return ok && basic.Kind() == types.Uint8
+}
+
+// hasMethod reports whether the method set of *typ contains a method with the
+// given name.
+func hasMethod(typ types.Type, name string) bool {
+	return types.NewMethodSet(types.NewPointer(typ)).Lookup(nil, name) != nil
+}
+
+// streamUint returns the bit size of the Stream method decoding an unsigned
+// integer type, along with the type returned by the method.
+func streamUint(t *types.Basic) (int, types.Type) {
+	switch t.Kind() {
+	case types.Uint8:
+		return 8, types.Typ[types.Uint8]
+	case types.Uint16:
+		return 16, types.Typ[types.Uint16]
+	case types.Uint32:
+		return 32, types.Typ[types.Uint32]
+	default:
+		return 64, types.Typ[types.Uint64]
+	}
+}
@@ -25,12 +25,19 @@ import (
 
 // StartIPCEndpoint starts an IPC endpoint.
 func StartIPCEndpoint(ipcEndpoint string, apis []API) (net.Listener, *Server, error) {
+	return StartIPCEndpointWithLimits(ipcEndpoint, apis, Limits{})
+}
+
+// StartIPCEndpointWithLimits starts an IPC endpoint, enforcing the given resource
+// limits on its connections.
+func StartIPCEndpointWithLimits(ipcEndpoint string, apis []API, limits Limits) (net.Listener, *Server, error) {
 	// Register all the APIs exposed by the services.
 	var (
 		handler    = NewServer()
 		regMap     = make(map[string]struct{})
 		registered []string
 	)
+	handler.SetLimits(limits)
 	for _, api := range apis {
 		if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
 			log.Info("IPC registration failed", "namespace", api.Namespace, "error", err)
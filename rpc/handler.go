@@ -19,13 +19,16 @@ package rpc
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/internal/tracing"
 	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/time/rate"
 )
 
 // handler handles JSON-RPC messages. There is one handler per connection. Note that
@@ -61,6 +64,8 @@ type handler struct {
 	conn           jsonWriter                     // where responses will be sent
 	log            log.Logger
 	allowSubscribe bool
+	limiter        *limiter // server limits, nil if unlimited
+	quota          *quota   // connection call quota, nil if unlimited
 
 	subLock    sync.Mutex
 	serverSubs map[ID]*Subscription
@@ -71,7 +76,7 @@ type callProc struct {
 	notifiers []*Notifier
 }
 
-func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *serviceRegistry) *handler {
+func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *serviceRegistry, limiter *limiter) *handler {
 	rootCtx, cancelRoot := context.WithCancel(connCtx)
 	h := &handler{
 		reg:            reg,
@@ -84,6 +89,10 @@ func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *
 		allowSubscribe: true,
 		serverSubs:     make(map[ID]*Subscription),
 		log:            log.Root(),
+		limiter:        limiter,
+	}
+	if limiter != nil {
+		h.quota = limiter.connQuota()
 	}
 	if conn.remoteAddr() != "" {
 		h.log = h.log.New("conn", conn.remoteAddr())
@@ -101,7 +110,15 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 		})
 		return
 	}
-
+	// Reject all calls of the batch if it exceeds the size limit
+	if h.limiter != nil {
+		if limit := h.limiter.limits.BatchItemLimit; limit > 0 && len(msgs) > limit {
+			h.startCallProc(func(cp *callProc) {
+				h.rejectBatch(cp, msgs, &limitExceededError{fmt.Sprintf("batch too large (%d > %d)", len(msgs), limit)})
+			})
+			return
+		}
+	}
 	// Handle non-call messages first:
 	calls := make([]*jsonrpcMessage, 0, len(msgs))
 	for _, msg := range msgs {
@@ -114,10 +131,24 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 	}
 	// Process calls on a goroutine because they may block indefinitely:
 	h.startCallProc(func(cp *callProc) {
+		var limit, size int
+		if h.limiter != nil {
+			limit = h.limiter.limits.BatchResponseLimit
+		}
 		answers := make([]*jsonrpcMessage, 0, len(msgs))
 		for _, msg := range calls {
+			// Once the response is too large, the remaining calls are not executed
+			// anymore but answered with an error.
+			if limit > 0 && size > limit {
+				if msg.isCall() {
+					limitedRequestGauge.Inc(1)
+					answers = append(answers, msg.errorResponse(&limitExceededError{"batch response too large"}))
+				}
+				continue
+			}
 			if answer := h.handleCallMsg(cp, msg); answer != nil {
 				answers = append(answers, answer)
+				size += len(answer.Result)
 			}
 		}
 		h.addSubscriptions(cp.notifiers)
@@ -130,6 +161,22 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 	})
 }
 
+// rejectBatch answers all calls in a batch with the given error, without
+// executing any of them.
+func (h *handler) rejectBatch(cp *callProc, msgs []*jsonrpcMessage, err error) {
+	limitedRequestGauge.Inc(1)
+
+	answers := make([]*jsonrpcMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg.isCall() {
+			answers = append(answers, msg.errorResponse(err))
+		}
+	}
+	if len(answers) > 0 {
+		h.conn.writeJSON(cp.ctx, answers)
+	}
+}
+
 // handleMsg handles a single message.
 func (h *handler) handleMsg(msg *jsonrpcMessage) {
 	if ok := h.handleImmediate(msg); ok {
@@ -317,6 +364,14 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 
 // handleCall processes method calls.
 func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
+	if !msg.isUnsubscribe() {
+		release, err := h.acquireQuota(msg.Method)
+		if err != nil {
+			limitedRequestGauge.Inc(1)
+			return msg.errorResponse(err)
+		}
+		defer release()
+	}
 	if msg.isSubscribe() {
 		return h.handleSubscribe(cp, msg)
 	}
@@ -333,8 +388,14 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 	if err != nil {
 		return msg.errorResponse(&invalidParamsError{err.Error()})
 	}
+	ctx, span := tracing.Start(cp.ctx, "rpc."+msg.Method, tracing.String("rpc.system", "jsonrpc"), tracing.String("rpc.method", msg.Method))
+	defer span.End()
+
 	start := time.Now()
-	answer := h.runMethod(cp.ctx, msg, callb, args)
+	answer := h.runMethod(ctx, msg, callb, args)
+	if answer.Error != nil {
+		span.SetError(answer.Error)
+	}
 
 	// Collect the statistics for RPC calls if metrics is enabled.
 	// We only care about pure rpc call. Filter out subscription.
@@ -351,6 +412,40 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 	return answer
 }
 
+// acquireQuota reserves a call slot for the given method in both the connection
+// and the method quota, returning the function to give them back.
+func (h *handler) acquireQuota(method string) (func(), error) {
+	var quotas []*quota
+	if h.quota != nil {
+		quotas = append(quotas, h.quota)
+	}
+	if h.limiter != nil {
+		if q := h.limiter.methods[method]; q != nil {
+			quotas = append(quotas, q)
+		}
+	}
+	var (
+		now          = time.Now()
+		reservations = make([]*rate.Reservation, len(quotas))
+	)
+	for i, q := range quotas {
+		r, err := q.acquire(now)
+		if err != nil {
+			// Refund the quotas already taken, the call is not issued
+			for j, q := range quotas[:i] {
+				q.cancel(reservations[j], now)
+			}
+			return nil, err
+		}
+		reservations[i] = r
+	}
+	return func() {
+		for _, q := range quotas {
+			q.release()
+		}
+	}, nil
+}
+
 // handleSubscribe processes *_subscribe method calls.
 func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
 	if !h.allowSubscribe {
@@ -384,8 +479,10 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMes
 	return h.runMethod(ctx, msg, callb, args)
 }
 
-// runMethod runs the Go callback for an RPC method.
+// runMethod runs the Go callback for an RPC method. The callback can retrieve a
+// logger carrying the connection and request identifiers with log.FromContext.
 func (h *handler) runMethod(ctx context.Context, msg *jsonrpcMessage, callb *callback, args []reflect.Value) *jsonrpcMessage {
+	ctx = log.NewContext(ctx, h.log.New("reqid", idForLog{msg.ID}))
 	result, err := callb.call(ctx, msg.Method, args)
 	if err != nil {
 		return msg.errorResponse(err)
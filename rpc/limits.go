@@ -0,0 +1,143 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"math"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Limits configures the resources a server grants to its clients. The zero value
+// of any field disables the corresponding limit.
+//
+// The connection limits apply to every connection separately. Note that HTTP is
+// stateless, every HTTP request is handled as a connection of its own, so only
+// the batch and method limits are meaningful for HTTP servers.
+type Limits struct {
+	BatchItemLimit     int     `toml:",omitempty"` // Maximum number of requests in a batch
+	BatchResponseLimit int     `toml:",omitempty"` // Maximum size of a batch response in bytes
+	ConcurrentCalls    int     `toml:",omitempty"` // Maximum number of calls executing concurrently on a connection
+	RequestRate        float64 `toml:",omitempty"` // Number of calls per second allowed on a connection
+	RequestBurst       int     `toml:",omitempty"` // Number of calls allowed in a burst above the request rate
+
+	// Methods contains the limits of individual methods, keyed by the full method
+	// name (e.g. "eth_getLogs"). These are enforced across all the connections of
+	// a server, on top of the connection limits.
+	Methods map[string]MethodLimits `toml:",omitempty"`
+}
+
+// MethodLimits configures the resources the calls of a single method may use.
+type MethodLimits struct {
+	ConcurrentCalls int     `toml:",omitempty"` // Maximum number of calls executing concurrently
+	RequestRate     float64 `toml:",omitempty"` // Number of calls per second allowed
+	RequestBurst    int     `toml:",omitempty"` // Number of calls allowed in a burst above the request rate
+}
+
+// limiter enforces the limits of a server. It holds the method quotas shared by
+// all connections and creates the quotas of individual connections.
+type limiter struct {
+	limits  Limits
+	methods map[string]*quota
+}
+
+func newLimiter(limits Limits) *limiter {
+	l := &limiter{
+		limits:  limits,
+		methods: make(map[string]*quota),
+	}
+	for method, ml := range limits.Methods {
+		if q := newQuota(ml.ConcurrentCalls, ml.RequestRate, ml.RequestBurst); q != nil {
+			l.methods[method] = q
+		}
+	}
+	return l
+}
+
+// connQuota creates the quota of a new connection, or nil if the calls on a
+// connection are not limited.
+func (l *limiter) connQuota() *quota {
+	return newQuota(l.limits.ConcurrentCalls, l.limits.RequestRate, l.limits.RequestBurst)
+}
+
+// quota is a combined concurrency and rate limit, shared by all the calls it
+// governs.
+type quota struct {
+	calls chan struct{} // semaphore of the executing calls, nil if unlimited
+	rate  *rate.Limiter // token bucket of the issued calls, nil if unlimited
+}
+
+// newQuota creates a quota with the given limits, or nil if nothing is limited.
+// If no burst is configured, a second's worth of calls is allowed.
+func newQuota(concurrent int, rps float64, burst int) *quota {
+	if concurrent <= 0 && rps <= 0 {
+		return nil
+	}
+	q := new(quota)
+	if concurrent > 0 {
+		q.calls = make(chan struct{}, concurrent)
+	}
+	if rps > 0 {
+		if burst <= 0 {
+			burst = int(math.Ceil(rps))
+		}
+		q.rate = rate.NewLimiter(rate.Limit(rps), burst)
+	}
+	return q
+}
+
+// acquire reserves a call slot in the quota at the given time. The slot must be
+// given back with release once the call is done, or with cancel if the call is
+// rejected before it runs.
+func (q *quota) acquire(now time.Time) (*rate.Reservation, error) {
+	var r *rate.Reservation
+	if q.rate != nil {
+		if r = q.rate.ReserveN(now, 1); r.DelayFrom(now) > 0 {
+			r.CancelAt(now)
+			return nil, &limitExceededError{"request rate limit exceeded"}
+		}
+	}
+	if q.calls != nil {
+		select {
+		case q.calls <- struct{}{}:
+		default:
+			if r != nil {
+				r.CancelAt(now)
+			}
+			return nil, &limitExceededError{"too many concurrent requests"}
+		}
+	}
+	return r, nil
+}
+
+// release gives back a call slot reserved by acquire.
+func (q *quota) release() {
+	if q.calls != nil {
+		<-q.calls
+	}
+}
+
+// cancel gives back a call slot reserved by acquire, along with the rate token
+// of the call, as if it was never issued. The time must be the one the slot was
+// acquired at, tokens can only be refunded if the reservation is not in the past.
+func (q *quota) cancel(r *rate.Reservation, now time.Time) {
+	q.release()
+	if r != nil {
+		r.CancelAt(now)
+	}
+}
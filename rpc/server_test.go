@@ -150,3 +150,126 @@ func TestServerShortLivedConn(t *testing.T) {
 		}
 	}
 }
+
+// checkLimitError checks that the given error is a limit violation reported by
+// the server.
+func checkLimitError(t *testing.T, err error) {
+	t.Helper()
+
+	if err == nil {
+		t.Fatalf("limit not enforced")
+	}
+	if rpcErr, ok := err.(Error); !ok || rpcErr.ErrorCode() != -32005 {
+		t.Fatalf("wrong error for exceeded limit: %v", err)
+	}
+}
+
+func TestServerBatchLimits(t *testing.T) {
+	server := newTestServer()
+	server.RegisterName("large", largeRespService{100})
+	server.SetLimits(Limits{BatchItemLimit: 3, BatchResponseLimit: 150})
+	defer server.Stop()
+
+	client := DialInProc(server)
+	defer client.Close()
+
+	// Batches above the item limit must be rejected entirely
+	batch := make([]BatchElem, 4)
+	for i := range batch {
+		batch[i] = BatchElem{Method: "test_echo", Args: []interface{}{"x", i, &echoArgs{"y"}}, Result: new(echoResult)}
+	}
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatalf("batch call failed: %v", err)
+	}
+	for _, elem := range batch {
+		checkLimitError(t, elem.Error)
+	}
+	// Calls above the response size limit must be rejected
+	batch = make([]BatchElem, 3)
+	for i := range batch {
+		batch[i] = BatchElem{Method: "large_largeResp", Result: new(string)}
+	}
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatalf("batch call failed: %v", err)
+	}
+	for i, elem := range batch[:2] {
+		if elem.Error != nil {
+			t.Fatalf("call %d failed: %v", i, elem.Error)
+		}
+	}
+	checkLimitError(t, batch[2].Error)
+
+	// Batches within the limits must be fully served
+	batch = batch[:1]
+	if err := client.BatchCall(batch); err != nil || batch[0].Error != nil {
+		t.Fatalf("batch call failed: %v %v", err, batch[0].Error)
+	}
+}
+
+func TestServerRateLimit(t *testing.T) {
+	server := newTestServer()
+	server.SetLimits(Limits{
+		RequestRate:  0.01,
+		RequestBurst: 2,
+		Methods: map[string]MethodLimits{
+			"test_echo": {RequestRate: 0.01, RequestBurst: 1},
+		},
+	})
+	defer server.Stop()
+
+	client1, client2 := DialInProc(server), DialInProc(server)
+	defer client1.Close()
+	defer client2.Close()
+
+	// The method limit is shared between all connections
+	var result echoResult
+	if err := client1.Call(&result, "test_echo", "x", 1, &echoArgs{"y"}); err != nil {
+		t.Fatalf("first call failed: %v", err)
+	}
+	checkLimitError(t, client2.Call(&result, "test_echo", "x", 1, &echoArgs{"y"}))
+
+	// The connection limit applies to all methods of a connection
+	if err := client1.Call(nil, "test_noArgsRets"); err != nil {
+		t.Fatalf("call within burst failed: %v", err)
+	}
+	checkLimitError(t, client1.Call(nil, "test_noArgsRets"))
+	if err := client2.Call(nil, "test_noArgsRets"); err != nil {
+		t.Fatalf("call on other connection failed: %v", err)
+	}
+	// Calls rejected by the method limit don't use up the connection limit
+	checkLimitError(t, client2.Call(&result, "test_echo", "x", 1, &echoArgs{"y"}))
+	if err := client2.Call(nil, "test_noArgsRets"); err != nil {
+		t.Fatalf("call after rejected call failed: %v", err)
+	}
+	checkLimitError(t, client2.Call(nil, "test_noArgsRets"))
+}
+
+func TestServerConcurrencyLimit(t *testing.T) {
+	server := newTestServer()
+	server.SetLimits(Limits{ConcurrentCalls: 1})
+	defer server.Stop()
+
+	client := DialInProc(server)
+	defer client.Close()
+
+	// Occupy the single call slot of the connection
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Call(nil, "test_sleep", 500*time.Millisecond)
+	}()
+	var err error
+	for deadline := time.Now().Add(400 * time.Millisecond); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if err = client.Call(nil, "test_noArgsRets"); err != nil {
+			break
+		}
+	}
+	checkLimitError(t, err)
+
+	// Once the call is done, the slot must be released
+	if err := <-done; err != nil {
+		t.Fatalf("sleep call failed: %v", err)
+	}
+	if err := client.Call(nil, "test_noArgsRets"); err != nil {
+		t.Fatalf("call after release failed: %v", err)
+	}
+}
@@ -18,6 +18,7 @@ package rpc
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -122,3 +123,28 @@ func TestBlockNumberOrHash_UnmarshalJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestBlockNumberOrHash_JSONRoundtrip(t *testing.T) {
+	tests := []BlockNumberOrHash{
+		BlockNumberOrHashWithNumber(1),
+		BlockNumberOrHashWithNumber(math.MaxInt64),
+		BlockNumberOrHashWithNumber(PendingBlockNumber),
+		BlockNumberOrHashWithNumber(LatestBlockNumber),
+		BlockNumberOrHashWithNumber(EarliestBlockNumber),
+		BlockNumberOrHashWithHash(common.HexToHash("0x01"), false),
+		BlockNumberOrHashWithHash(common.HexToHash("0x01"), true),
+	}
+	for i, test := range tests {
+		blob, err := json.Marshal(test)
+		if err != nil {
+			t.Fatalf("test %d: failed to marshal: %v", i, err)
+		}
+		var bnh BlockNumberOrHash
+		if err := json.Unmarshal(blob, &bnh); err != nil {
+			t.Fatalf("test %d: failed to unmarshal %s: %v", i, blob, err)
+		}
+		if !reflect.DeepEqual(bnh, test) {
+			t.Errorf("test %d: roundtrip mismatch: have %v, want %v", i, bnh, test)
+		}
+	}
+}
@@ -68,6 +68,7 @@ var (
 // servers even while the trie is executing expensive garbage collection.
 type Database struct {
 	diskdb ethdb.KeyValueStore // Persistent storage for matured trie nodes
+	scheme string              // Storage scheme of the persisted trie nodes (hash or path based)
 
 	cleans  *fastcache.Cache            // GC friendly memory cache of clean node RLPs
 	dirties map[common.Hash]*cachedNode // Data and references relationships of dirty trie nodes
@@ -88,6 +89,11 @@ type Database struct {
 	childrenSize  common.StorageSize // Storage size of the external children tracking
 	preimagesSize common.StorageSize // Storage size of the preimages cache
 
+	pathNodes    map[common.Hash]map[string][]byte // Committed trie nodes by owner and path, pending flush (path scheme)
+	pathWipes    map[common.Hash]struct{}          // Storage tries to delete entirely on the next flush (path scheme)
+	pathParent   common.Hash                       // State root the pending trie nodes are based on (path scheme)
+	reverseDiffs uint64                            // Number of reverse diffs to retain on disk (path scheme)
+
 	lock sync.RWMutex
 }
 
@@ -274,9 +280,10 @@ func expandNode(hash hashNode, n node) node {
 
 // Config defines all necessary options for database.
 type Config struct {
-	Cache     int    // Memory allowance (MB) to use for caching trie nodes in memory
-	Journal   string // Journal of clean cache to survive node restarts
-	Preimages bool   // Flag whether the preimage of trie key is recorded
+	Cache        int    // Memory allowance (MB) to use for caching trie nodes in memory
+	Journal      string // Journal of clean cache to survive node restarts
+	Preimages    bool   // Flag whether the preimage of trie key is recorded
+	ReverseDiffs uint64 // Number of recent states to keep reverse diffs for in the path-based scheme
 }
 
 // NewDatabase creates a new trie database to store ephemeral trie content before
@@ -300,6 +307,7 @@ func NewDatabaseWithConfig(diskdb ethdb.KeyValueStore, config *Config) *Database
 	}
 	db := &Database{
 		diskdb: diskdb,
+		scheme: rawdb.HashScheme,
 		cleans: cleans,
 		dirties: map[common.Hash]*cachedNode{{}: {
 			children: make(map[common.Hash]uint16),
@@ -308,6 +316,17 @@ func NewDatabaseWithConfig(diskdb ethdb.KeyValueStore, config *Config) *Database
 	if config == nil || config.Preimages { // TODO(karalabe): Flip to default off in the future
 		db.preimages = make(map[common.Hash][]byte)
 	}
+	// The storage scheme is a property of the disk database, pick up whatever
+	// it was created with.
+	if diskdb != nil && rawdb.ReadStateScheme(diskdb) == rawdb.PathScheme {
+		db.scheme = rawdb.PathScheme
+		db.pathNodes = make(map[common.Hash]map[string][]byte)
+		db.pathWipes = make(map[common.Hash]struct{})
+		db.reverseDiffs = DefaultReverseDiffs
+		if config != nil && config.ReverseDiffs > 0 {
+			db.reverseDiffs = config.ReverseDiffs
+		}
+	}
 	return db
 }
 
@@ -316,6 +335,11 @@ func (db *Database) DiskDB() ethdb.KeyValueStore {
 	return db.diskdb
 }
 
+// Scheme returns the storage scheme of the persisted trie nodes.
+func (db *Database) Scheme() string {
+	return db.scheme
+}
+
 // insert inserts a collapsed trie node into the memory database.
 // The blob size must be specified to allow proper size tracking.
 // All nodes inserted by this function will be reference tracked
@@ -716,6 +740,20 @@ func (db *Database) Commit(node common.Hash, report bool, callback func(common.H
 		}
 		batch.Reset()
 	}
+	// In the path-based scheme nodes are not pooled in the hash-keyed memory
+	// cache, flush the ones collected by path instead.
+	if db.scheme == rawdb.PathScheme {
+		if err := db.commitPath(node, report); err != nil {
+			log.Error("Failed to commit trie from trie database", "err", err)
+			return err
+		}
+		db.lock.Lock()
+		if db.preimages != nil {
+			db.preimages, db.preimagesSize = make(map[common.Hash][]byte), 0
+		}
+		db.lock.Unlock()
+		return nil
+	}
 	// Move the trie itself into the batch, flushing if enough data is accumulated
 	nodes, storage := len(db.dirties), db.dirtiesSize
 
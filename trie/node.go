@@ -105,6 +105,15 @@ func (n valueNode) fstring(ind string) string {
 	return fmt.Sprintf("%x ", []byte(n))
 }
 
+// nodeToBytes returns the RLP encoding of a collapsed trie node.
+func nodeToBytes(n node) []byte {
+	blob, err := rlp.EncodeToBytes(n)
+	if err != nil {
+		panic(fmt.Sprintf("encode error: %v", err))
+	}
+	return blob
+}
+
 func mustDecodeNode(hash, buf []byte) node {
 	n, err := decodeNode(hash, buf)
 	if err != nil {
@@ -0,0 +1,397 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// DefaultReverseDiffs is the number of recent states for which reverse diffs are
+// retained in the path-based scheme if not configured otherwise. It matches the
+// number of tries the blockchain keeps in memory in the hash-based scheme.
+const DefaultReverseDiffs = 128
+
+// errNotPathScheme is returned if a path-based scheme operation is requested on
+// a database storing trie nodes by hash.
+var errNotPathScheme = errors.New("not supported in the hash-based state scheme")
+
+// nodeSet is the set of trie nodes collected from a single trie by the committer
+// in the path-based scheme, keyed by their path. Deleted nodes have a nil blob.
+type nodeSet struct {
+	parent common.Hash // Root of the trie the nodes are based on
+	nodes  map[string][]byte
+}
+
+// newNodeSet initializes an empty node set for changes on top of the given root.
+func newNodeSet(parent common.Hash) *nodeSet {
+	return &nodeSet{parent: parent, nodes: make(map[string][]byte)}
+}
+
+// add inserts an encoded trie node at the given path.
+func (set *nodeSet) add(path []byte, blob []byte) {
+	set.nodes[string(path)] = blob
+}
+
+// markDeleted marks the trie node at the given path as deleted.
+func (set *nodeSet) markDeleted(path []byte) {
+	set.nodes[string(path)] = nil
+}
+
+// reverseDiff is the set of trie node changes which reverts the persisted state
+// from a root to its parent in the path-based scheme.
+type reverseDiff struct {
+	Parent common.Hash       // State root before the state transition
+	Root   common.Hash       // State root after the state transition
+	Nodes  []reverseDiffNode // Trie nodes to restore, with their value before the transition
+}
+
+// reverseDiffNode is a single trie node in a reverse diff. An empty blob means
+// that the node did not exist before the state transition.
+type reverseDiffNode struct {
+	Owner common.Hash
+	Path  []byte
+	Blob  []byte
+}
+
+// pathKey identifies a trie node in the path-based scheme.
+type pathKey struct {
+	owner common.Hash
+	path  string
+}
+
+// readPathNode retrieves the trie node of the given owner at the given path.
+func readPathNode(db ethdb.KeyValueReader, owner common.Hash, path []byte) []byte {
+	if owner == (common.Hash{}) {
+		return rawdb.ReadAccountTrieNode(db, path)
+	}
+	return rawdb.ReadStorageTrieNode(db, owner, path)
+}
+
+// writePathNode stores the trie node of the given owner at the given path,
+// deleting it if the blob is empty.
+func writePathNode(db ethdb.KeyValueWriter, owner common.Hash, path []byte, blob []byte) {
+	switch {
+	case owner == (common.Hash{}) && len(blob) == 0:
+		rawdb.DeleteAccountTrieNode(db, path)
+	case owner == (common.Hash{}):
+		rawdb.WriteAccountTrieNode(db, path, blob)
+	case len(blob) == 0:
+		rawdb.DeleteStorageTrieNode(db, owner, path)
+	default:
+		rawdb.WriteStorageTrieNode(db, owner, path, blob)
+	}
+}
+
+// updatePathNodes merges the nodes committed from a trie into the ones pending
+// flush. Later commits override earlier ones at the same path. The parent of the
+// first account trie change since the last flush is the state all the pending
+// nodes are based on.
+func (db *Database) updatePathNodes(owner common.Hash, set *nodeSet) {
+	if len(set.nodes) == 0 {
+		return
+	}
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if owner == (common.Hash{}) && db.pathParent == (common.Hash{}) {
+		db.pathParent = set.parent
+	}
+	pending := db.pathNodes[owner]
+	if pending == nil {
+		pending = make(map[string][]byte, len(set.nodes))
+		db.pathNodes[owner] = pending
+	}
+	for path, blob := range set.nodes {
+		pending[path] = blob
+	}
+}
+
+// WipeStorage schedules the entire storage trie of the given account for removal
+// on the next commit, discarding any of its nodes pending flush. Storage tries
+// committed afterwards are retained. It's a noop in the hash-based scheme, where
+// stale trie nodes are garbage collected by reference counting.
+func (db *Database) WipeStorage(owner common.Hash) {
+	if db.scheme != rawdb.PathScheme {
+		return
+	}
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	delete(db.pathNodes, owner)
+	db.pathWipes[owner] = struct{}{}
+}
+
+// pathNode retrieves the encoded trie node of the given owner at the given path,
+// looking into the clean cache, the nodes pending flush and finally the disk.
+// Nil is returned if the node there doesn't match the requested hash, meaning
+// it belongs to a state which is not persisted.
+func (db *Database) pathNode(owner common.Hash, path []byte, hash common.Hash) []byte {
+	// The clean cache is keyed by content, it's valid regardless of the path.
+	// Root nodes are always looked up by path though, so that opening a trie
+	// of a state which is not persisted fails right away.
+	if db.cleans != nil && len(path) > 0 {
+		if enc := db.cleans.Get(nil, hash[:]); enc != nil {
+			memcacheCleanHitMeter.Mark(1)
+			memcacheCleanReadMeter.Mark(int64(len(enc)))
+			return enc
+		}
+	}
+	db.lock.RLock()
+	if blob, ok := db.pathNodes[owner][string(path)]; ok {
+		db.lock.RUnlock()
+		if blob != nil && crypto.Keccak256Hash(blob) == hash {
+			return blob
+		}
+		return nil
+	}
+	_, wiped := db.pathWipes[owner]
+	db.lock.RUnlock()
+
+	if wiped {
+		return nil
+	}
+	blob := readPathNode(db.diskdb, owner, path)
+	if len(blob) == 0 || crypto.Keccak256Hash(blob) != hash {
+		return nil
+	}
+	if db.cleans != nil {
+		db.cleans.Set(hash[:], blob)
+		memcacheCleanMissMeter.Mark(1)
+		memcacheCleanWriteMeter.Mark(int64(len(blob)))
+	}
+	return blob
+}
+
+// diskRoot returns the root hash of the state persisted in the path-based scheme.
+func (db *Database) diskRoot() common.Hash {
+	blob := rawdb.ReadAccountTrieNode(db.diskdb, nil)
+	if len(blob) == 0 {
+		return emptyRoot
+	}
+	return crypto.Keccak256Hash(blob)
+}
+
+// commitPath flushes all the trie nodes pending in the path-based scheme to disk,
+// transitioning the persisted state to the given root. The previous value of all
+// the overwritten nodes is stored as a reverse diff, so that the transition can
+// be undone by Recover.
+func (db *Database) commitPath(root common.Hash, report bool) error {
+	start := time.Now()
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	// Whatever happens, the pending nodes are based on the current disk state,
+	// so drop them after this flush attempt.
+	defer func() {
+		db.pathNodes = make(map[common.Hash]map[string][]byte)
+		db.pathWipes = make(map[common.Hash]struct{})
+		db.pathParent = common.Hash{}
+	}()
+	// Assemble the final set of changes. Wiped storage tries are deleted first
+	// so that nodes committed after the wipe override the deletions.
+	changes := make(map[pathKey][]byte)
+	for owner := range db.pathWipes {
+		it := rawdb.IterateStorageTrieNodes(db.diskdb, owner)
+		for it.Next() {
+			if key := it.Key(); rawdb.IsStorageTrieNode(key) {
+				_, path := rawdb.ResolveStorageTrieNode(key)
+				changes[pathKey{owner, string(path)}] = nil
+			}
+		}
+		it.Release()
+		if err := it.Error(); err != nil {
+			return err
+		}
+	}
+	for owner, nodes := range db.pathNodes {
+		for path, blob := range nodes {
+			changes[pathKey{owner, path}] = blob
+		}
+	}
+	// Ensure the changes actually transition the persisted state to the requested
+	// one. The pending nodes must be based on the persisted state, otherwise they
+	// would partially revert any state persisted since they were collected.
+	parent := db.diskRoot()
+	if db.pathParent != (common.Hash{}) && db.pathParent != parent {
+		return fmt.Errorf("stale trie nodes: based on %x, persisted state is %x", db.pathParent, parent)
+	}
+	want := parent
+	if blob, ok := changes[pathKey{}]; ok {
+		want = emptyRoot
+		if len(blob) != 0 {
+			want = crypto.Keccak256Hash(blob)
+		}
+	}
+	if want != root {
+		return fmt.Errorf("state root mismatch: have %x, want %x", want, root)
+	}
+	// Write the changes in a deterministic order, collecting the reverse diff
+	keys := make([]pathKey, 0, len(changes))
+	for key := range changes {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if c := bytes.Compare(keys[i].owner[:], keys[j].owner[:]); c != 0 {
+			return c < 0
+		}
+		return keys[i].path < keys[j].path
+	})
+	var (
+		batch = db.diskdb.NewBatch()
+		diff  = &reverseDiff{Parent: parent, Root: root}
+	)
+	for _, key := range keys {
+		var (
+			path = []byte(key.path)
+			blob = changes[key]
+			prev = readPathNode(db.diskdb, key.owner, path)
+		)
+		if bytes.Equal(prev, blob) {
+			continue
+		}
+		diff.Nodes = append(diff.Nodes, reverseDiffNode{Owner: key.owner, Path: path, Blob: prev})
+		writePathNode(batch, key.owner, path, blob)
+	}
+	if parent != root || len(diff.Nodes) > 0 {
+		if err := db.writeReverseDiff(batch, diff); err != nil {
+			return err
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	logger := log.Info
+	if !report {
+		logger = log.Debug
+	}
+	logger("Persisted trie by path", "root", root, "parent", parent, "nodes", len(diff.Nodes), "size", common.StorageSize(batch.ValueSize()), "time", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// writeReverseDiff stores the given reverse diff as the latest one, pruning the
+// ones which fell out of the retention window.
+func (db *Database) writeReverseDiff(batch ethdb.Batch, diff *reverseDiff) error {
+	enc, err := rlp.EncodeToBytes(diff)
+	if err != nil {
+		return err
+	}
+	id := rawdb.ReadReverseDiffHead(db.diskdb) + 1
+
+	// Prune the stale diffs first, their lookups might be overwritten below
+	var stale uint64
+	if id > db.reverseDiffs {
+		stale = id - db.reverseDiffs
+	}
+	for ; stale > 0; stale-- {
+		blob := rawdb.ReadReverseDiff(db.diskdb, stale)
+		if len(blob) == 0 {
+			break
+		}
+		var old reverseDiff
+		if err := rlp.DecodeBytes(blob, &old); err != nil {
+			return err
+		}
+		if lookup := rawdb.ReadReverseDiffLookup(db.diskdb, old.Parent); lookup != nil && *lookup == stale {
+			rawdb.DeleteReverseDiffLookup(batch, old.Parent)
+		}
+		rawdb.DeleteReverseDiff(batch, stale)
+	}
+	rawdb.WriteReverseDiff(batch, id, enc)
+	rawdb.WriteReverseDiffLookup(batch, diff.Parent, id)
+	rawdb.WriteReverseDiffHead(batch, id)
+	return nil
+}
+
+// Recoverable reports whether the persisted state can be reverted to the one
+// with the given root using the retained reverse diffs.
+func (db *Database) Recoverable(root common.Hash) bool {
+	if db.scheme != rawdb.PathScheme {
+		return false
+	}
+	id := rawdb.ReadReverseDiffLookup(db.diskdb, root)
+	if id == nil || *id > rawdb.ReadReverseDiffHead(db.diskdb) {
+		return false
+	}
+	return len(rawdb.ReadReverseDiff(db.diskdb, *id)) != 0
+}
+
+// Recover reverts the persisted state to the one with the given root by applying
+// the retained reverse diffs, newest first. Any nodes pending flush are dropped.
+func (db *Database) Recover(root common.Hash) error {
+	if db.scheme != rawdb.PathScheme {
+		return errNotPathScheme
+	}
+	start := time.Now()
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	current := db.diskRoot()
+	if current == root {
+		return nil
+	}
+	id := rawdb.ReadReverseDiffLookup(db.diskdb, root)
+	head := rawdb.ReadReverseDiffHead(db.diskdb)
+	if id == nil || *id > head {
+		return fmt.Errorf("state %x is not recoverable", root)
+	}
+	batch := db.diskdb.NewBatch()
+	for i := head; i >= *id; i-- {
+		blob := rawdb.ReadReverseDiff(db.diskdb, i)
+		if len(blob) == 0 {
+			return fmt.Errorf("state %x is not recoverable, reverse diff %d missing", root, i)
+		}
+		var diff reverseDiff
+		if err := rlp.DecodeBytes(blob, &diff); err != nil {
+			return err
+		}
+		if diff.Root != current {
+			return fmt.Errorf("reverse diff %d root mismatch: have %x, want %x", i, diff.Root, current)
+		}
+		for j := len(diff.Nodes) - 1; j >= 0; j-- {
+			node := diff.Nodes[j]
+			writePathNode(batch, node.Owner, node.Path, node.Blob)
+		}
+		if lookup := rawdb.ReadReverseDiffLookup(db.diskdb, diff.Parent); lookup != nil && *lookup == i {
+			rawdb.DeleteReverseDiffLookup(batch, diff.Parent)
+		}
+		rawdb.DeleteReverseDiff(batch, i)
+		current = diff.Parent
+	}
+	rawdb.WriteReverseDiffHead(batch, *id-1)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	db.pathNodes = make(map[common.Hash]map[string][]byte)
+	db.pathWipes = make(map[common.Hash]struct{})
+	db.pathParent = common.Hash{}
+
+	log.Info("Recovered persisted state", "root", root, "diffs", head-*id+1, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
@@ -0,0 +1,313 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+)
+
+// newPathDatabase creates an in-memory trie database using the path-based scheme.
+func newPathDatabase(diffs uint64) *Database {
+	diskdb := memorydb.New()
+	rawdb.WriteStateScheme(diskdb, rawdb.PathScheme)
+	return NewDatabaseWithConfig(diskdb, &Config{ReverseDiffs: diffs})
+}
+
+// pathTestState generates a series of states by applying random insertions,
+// updates and deletions on a small key space, to exercise embedded nodes and
+// node collapses too.
+type pathTestState struct {
+	keys     [][]byte
+	roots    []common.Hash
+	contents []map[string][]byte
+}
+
+func newPathTestState(rnd *rand.Rand) *pathTestState {
+	keys := make([][]byte, 256)
+	for i := range keys {
+		keys[i] = make([]byte, 1+rnd.Intn(8))
+		rnd.Read(keys[i])
+	}
+	return &pathTestState{keys: keys}
+}
+
+// mutate applies a batch of random changes to the trie and to the tracked
+// contents, committing the new state to disk.
+func (s *pathTestState) mutate(t *testing.T, rnd *rand.Rand, db *Database, tr *Trie, contents map[string][]byte) {
+	for i := 0; i < 64; i++ {
+		key := s.keys[rnd.Intn(len(s.keys))]
+		if rnd.Intn(3) == 0 {
+			tr.Delete(key)
+			delete(contents, string(key))
+			continue
+		}
+		val := make([]byte, 1+rnd.Intn(32))
+		rnd.Read(val)
+		tr.Update(key, val)
+		contents[string(key)] = val
+	}
+	root, err := tr.Commit(nil)
+	if err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	if err := db.Commit(root, false, nil); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	snapshot := make(map[string][]byte, len(contents))
+	for k, v := range contents {
+		snapshot[k] = v
+	}
+	s.roots = append(s.roots, root)
+	s.contents = append(s.contents, snapshot)
+}
+
+// checkPathState verifies that the state with the given root is persisted and
+// contains exactly the expected entries, without any stale nodes left on disk.
+func checkPathState(t *testing.T, db *Database, root common.Hash, contents map[string][]byte) {
+	t.Helper()
+
+	tr, err := New(root, db)
+	if err != nil {
+		t.Fatalf("failed to open trie %x: %v", root, err)
+	}
+	for k, v := range contents {
+		if have := tr.Get([]byte(k)); !bytes.Equal(have, v) {
+			t.Fatalf("value mismatch for %x: have %x, want %x", k, have, v)
+		}
+	}
+	var (
+		leaves    int
+		reachable = make(map[string]struct{})
+		it        = tr.NodeIterator(nil)
+	)
+	for it.Next(true) {
+		if it.Leaf() {
+			leaves++
+		}
+		if it.Hash() != (common.Hash{}) {
+			reachable[string(it.Path())] = struct{}{}
+		}
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("failed to iterate trie: %v", err)
+	}
+	if leaves != len(contents) {
+		t.Fatalf("leaf count mismatch: have %d, want %d", leaves, len(contents))
+	}
+	// Ensure that all the nodes on disk belong to the state
+	dit := db.DiskDB().NewIterator([]byte("A"), nil)
+	defer dit.Release()
+
+	var persisted int
+	for dit.Next() {
+		if !rawdb.IsAccountTrieNode(dit.Key()) {
+			continue
+		}
+		persisted++
+		if _, ok := reachable[string(dit.Key()[1:])]; !ok {
+			t.Fatalf("stale trie node at path %x", dit.Key()[1:])
+		}
+	}
+	if persisted != len(reachable) {
+		t.Fatalf("persisted node count mismatch: have %d, want %d", persisted, len(reachable))
+	}
+}
+
+// Tests that committing a series of states in the path-based scheme keeps only
+// the latest one on disk, and that earlier ones can be recovered.
+func TestPathCommitAndRecover(t *testing.T) {
+	var (
+		rnd   = rand.New(rand.NewSource(1))
+		db    = newPathDatabase(0)
+		state = newPathTestState(rnd)
+	)
+	tr, _ := New(common.Hash{}, db)
+	contents := make(map[string][]byte)
+	for i := 0; i < 20; i++ {
+		state.mutate(t, rnd, db, tr, contents)
+		checkPathState(t, db, state.roots[i], state.contents[i])
+	}
+	// All the previous states should be recoverable, the current one not
+	for i := 0; i < len(state.roots)-1; i++ {
+		if !db.Recoverable(state.roots[i]) {
+			t.Fatalf("state %d not recoverable", i)
+		}
+	}
+	if db.Recoverable(state.roots[len(state.roots)-1]) {
+		t.Fatalf("current state reported recoverable")
+	}
+	// Roll back to a previous state and check that newer ones are gone
+	if err := db.Recover(state.roots[10]); err != nil {
+		t.Fatalf("failed to recover state: %v", err)
+	}
+	checkPathState(t, db, state.roots[10], state.contents[10])
+	for i := 10; i < len(state.roots); i++ {
+		if db.Recoverable(state.roots[i]) {
+			t.Fatalf("state %d recoverable after rollback", i)
+		}
+	}
+	if _, err := New(state.roots[15], db); err == nil {
+		t.Fatalf("rolled back state still accessible")
+	}
+	// Build a different chain of states on top of the recovered one
+	tr, _ = New(state.roots[10], db)
+	contents = state.contents[10]
+	state.roots, state.contents = state.roots[:11], state.contents[:11]
+	for i := 0; i < 5; i++ {
+		state.mutate(t, rnd, db, tr, contents)
+		checkPathState(t, db, state.roots[11+i], state.contents[11+i])
+	}
+	for i := 0; i < len(state.roots)-1; i++ {
+		if !db.Recoverable(state.roots[i]) {
+			t.Fatalf("state %d not recoverable", i)
+		}
+	}
+	if err := db.Recover(state.roots[0]); err != nil {
+		t.Fatalf("failed to recover state: %v", err)
+	}
+	checkPathState(t, db, state.roots[0], state.contents[0])
+}
+
+// Tests that reverse diffs falling out of the retention window are pruned.
+func TestPathReverseDiffPruning(t *testing.T) {
+	var (
+		rnd   = rand.New(rand.NewSource(2))
+		db    = newPathDatabase(4)
+		state = newPathTestState(rnd)
+	)
+	tr, _ := New(common.Hash{}, db)
+	contents := make(map[string][]byte)
+	for i := 0; i < 10; i++ {
+		state.mutate(t, rnd, db, tr, contents)
+	}
+	for i, root := range state.roots {
+		if want := i >= 5 && i < 9; db.Recoverable(root) != want {
+			t.Fatalf("state %d recoverability mismatch: have %v, want %v", i, !want, want)
+		}
+	}
+	if blob := rawdb.ReadReverseDiff(db.DiskDB(), 6); len(blob) != 0 {
+		t.Fatalf("stale reverse diff not pruned")
+	}
+	if err := db.Recover(state.roots[2]); err == nil {
+		t.Fatalf("recovered pruned state")
+	}
+	if err := db.Recover(state.roots[5]); err != nil {
+		t.Fatalf("failed to recover state: %v", err)
+	}
+	checkPathState(t, db, state.roots[5], state.contents[5])
+}
+
+// Tests that wiped storage tries are removed from disk, but can be restored by
+// recovering an earlier state.
+func TestPathWipeStorage(t *testing.T) {
+	db := newPathDatabase(0)
+	owner := common.HexToHash("0x01")
+
+	// Create a state with a single storage trie
+	storage, _ := NewWithOwner(owner, common.Hash{}, db)
+	for i := byte(0); i < 100; i++ {
+		storage.Update(common.LeftPadBytes([]byte{i}, 32), []byte{i})
+	}
+	storageRoot, _ := storage.Commit(nil)
+
+	account, _ := New(common.Hash{}, db)
+	account.Update(owner[:], storageRoot[:])
+	parent, _ := account.Commit(nil)
+	if err := db.Commit(parent, false, nil); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	// Wipe the storage trie and recreate it with different content
+	db.WipeStorage(owner)
+
+	storage, _ = NewWithOwner(owner, common.Hash{}, db)
+	storage.Update(common.LeftPadBytes([]byte{0xff}, 32), []byte{0xff})
+	newStorageRoot, _ := storage.Commit(nil)
+
+	account.Update(owner[:], newStorageRoot[:])
+	root, _ := account.Commit(nil)
+	if err := db.Commit(root, false, nil); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	it := rawdb.IterateStorageTrieNodes(db.DiskDB(), owner)
+	var nodes int
+	for it.Next() {
+		nodes++
+	}
+	it.Release()
+	if nodes != 1 {
+		t.Fatalf("storage node count mismatch: have %d, want 1", nodes)
+	}
+	if _, err := NewWithOwner(owner, storageRoot, db); err == nil {
+		t.Fatalf("wiped storage trie still accessible")
+	}
+	// Recover the parent state, the old storage should be back
+	if err := db.Recover(parent); err != nil {
+		t.Fatalf("failed to recover state: %v", err)
+	}
+	storage, err := NewWithOwner(owner, storageRoot, db)
+	if err != nil {
+		t.Fatalf("failed to open recovered storage trie: %v", err)
+	}
+	for i := byte(0); i < 100; i++ {
+		if have := storage.Get(common.LeftPadBytes([]byte{i}, 32)); !bytes.Equal(have, []byte{i}) {
+			t.Fatalf("storage slot %d mismatch: have %x, want %x", i, have, []byte{i})
+		}
+	}
+}
+
+// Tests that trie nodes based on a state other than the persisted one are rejected,
+// instead of being flushed on top of a newer state.
+func TestPathCommitStale(t *testing.T) {
+	db := newPathDatabase(0)
+
+	tr, _ := New(common.Hash{}, db)
+	for i := byte(0); i < 16; i++ {
+		tr.Update([]byte{i}, []byte{i})
+	}
+	parent, _ := tr.Commit(nil)
+	if err := db.Commit(parent, false, nil); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	// Open two tries on the same state, and persist the changes of the first one.
+	// The second one has all its nodes resolved already.
+	first, _ := New(parent, db)
+	second, _ := New(parent, db)
+	for i := byte(0); i < 16; i++ {
+		second.Get([]byte{i})
+	}
+
+	first.Update([]byte{0}, []byte{0xaa})
+	root, _ := first.Commit(nil)
+	if err := db.Commit(root, false, nil); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	// The changes of the second one are based on the stale parent state
+	second.Update([]byte{1}, []byte{0xbb})
+	stale, _ := second.Commit(nil)
+	if err := db.Commit(stale, false, nil); err == nil {
+		t.Fatalf("stale trie nodes committed")
+	}
+	if have := db.diskRoot(); have != root {
+		t.Fatalf("persisted state changed: have %x, want %x", have, root)
+	}
+}
@@ -146,6 +146,98 @@ func NewSync(root common.Hash, database ethdb.KeyValueReader, callback LeafCallb
 	return ts
 }
 
+// SyncRequest is a pending retrieval request of a Sync, in a form that can be
+// persisted to resume the sync later on.
+type SyncRequest struct {
+	Path    []byte        // Merkle path leading to the node, in nibbles
+	Hash    common.Hash   // Hash of the node or code to retrieve
+	Data    []byte        // Retrieved node data waiting for its children, nil if not retrieved yet
+	Code    bool          // Whether this is a code entry
+	Parents []common.Hash // Hashes of the parent node requests
+}
+
+// ResumeSync creates a trie data download scheduler from the pending requests of
+// a previous one, as returned by Sync.Requests. The leaf callback of each node
+// request is chosen based on its path.
+func ResumeSync(reqs []SyncRequest, database ethdb.KeyValueReader, callback func(path []byte) LeafCallback, bloom *SyncBloom) (*Sync, error) {
+	ts := &Sync{
+		database: database,
+		membatch: newSyncMemBatch(),
+		nodeReqs: make(map[common.Hash]*request),
+		codeReqs: make(map[common.Hash]*request),
+		queue:    prque.New(nil),
+		fetches:  make(map[int]int),
+		bloom:    bloom,
+	}
+	// Recreate all the requests first, then link them to their parents
+	for _, r := range reqs {
+		req := &request{
+			path: common.CopyBytes(r.Path),
+			hash: r.Hash,
+			data: common.CopyBytes(r.Data),
+			code: r.Code,
+		}
+		reqset := ts.nodeReqs
+		if req.code {
+			reqset = ts.codeReqs
+		} else {
+			req.callback = callback(req.path)
+		}
+		if _, ok := reqset[req.hash]; ok {
+			return nil, fmt.Errorf("duplicate sync request %x", req.hash)
+		}
+		reqset[req.hash] = req
+	}
+	for _, r := range reqs {
+		req := ts.nodeReqs[r.Hash]
+		if r.Code {
+			req = ts.codeReqs[r.Hash]
+		}
+		for _, parent := range r.Parents {
+			ancestor := ts.nodeReqs[parent]
+			if ancestor == nil || ancestor.data == nil {
+				return nil, fmt.Errorf("parent %x of sync request %x not found", parent, r.Hash)
+			}
+			ancestor.deps++
+			req.parents = append(req.parents, ancestor)
+		}
+	}
+	// Schedule the requests not retrieved yet. The retrieved ones count as
+	// active fetches until they are committed.
+	for _, reqset := range []map[common.Hash]*request{ts.nodeReqs, ts.codeReqs} {
+		for _, req := range reqset {
+			if req.data == nil {
+				ts.queue.Push(req.hash, syncPriority(req.path))
+			} else {
+				ts.fetches[len(req.path)]++
+			}
+		}
+	}
+	return ts, nil
+}
+
+// Requests returns all the pending requests of the scheduler, including those
+// which are already in flight. Together with the content committed to the
+// database, they describe the complete state of the sync.
+func (s *Sync) Requests() []SyncRequest {
+	reqs := make([]SyncRequest, 0, s.Pending())
+	for _, reqset := range []map[common.Hash]*request{s.nodeReqs, s.codeReqs} {
+		for _, req := range reqset {
+			r := SyncRequest{
+				Path: req.path,
+				Hash: req.hash,
+				Data: req.data,
+				Code: req.code,
+			}
+			for _, parent := range req.parents {
+				r.Parents = append(r.Parents, parent.hash)
+			}
+			reqs = append(reqs, r)
+		}
+	}
+	return reqs
+}
+
 // AddSubTrie registers a new trie to the sync code, rooted at the designated parent.
 func (s *Sync) AddSubTrie(root common.Hash, path []byte, parent common.Hash, callback LeafCallback) {
 	// Short circuit if the trie is empty or already known
@@ -353,11 +445,17 @@ func (s *Sync) schedule(req *request) {
 	// is a trie node and code has same hash. In this case two elements
 	// with same hash and same or different depth will be pushed. But it's
 	// ok the worst case is the second response will be treated as duplicated.
-	prio := int64(len(req.path)) << 56 // depth >= 128 will never happen, storage leaves will be included in their parents
-	for i := 0; i < 14 && i < len(req.path); i++ {
-		prio |= int64(15-req.path[i]) << (52 - i*4) // 15-nibble => lexicographic order
+	s.queue.Push(req.hash, syncPriority(req.path))
+}
+
+// syncPriority returns the priority of a request with the given path in the
+// fetch queue.
+func syncPriority(path []byte) int64 {
+	prio := int64(len(path)) << 56 // depth >= 128 will never happen, storage leaves will be included in their parents
+	for i := 0; i < 14 && i < len(path); i++ {
+		prio |= int64(15-path[i]) << (52 - i*4) // 15-nibble => lexicographic order
 	}
-	s.queue.Push(req.hash, prio)
+	return prio
 }
 
 // children retrieves all the missing children of a state trie entry for future
@@ -476,3 +476,55 @@ func TestSyncOrdering(t *testing.T) {
 		}
 	}
 }
+
+// Tests that a sync can be suspended with some requests in flight and some
+// nodes waiting for their children, and resumed from its pending requests.
+func TestResumeSync(t *testing.T) {
+	// Create a random trie to copy
+	srcDb, srcTrie, srcData := makeTestTrie()
+
+	// Create a destination trie and sync with the scheduler
+	diskdb := memorydb.New()
+	triedb := NewDatabase(diskdb)
+	sched := NewSync(srcTrie.Hash(), diskdb, nil, nil)
+
+	process := func(queue []common.Hash) {
+		for _, hash := range queue {
+			data, err := srcDb.Node(hash)
+			if err != nil {
+				t.Fatalf("failed to retrieve node data for %x: %v", hash, err)
+			}
+			if err := sched.Process(SyncResult{hash, data}); err != nil {
+				t.Fatalf("failed to process result %v", err)
+			}
+		}
+		batch := diskdb.NewBatch()
+		if err := sched.Commit(batch); err != nil {
+			t.Fatalf("failed to commit data: %v", err)
+		}
+		batch.Write()
+	}
+	// Sync a few levels of the trie, leaving the last batch of requests in flight
+	nodes, _, _ := sched.Missing(0)
+	for i := 0; i < 2 && len(nodes) > 0; i++ {
+		process(nodes)
+		nodes, _, _ = sched.Missing(0)
+	}
+	if len(nodes) == 0 || sched.Pending() == len(nodes) {
+		t.Fatalf("sync has no in-flight requests or retrieved nodes pending")
+	}
+	// Resume the sync from its requests and finish it
+	var err error
+	sched, err = ResumeSync(sched.Requests(), diskdb, func([]byte) LeafCallback { return nil }, nil)
+	if err != nil {
+		t.Fatalf("failed to resume sync: %v", err)
+	}
+	for nodes, _, _ = sched.Missing(0); len(nodes) > 0; nodes, _, _ = sched.Missing(0) {
+		process(nodes)
+	}
+	if pending := sched.Pending(); pending != 0 {
+		t.Fatalf("sync not finished, %d requests pending", pending)
+	}
+	// Cross check that the two tries are in sync
+	checkTrieContents(t, triedb, srcTrie.Hash().Bytes(), srcData)
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+// tracer tracks the paths of the trie nodes removed from a trie since it was
+// last committed. In the hash-based scheme stale nodes are garbage collected by
+// reference counting, but in the path-based scheme they must be deleted from
+// disk explicitly, as nothing else would ever overwrite their paths.
+//
+// Nodes which are re-created at the same path afterwards are overwritten by the
+// committer anyway, so insertions don't need to be tracked. All methods are safe
+// to call on a nil tracer, which is what tries in the hash-based scheme use.
+type tracer struct {
+	deletes map[string]struct{}
+}
+
+// newTracer initializes an empty tracer.
+func newTracer() *tracer {
+	return &tracer{deletes: make(map[string]struct{})}
+}
+
+// onDelete tracks a trie node removed from the trie at the given path.
+func (t *tracer) onDelete(path []byte) {
+	if t == nil {
+		return
+	}
+	t.deletes[string(path)] = struct{}{}
+}
+
+// deleted returns the paths of all trie nodes removed since the last reset.
+func (t *tracer) deleted() [][]byte {
+	if t == nil {
+		return nil
+	}
+	paths := make([][]byte, 0, len(t.deletes))
+	for path := range t.deletes {
+		paths = append(paths, []byte(path))
+	}
+	return paths
+}
+
+// reset clears all the tracked paths.
+func (t *tracer) reset() {
+	if t == nil {
+		return
+	}
+	t.deletes = make(map[string]struct{})
+}
+
+// copy returns a deep-copied tracer.
+func (t *tracer) copy() *tracer {
+	if t == nil {
+		return nil
+	}
+	deletes := make(map[string]struct{}, len(t.deletes))
+	for path := range t.deletes {
+		deletes[path] = struct{}{}
+	}
+	return &tracer{deletes: deletes}
+}
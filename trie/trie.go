@@ -24,6 +24,7 @@ import (
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 )
@@ -58,12 +59,22 @@ type LeafCallback func(paths [][]byte, hexpath []byte, leaf []byte, parent commo
 //
 // Trie is not safe for concurrent use.
 type Trie struct {
-	db   *Database
-	root node
+	db    *Database
+	root  node
+	owner common.Hash // Hash of the account owning a storage trie, zero for the account trie
+
 	// Keep track of the number leafs which have been inserted since the last
 	// hashing operation. This number will not directly map to the number of
 	// actually unhashed nodes
 	unhashed int
+
+	// tracer tracks the removed trie nodes for the path-based scheme, it's
+	// nil for the hash-based one.
+	tracer *tracer
+
+	// origin is the root hash of the state the trie nodes committed next are
+	// based on. It's only tracked for the path-based scheme.
+	origin common.Hash
 }
 
 // newFlag returns the cache flag value for a newly created node.
@@ -78,11 +89,27 @@ func (t *Trie) newFlag() nodeFlag {
 // New will panic if db is nil and returns a MissingNodeError if root does
 // not exist in the database. Accessing the trie loads nodes from db on demand.
 func New(root common.Hash, db *Database) (*Trie, error) {
+	return NewWithOwner(common.Hash{}, root, db)
+}
+
+// NewWithOwner creates a trie with an existing root node from db, owned by the
+// account with the given hash. The owner is only relevant for storage tries in
+// the path-based scheme, where it namespaces the trie nodes on disk; account
+// tries use the zero hash.
+func NewWithOwner(owner common.Hash, root common.Hash, db *Database) (*Trie, error) {
 	if db == nil {
 		panic("trie.New called without a database")
 	}
 	trie := &Trie{
-		db: db,
+		db:     db,
+		owner:  owner,
+		origin: root,
+	}
+	if root == (common.Hash{}) {
+		trie.origin = emptyRoot
+	}
+	if db.scheme == rawdb.PathScheme {
+		trie.tracer = newTracer()
 	}
 	if root != (common.Hash{}) && root != emptyRoot {
 		rootnode, err := trie.resolveHash(root[:], nil)
@@ -188,7 +215,7 @@ func (t *Trie) tryGetNode(origNode node, path []byte, pos int) (item []byte, new
 		if hash == nil {
 			return nil, origNode, 0, errors.New("non-consensus node")
 		}
-		blob, err := t.db.Node(common.BytesToHash(hash))
+		blob, err := t.resolveBlob(hash, path[:pos])
 		return blob, origNode, 1, err
 	}
 	// Path still needs to be traversed, descend into children
@@ -373,6 +400,9 @@ func (t *Trie) delete(n node, prefix, key []byte) (bool, node, error) {
 			return false, n, nil // don't replace n on mismatch
 		}
 		if matchlen == len(key) {
+			// The matched short node is deleted entirely, track it for the
+			// path-based scheme. The value node is always embedded.
+			t.tracer.onDelete(prefix)
 			return true, nil, nil // remove n entirely for whole matches
 		}
 		// The key is longer than n.Key. Remove the remaining suffix
@@ -391,6 +421,10 @@ func (t *Trie) delete(n node, prefix, key []byte) (bool, node, error) {
 			// always creates a new slice) instead of append to
 			// avoid modifying n.Key since it might be shared with
 			// other nodes.
+			//
+			// The child short node is merged into its parent, track it as
+			// deleted.
+			t.tracer.onDelete(append(prefix, n.Key...))
 			return true, &shortNode{concat(n.Key, child.Key...), child.Val, t.newFlag()}, nil
 		default:
 			return true, &shortNode{n.Key, child, t.newFlag()}, nil
@@ -433,11 +467,14 @@ func (t *Trie) delete(n node, prefix, key []byte) (bool, node, error) {
 				// shortNode{..., shortNode{...}}.  Since the entry
 				// might not be loaded yet, resolve it just for this
 				// check.
-				cnode, err := t.resolve(n.Children[pos], prefix)
+				cnode, err := t.resolve(n.Children[pos], append(prefix, byte(pos)))
 				if err != nil {
 					return false, nil, err
 				}
 				if cnode, ok := cnode.(*shortNode); ok {
+					// The child short node is merged into its parent,
+					// track it as deleted.
+					t.tracer.onDelete(append(prefix, byte(pos)))
 					k := append([]byte{byte(pos)}, cnode.Key...)
 					return true, &shortNode{k, cnode.Val, t.newFlag()}, nil
 				}
@@ -490,12 +527,31 @@ func (t *Trie) resolve(n node, prefix []byte) (node, error) {
 
 func (t *Trie) resolveHash(n hashNode, prefix []byte) (node, error) {
 	hash := common.BytesToHash(n)
+	if t.db.scheme == rawdb.PathScheme {
+		if blob := t.db.pathNode(t.owner, prefix, hash); len(blob) != 0 {
+			return mustDecodeNode(n, blob), nil
+		}
+		return nil, &MissingNodeError{NodeHash: hash, Path: prefix}
+	}
 	if node := t.db.node(hash); node != nil {
 		return node, nil
 	}
 	return nil, &MissingNodeError{NodeHash: hash, Path: prefix}
 }
 
+// resolveBlob retrieves the encoded trie node with the given hash at the given
+// path from the database.
+func (t *Trie) resolveBlob(n hashNode, prefix []byte) ([]byte, error) {
+	hash := common.BytesToHash(n)
+	if t.db.scheme == rawdb.PathScheme {
+		if blob := t.db.pathNode(t.owner, prefix, hash); len(blob) != 0 {
+			return blob, nil
+		}
+		return nil, &MissingNodeError{NodeHash: hash, Path: prefix}
+	}
+	return t.db.Node(hash)
+}
+
 // Hash returns the root hash of the trie. It does not write to the
 // database and can be used even if the trie doesn't have one.
 func (t *Trie) Hash() common.Hash {
@@ -510,6 +566,23 @@ func (t *Trie) Commit(onleaf LeafCallback) (root common.Hash, err error) {
 	if t.db == nil {
 		panic("commit called on trie with nil database")
 	}
+	// In the path-based scheme, nodes are collected per trie and flushed by
+	// path when the whole state is committed. Removed nodes need explicit
+	// deletion, so seed the set with the ones tracked since the last commit.
+	var nodes *nodeSet
+	if t.db.scheme == rawdb.PathScheme {
+		nodes = newNodeSet(t.origin)
+		for _, path := range t.tracer.deleted() {
+			nodes.markDeleted(path)
+		}
+		defer func() {
+			if err == nil {
+				t.db.updatePathNodes(t.owner, nodes)
+				t.tracer.reset()
+				t.origin = root
+			}
+		}()
+	}
 	if t.root == nil {
 		return emptyRoot, nil
 	}
@@ -517,6 +590,7 @@ func (t *Trie) Commit(onleaf LeafCallback) (root common.Hash, err error) {
 	// in the following procedure that all nodes are hashed.
 	rootHash := t.Hash()
 	h := newCommitter()
+	h.nodes = nodes
 	defer returnCommitterToPool(h)
 
 	// Do a quick check if we really need to commit, before we spin
@@ -569,4 +643,5 @@ func (t *Trie) hashRoot() (node, node, error) {
 func (t *Trie) Reset() {
 	t.root = nil
 	t.unhashed = 0
+	t.tracer.reset()
 }